@@ -6,10 +6,9 @@ import (
 	"fmt"
 	"log"
 	"log/slog"
-	"os"
-	"os/exec"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/akthe-at/go_task/config"
 	"github.com/akthe-at/go_task/data"
@@ -28,7 +27,14 @@ var (
 	noteAliases  string
 	noteBody     string
 	noteTags     string
+	noteTemplate string
 	openInEditor bool
+	dueDate      string
+	recurRule    string
+	parentTaskID int64
+	tagNames     []string
+	linkTaskIDs  []int64
+	linkAreaIDs  []int64
 )
 
 // addCmd Used for adding new tasks, projects, notes, etc.
@@ -53,6 +59,10 @@ var addTaskCmd = &cobra.Command{
 	Valid task priorities: low, medium, high, urgent
 	Valid task statuses: todo, planning, doing, done
 	You can also optionally provided an archived status for the task using the --archived flag.
+	A due date can be provided with the --due flag, e.g. --due 2024-11-05, --due fri, --due "next monday" or --due +3d
+	Subtasks are created by passing the ID of their parent task with the --parent flag, e.g. --parent 3
+	Recurring tasks are created with the --recur flag, e.g. --recur weekly, --recur "every 3 days" or --recur "FREQ=MONTHLY;BYMONTHDAY=1"
+	Tags are added with the --tag flag, which can be repeated or given a comma separated list, e.g. --tag work --tag home or --tag work,home
 
 `,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -69,6 +79,14 @@ var addTaskCmd = &cobra.Command{
 			log.Fatalf("You passed too many arguments for the form input, did you mean to use the --raw flag?")
 		}
 
+		var parentTask sql.NullInt64
+		if parentTaskID != 0 {
+			if _, err := queries.ReadTask(ctx, parentTaskID); err != nil {
+				log.Fatalf("Could not find the parent task %d: %v", parentTaskID, err)
+			}
+			parentTask = sql.NullInt64{Int64: parentTaskID, Valid: true}
+		}
+
 		if rawFlag {
 
 			var (
@@ -86,17 +104,33 @@ var addTaskCmd = &cobra.Command{
 				log.Fatalf("Invalid status type: %v", err)
 			}
 
+			validDueDate, err := data.ToNullDueDate(dueDate)
+			if err != nil {
+				log.Fatalf("Invalid due date: %v", err)
+			}
+			if validDueDate.Valid {
+				fmt.Printf("Due date %q resolved to %s\n", dueDate, data.DescribeDueDate(validDueDate))
+			}
+
+			validRecurrence, err := data.ToNullRecurrence(recurRule)
+			if err != nil {
+				log.Fatalf("Invalid recurrence: %v", err)
+			}
+
 			newTaskID, err := queries.GetTaskID(ctx)
 			if err != nil && err != sql.ErrNoRows {
 				log.Fatalf("Error getting task ID: %v", err)
 			}
 
 			newTask := sqlc.CreateTaskParams{
-				ID:       newTaskID,
-				Title:    inputTitle,
-				Priority: sql.NullString{String: string(validPriority), Valid: true},
-				Status:   sql.NullString{String: string(validStatus), Valid: true},
-				Archived: archived,
+				ID:           newTaskID,
+				Title:        inputTitle,
+				Priority:     sql.NullString{String: string(validPriority), Valid: true},
+				Status:       sql.NullString{String: string(validStatus), Valid: true},
+				Archived:     archived,
+				DueDate:      validDueDate,
+				Recurrence:   validRecurrence,
+				ParentTaskID: parentTask,
 			}
 
 			newTaskID, err = queries.CreateTask(ctx, newTask)
@@ -105,6 +139,10 @@ var addTaskCmd = &cobra.Command{
 			}
 			fmt.Println("Successfully created a task and it was assigned the following ID: ", newTaskID)
 
+			if err := data.AddTaskTags(ctx, queries, newTaskID, data.ParseTags(tagNames...)); err != nil {
+				log.Fatalf("Error tagging task: %v", err)
+			}
+
 			ok, projectDir, err := utils.CheckIfProjDir()
 			if err != nil {
 				log.Fatalf("Error while checking if project directory: %v", err)
@@ -141,15 +179,41 @@ var addTaskCmd = &cobra.Command{
 			}
 
 			if form.Submit {
+				// The --due flag is used when the due date was left blank in the form
+				dueInput := form.DueDate
+				if dueInput == "" {
+					dueInput = dueDate
+				}
+				validDueDate, err := data.ToNullDueDate(dueInput)
+				if err != nil {
+					log.Fatalf("Invalid due date: %v", err)
+				}
+				if validDueDate.Valid {
+					fmt.Printf("Due date %q resolved to %s\n", dueInput, data.DescribeDueDate(validDueDate))
+				}
+
+				// The --recur flag is used when the recurrence was left blank in the form
+				recurInput := form.Recurrence
+				if recurInput == "" {
+					recurInput = recurRule
+				}
+				validRecurrence, err := data.ToNullRecurrence(recurInput)
+				if err != nil {
+					log.Fatalf("Invalid recurrence: %v", err)
+				}
+
 				newTaskID, err := queries.GetTaskID(ctx)
 				if err != nil && err != sql.ErrNoRows {
 					log.Fatalf("Error getting task ID: %v", err)
 				}
 				newTask := sqlc.CreateTaskParams{
-					ID:       newTaskID,
-					Title:    form.TaskTitle,
-					Priority: sql.NullString{String: string(form.Priority), Valid: true},
-					Status:   sql.NullString{String: string(form.Status), Valid: true},
+					ID:           newTaskID,
+					Title:        form.TaskTitle,
+					Priority:     sql.NullString{String: string(form.Priority), Valid: true},
+					Status:       sql.NullString{String: string(form.Status), Valid: true},
+					DueDate:      validDueDate,
+					Recurrence:   validRecurrence,
+					ParentTaskID: parentTask,
 				}
 				result, err := queries.CreateTask(ctx, newTask)
 				if err != nil {
@@ -157,6 +221,11 @@ var addTaskCmd = &cobra.Command{
 				}
 
 				fmt.Println("Successfully created a task and it was assigned the following ID: ", result)
+
+				// Tags from the form and the --tag flag are combined
+				if err := data.AddTaskTags(ctx, queries, result, data.ParseTags(append(tagNames, form.Tags)...)); err != nil {
+					log.Fatalf("Error tagging task: %v", err)
+				}
 				if form.AreaAssignment == "yes" {
 					areaID, err := strconv.ParseInt(form.Area, 10, 64)
 					if err != nil {
@@ -214,6 +283,7 @@ var addAreaCmd = &cobra.Command{
 
 	New areas can be created using a form or directly from the command line by passing the --raw or -r flag.
 	You can also optionally provide an archived status for the area using the --archived flag.
+	Tags are added with the --tag flag, e.g. --tag work --tag home or --tag work,home
 
 	Valid area statuses: todo, planning, doing, done
 
@@ -268,6 +338,10 @@ if one of your arguments has white space, please wrap it in "" marks.`)
 				fmt.Println("Successfully created a new area")
 			}
 
+			if err := data.AddAreaTags(ctx, queries, areaID, data.ParseTags(tagNames...)); err != nil {
+				log.Fatalf("Error tagging area: %v", err)
+			}
+
 		} else {
 			form := &formInput.NewAreaForm{}
 			theTheme := tui.GetSelectedTheme()
@@ -294,6 +368,10 @@ if one of your arguments has white space, please wrap it in "" marks.`)
 				} else {
 					fmt.Println("Successfully created a new area")
 				}
+
+				if err := data.AddAreaTags(ctx, queries, areaID, data.ParseTags(tagNames...)); err != nil {
+					log.Fatalf("Error tagging area: %v", err)
+				}
 			}
 		}
 
@@ -338,12 +416,17 @@ To do this:
 	Type in: 'go_task add task note <task_id> <note_title> <note_path>'
 OR to generate a new note AND add it to a specific task:
 	Type in: 'go_task add task note <task_id> <note_title> -t <note_tags> -a <note_aliases> -b <note_body>'
+OR to generate a new note from a template in the templates directory next to your config file:
+	Type in: 'go_task add task note <task_id> <note_title> --template meeting'
+Generated notes use the default template of the task's area unless --template is passed, '--template none' skips it.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) < 2 {
+			log.Fatalf("You must provide at least 2 arguments! Usage: note <task_id> <note_title> [note_path]")
+		}
 		var (
 			inputTaskID    = args[0]
 			inputNoteTitle = args[1]
-			inputNotePath  = args[2]
 		)
 
 		taskID, err := strconv.Atoi(inputTaskID)
@@ -351,37 +434,28 @@ OR to generate a new note AND add it to a specific task:
 			log.Fatalf("Invalid task ID: %v", err)
 		}
 
-		if NewNote {
+		if NewNote || noteTemplate != "" {
 			if len(args) < 2 {
 				log.Fatalf("You must provide at least 2 arguments to generate a new note! Usage: note <task_id> <note_title> -t <note_tags> -a <note_aliases> -b <note_body>")
 			}
 			fmt.Println("Creating a new note for task: ", inputTaskID)
-			theTags := strings.Split(noteTags, " ")
+			theTags := data.ParseTags(noteTags)
 			theAliases := strings.Split(noteAliases, " ")
 
-			newNoteID := data.GenerateNoteID(inputNoteTitle)
-			outputPath, err := data.TemplateMarkdownNote(inputNoteTitle, newNoteID, noteBody, theAliases, theTags)
-			if err != nil {
-				log.Fatal("Error with generating Template!", err)
-			}
-			if openInEditor {
-				editor := config.GetEditorConfig()
-				cmdr := exec.Command(editor, outputPath)
-				cmdr.Stdin = os.Stdin
-				cmdr.Stdout = os.Stdout
-				cmdr.Stderr = os.Stderr
-				err = cmdr.Run()
-				if err != nil {
-					log.Fatalf("There was an error running the command: %v", err)
-				}
-			}
-
 			ctx := context.Background()
 			conn, _, err := db.ConnectDB()
 			if err != nil {
 				log.Fatalf("Error connecting to database: %v", err)
 			}
 
+			newNoteID := data.GenerateNoteID(inputNoteTitle)
+			parentLinks := parentNoteLinks(ctx, sqlc.New(conn), data.TaskNoteType, int64(taskID))
+			body := renderNoteBody(ctx, sqlc.New(conn), data.TaskNoteType, int64(taskID), inputNoteTitle, theTags)
+			outputPath, err := data.TemplateMarkdownNote(inputNoteTitle, newNoteID, body, theAliases, theTags, parentLinks)
+			if err != nil {
+				log.Fatal("Error with generating Template!", err)
+			}
+
 			tx, err := conn.Begin()
 			if err != nil {
 				log.Fatalf("addTaskNoteCmd: Error beginning transaction: %v", err)
@@ -407,6 +481,10 @@ OR to generate a new note AND add it to a specific task:
 				log.Fatalf("addTaskNoteCmd: There was an error creating the note: %v", err)
 			}
 
+			if err := data.AddNoteTags(ctx, qtx, noteID, theTags); err != nil {
+				log.Fatalf("addTaskNoteCmd: Error tagging the note: %v", err)
+			}
+
 			_, err = qtx.CreateTaskBridgeNote(ctx, sqlc.CreateTaskBridgeNoteParams{
 				NoteID:       noteID,
 				ParentCat:    sql.NullInt64{Int64: int64(data.TaskNoteType), Valid: true},
@@ -421,6 +499,16 @@ OR to generate a new note AND add it to a specific task:
 			}
 			fmt.Println("Note added to task successfully")
 
+			if _, err := data.SyncNoteStates(ctx, conn, noteID); err != nil {
+				fmt.Printf("Warning: the state of the task was not written to the note: %v\n", err)
+			}
+			if err := data.RefreshNoteIndex(ctx, conn, noteID, outputPath); err != nil {
+				fmt.Printf("Warning: the search index was not updated: %v\n", err)
+			}
+			if openInEditor {
+				utils.OpenNotes(outputPath)
+			}
+
 			ok, projectDir, err := utils.CheckIfProjDir()
 			if err != nil {
 				log.Fatalf("Error while checking if in a project directory: %v", err)
@@ -451,6 +539,7 @@ OR to generate a new note AND add it to a specific task:
 			if len(args) < 3 {
 				log.Fatalf("You must provide at least 3 arguments! Usage: note <task_id> <note_title> <note_path>")
 			}
+			inputNotePath := args[2]
 
 			ctx := context.Background()
 			conn, _, err := db.ConnectDB()
@@ -537,6 +626,9 @@ To do this:
 	Type in: 'go_task add area note <area_id> <note_title> <note_path>'
 OR to generate a new note AND add it to a specific area:
 	Type in: 'go_task add area note <area_id> <note_title> -t <note_tags> -a <note_aliases> -b <note_body>'
+OR to generate a new note from a template in the templates directory next to your config file:
+	Type in: 'go_task add area note <area_id> <note_title> --template design-doc'
+Generated notes use the default template of the area unless --template is passed, '--template none' skips it.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
 		ctx := context.Background()
@@ -555,6 +647,9 @@ OR to generate a new note AND add it to a specific area:
 
 		queries := sqlc.New(conn)
 		qtx := queries.WithTx(tx)
+		// Notes generated here get the state of the area and are added to the search index once the transaction is committed
+		var generatedNoteID int64
+		var generatedNotePath string
 		switch len(args) {
 		case 1:
 			if NewNote {
@@ -580,10 +675,14 @@ OR to generate a new note AND add it to a specific area:
 			}
 
 			if form.Submit {
+				notePath, err := form.NotePath(ctx, qtx, data.AreaNoteType, int64(areaID))
+				if err != nil {
+					log.Fatalf("Error generating the note: %v", err)
+				}
 				err = qtx.CreateNote(ctx, sqlc.CreateNoteParams{
 					ID:    noteID,
 					Title: form.Title,
-					Path:  form.Path,
+					Path:  notePath,
 				})
 				if err != nil {
 					log.Fatalf("addAreaNoteCmd: There was an error creating the note: %v", err)
@@ -628,7 +727,6 @@ OR to generate a new note AND add it to a specific area:
 			var (
 				inputAreaID    = args[0]
 				inputNoteTitle = args[1]
-				inputNotePath  = args[2]
 			)
 
 			areaID, err := strconv.Atoi(inputAreaID)
@@ -636,32 +734,22 @@ OR to generate a new note AND add it to a specific area:
 				log.Fatalf("Invalid area ID: %v", err)
 			}
 
-			if NewNote {
+			if NewNote || noteTemplate != "" {
 				if len(args) < 2 {
 					log.Fatalf("You must provide at least 2 arguments to generate a new note! Usage: note <area_id> <note_title> -t <note_tags> -a <note_aliases> -b <note_body>")
 				}
 				fmt.Println("Creating a new note for area: ", inputAreaID)
-				theTags := strings.Split(noteTags, " ")
+				theTags := data.ParseTags(noteTags)
 				theAliases := strings.Split(noteAliases, " ")
 
 				newNoteID := data.GenerateNoteID(inputNoteTitle)
-				outputPath, err := data.TemplateMarkdownNote(inputNoteTitle, newNoteID, noteBody, theAliases, theTags)
+				parentLinks := parentNoteLinks(ctx, qtx, data.AreaNoteType, int64(areaID))
+				body := renderNoteBody(ctx, qtx, data.AreaNoteType, int64(areaID), inputNoteTitle, theTags)
+				outputPath, err := data.TemplateMarkdownNote(inputNoteTitle, newNoteID, body, theAliases, theTags, parentLinks)
 				if err != nil {
 					log.Fatal("An error occurred while generating the template: ", err)
 				}
 
-				if openInEditor {
-					editor := config.GetEditorConfig()
-					cmdr := exec.Command(editor, outputPath)
-					cmdr.Stdin = os.Stdin
-					cmdr.Stdout = os.Stdout
-					cmdr.Stderr = os.Stderr
-					err := cmdr.Run()
-					if err != nil {
-						log.Fatalf("There was an error running the command: %v", err)
-					}
-				}
-
 				noteID, err := qtx.GetNoteID(ctx)
 				if err != nil && err != sql.ErrNoRows {
 					log.Fatalf("Error getting note ID: %v", err)
@@ -675,6 +763,11 @@ OR to generate a new note AND add it to a specific area:
 					log.Fatalf("addAreaNoteCmd: There was an error creating the note: %v", err)
 				}
 
+				if err := data.AddNoteTags(ctx, qtx, noteID, theTags); err != nil {
+					log.Fatalf("addAreaNoteCmd: Error tagging the note: %v", err)
+				}
+				generatedNoteID, generatedNotePath = noteID, outputPath
+
 				_, err = qtx.CreateAreaBridgeNote(ctx, sqlc.CreateAreaBridgeNoteParams{
 					NoteID:       noteID,
 					ParentCat:    sql.NullInt64{Int64: int64(data.AreaNoteType), Valid: true},
@@ -713,6 +806,7 @@ OR to generate a new note AND add it to a specific area:
 				if len(args) < 3 {
 					log.Fatalf("You must provide at least 3 arguments! Usage: add area note <area_id> <note_title> <note_path>")
 				}
+				inputNotePath := args[2]
 
 				noteID, err := qtx.GetNoteID(ctx)
 				if err != nil && err != sql.ErrNoRows {
@@ -770,6 +864,109 @@ OR to generate a new note AND add it to a specific area:
 		} else {
 			fmt.Println("Note added to Area successfully")
 		}
+
+		if generatedNotePath != "" {
+			if _, err := data.SyncNoteStates(ctx, conn, generatedNoteID); err != nil {
+				fmt.Printf("Warning: the state of the area was not written to the note: %v\n", err)
+			}
+			if err := data.RefreshNoteIndex(ctx, conn, generatedNoteID, generatedNotePath); err != nil {
+				fmt.Printf("Warning: the search index was not updated: %v\n", err)
+			}
+			if openInEditor {
+				utils.OpenNotes(generatedNotePath)
+			}
+		}
+	},
+}
+
+// parentNoteLinks looks up the notes a generated note links back to, the links are only written for Obsidian
+func parentNoteLinks(ctx context.Context, queries *sqlc.Queries, noteType data.NoteType, parentID int64) []string {
+	if !config.UserSettings.Selected.UseObsidian {
+		return nil
+	}
+	links, err := data.ParentNoteLinks(ctx, queries, noteType, parentID)
+	if err != nil {
+		log.Fatalf("Error reading the notes to link to: %v", err)
+	}
+	return links
+}
+
+// renderNoteBody fills the template picked with --template, or the default template of the area, for a generated note
+func renderNoteBody(ctx context.Context, queries *sqlc.Queries, noteType data.NoteType, parentID int64, title string, tags []string) string {
+	_, repoPath, err := utils.CheckIfProjDir()
+	if err != nil {
+		log.Fatalf("Error while checking if in a project directory: %v", err)
+	}
+	body, err := data.RenderNoteBody(ctx, queries, data.NoteTemplateRequest{
+		Dir:      config.TemplatesDir(),
+		Name:     noteTemplate,
+		NoteType: noteType,
+		ParentID: parentID,
+		Title:    title,
+		Body:     noteBody,
+		Tags:     tags,
+		RepoPath: repoPath,
+	}, time.Now())
+	if err != nil {
+		log.Fatalf("Error rendering the note template: %v", err)
+	}
+	return body
+}
+
+// addNoteCmd is the parent command for adding to existing notes
+var addNoteCmd = &cobra.Command{
+	Use:   "note",
+	Short: "Parent command for adding to existing notes",
+	Long: `This command is used for adding links and more to notes that already exist.
+	New notes are added with 'go_task add task note' and 'go_task add area note'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(`You invoked the "add note" cmd without providing any further subcommands or further arguments,
+please complete the command to achieve the desired outcome.`)
+	},
+}
+
+var addNoteLinkCmd = &cobra.Command{
+	Use:   "link <note_id> --task <task_id> --area <area_id>",
+	Short: "Link an existing note to more tasks and areas",
+	Long: `
+A note can belong to several tasks and areas, e.g. a design doc that covers three tasks.
+You can use this command like this: go_task add note link <note_id> --task <task_id> --area <area_id>
+
+Repeat --task and --area to link several tasks and areas at once:
+"go_task add note link 4 --task 12 --task 13 --area 2"
+
+The note keeps the task or area it was created under as its first parent, the frontmatter of the note shows the state of that parent.
+You can find the note ID by using the 'go_task list notes' command.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		noteID, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			log.Fatalf("Invalid note ID: %v", err)
+		}
+		if len(linkTaskIDs) == 0 && len(linkAreaIDs) == 0 {
+			log.Fatalf("No task or area provided - use --task <task_id> or --area <area_id>")
+		}
+
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		linked, err := data.LinkNote(ctx, conn, noteID, linkTaskIDs, linkAreaIDs)
+		if err != nil {
+			log.Fatalf("Error linking note: %v", err)
+		}
+		switch skipped := len(linkTaskIDs) + len(linkAreaIDs) - linked; {
+		case linked == 0:
+			fmt.Printf("Note %d was already linked to every task and area you provided\n", noteID)
+		case skipped > 0:
+			fmt.Printf("Linked note %d to %d more tasks or areas, %d links already existed\n", noteID, linked, skipped)
+		default:
+			fmt.Printf("Linked note %d to %d more tasks or areas\n", noteID, linked)
+		}
 	},
 }
 
@@ -778,9 +975,11 @@ func init() {
 	rootCmd.AddCommand(addCmd)
 	addCmd.AddCommand(addTaskCmd)
 	addCmd.AddCommand(addAreaCmd)
+	addCmd.AddCommand(addNoteCmd)
 	// subcommands
 	addTaskCmd.AddCommand(addTaskNoteCmd)
 	addAreaCmd.AddCommand(addAreaNoteCmd)
+	addNoteCmd.AddCommand(addNoteLinkCmd)
 	// flags
 	addCmd.PersistentFlags().BoolVarP(&rawFlag, "raw", "r", false, "Bypass using the form and use raw input instead")
 	addCmd.PersistentFlags().BoolVar(&archived, "archived", false, "Archive the task or area upon creation")
@@ -789,4 +988,12 @@ func init() {
 	addCmd.PersistentFlags().StringVarP(&noteTags, "tags", "t", "", "Tags for the note")
 	addCmd.PersistentFlags().StringVarP(&noteAliases, "aliases", "a", "", "Aliases for the note")
 	addCmd.PersistentFlags().StringVarP(&noteBody, "body", "b", "", "Text for the Note Body")
+	addCmd.PersistentFlags().StringVar(&noteTemplate, "template", "", "Generate the note from a template such as meeting, design-doc, bug or daily, 'none' skips the area default")
+	addTaskCmd.Flags().Int64Var(&parentTaskID, "parent", 0, "ID of the parent task, creates the new task as a subtask")
+	addTaskCmd.Flags().StringVar(&recurRule, "recur", "", "Recurrence rule for the task, e.g. daily, weekly, monthly, every 3 days or FREQ=WEEKLY;BYDAY=MO")
+	addTaskCmd.Flags().StringSliceVar(&tagNames, "tag", nil, "Tags for the task, repeat the flag or separate tags with commas")
+	addAreaCmd.Flags().StringSliceVar(&tagNames, "tag", nil, "Tags for the area, repeat the flag or separate tags with commas")
+	addTaskCmd.Flags().StringVar(&dueDate, "due", "", "Due date for the task, e.g. today, fri, next monday, +3d, eow, eom or YYYY-MM-DD")
+	addNoteLinkCmd.Flags().Int64SliceVar(&linkTaskIDs, "task", nil, "ID of a task the note belongs to")
+	addNoteLinkCmd.Flags().Int64SliceVar(&linkAreaIDs, "area", nil, "ID of an area the note belongs to")
 }
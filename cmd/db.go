@@ -23,6 +23,7 @@ package cmd
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/akthe-at/go_task/db"
 	"github.com/spf13/cobra"
@@ -32,34 +33,30 @@ import (
 var dbCmd = &cobra.Command{
 	Use:   "db",
 	Short: "This command is for interacting with the database.",
-	Long:  `With these commands you can initialize/setup the database, or reset the database to clear it to an initial state.`,
+	Long:  `With these commands you can initialize/setup the database, migrate it to the latest schema, check its schema version, or reset the database to clear it to an initial state.`,
 	Run: func(cmd *cobra.Command, args []string) {
 	},
 }
 
-// dbCmd represents the db command
+// dbInitCmd represents the db init command
 var dbInitCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initial setup of DB",
 	Long:  `This command will perform the initial setup of the sqlite database to hold the tasks and user data.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("DB is being setup...")
-		conn, dbPath, err := db.ConnectDB()
+		conn, _, err := db.Open()
 		if err != nil {
-			fmt.Println("Error opening database:", err)
+			log.Fatalf("Error opening database: %v", err)
 		}
 		defer conn.Close()
 
-		if !db.IsSetup(conn) && !db.FileExists(dbPath) {
-			fmt.Println("Setting up the database...")
-			err = db.SetupDB(conn)
-			if err != nil {
-				fmt.Println("Error setting up database:", err)
-			}
-		}
-		err = db.SetupDB(conn)
+		applied, err := db.Migrate(conn)
 		if err != nil {
-			fmt.Println("Error setting up database:", err)
+			log.Fatalf("Error setting up database: %v", err)
+		}
+		for _, m := range applied {
+			fmt.Printf("Applied migration %04d_%s\n", m.Version, m.Name)
 		}
 
 		fmt.Println("Setup complete")
@@ -73,24 +70,97 @@ var dbResetCmd = &cobra.Command{
 	The tables will be then recreated to their blank, default state.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// Open a database connection
-		conn, _, err := db.ConnectDB()
+		conn, _, err := db.Open()
 		if err != nil {
-			fmt.Println("Error opening database:", err)
+			log.Fatalf("Error opening database: %v", err)
 		}
 		defer conn.Close()
 
 		err = db.ResetDB(conn)
 		if err != nil {
-			fmt.Println("Error resetting database:", err)
+			log.Fatalf("Error resetting database: %v", err)
 		}
 		fmt.Println("Database reset complete")
 	},
 }
 
+var dbMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Apply any pending schema migrations.",
+	Long: `This command upgrades the database schema to the version expected by this build of go_task.
+	Every migration runs in its own transaction and existing data is kept.
+	Pending migrations are also applied by the first command that opens the database,
+	use 'go_task db status' to see which migrations are pending.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conn, _, err := db.Open()
+		if err != nil {
+			log.Fatalf("Error opening database: %v", err)
+		}
+		defer conn.Close()
+
+		applied, err := db.Migrate(conn)
+		for _, m := range applied {
+			fmt.Printf("Applied migration %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatalf("Error migrating database: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("The database is already up to date")
+			return
+		}
+		fmt.Println("Migration complete")
+	},
+}
+
+var dbStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the schema version of the DB and any pending migrations.",
+	Long:  `This command lists the migrations that have been applied to the database and the ones that are still pending.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		conn, dbPath, err := db.Open()
+		if err != nil {
+			log.Fatalf("Error opening database: %v", err)
+		}
+		defer conn.Close()
+
+		current, err := db.CurrentVersion(conn)
+		if err != nil {
+			log.Fatalf("Error reading schema version: %v", err)
+		}
+		latest, err := db.LatestVersion()
+		if err != nil {
+			log.Fatalf("Error reading migrations: %v", err)
+		}
+		applied, err := db.AppliedMigrations(conn)
+		if err != nil {
+			log.Fatalf("Error reading applied migrations: %v", err)
+		}
+		pending, err := db.PendingMigrations(conn)
+		if err != nil {
+			log.Fatalf("Error reading pending migrations: %v", err)
+		}
+
+		fmt.Println("Database:", dbPath)
+		fmt.Printf("Schema version: %d (this binary supports up to %d)\n", current, latest)
+		for _, m := range applied {
+			fmt.Printf("  [applied] %04d_%s (%s)\n", m.Version, m.Name, m.AppliedAt)
+		}
+		for _, m := range pending {
+			fmt.Printf("  [pending] %04d_%s\n", m.Version, m.Name)
+		}
+		if current > latest {
+			fmt.Println("The database is newer than this version of go_task, please upgrade go_task.")
+		}
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(dbCmd)
 	dbCmd.AddCommand(dbInitCmd)
 	dbCmd.AddCommand(dbResetCmd)
+	dbCmd.AddCommand(dbMigrateCmd)
+	dbCmd.AddCommand(dbStatusCmd)
 
 	// Here you will define your flags and configuration settings.
 
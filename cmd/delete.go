@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/akthe-at/go_task/data"
 	"github.com/akthe-at/go_task/db"
 	"github.com/akthe-at/go_task/sqlc"
 	"github.com/charmbracelet/log"
@@ -103,6 +104,7 @@ var deleteAreaCmd = &cobra.Command{
 	You can find the area ID by using the 'go_task list areas' command.
 
 	If you want to delete the notes associated with the area, you can use the --notes flag.
+	Notes that are also linked to other tasks or areas are kept.
 	`,
 	Run: func(cmd *cobra.Command, args []string) {
 		var areaIDs []int64
@@ -136,19 +138,23 @@ var deleteAreaCmd = &cobra.Command{
 
 		qtx := queries.WithTx(tx)
 
+		// The notes have to be looked up before the areas and their note links are gone.
+		// Notes that also belong to other tasks or areas are kept.
+		var noteIDs []int64
+		if deleteNotes {
+			noteIDs, err = data.UnsharedNoteIDs(ctx, qtx, nil, areaIDs)
+			if err != nil {
+				log.Fatalf("There was an error reading the notes associated with the area(s): %v", err)
+			}
+		}
+
 		_, err = qtx.DeleteMultipleAreas(ctx, areaIDs)
 		if err != nil {
 			log.Fatalf("Error deleting area(s): %v", err)
 		}
 
-		if deleteNotes {
-			_, err = qtx.DeleteNotes(ctx, areaIDs)
-			for _, noteID := range areaIDs {
-				_, err := queries.DeleteNote(ctx, noteID)
-				if err != nil {
-					log.Fatalf("Error deleting task: %s", err)
-				}
-			}
+		if len(noteIDs) > 0 {
+			_, err = qtx.DeleteNotes(ctx, noteIDs)
 			if err != nil {
 				log.Fatalf("There was an error deleting the notes associated with the area(s): %v", err)
 			}
@@ -0,0 +1,339 @@
+/*
+Copyright © 2024 Adam Kelly <arkelly111@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/akthe-at/go_task/config"
+	"github.com/akthe-at/go_task/data"
+	"github.com/akthe-at/go_task/db"
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	bundleFile string
+	importMode string
+	dryRun     bool
+	noUpdate   bool
+)
+
+// exportCmd writes the whole database to a JSON bundle
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export every task, area, and note to a JSON file",
+	Long: `This command writes your areas, tasks, notes, note links, note checkboxes, programming projects, dependencies, tags, saved views, and logged time
+	to one versioned JSON document, e.g. go_task export --file backup.json
+	Without --file the document is written to stdout. The note files themselves are not included, only their paths.
+	Use 'go_task import' to load the document into another database, or 'go_task export taskwarrior' and 'go_task export todotxt' to move your tasks to other apps.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		bundle, err := data.ExportBundle(ctx, conn, time.Now())
+		if err != nil {
+			log.Fatalf("Error exporting database: %v", err)
+		}
+
+		var out io.Writer = os.Stdout
+		if bundleFile != "" && bundleFile != "-" {
+			file, err := os.Create(bundleFile)
+			if err != nil {
+				log.Fatalf("Error creating export file: %v", err)
+			}
+			defer file.Close()
+			out = file
+		}
+		if err := data.WriteBundle(out, bundle); err != nil {
+			log.Fatalf("Error writing export: %v", err)
+		}
+		if out != os.Stdout {
+			fmt.Printf("Exported %d areas, %d tasks, and %d notes to %s\n", len(bundle.Areas), len(bundle.Tasks), len(bundle.Notes), bundleFile)
+		}
+	},
+}
+
+// importCmd loads a JSON bundle written by export
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import a JSON file written by 'go_task export'",
+	Long: `This command loads a document written by 'go_task export', e.g. go_task import --file backup.json
+	Without --file the document is read from stdin.
+
+	--mode merge (the default) adds everything next to your existing data. Every row gets a new ID and the links between them are kept.
+	--mode replace deletes your existing tasks, areas, notes, and saved views first and keeps the IDs from the file.
+	It also clears the history shown by 'go_task log' and can not be undone, export a backup first.
+	Programming projects and tags are matched by path and name, saved views that already exist are left alone.
+	Use --dry-run to see what would be imported without changing anything.
+	Tasks from other apps are imported with 'go_task import taskwarrior' and 'go_task import todotxt'.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		mode, err := data.ParseImportMode(importMode)
+		if err != nil {
+			log.Fatalf("Invalid --mode value: %v", err)
+		}
+
+		var in io.Reader = os.Stdin
+		if bundleFile != "" && bundleFile != "-" {
+			file, err := os.Open(bundleFile)
+			if err != nil {
+				log.Fatalf("Error opening import file: %v", err)
+			}
+			defer file.Close()
+			in = file
+		}
+		bundle, err := data.ReadBundle(in)
+		if err != nil {
+			log.Fatalf("Error reading import file: %v", err)
+		}
+
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		stats, err := data.ImportBundle(ctx, conn, bundle, mode, dryRun)
+		if err != nil {
+			log.Fatalf("Error importing: %v", err)
+		}
+		if dryRun {
+			fmt.Printf("Dry run, nothing was changed. A %s import would add:\n", mode)
+		} else {
+			fmt.Printf("Imported with mode %s:\n", mode)
+		}
+		printImportStats(stats)
+	},
+}
+
+// exportTaskwarriorCmd writes the tasks in the format 'task import' reads
+var exportTaskwarriorCmd = &cobra.Command{
+	Use:   "taskwarrior",
+	Short: "Export your tasks for Taskwarrior",
+	Long: `This command writes your tasks as Taskwarrior JSON, e.g. go_task export taskwarrior | task import
+	Areas become projects, priorities become H, M, and L, and linked notes become annotations.
+	Every task gets a UUID derived from its ID and creation time, so importing a newer export into Taskwarrior updates the same tasks
+	and a new task that reuses the ID of a deleted one does not overwrite the old one in Taskwarrior.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		tasks, err := data.ExportTaskwarrior(ctx, conn, time.Now())
+		if err != nil {
+			log.Fatalf("Error exporting tasks: %v", err)
+		}
+
+		var out io.Writer = os.Stdout
+		if bundleFile != "" && bundleFile != "-" {
+			file, err := os.Create(bundleFile)
+			if err != nil {
+				log.Fatalf("Error creating export file: %v", err)
+			}
+			defer file.Close()
+			out = file
+		}
+		if err := data.WriteTaskwarrior(out, tasks); err != nil {
+			log.Fatalf("Error writing export: %v", err)
+		}
+		if out != os.Stdout {
+			fmt.Printf("Exported %d tasks to %s\n", len(tasks), bundleFile)
+		}
+	},
+}
+
+// importTaskwarriorCmd loads the output of 'task export'
+var importTaskwarriorCmd = &cobra.Command{
+	Use:   "taskwarrior",
+	Short: "Import tasks exported from Taskwarrior",
+	Long: `This command adds the tasks from 'task export' to go_task, e.g. task export | go_task import taskwarrior
+	or go_task import taskwarrior < export.json
+
+	description, priority (H/M/L), status, due, tags, and recur are copied onto the tasks.
+	Projects become areas, an area is created when none with the same title exists.
+	The annotations of a task are written to a markdown note in your notes path that is linked to the task.
+	Dependencies are kept. Pending instances of recurring tasks are skipped, go_task creates the next instance itself.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var in io.Reader = os.Stdin
+		if bundleFile != "" && bundleFile != "-" {
+			file, err := os.Open(bundleFile)
+			if err != nil {
+				log.Fatalf("Error opening import file: %v", err)
+			}
+			defer file.Close()
+			in = file
+		}
+		tasks, err := data.ReadTaskwarrior(in)
+		if err != nil {
+			log.Fatalf("Error reading Taskwarrior export: %v", err)
+		}
+
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		stats, err := data.ImportTaskwarrior(ctx, conn, tasks, config.UserSettings.Selected.NotesPath, time.Now())
+		if err != nil {
+			log.Fatalf("Error importing from Taskwarrior: %v", err)
+		}
+		fmt.Printf("Imported %d tasks, %d new areas, %d tags, %d annotation notes, and %d dependencies\n",
+			stats.Tasks, stats.AreasCreated, stats.Tags, stats.Notes, stats.Dependencies)
+		if stats.SkippedInstances > 0 {
+			fmt.Printf("Skipped %d pending instances of recurring tasks\n", stats.SkippedInstances)
+		}
+		if stats.SkippedAnnotations > 0 {
+			fmt.Printf("Warning: %d annotations were not imported because no notes path is configured\n", stats.SkippedAnnotations)
+		}
+		for _, dropped := range stats.DroppedRecurrences {
+			fmt.Printf("Warning: the recurrence of %s has no go_task equivalent and was dropped\n", dropped)
+		}
+	},
+}
+
+// exportTodoTxtCmd writes the open and finished tasks as a todo.txt file
+var exportTodoTxtCmd = &cobra.Command{
+	Use:   "todotxt",
+	Short: "Export your tasks as a todo.txt file",
+	Long: `This command writes every task that is not archived as a todo.txt line, e.g. go_task export todotxt --file ~/Dropbox/todo/todo.txt
+	urgent, high, medium, and low become (A) to (D), the area becomes a +project with spaces written as dashes, and tags become @contexts.
+	Every line ends with an id: key, so 'go_task import todotxt' updates the same tasks after the file was edited in another app.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		tasks, err := data.ExportTodoTxt(ctx, conn, time.Now())
+		if err != nil {
+			log.Fatalf("Error exporting tasks: %v", err)
+		}
+
+		var out io.Writer = os.Stdout
+		if bundleFile != "" && bundleFile != "-" {
+			file, err := os.Create(bundleFile)
+			if err != nil {
+				log.Fatalf("Error creating export file: %v", err)
+			}
+			defer file.Close()
+			out = file
+		}
+		if err := data.WriteTodoTxt(out, tasks); err != nil {
+			log.Fatalf("Error writing export: %v", err)
+		}
+		if out != os.Stdout {
+			fmt.Printf("Exported %d tasks to %s\n", len(tasks), bundleFile)
+		}
+	},
+}
+
+// importTodoTxtCmd loads a todo.txt file
+var importTodoTxtCmd = &cobra.Command{
+	Use:   "todotxt",
+	Short: "Import tasks from a todo.txt file",
+	Long: `This command adds the tasks of a todo.txt file to go_task, e.g. go_task import todotxt --file ~/Dropbox/todo/todo.txt
+
+	Priorities (A) to (D) become urgent, high, medium, and low, lines marked x are done, and due:YYYY-MM-DD sets the due date.
+	The first +project becomes the area, it is created when no area has that title, @contexts become tags.
+	Lines with the id: key written by 'go_task export todotxt' update their task instead of adding a new one.
+	The task is only updated when its title or creation date still matches the line, otherwise the line is added as a new task.
+	Use --no-update to only add the lines that are new to go_task and leave existing tasks alone.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var in io.Reader = os.Stdin
+		if bundleFile != "" && bundleFile != "-" {
+			file, err := os.Open(bundleFile)
+			if err != nil {
+				log.Fatalf("Error opening import file: %v", err)
+			}
+			defer file.Close()
+			in = file
+		}
+		tasks, err := data.ReadTodoTxt(in)
+		if err != nil {
+			log.Fatalf("Error reading todo.txt: %v", err)
+		}
+
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		stats, err := data.ImportTodoTxt(ctx, conn, tasks, noUpdate, time.Now())
+		if err != nil {
+			log.Fatalf("Error importing todo.txt: %v", err)
+		}
+		fmt.Printf("Imported %d new tasks, updated %d tasks, created %d areas, and added %d tags\n",
+			stats.Tasks, stats.Updated, stats.AreasCreated, stats.Tags)
+		if stats.NextInstances > 0 {
+			fmt.Printf("Created the next instance of %d recurring tasks\n", stats.NextInstances)
+		}
+		if stats.Skipped > 0 {
+			fmt.Printf("Skipped %d lines of existing tasks\n", stats.Skipped)
+		}
+		for _, id := range stats.ReusedIDs {
+			fmt.Printf("Warning: task %d no longer matches its line, the line was added as a new task\n", id)
+		}
+	},
+}
+
+func printImportStats(stats data.ImportStats) {
+	fmt.Printf("  %d areas, %d tasks, %d notes, and %d note links\n", stats.Areas, stats.Tasks, stats.Notes, stats.BridgeNotes)
+	fmt.Printf("  %d tasks linked to note checkboxes\n", stats.NoteCheckboxes)
+	fmt.Printf("  %d programming projects (%d already existed) and %d project links\n", stats.Projects, stats.ProjectsReused, stats.ProjectLinks)
+	fmt.Printf("  %d task dependencies and %d tags\n", stats.TaskDependencies, stats.Tags)
+	fmt.Printf("  %d saved views (%d kept because a view with the same name exists)\n", stats.SavedViews, stats.SavedViewsKept)
+	fmt.Printf("  %d time entries\n", stats.TimeEntries)
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+
+	exportCmd.AddCommand(exportTaskwarriorCmd)
+	importCmd.AddCommand(importTaskwarriorCmd)
+	exportCmd.AddCommand(exportTodoTxtCmd)
+	importCmd.AddCommand(importTodoTxtCmd)
+
+	exportCmd.PersistentFlags().StringVarP(&bundleFile, "file", "f", "", "File to write the export to, defaults to stdout")
+	importCmd.PersistentFlags().StringVarP(&bundleFile, "file", "f", "", "File to import, defaults to stdin")
+	importCmd.Flags().StringVar(&importMode, "mode", string(data.ImportMerge), "Import mode, merge or replace")
+	importCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be imported without changing anything")
+	importTodoTxtCmd.Flags().BoolVar(&noUpdate, "no-update", false, "Only add new tasks, leave the tasks of lines with an id: key alone")
+}
@@ -0,0 +1,93 @@
+/*
+Copyright © 2024 Adam Kelly <arkelly111@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/akthe-at/go_task/config"
+	"github.com/akthe-at/go_task/data"
+	"github.com/akthe-at/go_task/db"
+	"github.com/akthe-at/go_task/utils"
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+var journalNoOpen bool
+
+// journalCmd represents the journal command
+var journalCmd = &cobra.Command{
+	Use:   "journal [date]",
+	Short: "Create or open the daily journal note",
+	Long: `Journal opens the daily note of today, or of the given date, e.g. go_task journal yesterday
+	The note is written to the journal folder of your notes path the first time you open it that day.
+	It lists the tasks moved to done that day, the tasks you are doing, and the tasks due that day,
+	and it is linked to every task it lists. Opening it again the same day reopens the same file.
+	Dates can be given as ` + data.DateInputHelp + `.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		now := time.Now()
+		day := now
+		if len(args) == 1 {
+			var err error
+			day, err = data.ResolveDate(args[0], now)
+			if err != nil {
+				log.Fatalf("Error reading the date: %v", err)
+			}
+		}
+
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		journal, err := data.OpenJournal(ctx, conn, config.UserSettings.Selected.NotesPath, day)
+		if err != nil {
+			log.Fatalf("Error opening the journal: %v", err)
+		}
+		if journal.Created {
+			fmt.Printf("Created the journal of %s as note %d: %s\n", data.FormatResolvedDate(day), journal.NoteID, journal.Path)
+		}
+		if len(journal.TaskIDs) > 0 {
+			ids := make([]string, len(journal.TaskIDs))
+			for i, id := range journal.TaskIDs {
+				ids[i] = fmt.Sprint(id)
+			}
+			fmt.Printf("Linked the journal to tasks %s\n", strings.Join(ids, ", "))
+		}
+
+		if !journalNoOpen {
+			utils.OpenNotes(journal.Path)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(journalCmd)
+
+	journalCmd.Flags().BoolVar(&journalNoOpen, "no-open", false, "Write the journal without opening it")
+}
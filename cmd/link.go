@@ -0,0 +1,137 @@
+/*
+Copyright © 2024 Adam Kelly <arkelly111@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/akthe-at/go_task/data"
+	"github.com/akthe-at/go_task/db"
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+var blocksIDs []int64
+
+// linkCmd represents the link command
+var linkCmd = &cobra.Command{
+	Use:   "link",
+	Short: "The root command for linking tasks together.",
+	Long:  `This command is the root command for linking tasks together. Please use --help to see all of the available subcommands.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("The link cmd invoked without any additional arguments. Please provide a subcommand.")
+	},
+}
+
+var linkTaskCmd = &cobra.Command{
+	Use:   "task <task_id> --blocks <task_id>",
+	Short: "Mark a task as blocking other tasks",
+	Long: `
+	Record that a task has to be done before other tasks can start.
+	You can use this command like this: go_task link task <task_id> --blocks <task_id>
+
+	Repeat --blocks to block several tasks at once:
+	"go_task link task 9 --blocks 12 --blocks 13"
+
+	Links that would make a task wait on itself, directly or through other tasks, are refused.
+	Use 'go_task list tasks --ready' to see the tasks that are not blocked.
+	`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		blockerID := parseBlockerArgs(args)
+
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		for _, blockedID := range blocksIDs {
+			if err := data.LinkTasks(ctx, conn, blockerID, blockedID); err != nil {
+				log.Fatalf("Error linking tasks: %v", err)
+			}
+			fmt.Printf("Task %d now blocks task %d\n", blockerID, blockedID)
+		}
+	},
+}
+
+// unlinkCmd represents the unlink command
+var unlinkCmd = &cobra.Command{
+	Use:   "unlink",
+	Short: "The root command for unlinking tasks.",
+	Long:  `This command is the root command for removing links between tasks. Please use --help to see all of the available subcommands.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("The unlink cmd invoked without any additional arguments. Please provide a subcommand.")
+	},
+}
+
+var unlinkTaskCmd = &cobra.Command{
+	Use:   "task <task_id> --blocks <task_id>",
+	Short: "Stop a task from blocking other tasks",
+	Long: `
+	Remove the dependency between two tasks.
+	You can use this command like this: go_task unlink task <task_id> --blocks <task_id>
+	`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		blockerID := parseBlockerArgs(args)
+
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		for _, blockedID := range blocksIDs {
+			if err := data.UnlinkTasks(ctx, conn, blockerID, blockedID); err != nil {
+				log.Fatalf("Error unlinking tasks: %v", err)
+			}
+			fmt.Printf("Task %d no longer blocks task %d\n", blockerID, blockedID)
+		}
+	},
+}
+
+// parseBlockerArgs parses the blocking task ID and makes sure --blocks was provided
+func parseBlockerArgs(args []string) int64 {
+	blockerID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		log.Fatalf("Error converting task ID to integer: %v", err)
+	}
+	if len(blocksIDs) == 0 {
+		log.Fatalf("No blocked task provided - use --blocks <task_id>")
+	}
+	return blockerID
+}
+
+func init() {
+	rootCmd.AddCommand(linkCmd)
+	rootCmd.AddCommand(unlinkCmd)
+	linkCmd.AddCommand(linkTaskCmd)
+	unlinkCmd.AddCommand(unlinkTaskCmd)
+
+	linkTaskCmd.Flags().Int64SliceVar(&blocksIDs, "blocks", nil, "ID of the task that can not start until this task is done")
+	unlinkTaskCmd.Flags().Int64SliceVar(&blocksIDs, "blocks", nil, "ID of the task that should no longer wait on this task")
+}
@@ -29,17 +29,28 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/akthe-at/go_task/data"
 	"github.com/akthe-at/go_task/db"
 	"github.com/akthe-at/go_task/sqlc"
 	"github.com/akthe-at/go_task/tui"
 	"github.com/charmbracelet/lipgloss"
 	_ "github.com/charmbracelet/lipgloss/list"
 	"github.com/charmbracelet/lipgloss/table"
+	"github.com/charmbracelet/lipgloss/tree"
 	"github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
 )
 
+var (
+	overdueFlag bool
+	dueWithin   string
+	readyFlag   bool
+	tagFilter   []string
+	viewName    string
+)
+
 type TableRow interface {
 	ToRow() []string
 }
@@ -48,7 +59,9 @@ type TableRow interface {
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "This is the root cmd for listing tasks, areas, and notes.",
-	Long:  `This is the root cmd for listing tasks, areas, and notes. You need to supply a subcommand to list tasks, areas, or notes.`,
+	Long: `This is the root cmd for listing tasks, areas, and notes. You need to supply a subcommand to list tasks, areas, or notes.
+	Use --output to get json, jsonl, csv, tsv, or yaml instead of a table, e.g. go_task list tasks --output json | jq
+	When the output is piped or redirected tsv is written by default.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("The list root cmd called without arguments, please provide a subcommand.")
 	},
@@ -83,18 +96,50 @@ var taskCmd = &cobra.Command{
 			log.Fatalf("There was an error reading the tasks from the database: %v", err)
 		}
 
-		table := styleTaskTable(task)
-		fmt.Println(table)
+		format := outputFormat()
+		printRows(format, task, func() fmt.Stringer { return styleTaskTable(task) })
+		if format != data.OutputTable {
+			return
+		}
+
+		subtasks, err := queries.ReadSubtaskTree(ctx, sql.NullInt64{Int64: int64(taskID), Valid: true})
+		if err != nil {
+			log.Fatalf("There was an error reading the subtasks from the database: %v", err)
+		}
+		if len(subtasks) > 0 {
+			fmt.Println(styleSubtaskTree(task.TaskTitle, subtasks))
+		}
 	},
 }
 
 var tasksCmd = &cobra.Command{
-	Use:   "tasks",
+	Use:   "tasks [filter]",
 	Short: "List your tasks",
 	Long: `This command is used for calling for a list of your tasks.
 
+	Pass a filter to only list the tasks that match it, e.g. go_task list tasks 'status:doing priority>=high -archived'
+	Quote the whole filter so the shell does not read > or < as a redirect. Flags have to come before the filter,
+	everything after its first word is read as the filter so a negated term such as -archived is not taken for a flag.
+	Put -- before a filter that starts with a negated term, e.g. go_task list tasks -- '-archived status:doing'
+	` + data.FilterHelp + `
+	Use --view to apply a filter saved with 'go_task view save', it is combined with any filter you pass.
+
+	Use --overdue to only list tasks that are past their due date and not done yet.
+	Use --due-within to only list tasks that are due within a window, e.g. --due-within 7d or --due-within 2w.
+	Overdue tasks are included in the --due-within window.
+	Use --ready to only list tasks that can be worked on: not done, not archived, and not blocked by other tasks.
+	Use --tag to only list tasks with a tag, e.g. --tag work. Tasks have to carry every tag when it is repeated.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
+		var window int
+		if dueWithin != "" {
+			days, err := data.ParseDayWindow(dueWithin)
+			if err != nil {
+				log.Fatalf("Invalid --due-within value: %v", err)
+			}
+			window = days
+		}
+
 		ctx := context.Background()
 		conn, _, err := db.ConnectDB()
 		if err != nil {
@@ -103,12 +148,52 @@ var tasksCmd = &cobra.Command{
 		queries := sqlc.New(conn)
 		defer conn.Close()
 
+		expression := data.JoinFilterArgs(args)
+		if viewName != "" {
+			viewFilter, err := data.ViewFilter(ctx, queries, viewName)
+			if err != nil {
+				log.Fatalf("There was an error reading the saved view: %v", err)
+			}
+			expression = data.CombineFilters(viewFilter, expression)
+		}
+		var matchingIDs map[int64]bool
+		if strings.TrimSpace(expression) != "" {
+			matchingIDs, err = data.FilterTaskIDs(ctx, conn, expression, data.CurrentFilterEnv())
+			if err != nil {
+				log.Fatalf("Invalid filter: %v", err)
+			}
+		}
+
 		tasks, err := queries.ReadTasks(ctx)
 		if err != nil {
 			log.Errorf("There was an error reading the tasks from the database: %v", err)
 		}
-		table := styleTasksTable(tasks)
-		fmt.Println(table)
+
+		wantedTags := data.ParseTags(tagFilter...)
+		now := time.Now()
+		var filteredTasks []sqlc.ReadTasksRow
+		for _, task := range tasks {
+			if matchingIDs != nil && !matchingIDs[task.ID] {
+				continue
+			}
+			if overdueFlag && !data.IsOverdue(task.DueDate, task.Status.String, now) {
+				continue
+			}
+			if dueWithin != "" && !data.IsDueWithin(task.DueDate, task.Status.String, window, now) {
+				continue
+			}
+			blockedBy, _ := task.BlockedBy.(string)
+			if readyFlag && !data.IsTaskReady(task.Status.String, task.Archived, blockedBy) {
+				continue
+			}
+			tags, _ := task.Tags.(string)
+			if len(wantedTags) > 0 && !data.HasAllTags(tags, wantedTags) {
+				continue
+			}
+			filteredTasks = append(filteredTasks, task)
+		}
+
+		printRows(outputFormat(), filteredTasks, func() fmt.Stringer { return styleTasksTable(filteredTasks) })
 	},
 }
 
@@ -140,8 +225,7 @@ var taskNotesCmd = &cobra.Command{
 			log.Fatalf("There was an error reading the task notes from the database: %v", err)
 		}
 
-		table := styleTaskNotesTable(results)
-		fmt.Println(table)
+		printRows(outputFormat(), results, func() fmt.Stringer { return styleTaskNotesTable(results) })
 	},
 }
 
@@ -165,8 +249,7 @@ var allNotesCmd = &cobra.Command{
 			log.Errorf("There was an error reading the notes the database: %v", err)
 		}
 
-		table := styleAllNotesTable(allNotes)
-		fmt.Println(table)
+		printRows(outputFormat(), allNotes, func() fmt.Stringer { return styleAllNotesTable(allNotes) })
 	},
 }
 
@@ -190,18 +273,26 @@ var projectsCmd = &cobra.Command{
 		if err != nil {
 			log.Errorf("There was an error reading the areas/projects from the database: %v", err)
 		}
-		table := styleAreaTable(areas)
-		fmt.Println(table)
+		printRows(outputFormat(), areas, func() fmt.Stringer { return styleAreaTable(areas) })
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(listCmd)
+	listCmd.PersistentFlags().StringVarP(&outputFlag, "output", "o", "", outputFlagUsage())
 	listCmd.AddCommand(tasksCmd)
 	listCmd.AddCommand(projectsCmd)
 	listCmd.AddCommand(taskCmd)
 	listCmd.AddCommand(allNotesCmd)
 	taskCmd.AddCommand(taskNotesCmd)
+
+	tasksCmd.Flags().BoolVar(&readyFlag, "ready", false, "Only list tasks that are not done, not archived, and not blocked")
+	tasksCmd.Flags().BoolVar(&overdueFlag, "overdue", false, "Only list tasks that are past their due date")
+	tasksCmd.Flags().StringSliceVar(&tagFilter, "tag", nil, "Only list tasks with this tag, repeat the flag to require several tags")
+	tasksCmd.Flags().StringVar(&viewName, "view", "", "Only list tasks matching a saved view")
+	tasksCmd.Flags().StringVar(&dueWithin, "due-within", "", "Only list tasks due within a window of days or weeks, e.g. 7d or 2w")
+	// Stop parsing flags at the filter so negated terms such as -archived reach it
+	tasksCmd.Flags().SetInterspersed(false)
 }
 
 type TasksRowWrapper struct {
@@ -215,6 +306,7 @@ func (t TasksRowWrapper) ToRow() []string {
 	}
 
 	formattedDate := t.AgeInDays
+	formattedTags, _ := t.Tags.(string)
 	var formattedNotes string
 	if t.NoteTitles != nil {
 		note := t.NoteTitles.(string)
@@ -231,10 +323,13 @@ func (t TasksRowWrapper) ToRow() []string {
 		t.Title,
 		t.Priority.String,
 		t.Status.String,
+		t.DueDate.String,
 		fmt.Sprintf("%.2f Days", formattedDate),
+		data.FormatTimeSpent(time.Duration(t.TimeLogged) * time.Second),
 		formattedNotes,
 		formattedPath,
 		t.ParentArea.String,
+		formattedTags,
 	}
 }
 
@@ -243,10 +338,12 @@ type AreaRowWrapper struct {
 }
 
 func (a AreaRowWrapper) ToRow() []string {
+	formattedTags, _ := a.Tags.(string)
 	return []string{
 		fmt.Sprintf("%d", a.ID),
 		a.Title,
 		fmt.Sprintf("%v", a.Status.String),
+		formattedTags,
 	}
 }
 
@@ -268,6 +365,12 @@ type TaskRowWrapper struct {
 
 // ToRow converts the TaskRowWrapper to a slice of strings
 func (t TaskRowWrapper) ToRow() []string {
+	formattedBlockers, _ := t.BlockedBy.(string)
+	formattedTags, _ := t.Tags.(string)
+	var formattedRecurrence string
+	if t.Recurrence.Valid {
+		formattedRecurrence = data.DescribeRecurrence(t.Recurrence)
+	}
 	formattedPath := path.Base(t.ProgProj.String)
 	if formattedPath == "." {
 		formattedPath = ""
@@ -288,10 +391,15 @@ func (t TaskRowWrapper) ToRow() []string {
 		t.TaskTitle,
 		t.Priority.String,
 		t.Status.String,
+		t.DueDate.String,
+		formattedRecurrence,
 		fmt.Sprintf("%.2f Days", t.AgeInDays),
+		data.FormatTimeSpent(time.Duration(t.TimeLogged) * time.Second),
 		formattedNotes,
 		formattedPath,
 		t.ParentArea.String,
+		formattedBlockers,
+		formattedTags,
 	}
 }
 
@@ -300,12 +408,14 @@ type AllNotesRowWrapper struct {
 }
 
 func (a AllNotesRowWrapper) ToRow() []string {
+	formattedTags, _ := a.Tags.(string)
 	return []string{
 		fmt.Sprintf("%d", a.ID),
 		a.Title,
 		a.Path,
 		a.AreaOrTaskTitle,
 		a.ParentType,
+		formattedTags,
 	}
 }
 
@@ -352,14 +462,62 @@ func styleTable(rows []TableRow, headers []string, colWidths map[int]int) *table
 	return &t
 }
 
+// styleSubtaskTree renders the subtasks of a task as a tree with the parent's progress at the root
+func styleSubtaskTree(title string, subtasks []sqlc.ReadSubtaskTreeRow) *tree.Tree {
+	theme := tui.GetSelectedTheme()
+
+	children := make(map[int64][]sqlc.ReadSubtaskTreeRow)
+	var rootID int64
+	var total, done int64
+	for _, subtask := range subtasks {
+		children[subtask.ParentTaskID] = append(children[subtask.ParentTaskID], subtask)
+		if subtask.Depth == 1 {
+			rootID = subtask.ParentTaskID
+			total++
+			if subtask.Status.String == string(data.StatusDone) {
+				done++
+			}
+		}
+	}
+
+	itemStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Primary))
+	enumeratorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Success)).PaddingRight(1)
+
+	var addChildren func(node *tree.Tree, parentID int64)
+	addChildren = func(node *tree.Tree, parentID int64) {
+		for _, subtask := range children[parentID] {
+			label := fmt.Sprintf("%d %s [%s]", subtask.ID, subtask.Title, subtask.Status.String)
+			if subtask.DueDate.Valid {
+				label += " due " + subtask.DueDate.String
+			}
+			if len(children[subtask.ID]) == 0 {
+				node.Child(label)
+				continue
+			}
+			branch := tree.Root(label).
+				ItemStyle(itemStyle).
+				EnumeratorStyle(enumeratorStyle)
+			addChildren(branch, subtask.ID)
+			node.Child(branch)
+		}
+	}
+
+	root := tree.Root(fmt.Sprintf("%s (%s)", title, data.FormatSubtaskProgress(done, total))).
+		RootStyle(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Secondary)).Bold(true)).
+		ItemStyle(itemStyle).
+		EnumeratorStyle(enumeratorStyle)
+	addChildren(root, rootID)
+	return root
+}
+
 func styleTasksTable(tasks []sqlc.ReadTasksRow) *table.Table {
 	var rows []TableRow
 	for _, task := range tasks {
 		rows = append(rows, TasksRowWrapper{task})
 	}
 
-	headers := []string{"ID", "Task", "Priority", "Status", "Task Age", "Notes", "Project", "Area"}
-	colWidths := map[int]int{0: 2, 1: 15, 2: 10, 3: 10, 4: 10, 5: 15, 6: 10, 7: 10}
+	headers := []string{"ID", "Task", "Priority", "Status", "Due", "Task Age", "Time", "Notes", "Project", "Area", "Tags"}
+	colWidths := map[int]int{0: 2, 1: 15, 2: 10, 3: 10, 4: 12, 5: 10, 6: 8, 7: 15, 8: 10, 9: 10, 10: 15}
 	return styleTable(rows, headers, colWidths)
 }
 
@@ -369,8 +527,8 @@ func styleAreaTable(areas []sqlc.ReadAreasRow) *table.Table {
 		rows = append(rows, AreaRowWrapper{area})
 	}
 
-	headers := []string{"ID", "Name", "Status"}
-	colWidths := map[int]int{0: 5, 1: 15}
+	headers := []string{"ID", "Name", "Status", "Tags"}
+	colWidths := map[int]int{0: 5, 1: 15, 3: 15}
 	return styleTable(rows, headers, colWidths)
 }
 
@@ -388,8 +546,8 @@ func styleTaskTable(task sqlc.ReadTaskRow) *table.Table {
 	var rows []TableRow
 
 	rows = append(rows, TaskRowWrapper{task})
-	headers := []string{"ID", "Task", "Priority", "Status", "Task Age", "Notes", "Project", "Area"}
-	colWidths := map[int]int{0: 2, 1: 15, 2: 10, 3: 10, 4: 10, 5: 15, 6: 10, 7: 10}
+	headers := []string{"ID", "Task", "Priority", "Status", "Due", "Repeats", "Task Age", "Time", "Notes", "Project", "Area", "Blocked By", "Tags"}
+	colWidths := map[int]int{0: 2, 1: 15, 2: 10, 3: 10, 4: 12, 5: 15, 6: 10, 7: 8, 8: 15, 9: 10, 10: 10, 11: 15, 12: 15}
 	return styleTable(rows, headers, colWidths)
 }
 
@@ -398,7 +556,7 @@ func styleAllNotesTable(notes []sqlc.ReadAllNotesRow) *table.Table {
 	for _, note := range notes {
 		rows = append(rows, AllNotesRowWrapper{note})
 	}
-	headers := []string{"ID", "Title", "Path", "Parent Title", "Area/Task"}
-	colWidths := map[int]int{0: 5, 1: 15, 2: 15, 3: 15, 4: 15}
+	headers := []string{"ID", "Title", "Path", "Parents", "Area/Task", "Tags"}
+	colWidths := map[int]int{0: 5, 1: 15, 2: 15, 3: 15, 4: 15, 5: 15}
 	return styleTable(rows, headers, colWidths)
 }
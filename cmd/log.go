@@ -0,0 +1,111 @@
+/*
+Copyright © 2024 Adam Kelly <arkelly111@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/akthe-at/go_task/data"
+	"github.com/akthe-at/go_task/db"
+	"github.com/akthe-at/go_task/sqlc"
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+var logSince string
+
+// logCmd reads back the recorded changes
+var logCmd = &cobra.Command{
+	Use:   "log [task_id]",
+	Short: "Show the history of changes to your tasks, areas, and notes",
+	Long: `Show every recorded change of a task, e.g. go_task log 4, or every change to your tasks, areas, and notes, e.g. go_task log --since 2d
+	--since is a window counting back from today such as 2d or 1w, or a date such as yesterday or 2024-11-01.
+	Each change shows whether it was made from the cli or the tui, the history of data that existed before
+	it was recorded is marked migration.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var taskID int64
+		if len(args) == 1 {
+			var err error
+			taskID, err = strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				log.Fatalf("Error parsing task id %s: %v", args[0], err)
+			}
+		}
+		since, err := data.ResolveSince(logSince, time.Now())
+		if err != nil {
+			log.Fatalf("Invalid --since value: %v", err)
+		}
+
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		events, err := data.ReadEvents(ctx, sqlc.New(conn), taskID, since)
+		if err != nil {
+			log.Fatalf("Error reading the history: %v", err)
+		}
+		if len(events) == 0 {
+			if taskID != 0 {
+				fmt.Printf("No changes were recorded for task %d\n", taskID)
+			} else {
+				fmt.Println("No changes were recorded")
+			}
+			return
+		}
+		printRows(outputFormat(), events, func() fmt.Stringer { return styleEventsTable(events) })
+	},
+}
+
+type EventRowWrapper struct {
+	sqlc.ReadEventsRow
+}
+
+func (e EventRowWrapper) ToRow() []string {
+	return []string{
+		e.ChangedAt,
+		fmt.Sprintf("%s %d %s", e.Entity, e.EntityID, e.Title),
+		data.DescribeEvent(e.ReadEventsRow),
+		e.Source,
+	}
+}
+
+func styleEventsTable(events []sqlc.ReadEventsRow) fmt.Stringer {
+	var rows []TableRow
+	for _, event := range events {
+		rows = append(rows, EventRowWrapper{event})
+	}
+	return styleTable(rows, []string{"When", "What", "Change", "Source"}, map[int]int{0: 21, 1: 30, 2: 40, 3: 11})
+}
+
+func init() {
+	rootCmd.AddCommand(logCmd)
+
+	logCmd.Flags().StringVar(&logSince, "since", "", "Only show changes made since a day or within a window, e.g. 2d, 1w, or 2024-11-01")
+	logCmd.Flags().StringVarP(&outputFlag, "output", "o", "", outputFlagUsage())
+}
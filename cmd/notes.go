@@ -0,0 +1,228 @@
+/*
+Copyright © 2024 Adam Kelly <arkelly111@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/akthe-at/go_task/config"
+	"github.com/akthe-at/go_task/data"
+	"github.com/akthe-at/go_task/db"
+	"github.com/akthe-at/go_task/sqlc"
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+// notesRootCmd represents the notes command
+var notesRootCmd = &cobra.Command{
+	Use:   "notes",
+	Short: "Work with the markdown files of your notes",
+	Long: `The notes subcommands read and update the markdown files behind your notes, e.g.
+	go_task notes sync 4`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("The notes root cmd called without arguments, please provide a subcommand.")
+	},
+}
+
+// notesSyncCmd turns the checkbox lines of notes into tasks
+var notesSyncCmd = &cobra.Command{
+	Use:   "sync <note_id>...",
+	Short: "Sync the checkboxes of a note with tasks",
+	Long: `Sync turns every "- [ ] do thing" line of a note into a task, e.g. go_task notes sync 4
+	Lines in a task note become subtasks of that task, lines in an area note become tasks in that area.
+	Each line gets an invisible <!-- go_task:ID --> marker so later syncs find its task again, keep it when editing the line.
+
+	Ticking a box in the note marks its task done the next time you sync, unticking it reopens the task.
+	Finishing the task in go_task ticks the box in the note right away.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		for _, arg := range args {
+			noteID, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				log.Fatalf("Error parsing note id %s: %v", arg, err)
+			}
+			stats, err := data.SyncNoteCheckboxes(ctx, conn, noteID, time.Now())
+			if err != nil {
+				log.Fatalf("Error syncing note %d: %v", noteID, err)
+			}
+			fmt.Printf("Note %d: created %d tasks, marked %d done, and reopened %d\n", noteID, stats.Created, stats.Completed, stats.Reopened)
+			for _, nextID := range stats.NextTaskIDs {
+				fmt.Printf("A recurring task was finished, the next instance was created with ID %d\n", nextID)
+			}
+			for _, taskID := range stats.StaleTaskIDs {
+				fmt.Printf("Warning: the marker of task %d was not created by this note and was skipped, remove it to create a new task\n", taskID)
+			}
+		}
+	},
+}
+
+// notesResyncCmd repairs the frontmatter of every note
+var notesResyncCmd = &cobra.Command{
+	Use:   "resync",
+	Short: "Write the state of their task or area into the frontmatter of every note",
+	Long: `Resync writes task_id, status, priority, area and archived into the frontmatter of task notes,
+	and area_id, status, area and archived into the frontmatter of area notes, e.g. go_task notes resync
+	go_task keeps these properties up to date whenever a task or area changes, resync repairs notes that were
+	edited by hand or went out of date. Only these properties are touched, the rest of each note is left alone.
+	Notes without a frontmatter block were not generated by go_task and are skipped.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		stats, err := data.ResyncNoteStates(ctx, conn)
+		if err != nil {
+			log.Fatalf("Error resyncing notes: %v", err)
+		}
+		for _, path := range stats.Updated {
+			fmt.Printf("Updated %s\n", path)
+		}
+		for _, path := range stats.Missing {
+			fmt.Printf("Warning: %s does not exist\n", path)
+		}
+		fmt.Printf("Updated %d notes, %d were up to date, %d without frontmatter were skipped, and %d are missing\n",
+			len(stats.Updated), stats.Unchanged, stats.Skipped, len(stats.Missing))
+	},
+}
+
+var (
+	doctorFix   bool
+	doctorAdopt bool
+	doctorPrune bool
+	adoptTaskID int64
+	adoptAreaID int64
+)
+
+// notesDoctorCmd reconciles the notes in the database with the files on disk
+var notesDoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Find notes whose file is gone and markdown files that no note points at",
+	Long: `Doctor compares your notes with the markdown files in your notes path, e.g. go_task notes doctor
+	It reports notes whose file no longer exists and markdown files that no note points at.
+	A note whose file was moved or renamed is found again by the ID in its frontmatter.
+
+	--fix points notes at the file they were found in
+	--adopt creates notes for the files no note points at, they are linked to the task_id or area_id in their
+	frontmatter, or to the task or area passed with --task or --area
+	--prune deletes the notes whose file is gone and was not found anywhere`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if adoptTaskID != 0 && adoptAreaID != 0 {
+			log.Fatalf("Pass either --task or --area, not both")
+		}
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		notesPath := config.UserSettings.Selected.NotesPath
+		report, err := data.DiagnoseNotes(ctx, sqlc.New(conn), notesPath)
+		if err != nil {
+			log.Fatalf("Error checking your notes: %v", err)
+		}
+
+		fmt.Printf("%d notes are fine, %d are missing, and %d files in %s have no note\n", report.Healthy, len(report.Missing), len(report.Orphans), notesPath)
+		for _, note := range report.Missing {
+			if note.FoundPath != "" {
+				fmt.Printf("Moved: note %d %q from %s to %s\n", note.ID, note.Title, note.Path, note.FoundPath)
+			} else {
+				fmt.Printf("Missing: note %d %q, %s does not exist\n", note.ID, note.Title, note.Path)
+			}
+		}
+		for _, orphan := range report.Orphans {
+			fmt.Printf("No note: %s\n", orphan.Path)
+		}
+
+		changed := false
+		if doctorFix {
+			relinked, err := data.RelinkMovedNotes(ctx, conn, report.Missing)
+			if err != nil {
+				log.Fatalf("Error fixing the note paths: %v", err)
+			}
+			fmt.Printf("Fixed the path of %d notes\n", relinked)
+			changed = changed || relinked > 0
+		}
+		if doctorPrune {
+			pruned, err := data.PruneMissingNotes(ctx, conn, report.Missing)
+			if err != nil {
+				log.Fatalf("Error pruning notes: %v", err)
+			}
+			fmt.Printf("Deleted %d notes whose file is gone\n", pruned)
+			changed = changed || pruned > 0
+		}
+		if doctorAdopt {
+			parentType, parentID := data.TaskNoteType, adoptTaskID
+			if adoptAreaID != 0 {
+				parentType, parentID = data.AreaNoteType, adoptAreaID
+			}
+			stats, err := data.AdoptOrphanFiles(ctx, conn, report.Orphans, parentType, parentID)
+			if err != nil {
+				log.Fatalf("Error adopting files: %v", err)
+			}
+			fmt.Printf("Created %d notes\n", len(stats.NoteIDs))
+			for _, path := range stats.NoParent {
+				fmt.Printf("Warning: %s has no task_id or area_id in its frontmatter and was skipped, pass --task or --area to adopt it\n", path)
+			}
+			changed = changed || len(stats.NoteIDs) > 0
+		}
+		if !doctorFix && !doctorPrune && !doctorAdopt && (len(report.Missing) > 0 || len(report.Orphans) > 0) {
+			fmt.Println("Run again with --fix, --adopt, or --prune to repair your notes")
+		}
+
+		if built, err := data.SearchIndexBuilt(ctx, conn); changed && err == nil && built {
+			stats, err := data.Reindex(ctx, conn, notesPath)
+			if err != nil {
+				log.Fatalf("Error rebuilding the search index: %v", err)
+			}
+			printReindexStats(stats)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(notesRootCmd)
+	notesRootCmd.AddCommand(notesSyncCmd)
+	notesRootCmd.AddCommand(notesResyncCmd)
+	notesRootCmd.AddCommand(notesDoctorCmd)
+
+	notesDoctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Point notes at the file they were moved to")
+	notesDoctorCmd.Flags().BoolVar(&doctorAdopt, "adopt", false, "Create notes for markdown files that no note points at")
+	notesDoctorCmd.Flags().BoolVar(&doctorPrune, "prune", false, "Delete notes whose file is gone and was not found anywhere")
+	notesDoctorCmd.Flags().Int64Var(&adoptTaskID, "task", 0, "Task that adopted files without a task_id or area_id are linked to")
+	notesDoctorCmd.Flags().Int64Var(&adoptAreaID, "area", 0, "Area that adopted files without a task_id or area_id are linked to")
+}
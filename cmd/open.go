@@ -26,7 +26,6 @@ import (
 	"fmt"
 	"strconv"
 
-	"github.com/akthe-at/go_task/config"
 	"github.com/akthe-at/go_task/db"
 	"github.com/akthe-at/go_task/sqlc"
 	"github.com/akthe-at/go_task/utils"
@@ -48,7 +47,8 @@ var noteCmd = &cobra.Command{
 	Use:   "note",
 	Short: "Open a note",
 
-	Long: `This command is used to open a note. It requires a noteID to be provided as an argument.`,
+	Long: `This command is used to open a note. It requires a noteID to be provided as an argument.
+	With use_obsidian = true in the [selected] section of your config, notes inside your vault open in Obsidian.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		inputNoteID := args[0]
 		if len(args) != 1 {
@@ -68,8 +68,6 @@ var noteCmd = &cobra.Command{
 			log.Errorf("There was an error converting the noteID to an integer: %v", err)
 		}
 
-		editor := config.GetEditorConfig()
-
 		note, err := queries.ReadNoteByID(ctx, int64(noteID))
 		if err != nil {
 			log.Errorf("ReadNoteByID: There was an error reading the note: %v", err)
@@ -79,7 +77,7 @@ var noteCmd = &cobra.Command{
 			log.Fatalf("There was an error expanding the path: %v", err)
 		}
 
-		utils.OpenNoteInEditor(editor, notePath)
+		utils.OpenNotes(notePath)
 	},
 }
 
@@ -121,8 +119,7 @@ var notesCmd = &cobra.Command{
 			notePaths[i] = notePath
 		}
 
-		editor := config.GetEditorConfig()
-		utils.OpenNoteInEditor(editor, notePaths...)
+		utils.OpenNotes(notePaths...)
 	},
 }
 
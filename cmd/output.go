@@ -0,0 +1,75 @@
+/*
+Copyright © 2024 Adam Kelly <arkelly111@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/akthe-at/go_task/data"
+	"github.com/charmbracelet/log"
+)
+
+var outputFlag string
+
+// outputFormat resolves --output, without the flag a table is drawn for terminals and tsv is written to pipes and files
+func outputFormat() data.OutputFormat {
+	if outputFlag != "" {
+		format, err := data.ParseOutputFormat(outputFlag)
+		if err != nil {
+			log.Fatalf("Invalid --output value: %v", err)
+		}
+		return format
+	}
+	if stdoutIsTerminal() {
+		return data.OutputTable
+	}
+	return data.OutputTSV
+}
+
+func stdoutIsTerminal() bool {
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// printRows writes sqlc rows in the chosen format, drawTable is only called for the table format
+func printRows(format data.OutputFormat, rows interface{}, drawTable func() fmt.Stringer) {
+	if format == data.OutputTable {
+		fmt.Println(drawTable())
+		return
+	}
+	if err := data.WriteRows(os.Stdout, format, rows); err != nil {
+		log.Fatalf("Error writing %s output: %v", format, err)
+	}
+}
+
+func outputFlagUsage() string {
+	var names []string
+	for _, format := range data.OutputFormats {
+		names = append(names, string(format))
+	}
+	return fmt.Sprintf("Output format, one of %s. Defaults to table in a terminal and tsv otherwise", strings.Join(names, ", "))
+}
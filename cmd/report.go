@@ -0,0 +1,118 @@
+/*
+Copyright © 2024 Adam Kelly <arkelly111@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/akthe-at/go_task/data"
+	"github.com/akthe-at/go_task/db"
+	"github.com/akthe-at/go_task/sqlc"
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportBy    string
+	reportSince string
+)
+
+// timeReportHeaders are the headers of the first column of a time report
+var timeReportHeaders = map[data.TimeReportGroup]string{
+	data.TimeByArea: "Area",
+	data.TimeByTask: "Task",
+	data.TimeByRepo: "Repo",
+}
+
+// reportCmd represents the report command
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Summaries of your work",
+	Long: `The report subcommands summarize your work, e.g.
+	go_task report time --by area --since 7d`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("The report root cmd called without arguments, please provide a subcommand.")
+	},
+}
+
+// reportTimeCmd adds up the logged time
+var reportTimeCmd = &cobra.Command{
+	Use:   "time",
+	Short: "Add up the time logged on your tasks",
+	Long: `Add up the time logged on your tasks by area, task, or repo, e.g. go_task report time --by repo --since 2w
+	--since is a window counting back from today such as 7d or 2w, or a date such as yesterday or 2024-11-01.
+	Without --since all logged time is counted. A running timer counts once it is stopped.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		by, err := data.StringToTimeReportGroup(reportBy)
+		if err != nil {
+			log.Fatalf("Invalid --by value: %v", err)
+		}
+		since, err := data.ResolveSince(reportSince, time.Now())
+		if err != nil {
+			log.Fatalf("Invalid --since value: %v", err)
+		}
+
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		report, err := data.TimeReport(ctx, sqlc.New(conn), by, since)
+		if err != nil {
+			log.Fatalf("Error building the time report: %v", err)
+		}
+		if len(report) == 0 {
+			fmt.Println("No time was logged, use 'go_task start <task_id>' or 'go_task time add <task_id> <duration>'")
+			return
+		}
+
+		var total time.Duration
+		var rows []TableRow
+		for _, row := range report {
+			rows = append(rows, TimeReportRowWrapper{row})
+			total += row.Duration
+		}
+		rows = append(rows, TimeReportRowWrapper{data.TimeReportRow{Name: "Total", Duration: total}})
+		fmt.Println(styleTable(rows, []string{timeReportHeaders[by], "Time"}, map[int]int{0: 40, 1: 10}))
+	},
+}
+
+type TimeReportRowWrapper struct {
+	data.TimeReportRow
+}
+
+func (r TimeReportRowWrapper) ToRow() []string {
+	return []string{r.Name, data.FormatTimeSpent(r.Duration)}
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.AddCommand(reportTimeCmd)
+
+	reportTimeCmd.Flags().StringVar(&reportBy, "by", string(data.TimeByTask), "Group the logged time by area, task, or repo")
+	reportTimeCmd.Flags().StringVar(&reportSince, "since", "", "Only count time logged since a day or within a window, e.g. 7d, 2w, or 2024-11-01")
+}
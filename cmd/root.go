@@ -27,12 +27,17 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/akthe-at/go_task/config"
+	"github.com/akthe-at/go_task/data"
+	"github.com/akthe-at/go_task/db"
 	"github.com/akthe-at/go_task/tui"
 	dataTable "github.com/akthe-at/go_task/tui/dataTable"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -45,9 +50,28 @@ var rootCmd = &cobra.Command{
 	Long: `This application consists of a TUI interface and a CLI interface
 	To launch the TUI version, simpy run go_task with no arguments. All other subcommands
 	interact with the CLI version of the application`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		beginUndoStep(cmd, args)
+	},
 	Run: func(cmd *cobra.Command, args []string) {
+		db.EventSource = db.SourceTUI
 		tui.ClearTerminalScreen()
 		model := dataTable.NewRootModel()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to the database: %v", err)
+		}
+		defer conn.Close()
+		model.Conn = conn
+		if notesPath := config.UserSettings.Selected.NotesPath; notesPath != "" {
+			watcher, err := data.WatchNotes(conn, notesPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: changes to the notes in %s will not show up until you switch views: %v\n", notesPath, err)
+			} else {
+				defer watcher.Close()
+				model.NoteChanges = watcher.Changes()
+			}
+		}
 		p := tea.NewProgram(&model)
 		if _, err := p.Run(); err != nil {
 			fmt.Printf("Alas, there's been an error: %v", err)
@@ -79,6 +103,29 @@ func init() {
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 }
 
+// beginUndoStep records what a command changes as one step for undo, e.g. "delete area --notes 4".
+// The TUI records a step per key press instead, undo and the db commands record nothing.
+func beginUndoStep(cmd *cobra.Command, args []string) {
+	if !cmd.HasParent() || cmd == undoCmd || cmd == dbCmd || cmd.Parent() == dbCmd {
+		return
+	}
+	words := strings.Fields(strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()))
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		if flag.Value.Type() == "bool" {
+			words = append(words, "--"+flag.Name)
+		} else {
+			words = append(words, "--"+flag.Name, flag.Value.String())
+		}
+	})
+	for _, arg := range args {
+		if strings.ContainsAny(arg, " \t") {
+			arg = strconv.Quote(arg)
+		}
+		words = append(words, arg)
+	}
+	db.BeginUndoStep(strings.Join(words, " "))
+}
+
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
 	home, err := os.UserHomeDir()
@@ -90,6 +137,7 @@ func initConfig() {
 	if cfgFile != "" {
 		// Use config file from the flag.
 		viper.SetConfigFile(cfgFile)
+		configPath = filepath.Dir(cfgFile)
 	} else {
 		switch runtime.GOOS {
 		case "windows":
@@ -111,6 +159,11 @@ func initConfig() {
 		}
 	}
 
+	config.ConfigDir = configPath
+	if err := data.InstallNoteTemplates(config.TemplatesDir()); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: the default note templates were not installed: %v\n", err)
+	}
+
 	// TODO: This needs a better name.
 	viper.AddConfigPath(configPath)
 	viper.SetConfigType("toml")
@@ -129,6 +182,9 @@ func initConfig() {
 	config.UserSettings.Selected.NotesPath = viper.GetString("selected.notes_path")
 	config.UserSettings.Selected.UseObsidian = viper.GetBool("selected.use_obsidian")
 	config.UserSettings.Selected.Theme = viper.GetString("selected.theme")
+	config.UserSettings.Tasks.OnParentDone = viper.GetString("tasks.on_parent_done")
+	config.UserSettings.Pomodoro.Work = viper.GetString("pomodoro.work")
+	config.UserSettings.Pomodoro.Break = viper.GetString("pomodoro.break")
 
 	var userThemes tui.ColorThemes
 	if err := viper.Unmarshal(&userThemes); err != nil {
@@ -0,0 +1,161 @@
+/*
+Copyright © 2024 Adam Kelly <arkelly111@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/akthe-at/go_task/config"
+	"github.com/akthe-at/go_task/data"
+	"github.com/akthe-at/go_task/db"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+var searchLimit int
+
+// searchCmd represents the search command
+var searchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Full-text search across tasks, areas, and notes",
+	Long: `
+	Search the titles of your tasks, areas, and notes, and the contents of the markdown files in your notes path.
+	Results are ranked with the best match first, and matches in titles rank above matches in note bodies.
+	Every word is matched as a prefix and all words have to match, e.g. 'go_task search quarter tax'
+
+	The search index is built the first time you search. Titles are kept up to date automatically,
+	run 'go_task db reindex' when note files were changed outside of go_task.
+	Ranked full-text search uses SQLite's FTS5 module, which is only compiled in with the sqlite_fts5 build tag:
+	go install -tags sqlite_fts5 github.com/akthe-at/go_task@latest
+	Without it go_task falls back to matching every word anywhere in the titles and note bodies.
+	Both builds can use the same database, the index itself does not need FTS5.
+	`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		stats, err := data.EnsureSearchIndex(ctx, conn, config.UserSettings.Selected.NotesPath)
+		if err != nil {
+			log.Fatalf("Error preparing the search index: %v", err)
+		}
+		if stats != nil {
+			printReindexStats(*stats)
+		}
+
+		results, err := data.Search(ctx, conn, strings.Join(args, " "), searchLimit)
+		if err != nil {
+			log.Fatalf("Error searching: %v", err)
+		}
+		if len(results) == 0 {
+			fmt.Println("No matches found")
+			return
+		}
+		fmt.Println(styleSearchTable(results))
+	},
+}
+
+// dbReindexCmd rebuilds the search index
+var dbReindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Rebuild the full-text search index.",
+	Long: `This command rebuilds the search index from your tasks, areas, and notes and re-reads every markdown file in your notes path.
+	Run it after note files were changed outside of go_task.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		notesPath := config.UserSettings.Selected.NotesPath
+		stats, err := data.EnsureSearchIndex(ctx, conn, notesPath)
+		if err != nil {
+			log.Fatalf("Error preparing the search index: %v", err)
+		}
+		// A brand new index has just been filled, there is no need to do it twice
+		if stats == nil {
+			reindexed, err := data.Reindex(ctx, conn, notesPath)
+			if err != nil {
+				log.Fatalf("Error rebuilding the search index: %v", err)
+			}
+			stats = &reindexed
+		}
+		printReindexStats(*stats)
+	},
+}
+
+func printReindexStats(stats data.ReindexStats) {
+	fmt.Printf("Indexed %d tasks, %d areas, %d notes, and %d other markdown files\n",
+		stats.Tasks, stats.Areas, stats.Notes, stats.Files)
+	for _, missing := range stats.MissingNotes {
+		fmt.Printf("Warning: the file for note %s is missing, only its title was indexed\n", missing)
+	}
+}
+
+type SearchResultRowWrapper struct {
+	data.SearchResult
+}
+
+func (s SearchResultRowWrapper) ToRow() []string {
+	var id string
+	if s.Entity != data.SearchEntityFile {
+		id = fmt.Sprintf("%d", s.ID)
+	}
+	formattedPath := filepath.Base(s.Path)
+	if formattedPath == "." {
+		formattedPath = ""
+	}
+	return []string{
+		string(s.Entity),
+		id,
+		s.Title,
+		strings.Join(strings.Fields(s.Snippet), " "),
+		formattedPath,
+	}
+}
+
+func styleSearchTable(results []data.SearchResult) *table.Table {
+	var rows []TableRow
+	for _, result := range results {
+		rows = append(rows, SearchResultRowWrapper{result})
+	}
+	headers := []string{"Type", "ID", "Title", "Match", "File"}
+	colWidths := map[int]int{0: 6, 1: 5, 2: 20, 3: 40, 4: 20}
+	return styleTable(rows, headers, colWidths)
+}
+
+func init() {
+	rootCmd.AddCommand(searchCmd)
+	dbCmd.AddCommand(dbReindexCmd)
+
+	searchCmd.Flags().IntVarP(&searchLimit, "limit", "n", 20, "Maximum number of results to show")
+}
@@ -0,0 +1,159 @@
+/*
+Copyright © 2024 Adam Kelly <arkelly111@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/akthe-at/go_task/data"
+	"github.com/akthe-at/go_task/db"
+	"github.com/akthe-at/go_task/sqlc"
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+var timeDate string
+
+// startCmd starts the timer on a task
+var startCmd = &cobra.Command{
+	Use:   "start <task_id>",
+	Short: "Start the timer on a task",
+	Long: `Start the timer on a task, e.g. go_task start 4
+	Only one timer runs at a time, a timer running on another task is stopped first.
+	Use 'go_task stop' to stop the timer and log the time spent.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		taskID, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			log.Fatalf("Error parsing task id %s: %v", args[0], err)
+		}
+
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		started, stopped, err := data.StartTimer(ctx, conn, taskID, time.Now())
+		if errors.Is(err, data.ErrTimerRunning) {
+			fmt.Printf("The timer is already running on task %d %q since %s\n", taskID, started.TaskTitle, started.StartedAt.Format(time.Kitchen))
+			return
+		} else if err != nil {
+			log.Fatalf("Error starting the timer: %v", err)
+		}
+		for _, entry := range stopped {
+			fmt.Printf("Stopped the timer on task %d %q, logged %s\n", entry.TaskID, entry.TaskTitle, data.FormatTimeSpent(entry.Duration))
+		}
+		fmt.Printf("Started the timer on task %d %q\n", started.TaskID, started.TaskTitle)
+	},
+}
+
+// stopCmd stops the running timer
+var stopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the running timer",
+	Long:  `Stop the running timer and log the time since it was started on its task.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		stopped, ok, err := data.StopTimer(ctx, conn, time.Now())
+		if err != nil {
+			log.Fatalf("Error stopping the timer: %v", err)
+		}
+		if !ok {
+			fmt.Println("No timer is running, start one with 'go_task start <task_id>'")
+			return
+		}
+		fmt.Printf("Stopped the timer on task %d %q, logged %s\n", stopped.TaskID, stopped.TaskTitle, data.FormatTimeSpent(stopped.Duration))
+	},
+}
+
+// timeCmd represents the time command
+var timeCmd = &cobra.Command{
+	Use:   "time",
+	Short: "Log the time spent on tasks",
+	Long: `The time subcommands log time on tasks that was not tracked with 'go_task start' and 'go_task stop', e.g.
+	go_task time add 4 1h30m`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("The time root cmd called without arguments, please provide a subcommand.")
+	},
+}
+
+// timeAddCmd logs time on a task by hand
+var timeAddCmd = &cobra.Command{
+	Use:   "add <task_id> <duration>",
+	Short: "Log time spent on a task",
+	Long: `Log time spent on a task, e.g. go_task time add 4 1h30m --date yesterday
+	The duration is written like 1h30m, 45m, or 2h. Without --date the time is logged today.
+	Dates can be given as ` + data.DateInputHelp + `.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		taskID, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			log.Fatalf("Error parsing task id %s: %v", args[0], err)
+		}
+		spent, err := data.ParseTimeSpent(args[1])
+		if err != nil {
+			log.Fatalf("Error reading the time spent: %v", err)
+		}
+		day := time.Now()
+		if timeDate != "" {
+			day, err = data.ResolveDate(timeDate, day)
+			if err != nil {
+				log.Fatalf("Error reading the date: %v", err)
+			}
+		}
+
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		entry, err := data.AddTime(ctx, sqlc.New(conn), taskID, spent, day)
+		if err != nil {
+			log.Fatalf("Error logging time: %v", err)
+		}
+		fmt.Printf("Logged %s on task %d %q on %s\n", data.FormatTimeSpent(entry.Duration), entry.TaskID, entry.TaskTitle, data.FormatResolvedDate(entry.StartedAt))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(startCmd)
+	rootCmd.AddCommand(stopCmd)
+	rootCmd.AddCommand(timeCmd)
+	timeCmd.AddCommand(timeAddCmd)
+
+	timeAddCmd.Flags().StringVar(&timeDate, "date", "", "Day the time was spent, defaults to today")
+}
@@ -0,0 +1,106 @@
+/*
+Copyright © 2024 Adam Kelly <arkelly111@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/akthe-at/go_task/data"
+	"github.com/akthe-at/go_task/db"
+	"github.com/akthe-at/go_task/sqlc"
+	"github.com/akthe-at/go_task/tui"
+	"github.com/akthe-at/go_task/tui/formInput"
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+var undoList bool
+
+// undoCmd reverts the latest changes
+var undoCmd = &cobra.Command{
+	Use:   "undo [steps]",
+	Short: "Undo the latest changes to your tasks, areas, and notes",
+	Long: `Undo what the last command changed, e.g. go_task undo, or the last few, e.g. go_task undo 3
+	Every command and every key pressed in the TUI is one step, a delete is undone together with the
+	note links, project links, tags, and time entries it removed. --list picks a step from the latest
+	ones, it is undone along with every step made after it. The last 100 steps can be undone.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		steps := 1
+		if len(args) == 1 {
+			var err error
+			steps, err = strconv.Atoi(args[0])
+			if err != nil || steps < 1 {
+				log.Fatalf("Invalid number of steps %s, expected a number above 0", args[0])
+			}
+		}
+
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		var undone []sqlc.ReadUndoStepsRow
+		if undoList {
+			latest, err := data.ReadUndoSteps(ctx, sqlc.New(conn), data.MaxUndoSteps)
+			if err != nil {
+				log.Fatalf("Error reading the undo steps: %v", err)
+			}
+			if len(latest) == 0 {
+				fmt.Println("There is nothing to undo")
+				return
+			}
+			form := &formInput.UndoForm{}
+			if err := form.NewUndoForm(*tui.ThemeGoTask(tui.GetSelectedTheme()), latest); err != nil {
+				log.Fatalf("Error creating form: %v", err)
+			}
+			if !form.Submit {
+				fmt.Println("Nothing was undone")
+				return
+			}
+			undone, err = data.UndoThrough(ctx, conn, form.StepID)
+		} else {
+			undone, err = data.UndoLast(ctx, conn, steps)
+		}
+		if errors.Is(err, data.ErrNothingToUndo) {
+			fmt.Println("There is nothing to undo")
+			return
+		}
+		if err != nil {
+			log.Fatalf("Error undoing the changes: %v", err)
+		}
+		for _, step := range undone {
+			fmt.Printf("Undid %s (%s, %s)\n", step.Description, step.Source, step.CreatedAt)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+
+	undoCmd.Flags().BoolVar(&undoList, "list", false, "Pick the step to undo back to from the latest changes")
+}
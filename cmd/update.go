@@ -27,7 +27,10 @@ import (
 	"fmt"
 	"log"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/akthe-at/go_task/config"
 	"github.com/akthe-at/go_task/data"
 	"github.com/akthe-at/go_task/db"
 	"github.com/akthe-at/go_task/sqlc"
@@ -55,7 +58,16 @@ var updateTaskCmd = &cobra.Command{
 	Short: "Update a task's field",
 	Long: `To update a task you must pass  the field you wish to to modify, followed by the id of the task, and the new value for that field. 
 	For example, to update the title of a task with an id of 1 you would pass the following command:
-	go_task update task title 1 "New Title"`,
+	go_task update task title 1 "New Title"
+
+	Due dates are set with: go_task update task due 1 2024-11-05
+	Relative dates such as today, tomorrow, fri, "next monday", +3d, eow, and eom work as well.
+	Pass "none" as the new value to clear the due date of a task.
+	Recurring tasks are set up with: go_task update task recur 1 weekly
+	Rules such as daily, monthly, "every 3 days", or an RRULE like "FREQ=WEEKLY;BYDAY=MO,WE" are supported.
+	Pass "none" as the new value to stop a task from recurring.
+	Tags are changed with: go_task update task tag add 1 work home
+	and removed with: go_task update task tag remove 1 home`,
 	Run: func(cmd *cobra.Command, args []string) {
 		var (
 			inputID    = args[1]
@@ -98,6 +110,7 @@ var updateTaskCmd = &cobra.Command{
 			if err != nil {
 				log.Fatalf("There was an error updating the task priority: %v", err)
 			}
+			syncTaskNoteStates(ctx, conn, convertedID)
 
 		case "status":
 			status, err := data.StringToStatusType(inputEdit)
@@ -105,13 +118,26 @@ var updateTaskCmd = &cobra.Command{
 				log.Fatalf("Invalid status type: %v", err)
 			}
 
-			_, err = queries.UpdateTaskStatus(ctx, sqlc.UpdateTaskStatusParams{
-				Status: sql.NullString{String: string(status), Valid: true},
-				ID:     convertedID,
-			})
+			change, err := data.SetTaskStatus(ctx, conn, convertedID, status, time.Now())
 			if err != nil {
 				log.Fatalf("Error updating task status: %v", err)
 			}
+			for _, nextID := range change.NextTaskIDs {
+				fmt.Printf("A recurring task was finished, the next instance was created with ID %d\n", nextID)
+			}
+			for _, subtaskID := range change.CascadedIDs {
+				fmt.Printf("Subtask %d was marked done along with task %d\n", subtaskID, convertedID)
+			}
+			for _, path := range change.UpdatedNotes {
+				fmt.Printf("Updated the checkbox in %s\n", path)
+			}
+			if len(change.OpenSubtasks) > 0 {
+				fmt.Printf("Warning: task %d was marked done but these subtasks are still open:\n", convertedID)
+				for _, subtask := range change.OpenSubtasks {
+					fmt.Printf("  %d %s (%s)\n", subtask.ID, subtask.Title, subtask.Status.String)
+				}
+				fmt.Println("Set on_parent_done = \"cascade\" in the [tasks] section of your config to mark them done automatically.")
+			}
 
 		case "area":
 			areaID, err := strconv.ParseInt(inputEdit, 10, 64)
@@ -122,9 +148,48 @@ var updateTaskCmd = &cobra.Command{
 			if err != nil {
 				log.Fatalf("Error updating task area: %v", err)
 			}
+			syncTaskNoteStates(ctx, conn, convertedID)
+
+		case "due":
+			// "none" clears the due date of the task
+			if inputEdit == "none" {
+				inputEdit = ""
+			}
+			due, err := data.ToNullDueDate(inputEdit)
+			if err != nil {
+				log.Fatalf("Invalid due date: %v", err)
+			}
+
+			_, err = queries.UpdateTaskDueDate(ctx, sqlc.UpdateTaskDueDateParams{
+				DueDate: due,
+				ID:      convertedID,
+			})
+			if err != nil {
+				log.Fatalf("Error updating task due date: %v", err)
+			}
+			fmt.Printf("Due date for task %d set to %s\n", convertedID, data.DescribeDueDate(due))
+
+		case "recur":
+			// "none" stops the task from recurring
+			if inputEdit == "none" {
+				inputEdit = ""
+			}
+			recurrence, err := data.ToNullRecurrence(inputEdit)
+			if err != nil {
+				log.Fatalf("Invalid recurrence: %v", err)
+			}
+
+			_, err = queries.UpdateTaskRecurrence(ctx, sqlc.UpdateTaskRecurrenceParams{
+				Recurrence: recurrence,
+				ID:         convertedID,
+			})
+			if err != nil {
+				log.Fatalf("Error updating task recurrence: %v", err)
+			}
+			fmt.Printf("Task %d now recurs: %s\n", convertedID, data.DescribeRecurrence(recurrence))
 
 		case "archived":
-			archiveState, err := strconv.ParseBool(inputField)
+			archiveState, err := strconv.ParseBool(inputEdit)
 			if err != nil {
 				log.Fatalf("Invalid archive state: %v", err)
 			}
@@ -136,6 +201,7 @@ var updateTaskCmd = &cobra.Command{
 			if err != nil {
 				log.Fatalf("Error updating the archive status: %v", err)
 			}
+			syncTaskNoteStates(ctx, conn, convertedID)
 
 		default:
 			fmt.Printf("Unknown field: %v", inputField)
@@ -143,12 +209,147 @@ var updateTaskCmd = &cobra.Command{
 	},
 }
 
+// updateTaskTagCmd adds or removes tags on a task
+var updateTaskTagCmd = &cobra.Command{
+	Use:   "tag add|remove <task_id> <tag>...",
+	Short: "Add or remove tags on a task",
+	Long: `Add or remove one or more tags on a task. Tags can be separated by spaces or commas.
+	For example:
+	go_task update task tag add 1 work home
+	go_task update task tag remove 1 home`,
+	Run: func(cmd *cobra.Command, args []string) {
+		action, taskID, tags := parseTagArgs(args)
+
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+		queries := sqlc.New(conn)
+
+		if _, err := queries.ReadTaskForRecurrence(ctx, taskID); err != nil {
+			log.Fatalf("Could not find task %d: %v", taskID, err)
+		}
+
+		switch action {
+		case "add":
+			err = data.AddTaskTags(ctx, queries, taskID, tags)
+		case "remove":
+			err = data.RemoveTaskTags(ctx, queries, taskID, tags)
+		}
+		if err != nil {
+			log.Fatalf("Error updating task tags: %v", err)
+		}
+
+		current, err := queries.ReadTaskTags(ctx, taskID)
+		if err != nil {
+			log.Fatalf("Error reading task tags: %v", err)
+		}
+		fmt.Printf("Task %d is tagged: %s\n", taskID, describeTags(current))
+	},
+}
+
+// updateNoteCmd represents the note update command
+var updateNoteCmd = &cobra.Command{
+	Use:   "note",
+	Short: "Update a note's details",
+	Long:  `Update the details of a note, see the tag subcommand for changing the tags of a note.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("update note called without subcommand, please see help for more information")
+	},
+}
+
+// updateNoteTagCmd adds or removes tags on a note and keeps the note's frontmatter in sync
+var updateNoteTagCmd = &cobra.Command{
+	Use:   "tag add|remove <note_id> <tag>...",
+	Short: "Add or remove tags on a note",
+	Long: `Add or remove one or more tags on a note. Tags can be separated by spaces or commas.
+	Notes generated by go_task have their frontmatter Tags updated to match.
+	For example:
+	go_task update note tag add 1 work home
+	go_task update note tag remove 1 home`,
+	Run: func(cmd *cobra.Command, args []string) {
+		action, noteID, tags := parseTagArgs(args)
+
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+		queries := sqlc.New(conn)
+
+		note, err := queries.ReadNoteByID(ctx, noteID)
+		if err != nil {
+			log.Fatalf("Could not find note %d: %v", noteID, err)
+		}
+
+		switch action {
+		case "add":
+			err = data.AddNoteTags(ctx, queries, noteID, tags)
+		case "remove":
+			err = data.RemoveNoteTags(ctx, queries, noteID, tags)
+		}
+		if err != nil {
+			log.Fatalf("Error updating note tags: %v", err)
+		}
+
+		current, err := queries.ReadNoteTags(ctx, noteID)
+		if err != nil {
+			log.Fatalf("Error reading note tags: %v", err)
+		}
+		fmt.Printf("Note %d is tagged: %s\n", noteID, describeTags(current))
+
+		rewritten, err := data.WriteFrontmatterTags(note.Path, current)
+		if err != nil {
+			log.Fatalf("Error updating the frontmatter of %s: %v", note.Path, err)
+		}
+		if rewritten {
+			fmt.Printf("Updated the frontmatter tags of %s\n", note.Path)
+			if err := data.RefreshNoteIndex(ctx, conn, noteID, note.Path); err != nil {
+				fmt.Printf("Warning: the search index was not updated: %v\n", err)
+			}
+		}
+	},
+}
+
+// parseTagArgs reads the add|remove action, the ID, and the tags passed to the tag subcommands
+func parseTagArgs(args []string) (string, int64, []string) {
+	if len(args) < 3 {
+		log.Fatalf("You must provide an action, an ID, and at least one tag! Usage: tag add|remove <id> <tag>...")
+	}
+	action := args[0]
+	if action != "add" && action != "remove" {
+		log.Fatalf("Unknown tag action %q, must be either add or remove", action)
+	}
+	id, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		log.Fatalf("Error parsing id: %v", err)
+	}
+	tags := data.ParseTags(args[2:]...)
+	if len(tags) == 0 {
+		log.Fatalf("You must provide at least one tag")
+	}
+	return action, id, tags
+}
+
+func describeTags(tags []string) string {
+	if len(tags) == 0 {
+		return "no tags"
+	}
+	return strings.Join(tags, ", ")
+}
+
 // updateAreaCmd represents the project update command
 var updateAreaCmd = &cobra.Command{
 	Use:   "area",
 	Short: "Update area details",
 	Long: `You must pass the id for the area that you wish to update...followed by the field that you wish to
-	update such as title, status, etc.`,
+	update such as title, status, etc.
+
+	'template' sets the note template used for new notes of the area and its tasks, 'none' removes it:
+	go_task update area template <area_id> meeting`,
 	Run: func(cmd *cobra.Command, args []string) {
 		var (
 			inputID    = args[1]
@@ -177,9 +378,10 @@ var updateAreaCmd = &cobra.Command{
 			if err != nil {
 				log.Fatalf("Error updating area title: %v", err)
 			}
+			syncAreaNoteStates(ctx, conn, convertedID)
 
 		case "status":
-			status, err := data.StringToStatusType(inputField)
+			status, err := data.StringToStatusType(inputEdit)
 			if err != nil {
 				log.Fatalf("Invalid status type: %v", err)
 			}
@@ -191,9 +393,10 @@ var updateAreaCmd = &cobra.Command{
 			if err != nil {
 				log.Fatalf("Error updating area status: %v", err)
 			}
+			syncAreaNoteStates(ctx, conn, convertedID)
 
 		case "archived":
-			archiveState, err := strconv.ParseBool(inputField)
+			archiveState, err := strconv.ParseBool(inputEdit)
 			if err != nil {
 				log.Fatalf("Invalid archive state: %v", err)
 			}
@@ -205,6 +408,25 @@ var updateAreaCmd = &cobra.Command{
 			if err != nil {
 				log.Fatalf("Error updating the archive status: %v", err)
 			}
+			syncAreaNoteStates(ctx, conn, convertedID)
+
+		case "template":
+			noteTemplate := sql.NullString{String: inputEdit, Valid: inputEdit != data.NoNoteTemplate}
+			if noteTemplate.Valid {
+				if _, err := data.LoadNoteTemplate(config.TemplatesDir(), inputEdit); err != nil {
+					log.Fatalf("Invalid note template: %v", err)
+				}
+			}
+			updated, err := queries.UpdateAreaNoteTemplate(ctx, sqlc.UpdateAreaNoteTemplateParams{
+				NoteTemplate: noteTemplate,
+				ID:           convertedID,
+			})
+			if err != nil {
+				log.Fatalf("Error updating the note template: %v", err)
+			}
+			if updated == 0 {
+				log.Fatalf("Area %d does not exist", convertedID)
+			}
 
 		default:
 			fmt.Printf("Unknown field: %v", inputField)
@@ -212,8 +434,25 @@ var updateAreaCmd = &cobra.Command{
 	},
 }
 
+// syncTaskNoteStates writes the new state of a task into the frontmatter of its notes
+func syncTaskNoteStates(ctx context.Context, conn *sql.DB, taskID int64) {
+	if _, err := data.SyncTaskNoteStates(ctx, conn, taskID); err != nil {
+		log.Fatalf("The task was updated but the frontmatter of its notes was not: %v", err)
+	}
+}
+
+// syncAreaNoteStates writes the new state of an area into the frontmatter of its notes and the notes of its tasks
+func syncAreaNoteStates(ctx context.Context, conn *sql.DB, areaID int64) {
+	if _, err := data.SyncAreaNoteStates(ctx, conn, areaID); err != nil {
+		log.Fatalf("The area was updated but the frontmatter of its notes was not: %v", err)
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(updateCmd)
 	updateCmd.AddCommand(updateTaskCmd)
 	updateCmd.AddCommand(updateAreaCmd)
+	updateCmd.AddCommand(updateNoteCmd)
+	updateTaskCmd.AddCommand(updateTaskTagCmd)
+	updateNoteCmd.AddCommand(updateNoteTagCmd)
 }
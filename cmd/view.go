@@ -0,0 +1,142 @@
+/*
+Copyright © 2024 Adam Kelly <arkelly111@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/akthe-at/go_task/data"
+	"github.com/akthe-at/go_task/db"
+	"github.com/akthe-at/go_task/sqlc"
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+// viewCmd represents the view command
+var viewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Manage saved task filters",
+	Long: `Saved views give a name to a task filter so it can be reused, e.g.
+	go_task view save focus 'status:doing priority>=high -archived'
+	go_task list tasks --view focus
+
+	Quote the whole filter so the shell does not read > or < as a redirect.
+	` + data.FilterHelp,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("The view root cmd called without arguments, please provide a subcommand.")
+	},
+}
+
+// viewSaveCmd saves a filter under a name
+var viewSaveCmd = &cobra.Command{
+	Use:   "save <name> <filter>",
+	Short: "Save a task filter as a view",
+	Long: `Save a task filter under a name. Saving a view with a name that already exists replaces its filter.
+
+	Quote the whole filter, e.g. go_task view save focus 'status:doing priority>=high -archived'
+	Everything after the name is read as the filter, so a negated term such as -archived is not taken for a flag.`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+		queries := sqlc.New(conn)
+
+		expression := data.JoinFilterArgs(args[1:])
+		if err := data.SaveView(ctx, queries, args[0], expression, data.CurrentFilterEnv()); err != nil {
+			log.Fatalf("Error saving view: %v", err)
+		}
+		fmt.Printf("Saved view %s: %s\n", args[0], expression)
+	},
+}
+
+// viewListCmd lists the saved views
+var viewListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List your saved views",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+		queries := sqlc.New(conn)
+
+		views, err := queries.ReadSavedViews(ctx)
+		if err != nil {
+			log.Fatalf("Error reading saved views: %v", err)
+		}
+		if len(views) == 0 {
+			fmt.Println("No saved views yet, create one with 'go_task view save <name> <filter>'")
+			return
+		}
+
+		var rows []TableRow
+		for _, view := range views {
+			rows = append(rows, SavedViewRowWrapper{view})
+		}
+		fmt.Println(styleTable(rows, []string{"Name", "Filter"}, map[int]int{0: 15, 1: 60}))
+	},
+}
+
+// viewDeleteCmd deletes a saved view
+var viewDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a saved view",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		conn, _, err := db.ConnectDB()
+		if err != nil {
+			log.Fatalf("Error connecting to database: %v", err)
+		}
+		defer conn.Close()
+
+		if err := data.DeleteView(ctx, sqlc.New(conn), args[0]); err != nil {
+			log.Fatalf("Error deleting view: %v", err)
+		}
+		fmt.Printf("Deleted view %s\n", args[0])
+	},
+}
+
+type SavedViewRowWrapper struct {
+	sqlc.SavedView
+}
+
+func (v SavedViewRowWrapper) ToRow() []string {
+	return []string{v.Name, v.Filter}
+}
+
+func init() {
+	rootCmd.AddCommand(viewCmd)
+	viewCmd.AddCommand(viewSaveCmd)
+	viewCmd.AddCommand(viewListCmd)
+	viewCmd.AddCommand(viewDeleteCmd)
+
+	// Stop parsing flags at the name so negated filter terms such as -archived reach the filter
+	viewSaveCmd.Flags().SetInterspersed(false)
+}
@@ -2,16 +2,26 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/charmbracelet/log"
 )
 
 var UserSettings Config
 
+// ConfigDir is the directory holding config.toml, it is set when the config is read
+var ConfigDir string
+
 type Config struct {
-	Selected NoteSettings `toml:"selected"`
+	Selected NoteSettings     `toml:"selected"`
+	Tasks    TaskSettings     `toml:"tasks"`
+	Pomodoro PomodoroSettings `toml:"pomodoro"`
 }
 
+// NoteSettings controls how notes are written and opened
+// UseObsidian opens notes through obsidian:// URIs and writes Obsidian properties with wikilinks
+// to the parent notes into generated notes, the vault is the closest directory above NotesPath holding .obsidian.
 type NoteSettings struct {
 	Editor      string `toml:"editor"`
 	NotesPath   string `toml:"notes_path"`
@@ -19,6 +29,38 @@ type NoteSettings struct {
 	Theme       string `toml:"theme"`
 }
 
+// TaskSettings controls how tasks behave
+// OnParentDone is either "warn" (the default) or "cascade" and decides what happens
+// when a task is marked done while some of its subtasks are still open.
+type TaskSettings struct {
+	OnParentDone string `toml:"on_parent_done"`
+}
+
+// PomodoroSettings sets the intervals of the focus view as durations such as 25m or 1h
+type PomodoroSettings struct {
+	Work  string `toml:"work"`
+	Break string `toml:"break"`
+}
+
+// GetPomodoroIntervals gets the work and break intervals from the config file
+// An interval that is not set or is not a positive duration falls back to its default.
+func GetPomodoroIntervals(defaultWork, defaultBreak time.Duration) (time.Duration, time.Duration) {
+	return pomodoroInterval("work", UserSettings.Pomodoro.Work, defaultWork),
+		pomodoroInterval("break", UserSettings.Pomodoro.Break, defaultBreak)
+}
+
+func pomodoroInterval(key, value string, fallback time.Duration) time.Duration {
+	if value == "" {
+		return fallback
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < time.Second {
+		log.Warnf("Invalid pomodoro.%s ( %s ) in config file, falling back to %s.", key, value, fallback)
+		return fallback
+	}
+	return interval
+}
+
 // GetEditorConfig gets the editor from the config file
 // If no editor is set in the config file, it falls back to $EDITOR
 func GetEditorConfig() string {
@@ -29,3 +71,8 @@ func GetEditorConfig() string {
 	}
 	return editor
 }
+
+// TemplatesDir is the directory holding the note templates, next to config.toml
+func TemplatesDir() string {
+	return filepath.Join(ConfigDir, "templates")
+}
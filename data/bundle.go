@@ -0,0 +1,692 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/akthe-at/go_task/db"
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+// BundleVersion is the version of the export format written by ExportBundle.
+// Fields added since, such as completed_at, are optional and older builds skip them, so they keep the version,
+// it only goes up for a change an older build would read wrong.
+const BundleVersion = 1
+
+var (
+	// ErrInvalidBundle is returned when an import bundle can not be read or refers to rows it does not contain
+	ErrInvalidBundle = errors.New("invalid import bundle")
+	// ErrUnknownImportMode is returned for an import mode other than merge or replace
+	ErrUnknownImportMode = errors.New("unknown import mode")
+)
+
+type ImportMode string
+
+const (
+	// ImportMerge adds the bundle next to the existing data, every row gets a new ID
+	ImportMerge ImportMode = "merge"
+	// ImportReplace deletes the existing data first and keeps the IDs from the bundle
+	ImportReplace ImportMode = "replace"
+)
+
+// ParseImportMode checks an --mode value
+func ParseImportMode(input string) (ImportMode, error) {
+	switch ImportMode(input) {
+	case ImportMerge, ImportReplace:
+		return ImportMode(input), nil
+	}
+	return "", fmt.Errorf("%w: %q, use merge or replace", ErrUnknownImportMode, input)
+}
+
+// Bundle is a versioned JSON document holding every row of the database, note files are not included
+type Bundle struct {
+	Version          int                `json:"version"`
+	ExportedAt       time.Time          `json:"exported_at"`
+	Areas            []BundleArea       `json:"areas"`
+	Tasks            []BundleTask       `json:"tasks"`
+	Notes            []BundleNote       `json:"notes"`
+	BridgeNotes      []BundleBridgeNote `json:"bridge_notes"`
+	NoteCheckboxes   []BundleCheckbox   `json:"note_checkboxes"`
+	Projects         []BundleProject    `json:"programming_projects"`
+	ProjectLinks     []BundleLink       `json:"prog_project_links"`
+	TaskDependencies []BundleDependency `json:"task_dependencies"`
+	TaskTags         []BundleTag        `json:"task_tags"`
+	AreaTags         []BundleTag        `json:"area_tags"`
+	NoteTags         []BundleTag        `json:"note_tags"`
+	SavedViews       []BundleView       `json:"saved_views"`
+	TimeEntries      []BundleTimeEntry  `json:"time_entries"`
+}
+
+type BundleArea struct {
+	ID           int64   `json:"id"`
+	Title        string  `json:"title"`
+	Status       *string `json:"status"`
+	Archived     bool    `json:"archived"`
+	CreatedAt    string  `json:"created_at"`
+	LastMod      string  `json:"last_mod"`
+	NoteTemplate *string `json:"note_template,omitempty"`
+}
+
+type BundleTask struct {
+	ID           int64   `json:"id"`
+	Title        string  `json:"title"`
+	Priority     *string `json:"priority"`
+	Status       *string `json:"status"`
+	Archived     bool    `json:"archived"`
+	CreatedAt    string  `json:"created_at"`
+	LastMod      string  `json:"last_mod"`
+	DueDate      *string `json:"due_date"`
+	AreaID       *int64  `json:"area_id"`
+	Recurrence   *string `json:"recurrence"`
+	ParentTaskID *int64  `json:"parent_task_id"`
+	CompletedAt  *string `json:"completed_at,omitempty"`
+}
+
+type BundleNote struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	Path  string `json:"path"`
+}
+
+type BundleBridgeNote struct {
+	NoteID       int64  `json:"note_id"`
+	ParentCat    *int64 `json:"parent_cat"`
+	ParentTaskID *int64 `json:"parent_task_id"`
+	ParentAreaID *int64 `json:"parent_area_id"`
+}
+
+// BundleCheckbox links a task to the note whose checkbox line it was created from
+type BundleCheckbox struct {
+	TaskID int64 `json:"task_id"`
+	NoteID int64 `json:"note_id"`
+}
+
+type BundleProject struct {
+	ID   int64  `json:"id"`
+	Path string `json:"path"`
+}
+
+type BundleLink struct {
+	ProjectID    *int64 `json:"project_id"`
+	ParentCat    *int64 `json:"parent_cat"`
+	ParentTaskID *int64 `json:"parent_task_id"`
+	ParentAreaID *int64 `json:"parent_area_id"`
+}
+
+type BundleDependency struct {
+	BlockerID int64 `json:"blocker_id"`
+	BlockedID int64 `json:"blocked_id"`
+}
+
+// BundleTag links a task, area, or note to a tag, tags are stored by name so they merge with existing tags
+type BundleTag struct {
+	ID  int64  `json:"id"`
+	Tag string `json:"tag"`
+}
+
+type BundleView struct {
+	Name   string `json:"name"`
+	Filter string `json:"filter"`
+}
+
+// BundleTimeEntry is time logged on a task, a timer that is still running is not exported
+type BundleTimeEntry struct {
+	TaskID    int64  `json:"task_id"`
+	StartedAt string `json:"started_at"`
+	Seconds   int64  `json:"seconds"`
+}
+
+// ImportStats counts what an import added, or would add during a dry run
+type ImportStats struct {
+	Areas            int
+	Tasks            int
+	Notes            int
+	BridgeNotes      int
+	NoteCheckboxes   int
+	Projects         int
+	ProjectsReused   int
+	ProjectLinks     int
+	TaskDependencies int
+	Tags             int
+	SavedViews       int
+	SavedViewsKept   int
+	TimeEntries      int
+}
+
+// ExportBundle reads the whole database into a bundle inside one transaction so the rows are consistent
+func ExportBundle(ctx context.Context, conn *sql.DB, now time.Time) (*Bundle, error) {
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	queries := sqlc.New(tx)
+
+	// Empty sections are written as [] rather than null
+	bundle := &Bundle{
+		Version:          BundleVersion,
+		ExportedAt:       now,
+		Areas:            []BundleArea{},
+		Tasks:            []BundleTask{},
+		Notes:            []BundleNote{},
+		BridgeNotes:      []BundleBridgeNote{},
+		NoteCheckboxes:   []BundleCheckbox{},
+		Projects:         []BundleProject{},
+		ProjectLinks:     []BundleLink{},
+		TaskDependencies: []BundleDependency{},
+		TaskTags:         []BundleTag{},
+		AreaTags:         []BundleTag{},
+		NoteTags:         []BundleTag{},
+		SavedViews:       []BundleView{},
+		TimeEntries:      []BundleTimeEntry{},
+	}
+
+	areas, err := queries.ExportAreas(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export areas: %w", err)
+	}
+	for _, area := range areas {
+		bundle.Areas = append(bundle.Areas, BundleArea{
+			ID:           area.ID,
+			Title:        area.Title,
+			Status:       stringPtr(area.Status),
+			Archived:     area.Archived,
+			CreatedAt:    area.CreatedAt,
+			LastMod:      area.LastMod,
+			NoteTemplate: stringPtr(area.NoteTemplate),
+		})
+	}
+
+	tasks, err := queries.ExportTasks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export tasks: %w", err)
+	}
+	for _, task := range tasks {
+		bundle.Tasks = append(bundle.Tasks, BundleTask{
+			ID:           task.ID,
+			Title:        task.Title,
+			Priority:     stringPtr(task.Priority),
+			Status:       stringPtr(task.Status),
+			Archived:     task.Archived,
+			CreatedAt:    task.CreatedAt,
+			LastMod:      task.LastMod,
+			DueDate:      stringPtr(task.DueDate),
+			AreaID:       int64Ptr(task.AreaID),
+			Recurrence:   stringPtr(task.Recurrence),
+			ParentTaskID: int64Ptr(task.ParentTaskID),
+			CompletedAt:  stringPtr(task.CompletedAt),
+		})
+	}
+
+	notes, err := queries.ExportNotes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export notes: %w", err)
+	}
+	for _, note := range notes {
+		bundle.Notes = append(bundle.Notes, BundleNote{ID: note.ID, Title: note.Title, Path: note.Path})
+	}
+
+	bridges, err := queries.ExportBridgeNotes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export note links: %w", err)
+	}
+	for _, bridge := range bridges {
+		bundle.BridgeNotes = append(bundle.BridgeNotes, BundleBridgeNote{
+			NoteID:       bridge.NoteID,
+			ParentCat:    int64Ptr(bridge.ParentCat),
+			ParentTaskID: int64Ptr(bridge.ParentTaskID),
+			ParentAreaID: int64Ptr(bridge.ParentAreaID),
+		})
+	}
+
+	projects, err := queries.ExportProgProjects(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export programming projects: %w", err)
+	}
+	for _, project := range projects {
+		bundle.Projects = append(bundle.Projects, BundleProject{ID: project.ID, Path: project.Path})
+	}
+
+	links, err := queries.ExportProgProjectLinks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export programming project links: %w", err)
+	}
+	for _, link := range links {
+		bundle.ProjectLinks = append(bundle.ProjectLinks, BundleLink{
+			ProjectID:    int64Ptr(link.ProjectID),
+			ParentCat:    int64Ptr(link.ParentCat),
+			ParentTaskID: int64Ptr(link.ParentTaskID),
+			ParentAreaID: int64Ptr(link.ParentAreaID),
+		})
+	}
+
+	checkboxes, err := queries.ExportNoteCheckboxes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export note checkboxes: %w", err)
+	}
+	for _, checkbox := range checkboxes {
+		bundle.NoteCheckboxes = append(bundle.NoteCheckboxes, BundleCheckbox{TaskID: checkbox.TaskID, NoteID: checkbox.NoteID})
+	}
+
+	dependencies, err := queries.ExportTaskDependencies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export task dependencies: %w", err)
+	}
+	for _, dependency := range dependencies {
+		bundle.TaskDependencies = append(bundle.TaskDependencies, BundleDependency{
+			BlockerID: dependency.BlockerID,
+			BlockedID: dependency.BlockedID,
+		})
+	}
+
+	taskTags, err := queries.ExportTaskTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export task tags: %w", err)
+	}
+	for _, tag := range taskTags {
+		bundle.TaskTags = append(bundle.TaskTags, BundleTag{ID: tag.TaskID, Tag: tag.Name})
+	}
+	areaTags, err := queries.ExportAreaTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export area tags: %w", err)
+	}
+	for _, tag := range areaTags {
+		bundle.AreaTags = append(bundle.AreaTags, BundleTag{ID: tag.AreaID, Tag: tag.Name})
+	}
+	noteTags, err := queries.ExportNoteTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export note tags: %w", err)
+	}
+	for _, tag := range noteTags {
+		bundle.NoteTags = append(bundle.NoteTags, BundleTag{ID: tag.NoteID, Tag: tag.Name})
+	}
+
+	views, err := queries.ReadSavedViews(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export saved views: %w", err)
+	}
+	for _, view := range views {
+		bundle.SavedViews = append(bundle.SavedViews, BundleView{Name: view.Name, Filter: view.Filter})
+	}
+
+	entries, err := queries.ExportTimeEntries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export time entries: %w", err)
+	}
+	for _, entry := range entries {
+		bundle.TimeEntries = append(bundle.TimeEntries, BundleTimeEntry{TaskID: entry.TaskID, StartedAt: entry.StartedAt, Seconds: entry.Seconds.Int64})
+	}
+
+	return bundle, nil
+}
+
+// WriteBundle writes a bundle as indented JSON
+func WriteBundle(w io.Writer, bundle *Bundle) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(bundle)
+}
+
+// ReadBundle reads a bundle and refuses versions newer than this build understands
+func ReadBundle(r io.Reader) (*Bundle, error) {
+	var bundle Bundle
+	if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBundle, err)
+	}
+	if bundle.Version < 1 {
+		return nil, fmt.Errorf("%w: missing version", ErrInvalidBundle)
+	}
+	if bundle.Version > BundleVersion {
+		return nil, fmt.Errorf("%w: version %d is newer than this version of go_task supports (%d)", ErrInvalidBundle, bundle.Version, BundleVersion)
+	}
+	return &bundle, nil
+}
+
+// dataTables are cleared by a replace import, children before parents
+var dataTables = []string{
+	"time_entries", "note_tags", "area_tags", "task_tags", "tags",
+	"task_dependencies", "prog_project_links", "note_checkboxes", "bridge_notes",
+	"notes", "tasks", "areas", "programming_projects", "saved_views",
+}
+
+// historyTables are cleared by a replace import after dataTables, the history and undo steps of the replaced rows
+// no longer apply to anything, undo_log goes along with undo_steps
+var historyTables = []string{"events", "undo_steps"}
+
+/*
+ImportBundle loads a bundle inside a single transaction.
+ 1. Every row is inserted with a new ID and every foreign key is rewritten through an old to new ID map,
+    the replace mode clears the existing data first and asks for the old IDs so they usually survive
+ 2. Programming projects and tags are matched by path and name, saved views that already exist are kept
+ 3. Subtasks are inserted after their parent task, references to rows missing from the bundle fail with ErrInvalidBundle
+ 4. A dry run does all of the work and then rolls it back, so the stats show exactly what would change
+ 5. A replace import starts the history over and can not be undone, the rows it brings in are recorded as created
+*/
+func ImportBundle(ctx context.Context, conn *sql.DB, bundle *Bundle, mode ImportMode, dryRun bool) (ImportStats, error) {
+	var stats ImportStats
+
+	if mode == ImportReplace {
+		ctx = db.WithoutUndo(ctx)
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return stats, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	queries := sqlc.New(tx)
+
+	if mode == ImportReplace {
+		for _, table := range append(dataTables, historyTables...) {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+				return stats, fmt.Errorf("failed to clear %s: %w", table, err)
+			}
+		}
+	}
+	keepID := func(id int64) sql.NullInt64 {
+		return sql.NullInt64{Int64: id, Valid: mode == ImportReplace}
+	}
+
+	areaIDs := make(map[int64]int64)
+	for _, area := range bundle.Areas {
+		newID, err := queries.ImportArea(ctx, sqlc.ImportAreaParams{
+			ID:           keepID(area.ID),
+			Title:        area.Title,
+			Status:       nullStringPtr(area.Status),
+			Archived:     area.Archived,
+			CreatedAt:    area.CreatedAt,
+			LastMod:      area.LastMod,
+			NoteTemplate: nullStringPtr(area.NoteTemplate),
+		})
+		if err != nil {
+			return stats, fmt.Errorf("failed to import area %d: %w", area.ID, err)
+		}
+		areaIDs[area.ID] = newID
+		stats.Areas++
+	}
+
+	taskIDs := make(map[int64]int64)
+	pending := bundle.Tasks
+	for len(pending) > 0 {
+		var waiting []BundleTask
+		for _, task := range pending {
+			if task.ParentTaskID != nil {
+				if _, ok := taskIDs[*task.ParentTaskID]; !ok {
+					waiting = append(waiting, task)
+					continue
+				}
+			}
+			areaID, err := remapID(areaIDs, task.AreaID, "area", fmt.Sprintf("task %d", task.ID))
+			if err != nil {
+				return stats, err
+			}
+			parentID, _ := remapID(taskIDs, task.ParentTaskID, "task", fmt.Sprintf("task %d", task.ID))
+			newID, err := queries.ImportTask(ctx, sqlc.ImportTaskParams{
+				ID:           keepID(task.ID),
+				Title:        task.Title,
+				Priority:     nullStringPtr(task.Priority),
+				Status:       nullStringPtr(task.Status),
+				Archived:     task.Archived,
+				CreatedAt:    task.CreatedAt,
+				LastMod:      task.LastMod,
+				DueDate:      nullStringPtr(task.DueDate),
+				AreaID:       areaID,
+				Recurrence:   nullStringPtr(task.Recurrence),
+				ParentTaskID: parentID,
+				CompletedAt:  nullStringPtr(task.CompletedAt),
+			})
+			if err != nil {
+				return stats, fmt.Errorf("failed to import task %d: %w", task.ID, err)
+			}
+			taskIDs[task.ID] = newID
+			stats.Tasks++
+		}
+		if len(waiting) == len(pending) {
+			return stats, fmt.Errorf("%w: task %d has a parent task that is missing or part of a loop", ErrInvalidBundle, waiting[0].ID)
+		}
+		pending = waiting
+	}
+
+	noteIDs := make(map[int64]int64)
+	for _, note := range bundle.Notes {
+		newID, err := queries.ImportNote(ctx, sqlc.ImportNoteParams{ID: keepID(note.ID), Title: note.Title, Path: note.Path})
+		if err != nil {
+			return stats, fmt.Errorf("failed to import note %d: %w", note.ID, err)
+		}
+		noteIDs[note.ID] = newID
+		stats.Notes++
+	}
+
+	for _, bridge := range bundle.BridgeNotes {
+		noteID, ok := noteIDs[bridge.NoteID]
+		if !ok {
+			return stats, fmt.Errorf("%w: note link refers to missing note %d", ErrInvalidBundle, bridge.NoteID)
+		}
+		taskID, err := remapID(taskIDs, bridge.ParentTaskID, "task", fmt.Sprintf("note %d", bridge.NoteID))
+		if err != nil {
+			return stats, err
+		}
+		areaID, err := remapID(areaIDs, bridge.ParentAreaID, "area", fmt.Sprintf("note %d", bridge.NoteID))
+		if err != nil {
+			return stats, err
+		}
+		err = queries.ImportBridgeNote(ctx, sqlc.ImportBridgeNoteParams{
+			NoteID:       noteID,
+			ParentCat:    nullInt64Ptr(bridge.ParentCat),
+			ParentTaskID: taskID,
+			ParentAreaID: areaID,
+		})
+		if err != nil {
+			return stats, fmt.Errorf("failed to link note %d: %w", bridge.NoteID, err)
+		}
+		stats.BridgeNotes++
+	}
+
+	for _, checkbox := range bundle.NoteCheckboxes {
+		taskID, okTask := taskIDs[checkbox.TaskID]
+		noteID, okNote := noteIDs[checkbox.NoteID]
+		if !okTask || !okNote {
+			return stats, fmt.Errorf("%w: checkbox of task %d refers to a missing task or note", ErrInvalidBundle, checkbox.TaskID)
+		}
+		err := queries.UpsertNoteCheckbox(ctx, sqlc.UpsertNoteCheckboxParams{TaskID: taskID, NoteID: noteID})
+		if err != nil {
+			return stats, fmt.Errorf("failed to import the checkbox of task %d: %w", checkbox.TaskID, err)
+		}
+		stats.NoteCheckboxes++
+	}
+
+	projectIDs := make(map[int64]int64)
+	for _, project := range bundle.Projects {
+		existingID, err := queries.CheckProgProjectExists(ctx, project.Path)
+		if err != nil {
+			return stats, fmt.Errorf("failed to look up programming project %s: %w", project.Path, err)
+		}
+		if existingID != 0 {
+			projectIDs[project.ID] = existingID
+			stats.ProjectsReused++
+			continue
+		}
+		newID, err := queries.InsertProgProject(ctx, project.Path)
+		if err != nil {
+			return stats, fmt.Errorf("failed to import programming project %s: %w", project.Path, err)
+		}
+		projectIDs[project.ID] = newID
+		stats.Projects++
+	}
+
+	for _, link := range bundle.ProjectLinks {
+		projectID, err := remapID(projectIDs, link.ProjectID, "programming project", "a programming project link")
+		if err != nil {
+			return stats, err
+		}
+		taskID, err := remapID(taskIDs, link.ParentTaskID, "task", "a programming project link")
+		if err != nil {
+			return stats, err
+		}
+		areaID, err := remapID(areaIDs, link.ParentAreaID, "area", "a programming project link")
+		if err != nil {
+			return stats, err
+		}
+		err = queries.ImportProgProjectLink(ctx, sqlc.ImportProgProjectLinkParams{
+			ProjectID:    projectID,
+			ParentCat:    nullInt64Ptr(link.ParentCat),
+			ParentTaskID: taskID,
+			ParentAreaID: areaID,
+		})
+		if err != nil {
+			return stats, fmt.Errorf("failed to import programming project link: %w", err)
+		}
+		stats.ProjectLinks++
+	}
+
+	for _, dependency := range bundle.TaskDependencies {
+		blockerID, okBlocker := taskIDs[dependency.BlockerID]
+		blockedID, okBlocked := taskIDs[dependency.BlockedID]
+		if !okBlocker || !okBlocked {
+			return stats, fmt.Errorf("%w: dependency %d -> %d refers to a missing task", ErrInvalidBundle, dependency.BlockerID, dependency.BlockedID)
+		}
+		err := queries.CreateTaskDependency(ctx, sqlc.CreateTaskDependencyParams{BlockerID: blockerID, BlockedID: blockedID})
+		if err != nil {
+			return stats, fmt.Errorf("failed to import dependency %d -> %d: %w", dependency.BlockerID, dependency.BlockedID, err)
+		}
+		stats.TaskDependencies++
+	}
+
+	tagLinks := []struct {
+		kind string
+		ids  map[int64]int64
+		tags []BundleTag
+		add  func(ctx context.Context, queries *sqlc.Queries, id int64, tags []string) error
+	}{
+		{kind: "task", ids: taskIDs, tags: bundle.TaskTags, add: AddTaskTags},
+		{kind: "area", ids: areaIDs, tags: bundle.AreaTags, add: AddAreaTags},
+		{kind: "note", ids: noteIDs, tags: bundle.NoteTags, add: AddNoteTags},
+	}
+	for _, link := range tagLinks {
+		for _, tag := range link.tags {
+			newID, ok := link.ids[tag.ID]
+			if !ok {
+				return stats, fmt.Errorf("%w: tag %s refers to missing %s %d", ErrInvalidBundle, tag.Tag, link.kind, tag.ID)
+			}
+			if err := link.add(ctx, queries, newID, []string{tag.Tag}); err != nil {
+				return stats, err
+			}
+			stats.Tags++
+		}
+	}
+
+	for _, view := range bundle.SavedViews {
+		if _, err := queries.ReadSavedView(ctx, view.Name); err == nil {
+			stats.SavedViewsKept++
+			continue
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return stats, fmt.Errorf("failed to read view %s: %w", view.Name, err)
+		}
+		err := queries.UpsertSavedView(ctx, sqlc.UpsertSavedViewParams{Name: view.Name, Filter: view.Filter})
+		if err != nil {
+			return stats, fmt.Errorf("failed to import view %s: %w", view.Name, err)
+		}
+		stats.SavedViews++
+	}
+
+	for _, entry := range bundle.TimeEntries {
+		taskID, ok := taskIDs[entry.TaskID]
+		if !ok {
+			return stats, fmt.Errorf("%w: time entry refers to missing task %d", ErrInvalidBundle, entry.TaskID)
+		}
+		err := queries.ImportTimeEntry(ctx, sqlc.ImportTimeEntryParams{
+			TaskID:    taskID,
+			StartedAt: entry.StartedAt,
+			Seconds:   sql.NullInt64{Int64: entry.Seconds, Valid: true},
+		})
+		if err != nil {
+			return stats, fmt.Errorf("failed to import the time logged on task %d: %w", entry.TaskID, err)
+		}
+		stats.TimeEntries++
+	}
+
+	if dryRun {
+		return stats, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return stats, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return stats, nil
+}
+
+// remapID translates an optional foreign key from the bundle to the ID the row got in this database
+func remapID(ids map[int64]int64, oldID *int64, target, owner string) (sql.NullInt64, error) {
+	if oldID == nil {
+		return sql.NullInt64{}, nil
+	}
+	newID, ok := ids[*oldID]
+	if !ok {
+		return sql.NullInt64{}, fmt.Errorf("%w: %s refers to missing %s %d", ErrInvalidBundle, owner, target, *oldID)
+	}
+	return sql.NullInt64{Int64: newID, Valid: true}, nil
+}
+
+// findOrCreateArea returns the ID of the area with a title and creates the area when it does not exist yet,
+// the bool reports whether it was created. areaIDs caches the areas already looked up by lower case title,
+// a blank title means no area.
+func findOrCreateArea(ctx context.Context, queries *sqlc.Queries, areaIDs map[string]int64, title string, now time.Time) (sql.NullInt64, bool, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return sql.NullInt64{}, false, nil
+	}
+	if id, ok := areaIDs[strings.ToLower(title)]; ok {
+		return sql.NullInt64{Int64: id, Valid: true}, false, nil
+	}
+
+	created := false
+	id, err := queries.ReadAreaIDByTitle(ctx, title)
+	if errors.Is(err, sql.ErrNoRows) {
+		id, err = queries.ImportArea(ctx, sqlc.ImportAreaParams{
+			Title:     title,
+			Status:    sql.NullString{String: string(StatusToDo), Valid: true},
+			CreatedAt: now.Format(storedTimeLayout),
+			LastMod:   now.Format(storedTimeLayout),
+		})
+		if err != nil {
+			return sql.NullInt64{}, false, fmt.Errorf("failed to create area %s: %w", title, err)
+		}
+		created = true
+	} else if err != nil {
+		return sql.NullInt64{}, false, fmt.Errorf("failed to look up area %s: %w", title, err)
+	}
+	areaIDs[strings.ToLower(title)] = id
+	return sql.NullInt64{Int64: id, Valid: true}, created, nil
+}
+
+func stringPtr(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+func int64Ptr(value sql.NullInt64) *int64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int64
+}
+
+func nullStringPtr(value *string) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *value, Valid: true}
+}
+
+func nullInt64Ptr(value *int64) sql.NullInt64 {
+	if value == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *value, Valid: true}
+}
@@ -0,0 +1,240 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/akthe-at/go_task/db"
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+func seedBundleDB(t *testing.T, conn *sql.DB) {
+	t.Helper()
+	ctx := context.Background()
+	queries := sqlc.New(conn)
+
+	if _, err := queries.CreateArea(ctx, sqlc.CreateAreaParams{ID: 3, Title: "Work"}); err != nil {
+		t.Fatalf("CreateArea() error = %v", err)
+	}
+	tasks := []sqlc.CreateTaskParams{
+		{ID: 5, Title: "Write report", Status: sql.NullString{String: "doing", Valid: true}, AreaID: sql.NullInt64{Int64: 3, Valid: true}},
+		{ID: 7, Title: "Send report", DueDate: sql.NullString{String: "2024-11-08", Valid: true}},
+		// The subtask has the lowest ID so import has to wait for its parent
+		{ID: 2, Title: "Draft outline", ParentTaskID: sql.NullInt64{Int64: 5, Valid: true}},
+	}
+	for _, task := range tasks {
+		if _, err := queries.CreateTask(ctx, task); err != nil {
+			t.Fatalf("CreateTask() error = %v", err)
+		}
+	}
+	// The finished subtask carries a completion stamp, the journal reads it after an import
+	if _, err := queries.UpdateTaskStatus(ctx, sqlc.UpdateTaskStatusParams{Status: sql.NullString{String: "done", Valid: true}, ID: 2}); err != nil {
+		t.Fatalf("UpdateTaskStatus() error = %v", err)
+	}
+	if err := LinkTasks(ctx, conn, 5, 7); err != nil {
+		t.Fatalf("LinkTasks() error = %v", err)
+	}
+	if err := queries.CreateNote(ctx, sqlc.CreateNoteParams{ID: 4, Title: "report notes", Path: "/notes/report.md"}); err != nil {
+		t.Fatalf("CreateNote() error = %v", err)
+	}
+	_, err := queries.CreateTaskBridgeNote(ctx, sqlc.CreateTaskBridgeNoteParams{
+		NoteID:       4,
+		ParentCat:    sql.NullInt64{Int64: int64(TaskNoteType), Valid: true},
+		ParentTaskID: sql.NullInt64{Int64: 5, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateTaskBridgeNote() error = %v", err)
+	}
+	projectID, err := queries.InsertProgProject(ctx, "/src/go_task")
+	if err != nil {
+		t.Fatalf("InsertProgProject() error = %v", err)
+	}
+	err = queries.CreateProjectTaskLink(ctx, sqlc.CreateProjectTaskLinkParams{
+		ProjectID:    sql.NullInt64{Int64: projectID, Valid: true},
+		ParentCat:    sql.NullInt64{Int64: int64(TaskNoteType), Valid: true},
+		ParentTaskID: sql.NullInt64{Int64: 5, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateProjectTaskLink() error = %v", err)
+	}
+	if err := AddTaskTags(ctx, queries, 5, []string{"finance"}); err != nil {
+		t.Fatalf("AddTaskTags() error = %v", err)
+	}
+	if err := AddNoteTags(ctx, queries, 4, []string{"finance", "draft"}); err != nil {
+		t.Fatalf("AddNoteTags() error = %v", err)
+	}
+	if err := SaveView(ctx, queries, "focus", "status:doing", FilterEnv{Now: testNow}); err != nil {
+		t.Fatalf("SaveView() error = %v", err)
+	}
+	if _, err := AddTime(ctx, queries, 5, 90*time.Minute, testNow); err != nil {
+		t.Fatalf("AddTime() error = %v", err)
+	}
+	// The running timer has not logged anything yet, it stays out of the bundle
+	if _, _, err := StartTimer(ctx, conn, 7, testNow); err != nil {
+		t.Fatalf("StartTimer() error = %v", err)
+	}
+}
+
+func exportTestBundle(t *testing.T, conn *sql.DB) *Bundle {
+	t.Helper()
+	bundle, err := ExportBundle(context.Background(), conn, testNow)
+	if err != nil {
+		t.Fatalf("ExportBundle() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteBundle(&buf, bundle); err != nil {
+		t.Fatalf("WriteBundle() error = %v", err)
+	}
+	read, err := ReadBundle(&buf)
+	if err != nil {
+		t.Fatalf("ReadBundle() error = %v", err)
+	}
+	return read
+}
+
+func TestImportBundleReplace(t *testing.T) {
+	ctx := context.Background()
+	source := openTestDB(t)
+	seedBundleDB(t, source)
+	bundle := exportTestBundle(t, source)
+	for _, task := range bundle.Tasks {
+		if (task.ID == 2) != (task.CompletedAt != nil) {
+			t.Errorf("exported task %d completed_at = %v, want a stamp only on the finished task", task.ID, task.CompletedAt)
+		}
+	}
+
+	target := openTestDB(t)
+	t.Cleanup(db.EndUndoStep)
+	db.BeginUndoStep("add area")
+	if _, err := sqlc.New(target).CreateArea(ctx, sqlc.CreateAreaParams{ID: 1, Title: "Old area"}); err != nil {
+		t.Fatalf("CreateArea() error = %v", err)
+	}
+	db.BeginUndoStep("import --mode replace")
+	stats, err := ImportBundle(ctx, target, bundle, ImportReplace, false)
+	if err != nil {
+		t.Fatalf("ImportBundle() error = %v", err)
+	}
+	db.EndUndoStep()
+	want := ImportStats{Areas: 1, Tasks: 3, Notes: 1, BridgeNotes: 1, Projects: 1, ProjectLinks: 1, TaskDependencies: 1, Tags: 3, SavedViews: 1, TimeEntries: 1}
+	if stats != want {
+		t.Errorf("ImportBundle() stats = %+v, want %+v", stats, want)
+	}
+
+	// The history starts over with the imported rows, and neither the import nor the steps before it can be undone
+	events, err := ReadEvents(ctx, sqlc.New(target), 0, time.Time{})
+	if err != nil {
+		t.Fatalf("ReadEvents() error = %v", err)
+	}
+	for _, event := range events {
+		if event.Field != EventCreated {
+			t.Errorf("event after a replace import = %+v, want only created events", event)
+		}
+	}
+	if len(events) != stats.Areas+stats.Tasks+stats.Notes {
+		t.Errorf("ReadEvents() after a replace import returned %d events, want %d", len(events), stats.Areas+stats.Tasks+stats.Notes)
+	}
+	if _, err := UndoLast(ctx, target, 1); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("UndoLast() after a replace import error = %v, want ErrNothingToUndo", err)
+	}
+
+	// Replacing keeps the IDs, so exporting again gives the same document
+	again := exportTestBundle(t, target)
+	first := jsonWithoutTimestamp(t, bundle)
+	second := jsonWithoutTimestamp(t, again)
+	if first != second {
+		t.Errorf("bundle changed after a replace import\nbefore: %s\nafter:  %s", first, second)
+	}
+}
+
+func TestImportBundleMerge(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	seedBundleDB(t, conn)
+	bundle := exportTestBundle(t, conn)
+
+	stats, err := ImportBundle(ctx, conn, bundle, ImportMerge, true)
+	if err != nil {
+		t.Fatalf("ImportBundle(dry run) error = %v", err)
+	}
+	want := ImportStats{Areas: 1, Tasks: 3, Notes: 1, BridgeNotes: 1, ProjectsReused: 1, ProjectLinks: 1, TaskDependencies: 1, Tags: 3, SavedViewsKept: 1, TimeEntries: 1}
+	if stats != want {
+		t.Errorf("ImportBundle(dry run) stats = %+v, want %+v", stats, want)
+	}
+	if after := exportTestBundle(t, conn); len(after.Tasks) != 3 {
+		t.Fatalf("dry run left %d tasks, want 3", len(after.Tasks))
+	}
+
+	if _, err := ImportBundle(ctx, conn, bundle, ImportMerge, false); err != nil {
+		t.Fatalf("ImportBundle() error = %v", err)
+	}
+	merged := exportTestBundle(t, conn)
+	if len(merged.Tasks) != 6 || len(merged.Areas) != 2 || len(merged.Notes) != 2 || len(merged.Projects) != 1 {
+		t.Fatalf("merge gave %d tasks, %d areas, %d notes, %d projects, want 6, 2, 2, 1",
+			len(merged.Tasks), len(merged.Areas), len(merged.Notes), len(merged.Projects))
+	}
+
+	// The copies point at each other, not at the original rows
+	byTitle := make(map[string][]BundleTask)
+	for _, task := range merged.Tasks {
+		byTitle[task.Title] = append(byTitle[task.Title], task)
+	}
+	copyOf := func(title string) BundleTask { return byTitle[title][1] }
+	if parent := copyOf("Draft outline").ParentTaskID; parent == nil || *parent != copyOf("Write report").ID {
+		t.Errorf("copied subtask has parent %v, want %d", parent, copyOf("Write report").ID)
+	}
+	if area := copyOf("Write report").AreaID; area == nil || *area != merged.Areas[1].ID {
+		t.Errorf("copied task has area %v, want %d", area, merged.Areas[1].ID)
+	}
+	wantDependency := BundleDependency{BlockerID: copyOf("Write report").ID, BlockedID: copyOf("Send report").ID}
+	if merged.TaskDependencies[1] != wantDependency {
+		t.Errorf("copied dependency = %+v, want %+v", merged.TaskDependencies[1], wantDependency)
+	}
+	if bridge := merged.BridgeNotes[1]; bridge.NoteID != merged.Notes[1].ID || *bridge.ParentTaskID != copyOf("Write report").ID {
+		t.Errorf("copied note link = %+v", bridge)
+	}
+}
+
+func TestImportBundleInvalid(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	parent := int64(99)
+	tests := []struct {
+		name   string
+		bundle Bundle
+	}{
+		{name: "missing parent", bundle: Bundle{Version: 1, Tasks: []BundleTask{{ID: 1, Title: "orphan", ParentTaskID: &parent}}}},
+		{name: "missing area", bundle: Bundle{Version: 1, Tasks: []BundleTask{{ID: 1, Title: "orphan", AreaID: &parent}}}},
+		{name: "missing dependency", bundle: Bundle{Version: 1, TaskDependencies: []BundleDependency{{BlockerID: 1, BlockedID: 2}}}},
+		{name: "missing tag owner", bundle: Bundle{Version: 1, NoteTags: []BundleTag{{ID: 3, Tag: "x"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ImportBundle(ctx, conn, &tt.bundle, ImportMerge, false); !errors.Is(err, ErrInvalidBundle) {
+				t.Errorf("ImportBundle() error = %v, want %v", err, ErrInvalidBundle)
+			}
+		})
+	}
+
+	if _, err := ReadBundle(strings.NewReader(`{"version": 2}`)); !errors.Is(err, ErrInvalidBundle) {
+		t.Errorf("ReadBundle(newer version) error = %v, want %v", err, ErrInvalidBundle)
+	}
+	if _, err := ReadBundle(strings.NewReader(`{"tasks": []}`)); !errors.Is(err, ErrInvalidBundle) {
+		t.Errorf("ReadBundle(no version) error = %v, want %v", err, ErrInvalidBundle)
+	}
+}
+
+func jsonWithoutTimestamp(t *testing.T, bundle *Bundle) string {
+	t.Helper()
+	copied := *bundle
+	copied.ExportedAt = testNow
+	var buf bytes.Buffer
+	if err := WriteBundle(&buf, &copied); err != nil {
+		t.Fatalf("WriteBundle() error = %v", err)
+	}
+	return buf.String()
+}
@@ -0,0 +1,297 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akthe-at/go_task/sqlc"
+	"github.com/akthe-at/go_task/utils"
+)
+
+var (
+	// checkboxLine matches markdown task list items such as "- [ ] do thing" and "  * [x] done thing"
+	checkboxLine = regexp.MustCompile(`^(\s*[-*+] \[)([ xX])(\]\s+)(.*?)\s*$`)
+	// checkboxMarker is appended to a checkbox line once it has a task, it is invisible in rendered markdown
+	checkboxMarker = regexp.MustCompile(`\s*<!-- go_task:(\d+) -->$`)
+)
+
+// NoteSyncStats describes what SyncNoteCheckboxes changed
+type NoteSyncStats struct {
+	Created   int
+	Completed int
+	Reopened  int
+	// NextTaskIDs are the new instances of recurring tasks that were ticked off in the note
+	NextTaskIDs []int64
+	// StaleTaskIDs are markers whose task was deleted or belongs to another note, their lines are left alone
+	StaleTaskIDs []int64
+}
+
+// checkboxItem is a checkbox line of a note
+type checkboxItem struct {
+	line    int
+	checked bool
+	text    string
+	// taskID is 0 when the line has no marker yet
+	taskID int64
+}
+
+// parseCheckboxes finds the checkbox lines of a note, lines in the frontmatter and in fenced code blocks are skipped
+func parseCheckboxes(lines []string) []checkboxItem {
+	var items []checkboxItem
+	inFrontmatter := len(lines) > 0 && strings.TrimRight(lines[0], "\r") == frontmatterDelimiter
+	inFence := false
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if inFrontmatter {
+			if i > 0 && line == frontmatterDelimiter {
+				inFrontmatter = false
+			}
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		match := checkboxLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		item := checkboxItem{line: i, checked: match[2] != " ", text: match[4]}
+		if marker := checkboxMarker.FindStringSubmatchIndex(item.text); marker != nil {
+			item.taskID, _ = strconv.ParseInt(item.text[marker[2]:marker[3]], 10, 64)
+			item.text = strings.TrimSpace(item.text[:marker[0]])
+		}
+		if item.text == "" {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// setCheckbox ticks or unticks the box of a checkbox line and keeps the rest of the line as it was
+func setCheckbox(line string, checked bool) string {
+	match := checkboxLine.FindStringSubmatchIndex(strings.TrimRight(line, "\r"))
+	if match == nil {
+		return line
+	}
+	mark := " "
+	if checked {
+		mark = "x"
+	}
+	return line[:match[4]] + mark + line[match[5]:]
+}
+
+// addCheckboxMarker appends the marker of a task to a checkbox line
+func addCheckboxMarker(line string, taskID int64) string {
+	ending := ""
+	if strings.HasSuffix(line, "\r") {
+		ending = "\r"
+	}
+	return fmt.Sprintf("%s <!-- go_task:%d -->%s", strings.TrimRight(line, " \t\r"), taskID, ending)
+}
+
+/*
+SyncNoteCheckboxes turns the checkbox lines of a note into tasks and brings their status in line with the note.
+ 1. A line without a marker becomes a subtask of the task the note belongs to, or a task in the area the note belongs to,
+    the line gets a <!-- go_task:ID --> marker so the next sync finds the task again
+ 2. For lines with a marker the note wins, a ticked box marks the task done and an unticked box reopens it.
+    go_task ticks the box itself when the task is finished, see SetTaskStatus, so the note is up to date before a sync
+ 3. Markers only count when the task was created from this note, a task ID that was reused after a delete is left alone
+ 4. The note is written before the transaction is committed and restored when the commit fails
+*/
+func SyncNoteCheckboxes(ctx context.Context, conn *sql.DB, noteID int64, now time.Time) (NoteSyncStats, error) {
+	var stats NoteSyncStats
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return stats, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	queries := sqlc.New(tx)
+
+	note, err := queries.ReadNoteByID(ctx, noteID)
+	if err != nil {
+		return stats, fmt.Errorf("failed to read note %d: %w", noteID, err)
+	}
+	parent, err := queries.ReadNoteParent(ctx, noteID)
+	if err != nil {
+		return stats, fmt.Errorf("failed to read the parent of note %d: %w", noteID, err)
+	}
+	var parentTaskID, areaID sql.NullInt64
+	switch NoteType(parent.ParentCat.Int64) {
+	case TaskNoteType:
+		parentTask, err := queries.ReadTaskForRecurrence(ctx, parent.ParentTaskID.Int64)
+		if err != nil {
+			return stats, fmt.Errorf("failed to read task %d: %w", parent.ParentTaskID.Int64, err)
+		}
+		parentTaskID, areaID = parent.ParentTaskID, parentTask.AreaID
+	case AreaNoteType:
+		areaID = parent.ParentAreaID
+	}
+
+	checkboxes, err := queries.ExportNoteCheckboxes(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("failed to read note checkboxes: %w", err)
+	}
+	ownNote := make(map[int64]bool)
+	for _, checkbox := range checkboxes {
+		ownNote[checkbox.TaskID] = checkbox.NoteID == noteID
+	}
+
+	path, err := utils.ExpandPath(note.Path)
+	if err != nil {
+		return stats, fmt.Errorf("failed to expand the path of note %d: %w", noteID, err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return stats, fmt.Errorf("failed to read note %s: %w", path, err)
+	}
+	lines := strings.Split(string(content), "\n")
+
+	for _, item := range parseCheckboxes(lines) {
+		status := StatusToDo
+		if item.checked {
+			status = StatusDone
+		}
+
+		if item.taskID == 0 {
+			taskID, err := queries.GetTaskID(ctx)
+			if err != nil {
+				return stats, fmt.Errorf("failed to get an ID for %q: %w", item.text, err)
+			}
+			// New boxes get the priority the add task forms start with
+			_, err = queries.CreateTask(ctx, sqlc.CreateTaskParams{
+				ID:           taskID,
+				Title:        item.text,
+				Priority:     sql.NullString{String: string(PriorityTypeLow), Valid: true},
+				Status:       sql.NullString{String: string(status), Valid: true},
+				AreaID:       areaID,
+				ParentTaskID: parentTaskID,
+			})
+			if err != nil {
+				return stats, fmt.Errorf("failed to create a task for %q: %w", item.text, err)
+			}
+			if err := queries.UpsertNoteCheckbox(ctx, sqlc.UpsertNoteCheckboxParams{TaskID: taskID, NoteID: noteID}); err != nil {
+				return stats, fmt.Errorf("failed to link task %d to note %d: %w", taskID, noteID, err)
+			}
+			lines[item.line] = addCheckboxMarker(lines[item.line], taskID)
+			stats.Created++
+			continue
+		}
+
+		if !ownNote[item.taskID] {
+			stats.StaleTaskIDs = append(stats.StaleTaskIDs, item.taskID)
+			continue
+		}
+		task, err := queries.ReadTaskForRecurrence(ctx, item.taskID)
+		if err != nil {
+			return stats, fmt.Errorf("failed to read task %d: %w", item.taskID, err)
+		}
+		wasDone := task.Status.String == string(StatusDone)
+		if item.checked == wasDone {
+			continue
+		}
+		var change StatusChange
+		if err := setStatus(ctx, queries, item.taskID, status, now, true, &change); err != nil {
+			return stats, err
+		}
+		stats.NextTaskIDs = append(stats.NextTaskIDs, change.NextTaskIDs...)
+		if item.checked {
+			stats.Completed++
+		} else {
+			stats.Reopened++
+		}
+	}
+
+	updated := strings.Join(lines, "\n")
+	if updated != string(content) {
+		if err := os.WriteFile(path, []byte(updated), 0644); err != nil {
+			return stats, fmt.Errorf("failed to write note %s: %w", path, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		if updated != string(content) {
+			os.WriteFile(path, content, 0644)
+		}
+		return stats, fmt.Errorf("failed to commit the sync of note %d: %w", noteID, err)
+	}
+
+	refreshNoteIndexQuietly(ctx, conn, noteID, path)
+	return stats, nil
+}
+
+/*
+updateNoteCheckboxes ticks or unticks the checkbox lines of tasks that were created from a note.
+ 1. Only lines carrying the marker of one of the tasks are touched, the note is only written when a box changed
+ 2. Notes that were moved or deleted are skipped, 'notes sync' reports them once they are found again
+ 3. The paths of the rewritten notes are returned
+*/
+func updateNoteCheckboxes(ctx context.Context, conn *sql.DB, taskIDs []int64, done bool) ([]string, error) {
+	queries := sqlc.New(conn)
+	rows, err := queries.ReadCheckboxNotes(ctx, taskIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the notes of tasks %v: %w", taskIDs, err)
+	}
+
+	type noteTasks struct {
+		id    int64
+		path  string
+		tasks map[int64]bool
+	}
+	var notes []*noteTasks
+	byID := make(map[int64]*noteTasks)
+	for _, row := range rows {
+		note, ok := byID[row.NoteID]
+		if !ok {
+			path, err := utils.ExpandPath(row.Path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to expand the path of note %d: %w", row.NoteID, err)
+			}
+			note = &noteTasks{id: row.NoteID, path: path, tasks: make(map[int64]bool)}
+			byID[row.NoteID] = note
+			notes = append(notes, note)
+		}
+		note.tasks[row.TaskID] = true
+	}
+
+	var updatedPaths []string
+	for _, note := range notes {
+		content, err := os.ReadFile(note.path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return updatedPaths, fmt.Errorf("failed to read note %s: %w", note.path, err)
+		}
+		lines := strings.Split(string(content), "\n")
+		changed := false
+		for _, item := range parseCheckboxes(lines) {
+			if note.tasks[item.taskID] && item.checked != done {
+				lines[item.line] = setCheckbox(lines[item.line], done)
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if err := os.WriteFile(note.path, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+			return updatedPaths, fmt.Errorf("failed to write note %s: %w", note.path, err)
+		}
+		updatedPaths = append(updatedPaths, note.path)
+		refreshNoteIndexQuietly(ctx, conn, note.id, note.path)
+	}
+	return updatedPaths, nil
+}
@@ -0,0 +1,155 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+func TestParseCheckboxes(t *testing.T) {
+	lines := strings.Split(`---
+Tags:
+- [ ] not a task
+---
+# Plan
+- [ ] buy paint
+  * [X] sand the walls <!-- go_task:7 -->
++ [x]
+- [] not a checkbox
+`+"```"+`
+- [ ] example in a code block
+`+"```"+`
+1. [ ] numbered lists are not task lists
+- [ ] windows line ending`+"\r", "\n")
+
+	want := []checkboxItem{
+		{line: 5, text: "buy paint"},
+		{line: 6, checked: true, text: "sand the walls", taskID: 7},
+		{line: 13, text: "windows line ending"},
+	}
+	if got := parseCheckboxes(lines); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseCheckboxes() = %+v\nwant %+v", got, want)
+	}
+
+	tests := []struct {
+		line    string
+		checked bool
+		want    string
+	}{
+		{line: "- [ ] buy paint", checked: true, want: "- [x] buy paint"},
+		{line: "  * [X] sand <!-- go_task:7 -->", checked: false, want: "  * [ ] sand <!-- go_task:7 -->"},
+		{line: "- [ ] crlf\r", checked: true, want: "- [x] crlf\r"},
+		{line: "plain text", checked: true, want: "plain text"},
+	}
+	for _, tt := range tests {
+		if got := setCheckbox(tt.line, tt.checked); got != tt.want {
+			t.Errorf("setCheckbox(%q, %t) = %q, want %q", tt.line, tt.checked, got, tt.want)
+		}
+	}
+	if got := addCheckboxMarker("- [ ] crlf  \r", 3); got != "- [ ] crlf <!-- go_task:3 -->\r" {
+		t.Errorf("addCheckboxMarker() = %q", got)
+	}
+}
+
+func TestSyncNoteCheckboxes(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+
+	areaID, err := queries.ImportArea(ctx, sqlc.ImportAreaParams{Title: "Home", CreatedAt: "2024-11-01 09:00:00", LastMod: "2024-11-01 09:00:00"})
+	if err != nil {
+		t.Fatalf("ImportArea() error = %v", err)
+	}
+	parentID, err := queries.ImportTask(ctx, sqlc.ImportTaskParams{
+		Title: "Paint the hallway", Status: sql.NullString{String: "doing", Valid: true},
+		CreatedAt: "2024-11-01 09:00:00", LastMod: "2024-11-01 09:00:00", AreaID: sql.NullInt64{Int64: areaID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("ImportTask() error = %v", err)
+	}
+	notePath := filepath.Join(t.TempDir(), "hallway.md")
+	if err := os.WriteFile(notePath, []byte("# Hallway\n- [ ] buy paint\n- [x] sand the walls\n- [ ] stale <!-- go_task:1 -->\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := queries.CreateNote(ctx, sqlc.CreateNoteParams{ID: 1, Title: "Hallway", Path: notePath}); err != nil {
+		t.Fatalf("CreateNote() error = %v", err)
+	}
+	_, err = queries.CreateTaskBridgeNote(ctx, sqlc.CreateTaskBridgeNoteParams{
+		NoteID:       1,
+		ParentCat:    sql.NullInt64{Int64: int64(TaskNoteType), Valid: true},
+		ParentTaskID: sql.NullInt64{Int64: parentID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateTaskBridgeNote() error = %v", err)
+	}
+
+	stats, err := SyncNoteCheckboxes(ctx, conn, 1, testNow)
+	if err != nil {
+		t.Fatalf("SyncNoteCheckboxes() error = %v", err)
+	}
+	if want := (NoteSyncStats{Created: 2, StaleTaskIDs: []int64{1}}); !reflect.DeepEqual(stats, want) {
+		t.Errorf("SyncNoteCheckboxes() stats = %+v, want %+v", stats, want)
+	}
+	wantNote := "# Hallway\n- [ ] buy paint <!-- go_task:2 -->\n- [x] sand the walls <!-- go_task:3 -->\n- [ ] stale <!-- go_task:1 -->\n"
+	assertFileContent(t, notePath, wantNote)
+
+	subtasks, err := queries.ReadSubtaskTree(ctx, sql.NullInt64{Int64: parentID, Valid: true})
+	if err != nil {
+		t.Fatalf("ReadSubtaskTree() error = %v", err)
+	}
+	var got []string
+	for _, subtask := range subtasks {
+		got = append(got, subtask.Title+" "+subtask.Status.String+" "+subtask.Priority.String)
+	}
+	if want := []string{"buy paint todo low", "sand the walls done low"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("subtasks = %v, want %v", got, want)
+	}
+
+	// Finishing the task in go_task ticks its box right away
+	change, err := SetTaskStatus(ctx, conn, 2, StatusDone, testNow)
+	if err != nil {
+		t.Fatalf("SetTaskStatus() error = %v", err)
+	}
+	if !reflect.DeepEqual(change.UpdatedNotes, []string{notePath}) {
+		t.Errorf("SetTaskStatus() updated notes = %v, want [%s]", change.UpdatedNotes, notePath)
+	}
+	wantNote = strings.Replace(wantNote, "- [ ] buy paint", "- [x] buy paint", 1)
+	assertFileContent(t, notePath, wantNote)
+
+	// Unticking a box in the note reopens the task on the next sync, a second sync changes nothing
+	edited := strings.Replace(wantNote, "- [x] sand the walls", "- [ ] sand the walls", 1)
+	if err := os.WriteFile(notePath, []byte(edited), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []NoteSyncStats{{Reopened: 1, StaleTaskIDs: []int64{1}}, {StaleTaskIDs: []int64{1}}} {
+		stats, err = SyncNoteCheckboxes(ctx, conn, 1, testNow)
+		if err != nil {
+			t.Fatalf("SyncNoteCheckboxes() error = %v", err)
+		}
+		if !reflect.DeepEqual(stats, want) {
+			t.Errorf("SyncNoteCheckboxes() stats = %+v, want %+v", stats, want)
+		}
+	}
+	task, err := queries.ReadTaskForRecurrence(ctx, 3)
+	if err != nil || task.Status.String != string(StatusToDo) {
+		t.Errorf("task 3 status = %q, %v, want todo", task.Status.String, err)
+	}
+	assertFileContent(t, notePath, edited)
+}
+
+func assertFileContent(t *testing.T, path, want string) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	if string(content) != want {
+		t.Errorf("%s =\n%s\nwant\n%s", path, content, want)
+	}
+}
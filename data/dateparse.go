@@ -0,0 +1,142 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateInputHelp describes the date formats understood by ResolveDate
+const DateInputHelp = "today, tomorrow, fri, next monday, +3d, +2w, +1m, eow, eom or YYYY-MM-DD"
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+var absoluteDateLayouts = []string{DueDateLayout, "2006/01/02"}
+
+/*
+ResolveDate turns user input into a calendar date relative to now.
+ 1. "today", "tomorrow" and "yesterday"
+ 2. A weekday such as "fri" or "friday" resolves to the next time that day comes around, today included
+ 3. "next <weekday>" resolves to that day in the following week, weeks start on Monday
+ 4. "+3d", "+2w" and "+1m" move forward a number of days, weeks, or months
+ 5. "eow" is the Sunday ending the current week and "eom" the last day of the current month
+ 6. Absolute dates in the YYYY-MM-DD format
+
+The returned time is always at the start of the day in now's location.
+*/
+func ResolveDate(input string, now time.Time) (time.Time, error) {
+	today := startOfDay(now)
+	normalized := strings.Join(strings.Fields(strings.ToLower(input)), " ")
+
+	switch normalized {
+	case "":
+		return time.Time{}, fmt.Errorf("no date given, expected one of: %s", DateInputHelp)
+	case "today", "tod":
+		return today, nil
+	case "tomorrow", "tom":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "eow":
+		return endOfWeek(today), nil
+	case "eom":
+		return time.Date(today.Year(), today.Month()+1, 0, 0, 0, 0, 0, today.Location()), nil
+	}
+
+	if day, ok := weekdays[normalized]; ok {
+		return nextWeekday(today, day), nil
+	}
+
+	if rest, ok := strings.CutPrefix(normalized, "next "); ok {
+		day, ok := weekdays[rest]
+		if !ok {
+			return time.Time{}, fmt.Errorf("invalid date ( %s ) 'next' must be followed by a weekday such as 'next monday'", input)
+		}
+		startOfNextWeek := endOfWeek(today).AddDate(0, 0, 1)
+		return nextWeekday(startOfNextWeek, day), nil
+	}
+
+	if strings.HasPrefix(normalized, "+") {
+		return resolveOffset(normalized, today, input)
+	}
+
+	for _, layout := range absoluteDateLayouts {
+		if date, err := time.ParseInLocation(layout, normalized, now.Location()); err == nil {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date ( %s ) expected one of: %s", input, DateInputHelp)
+}
+
+// resolveOffset handles relative input such as +3d, +2w, and +1m
+func resolveOffset(offset string, today time.Time, input string) (time.Time, error) {
+	if len(offset) < 3 {
+		return time.Time{}, fmt.Errorf("invalid date offset ( %s ) must look like +3d, +2w, or +1m", input)
+	}
+	unit := offset[len(offset)-1:]
+	amount, err := strconv.Atoi(offset[1 : len(offset)-1])
+	if err != nil || amount < 0 {
+		return time.Time{}, fmt.Errorf("invalid date offset ( %s ) must look like +3d, +2w, or +1m", input)
+	}
+
+	switch unit {
+	case "d":
+		return today.AddDate(0, 0, amount), nil
+	case "w":
+		return today.AddDate(0, 0, amount*7), nil
+	case "m":
+		return addMonthsClamped(today, amount), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date offset ( %s ) must look like +3d, +2w, or +1m", input)
+}
+
+// addMonthsClamped adds months without overflowing into the following month,
+// so Jan 31 +1m is the last day of February rather than early March.
+func addMonthsClamped(t time.Time, months int) time.Time {
+	firstOfTarget := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	lastDay := firstOfTarget.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(firstOfTarget.Year(), firstOfTarget.Month(), day, 0, 0, 0, 0, t.Location())
+}
+
+// nextWeekday returns the first given weekday on or after from
+func nextWeekday(from time.Time, day time.Weekday) time.Time {
+	delta := (int(day) - int(from.Weekday()) + 7) % 7
+	return from.AddDate(0, 0, delta)
+}
+
+// endOfWeek returns the Sunday that ends the Monday based week containing t
+func endOfWeek(t time.Time) time.Time {
+	return nextWeekday(t, time.Sunday)
+}
+
+// FormatResolvedDate renders a resolved date for confirmation messages, e.g. "Fri 2024-11-08"
+func FormatResolvedDate(t time.Time) string {
+	return t.Format("Mon " + DueDateLayout)
+}
+
+// DescribeDueDate renders a stored due date for confirmation messages, "none" when it is unset
+func DescribeDueDate(due sql.NullString) string {
+	if !due.Valid {
+		return "none"
+	}
+	date, err := ParseDueDate(due.String)
+	if err != nil {
+		return due.String
+	}
+	return FormatResolvedDate(date)
+}
@@ -0,0 +1,111 @@
+package data
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestResolveDate(t *testing.T) {
+	// Tuesday, the clock is fixed so relative dates are deterministic
+	now := time.Date(2024, 11, 5, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "today", input: "today", want: "2024-11-05"},
+		{name: "tomorrow", input: "tomorrow", want: "2024-11-06"},
+		{name: "yesterday", input: "yesterday", want: "2024-11-04"},
+		{name: "mixed case and whitespace", input: "  ToMoRRoW ", want: "2024-11-06"},
+		{name: "short weekday", input: "fri", want: "2024-11-08"},
+		{name: "long weekday", input: "friday", want: "2024-11-08"},
+		{name: "weekday is today", input: "tue", want: "2024-11-05"},
+		{name: "weekday earlier in the week", input: "mon", want: "2024-11-11"},
+		{name: "next monday", input: "next monday", want: "2024-11-11"},
+		{name: "next friday", input: "next fri", want: "2024-11-15"},
+		{name: "next sunday", input: "next   sunday", want: "2024-11-17"},
+		{name: "plus days", input: "+3d", want: "2024-11-08"},
+		{name: "plus zero days", input: "+0d", want: "2024-11-05"},
+		{name: "plus weeks", input: "+2w", want: "2024-11-19"},
+		{name: "plus months", input: "+1m", want: "2024-12-05"},
+		{name: "end of week", input: "eow", want: "2024-11-10"},
+		{name: "end of month", input: "eom", want: "2024-11-30"},
+		{name: "absolute date", input: "2025-01-31", want: "2025-01-31"},
+		{name: "absolute date with slashes", input: "2025/01/31", want: "2025-01-31"},
+		{name: "empty", input: "", wantErr: true},
+		{name: "next without weekday", input: "next week", wantErr: true},
+		{name: "bad offset unit", input: "+3y", wantErr: true},
+		{name: "bad offset amount", input: "+xd", wantErr: true},
+		{name: "bare plus", input: "+", wantErr: true},
+		{name: "plus without unit", input: "+d", wantErr: true},
+		{name: "garbage", input: "someday", wantErr: true},
+		{name: "impossible date", input: "2024-02-30", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveDate(tt.input, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveDate(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if formatted := got.Format(DueDateLayout); formatted != tt.want {
+				t.Errorf("ResolveDate(%q) = %s, want %s", tt.input, formatted, tt.want)
+			}
+			if got.Hour() != 0 || got.Minute() != 0 {
+				t.Errorf("ResolveDate(%q) = %v, want the start of the day", tt.input, got)
+			}
+		})
+	}
+}
+
+func TestResolveDateEdges(t *testing.T) {
+	tests := []struct {
+		name  string
+		now   time.Time
+		input string
+		want  string
+	}{
+		{name: "eow on a sunday", now: time.Date(2024, 11, 10, 9, 0, 0, 0, time.UTC), input: "eow", want: "2024-11-10"},
+		{name: "next monday from a sunday", now: time.Date(2024, 11, 10, 9, 0, 0, 0, time.UTC), input: "next mon", want: "2024-11-11"},
+		{name: "eom in a leap year", now: time.Date(2024, 2, 10, 9, 0, 0, 0, time.UTC), input: "eom", want: "2024-02-29"},
+		{name: "eom in december", now: time.Date(2024, 12, 10, 9, 0, 0, 0, time.UTC), input: "eom", want: "2024-12-31"},
+		{name: "plus month clamps to month end", now: time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC), input: "+1m", want: "2025-02-28"},
+		{name: "plus days crosses the year", now: time.Date(2024, 12, 30, 9, 0, 0, 0, time.UTC), input: "+3d", want: "2025-01-02"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveDate(tt.input, tt.now)
+			if err != nil {
+				t.Fatalf("ResolveDate(%q) unexpected error: %v", tt.input, err)
+			}
+			if formatted := got.Format(DueDateLayout); formatted != tt.want {
+				t.Errorf("ResolveDate(%q) = %s, want %s", tt.input, formatted, tt.want)
+			}
+		})
+	}
+}
+
+func TestToNullDueDateAt(t *testing.T) {
+	now := time.Date(2024, 11, 5, 15, 30, 0, 0, time.UTC)
+
+	got, err := ToNullDueDateAt("tomorrow", now)
+	if err != nil {
+		t.Fatalf("ToNullDueDateAt() unexpected error: %v", err)
+	}
+	want := sql.NullString{String: "2024-11-06", Valid: true}
+	if got != want {
+		t.Errorf("ToNullDueDateAt() = %v, want %v", got, want)
+	}
+}
+
+func TestFormatResolvedDate(t *testing.T) {
+	got := FormatResolvedDate(time.Date(2024, 11, 8, 0, 0, 0, 0, time.UTC))
+	if got != "Fri 2024-11-08" {
+		t.Errorf("FormatResolvedDate() = %s, want Fri 2024-11-08", got)
+	}
+}
@@ -0,0 +1,91 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DueDateLayout is the layout due dates are stored with in the tasks table
+const DueDateLayout = "2006-01-02"
+
+// storedTimeLayout is the local format of the timestamps SQLite's CURRENT_TIMESTAMP writes, such as tasks.created_at and tasks.last_mod
+const storedTimeLayout = "2006-01-02 15:04:05"
+
+// ParseDueDate parses a due date in the YYYY-MM-DD format
+func ParseDueDate(input string) (time.Time, error) {
+	due, err := time.ParseInLocation(DueDateLayout, strings.TrimSpace(input), time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid due date ( %s ) must be in the YYYY-MM-DD format", input)
+	}
+	return due, nil
+}
+
+// ToNullDueDate converts user input into the value stored in tasks.due_date.
+// Blank input means the task has no due date, anything else is resolved with ResolveDate.
+func ToNullDueDate(input string) (sql.NullString, error) {
+	return ToNullDueDateAt(input, time.Now())
+}
+
+// ToNullDueDateAt is ToNullDueDate with relative dates resolved against now
+func ToNullDueDateAt(input string, now time.Time) (sql.NullString, error) {
+	if strings.TrimSpace(input) == "" {
+		return sql.NullString{}, nil
+	}
+	due, err := ResolveDate(input, now)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: due.Format(DueDateLayout), Valid: true}, nil
+}
+
+// ParseDayWindow parses a window of days such as "7d", "2w", or a bare "7"
+func ParseDayWindow(input string) (int, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	multiplier := 1
+	switch {
+	case strings.HasSuffix(input, "d"):
+		input = strings.TrimSuffix(input, "d")
+	case strings.HasSuffix(input, "w"):
+		input = strings.TrimSuffix(input, "w")
+		multiplier = 7
+	}
+
+	days, err := strconv.Atoi(input)
+	if err != nil || days < 0 {
+		return 0, fmt.Errorf("invalid window ( %s ) must be a number of days or weeks such as 7d or 2w", input)
+	}
+	return days * multiplier, nil
+}
+
+// IsOverdue reports whether a task that is not done was due before today
+func IsOverdue(due sql.NullString, status string, now time.Time) bool {
+	if !due.Valid || status == string(StatusDone) {
+		return false
+	}
+	dueDate, err := ParseDueDate(due.String)
+	if err != nil {
+		return false
+	}
+	return dueDate.Before(startOfDay(now))
+}
+
+// IsDueWithin reports whether a task that is not done is due within the given number of days.
+// Overdue tasks are also considered to be due within the window.
+func IsDueWithin(due sql.NullString, status string, days int, now time.Time) bool {
+	if !due.Valid || status == string(StatusDone) {
+		return false
+	}
+	dueDate, err := ParseDueDate(due.String)
+	if err != nil {
+		return false
+	}
+	return !dueDate.After(startOfDay(now).AddDate(0, 0, days))
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,88 @@
+package data
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestToNullDueDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    sql.NullString
+		wantErr bool
+	}{
+		{name: "blank input", input: "", want: sql.NullString{}},
+		{name: "valid date", input: "2024-11-05", want: sql.NullString{String: "2024-11-05", Valid: true}},
+		{name: "surrounding whitespace", input: " 2024-11-05 ", want: sql.NullString{String: "2024-11-05", Valid: true}},
+		{name: "invalid date", input: "11/05/2024", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToNullDueDate(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ToNullDueDate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ToNullDueDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDayWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{name: "days", input: "7d", want: 7},
+		{name: "weeks", input: "2w", want: 14},
+		{name: "bare number", input: "3", want: 3},
+		{name: "negative", input: "-1d", wantErr: true},
+		{name: "garbage", input: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDayWindow(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDayWindow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDayWindow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDueDateFilters(t *testing.T) {
+	now := time.Date(2024, time.November, 5, 15, 30, 0, 0, time.Local)
+	due := func(s string) sql.NullString { return sql.NullString{String: s, Valid: true} }
+
+	tests := []struct {
+		name        string
+		due         sql.NullString
+		status      string
+		wantOverdue bool
+		wantWithin  bool
+	}{
+		{name: "no due date", due: sql.NullString{}, status: "todo"},
+		{name: "due yesterday", due: due("2024-11-04"), status: "todo", wantOverdue: true, wantWithin: true},
+		{name: "due today", due: due("2024-11-05"), status: "doing", wantWithin: true},
+		{name: "due in a week", due: due("2024-11-12"), status: "todo", wantWithin: true},
+		{name: "due in eight days", due: due("2024-11-13"), status: "todo"},
+		{name: "done tasks are never overdue", due: due("2024-11-01"), status: "done"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsOverdue(tt.due, tt.status, now); got != tt.wantOverdue {
+				t.Errorf("IsOverdue() = %v, want %v", got, tt.wantOverdue)
+			}
+			if got := IsDueWithin(tt.due, tt.status, 7, now); got != tt.wantWithin {
+				t.Errorf("IsDueWithin() = %v, want %v", got, tt.wantWithin)
+			}
+		})
+	}
+}
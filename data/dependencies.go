@@ -0,0 +1,93 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+var (
+	// ErrDependencyCycle is returned when linking two tasks would make a task wait on itself
+	ErrDependencyCycle = errors.New("dependency would create a cycle")
+	// ErrDependencyNotFound is returned when unlinking two tasks that were not linked
+	ErrDependencyNotFound = errors.New("dependency does not exist")
+)
+
+/*
+LinkTasks records that blockerID has to be done before blockedID can start.
+ 1. Both tasks have to exist and a task can not block itself
+ 2. The link is refused with ErrDependencyCycle when blockedID already blocks blockerID,
+    directly or through other tasks
+ 3. Linking two tasks that are already linked is a no-op
+*/
+func LinkTasks(ctx context.Context, conn *sql.DB, blockerID, blockedID int64) error {
+	if blockerID == blockedID {
+		return fmt.Errorf("%w: task %d can not block itself", ErrDependencyCycle, blockerID)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	queries := sqlc.New(tx)
+
+	for _, id := range []int64{blockerID, blockedID} {
+		if _, err := queries.ReadTaskForRecurrence(ctx, id); err != nil {
+			return fmt.Errorf("could not find task %d: %w", id, err)
+		}
+	}
+
+	cycle, err := queries.DependencyPathExists(ctx, sqlc.DependencyPathExistsParams{
+		FromID: blockedID,
+		ToID:   blockerID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check for dependency cycles: %w", err)
+	}
+	if cycle != 0 {
+		return fmt.Errorf("%w: task %d already has to wait on task %d", ErrDependencyCycle, blockerID, blockedID)
+	}
+
+	blockers, err := queries.ReadTaskBlockers(ctx, blockedID)
+	if err != nil {
+		return fmt.Errorf("failed to read the blockers of task %d: %w", blockedID, err)
+	}
+	for _, blocker := range blockers {
+		if blocker.ID == blockerID {
+			return tx.Commit()
+		}
+	}
+
+	err = queries.CreateTaskDependency(ctx, sqlc.CreateTaskDependencyParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to link task %d to task %d: %w", blockerID, blockedID, err)
+	}
+	return tx.Commit()
+}
+
+// UnlinkTasks removes the dependency between blockerID and blockedID
+func UnlinkTasks(ctx context.Context, conn *sql.DB, blockerID, blockedID int64) error {
+	removed, err := sqlc.New(conn).DeleteTaskDependency(ctx, sqlc.DeleteTaskDependencyParams{
+		BlockerID: blockerID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unlink task %d from task %d: %w", blockerID, blockedID, err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("%w: task %d does not block task %d", ErrDependencyNotFound, blockerID, blockedID)
+	}
+	return nil
+}
+
+// IsTaskReady reports whether a task can be worked on: it is not done, not archived, and has no open blockers
+func IsTaskReady(status string, archived bool, blockedBy string) bool {
+	return status != string(StatusDone) && !archived && blockedBy == ""
+}
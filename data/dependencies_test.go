@@ -0,0 +1,101 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+func TestLinkTasks(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+	for id := int64(1); id <= 4; id++ {
+		_, err := queries.CreateTask(ctx, sqlc.CreateTaskParams{
+			ID:     id,
+			Title:  "task",
+			Status: sql.NullString{String: string(StatusToDo), Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("CreateTask() error = %v", err)
+		}
+	}
+
+	// 1 -> 2 -> 3, task 4 is unrelated
+	if err := LinkTasks(ctx, conn, 1, 2); err != nil {
+		t.Fatalf("LinkTasks(1, 2) error = %v", err)
+	}
+	if err := LinkTasks(ctx, conn, 2, 3); err != nil {
+		t.Fatalf("LinkTasks(2, 3) error = %v", err)
+	}
+	if err := LinkTasks(ctx, conn, 1, 2); err != nil {
+		t.Errorf("LinkTasks(1, 2) twice should be a no-op, got %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		blocker int64
+		blocked int64
+		wantErr error
+	}{
+		{name: "self", blocker: 4, blocked: 4, wantErr: ErrDependencyCycle},
+		{name: "direct cycle", blocker: 2, blocked: 1, wantErr: ErrDependencyCycle},
+		{name: "transitive cycle", blocker: 3, blocked: 1, wantErr: ErrDependencyCycle},
+		{name: "missing task", blocker: 1, blocked: 42},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := LinkTasks(ctx, conn, tt.blocker, tt.blocked)
+			if err == nil {
+				t.Fatalf("LinkTasks(%d, %d) should fail", tt.blocker, tt.blocked)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("LinkTasks(%d, %d) error = %v, want %v", tt.blocker, tt.blocked, err, tt.wantErr)
+			}
+		})
+	}
+
+	blockers, err := queries.ReadTaskBlockers(ctx, 2)
+	if err != nil || len(blockers) != 1 || blockers[0].ID != 1 {
+		t.Errorf("ReadTaskBlockers(2) = %v, %v, want task 1", blockers, err)
+	}
+
+	readyIDs := func() []int64 {
+		tasks, err := queries.ReadTasks(ctx)
+		if err != nil {
+			t.Fatalf("ReadTasks() error = %v", err)
+		}
+		var ready []int64
+		for _, task := range tasks {
+			blockedBy, _ := task.BlockedBy.(string)
+			if IsTaskReady(task.Status.String, task.Archived, blockedBy) {
+				ready = append(ready, task.ID)
+			}
+		}
+		return ready
+	}
+	if got := readyIDs(); len(got) != 2 || got[0] != 1 || got[1] != 4 {
+		t.Errorf("ready tasks = %v, want [1 4]", got)
+	}
+
+	// Finishing a blocker unblocks the next task in the chain
+	if _, err := SetTaskStatus(ctx, conn, 1, StatusDone, testNow); err != nil {
+		t.Fatalf("SetTaskStatus() error = %v", err)
+	}
+	if got := readyIDs(); len(got) != 2 || got[0] != 2 || got[1] != 4 {
+		t.Errorf("ready tasks = %v, want [2 4]", got)
+	}
+
+	if err := UnlinkTasks(ctx, conn, 2, 3); err != nil {
+		t.Errorf("UnlinkTasks(2, 3) error = %v", err)
+	}
+	if err := UnlinkTasks(ctx, conn, 2, 3); !errors.Is(err, ErrDependencyNotFound) {
+		t.Errorf("UnlinkTasks(2, 3) twice error = %v, want %v", err, ErrDependencyNotFound)
+	}
+	if err := LinkTasks(ctx, conn, 3, 1); err != nil {
+		t.Errorf("LinkTasks(3, 1) after unlinking should succeed, got %v", err)
+	}
+}
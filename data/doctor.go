@@ -0,0 +1,307 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/akthe-at/go_task/sqlc"
+	"github.com/akthe-at/go_task/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// MissingNote is a note whose file does not exist anymore
+type MissingNote struct {
+	ID    int64
+	Title string
+	Path  string
+	// FoundPath is the file under the notes path carrying the frontmatter ID of the note, blank when it was not found
+	FoundPath string
+}
+
+// OrphanFile is a markdown file under the notes path that no note points at
+type OrphanFile struct {
+	Path  string
+	Title string
+	Tags  []string
+	// TaskID and AreaID are read from the task_id and area_id frontmatter properties, 0 when they are not set
+	TaskID int64
+	AreaID int64
+}
+
+// NoteDoctorReport is what DiagnoseNotes found
+type NoteDoctorReport struct {
+	// Healthy counts the notes whose file exists
+	Healthy int
+	Missing []MissingNote
+	Orphans []OrphanFile
+}
+
+// AdoptStats describes what AdoptOrphanFiles imported
+type AdoptStats struct {
+	NoteIDs []int64
+	// NoParent are the files that were left alone because no task or area was found for them
+	NoParent []string
+}
+
+// noteFrontmatter holds the frontmatter properties the doctor looks at, keys are matched regardless of case
+type noteFrontmatter struct {
+	id     string
+	title  string
+	tags   []string
+	taskID int64
+	areaID int64
+}
+
+// readNoteFrontmatter reads the properties of a note that go_task or Obsidian wrote, notes without frontmatter return nothing
+func readNoteFrontmatter(content []byte) noteFrontmatter {
+	var properties noteFrontmatter
+	frontmatter, _, ok := splitFrontmatter(content)
+	if !ok {
+		return properties
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(frontmatter, &values); err != nil {
+		return properties
+	}
+
+	for key, value := range values {
+		switch strings.ToLower(key) {
+		case "id":
+			properties.id = fmt.Sprint(value)
+		case "title":
+			properties.title = fmt.Sprint(value)
+		case "tags":
+			switch tags := value.(type) {
+			case string:
+				properties.tags = ParseTags(tags)
+			case []interface{}:
+				for _, tag := range tags {
+					properties.tags = append(properties.tags, ParseTags(fmt.Sprint(tag))...)
+				}
+			}
+		case "task_id":
+			properties.taskID, _ = strconv.ParseInt(fmt.Sprint(value), 10, 64)
+		case "area_id":
+			properties.areaID, _ = strconv.ParseInt(fmt.Sprint(value), 10, 64)
+		}
+	}
+	return properties
+}
+
+/*
+DiagnoseNotes compares the notes in the database with the markdown files under notesPath.
+ 1. Notes whose file does not exist are reported as missing
+ 2. A missing note is found again when a file that no note points at carries its ID in the frontmatter,
+    GenerateNoteID names the file after that ID so the ID outlives moves and renames
+ 3. Markdown files that no note points at, and that did not turn out to be a missing note, are reported as orphans
+ 4. Hidden folders such as .obsidian, .git and .trash are skipped
+*/
+func DiagnoseNotes(ctx context.Context, queries *sqlc.Queries, notesPath string) (NoteDoctorReport, error) {
+	var report NoteDoctorReport
+	notes, err := queries.ExportNotes(ctx)
+	if err != nil {
+		return report, fmt.Errorf("failed to read notes: %w", err)
+	}
+
+	referenced := make(map[string]bool)
+	var missing []MissingNote
+	for _, note := range notes {
+		path, err := absNotePath(note.Path)
+		if err != nil {
+			return report, fmt.Errorf("failed to resolve the path of note %d: %w", note.ID, err)
+		}
+		referenced[path] = true
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			missing = append(missing, MissingNote{ID: note.ID, Title: note.Title, Path: note.Path})
+		} else if err != nil {
+			return report, fmt.Errorf("failed to check note %s: %w", path, err)
+		} else {
+			report.Healthy++
+		}
+	}
+
+	var unreferenced []OrphanFile
+	byID := make(map[string]int)
+	if notesPath != "" {
+		root, err := absNotePath(notesPath)
+		if err != nil {
+			return report, fmt.Errorf("failed to resolve the notes path %s: %w", notesPath, err)
+		}
+		err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				if path != root && strings.HasPrefix(entry.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.EqualFold(filepath.Ext(path), ".md") || referenced[path] {
+				return nil
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			properties := readNoteFrontmatter(content)
+			orphan := OrphanFile{
+				Path:   path,
+				Title:  properties.title,
+				Tags:   properties.tags,
+				TaskID: properties.taskID,
+				AreaID: properties.areaID,
+			}
+			if orphan.Title == "" {
+				orphan.Title = strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+			}
+			if _, taken := byID[properties.id]; properties.id != "" && !taken {
+				byID[properties.id] = len(unreferenced)
+			}
+			unreferenced = append(unreferenced, orphan)
+			return nil
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return report, fmt.Errorf("failed to read the notes in %s: %w", notesPath, err)
+		}
+	}
+
+	claimed := make(map[int]bool)
+	for _, note := range missing {
+		id := strings.TrimSuffix(filepath.Base(note.Path), filepath.Ext(note.Path))
+		if i, ok := byID[id]; ok && !claimed[i] {
+			note.FoundPath = unreferenced[i].Path
+			claimed[i] = true
+		}
+		report.Missing = append(report.Missing, note)
+	}
+	for i, orphan := range unreferenced {
+		if !claimed[i] {
+			report.Orphans = append(report.Orphans, orphan)
+		}
+	}
+	return report, nil
+}
+
+// absNotePath expands ~ and makes a note path absolute so paths typed in different ways compare equal
+func absNotePath(path string) (string, error) {
+	path, err := utils.ExpandPath(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(path)
+}
+
+// RelinkMovedNotes points missing notes at the file they were found in, it returns how many notes were relinked
+func RelinkMovedNotes(ctx context.Context, conn *sql.DB, missing []MissingNote) (int, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	queries := sqlc.New(tx)
+
+	relinked := 0
+	for _, note := range missing {
+		if note.FoundPath == "" {
+			continue
+		}
+		if _, err := queries.UpdateNotePath(ctx, sqlc.UpdateNotePathParams{Path: note.FoundPath, ID: note.ID}); err != nil {
+			return 0, fmt.Errorf("failed to update the path of note %d: %w", note.ID, err)
+		}
+		relinked++
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit the new note paths: %w", err)
+	}
+	return relinked, nil
+}
+
+// PruneMissingNotes deletes the notes whose file is gone and was not found again, it returns how many notes were deleted
+func PruneMissingNotes(ctx context.Context, conn *sql.DB, missing []MissingNote) (int64, error) {
+	var ids []int64
+	for _, note := range missing {
+		if note.FoundPath == "" {
+			ids = append(ids, note.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result, err := sqlc.New(conn).DeleteNotes(ctx, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete notes %v: %w", ids, err)
+	}
+	return result.RowsAffected()
+}
+
+/*
+AdoptOrphanFiles creates notes for markdown files that no note points at.
+ 1. A note needs a task or area to show up in go_task, the task_id or area_id frontmatter property is used when
+    that task or area exists, otherwise the note goes to the fallback parent
+ 2. Files without a parent are reported in NoParent and left alone
+ 3. The title and tags are read from the frontmatter, the file name is the title of files without one
+*/
+func AdoptOrphanFiles(ctx context.Context, conn *sql.DB, orphans []OrphanFile, fallbackType NoteType, fallbackID int64) (AdoptStats, error) {
+	var stats AdoptStats
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return stats, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	queries := sqlc.New(tx)
+
+	for _, orphan := range orphans {
+		parentType, parentID := fallbackType, fallbackID
+		if orphan.TaskID != 0 {
+			if _, err := queries.ReadTaskForRecurrence(ctx, orphan.TaskID); err == nil {
+				parentType, parentID = TaskNoteType, orphan.TaskID
+			} else if !errors.Is(err, sql.ErrNoRows) {
+				return stats, fmt.Errorf("failed to read task %d: %w", orphan.TaskID, err)
+			}
+		} else if orphan.AreaID != 0 {
+			if _, err := queries.ReadArea(ctx, orphan.AreaID); err == nil {
+				parentType, parentID = AreaNoteType, orphan.AreaID
+			} else if !errors.Is(err, sql.ErrNoRows) {
+				return stats, fmt.Errorf("failed to read area %d: %w", orphan.AreaID, err)
+			}
+		}
+		if parentID == 0 {
+			stats.NoParent = append(stats.NoParent, orphan.Path)
+			continue
+		}
+
+		noteID, err := queries.GetNoteID(ctx)
+		if err != nil {
+			return stats, fmt.Errorf("failed to get an ID for %s: %w", orphan.Path, err)
+		}
+		if err := queries.CreateNote(ctx, sqlc.CreateNoteParams{ID: noteID, Title: orphan.Title, Path: orphan.Path}); err != nil {
+			return stats, fmt.Errorf("failed to create a note for %s: %w", orphan.Path, err)
+		}
+		bridge := sqlc.ImportBridgeNoteParams{NoteID: noteID, ParentCat: sql.NullInt64{Int64: int64(parentType), Valid: true}}
+		if parentType == TaskNoteType {
+			bridge.ParentTaskID = sql.NullInt64{Int64: parentID, Valid: true}
+		} else {
+			bridge.ParentAreaID = sql.NullInt64{Int64: parentID, Valid: true}
+		}
+		if err := queries.ImportBridgeNote(ctx, bridge); err != nil {
+			return stats, fmt.Errorf("failed to link %s to its parent: %w", orphan.Path, err)
+		}
+		if err := AddNoteTags(ctx, queries, noteID, orphan.Tags); err != nil {
+			return stats, fmt.Errorf("failed to tag %s: %w", orphan.Path, err)
+		}
+		stats.NoteIDs = append(stats.NoteIDs, noteID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return stats, fmt.Errorf("failed to commit the adopted notes: %w", err)
+	}
+	return stats, nil
+}
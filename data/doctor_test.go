@@ -0,0 +1,109 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+func TestNoteDoctor(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+
+	taskID, err := queries.ImportTask(ctx, sqlc.ImportTaskParams{Title: "Paint", CreatedAt: "2024-11-01 09:00:00", LastMod: "2024-11-01 09:00:00"})
+	if err != nil {
+		t.Fatalf("ImportTask() error = %v", err)
+	}
+	areaID, err := queries.ImportArea(ctx, sqlc.ImportAreaParams{Title: "Home", CreatedAt: "2024-11-01 09:00:00", LastMod: "2024-11-01 09:00:00"})
+	if err != nil {
+		t.Fatalf("ImportArea() error = %v", err)
+	}
+
+	notesPath := t.TempDir()
+	files := map[string]string{
+		"kept.md":                    "# Kept\n",
+		"archive/1730800000-plan.md": "---\nTitle: Plan\nID: 1730800000-plan\n---\nmoved here\n",
+		"inbox/idea.md":              "---\ntitle: Idea\ntags: [later]\narea_id: 1\n---\n",
+		"loose.md":                   "# Loose\n",
+		".obsidian/workspace.md":     "not a note\n",
+		"image.png":                  "not markdown\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(notesPath, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	notes := []sqlc.ImportNoteParams{
+		{Title: "Kept", Path: filepath.Join(notesPath, "kept.md")},
+		{Title: "Plan", Path: filepath.Join(notesPath, "1730800000-plan.md")},
+		{Title: "Gone", Path: filepath.Join(notesPath, "gone.md")},
+	}
+	for _, note := range notes {
+		noteID, err := queries.ImportNote(ctx, note)
+		if err != nil {
+			t.Fatalf("ImportNote() error = %v", err)
+		}
+		err = queries.ImportBridgeNote(ctx, sqlc.ImportBridgeNoteParams{
+			NoteID: noteID, ParentCat: sql.NullInt64{Int64: int64(TaskNoteType), Valid: true}, ParentTaskID: sql.NullInt64{Int64: taskID, Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("ImportBridgeNote() error = %v", err)
+		}
+	}
+
+	report, err := DiagnoseNotes(ctx, queries, notesPath)
+	if err != nil {
+		t.Fatalf("DiagnoseNotes() error = %v", err)
+	}
+	want := NoteDoctorReport{
+		Healthy: 1,
+		Missing: []MissingNote{
+			{ID: 2, Title: "Plan", Path: notes[1].Path, FoundPath: filepath.Join(notesPath, "archive/1730800000-plan.md")},
+			{ID: 3, Title: "Gone", Path: notes[2].Path},
+		},
+		Orphans: []OrphanFile{
+			{Path: filepath.Join(notesPath, "inbox/idea.md"), Title: "Idea", Tags: []string{"later"}, AreaID: areaID},
+			{Path: filepath.Join(notesPath, "loose.md"), Title: "loose"},
+		},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Fatalf("DiagnoseNotes() =\n%+v\nwant\n%+v", report, want)
+	}
+
+	if relinked, err := RelinkMovedNotes(ctx, conn, report.Missing); err != nil || relinked != 1 {
+		t.Errorf("RelinkMovedNotes() = %d, %v, want 1", relinked, err)
+	}
+	if pruned, err := PruneMissingNotes(ctx, conn, report.Missing); err != nil || pruned != 1 {
+		t.Errorf("PruneMissingNotes() = %d, %v, want 1", pruned, err)
+	}
+	stats, err := AdoptOrphanFiles(ctx, conn, report.Orphans, TaskNoteType, 0)
+	if err != nil {
+		t.Fatalf("AdoptOrphanFiles() error = %v", err)
+	}
+	if want := (AdoptStats{NoteIDs: []int64{3}, NoParent: []string{filepath.Join(notesPath, "loose.md")}}); !reflect.DeepEqual(stats, want) {
+		t.Errorf("AdoptOrphanFiles() = %+v, want %+v", stats, want)
+	}
+	tags, err := queries.ReadNoteTags(ctx, 3)
+	if err != nil || !reflect.DeepEqual(tags, []string{"later"}) {
+		t.Errorf("tags of the adopted note = %v, %v, want [later]", tags, err)
+	}
+
+	report, err = DiagnoseNotes(ctx, queries, notesPath)
+	if err != nil {
+		t.Fatalf("DiagnoseNotes() error = %v", err)
+	}
+	want = NoteDoctorReport{Healthy: 3, Orphans: []OrphanFile{{Path: filepath.Join(notesPath, "loose.md"), Title: "loose"}}}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("DiagnoseNotes() after the repair =\n%+v\nwant\n%+v", report, want)
+	}
+}
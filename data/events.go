@@ -0,0 +1,57 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+// EventCreated, EventDeleted, and EventRestored are the fields of the events recorded when a task, area, or note
+// is created, deleted, or brought back by undo
+const (
+	EventCreated  = "created"
+	EventDeleted  = "deleted"
+	EventRestored = "restored"
+)
+
+/*
+ReadEvents reads the recorded changes since the given day, oldest first.
+ 1. A taskID of 0 reads the changes to every task, area, and note, otherwise only those of the task
+    since it was last created, a deleted task whose ID was used again does not show up in its history
+ 2. A zero since reads the whole history
+ 3. Rows carry the current title, a deleted task, area, or note keeps the title it had when it was deleted
+*/
+func ReadEvents(ctx context.Context, queries *sqlc.Queries, taskID int64, since time.Time) ([]sqlc.ReadEventsRow, error) {
+	var changedAt string
+	if !since.IsZero() {
+		changedAt = since.Format(storedTimeLayout)
+	}
+	events, err := queries.ReadEvents(ctx, sqlc.ReadEventsParams{TaskID: taskID, ChangedAt: changedAt})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the history: %w", err)
+	}
+	return events, nil
+}
+
+// DescribeEvent renders the change an event records, e.g. status: planning -> doing
+func DescribeEvent(event sqlc.ReadEventsRow) string {
+	switch event.Field {
+	case EventCreated:
+		return "created as " + event.NewValue.String
+	case EventDeleted:
+		return "deleted"
+	case EventRestored:
+		return "restored as " + event.NewValue.String
+	}
+	return fmt.Sprintf("%s: %s -> %s", event.Field, describeEventValue(event.OldValue), describeEventValue(event.NewValue))
+}
+
+func describeEventValue(value sql.NullString) string {
+	if !value.Valid || value.String == "" {
+		return "none"
+	}
+	return value.String
+}
@@ -0,0 +1,126 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/akthe-at/go_task/db"
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+func TestReadEvents(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+
+	areaID, err := queries.ImportArea(ctx, sqlc.ImportAreaParams{Title: "Work", CreatedAt: "2024-11-01 09:00:00", LastMod: "2024-11-01 09:00:00"})
+	if err != nil {
+		t.Fatalf("ImportArea() error = %v", err)
+	}
+	taskID, err := queries.ImportTask(ctx, sqlc.ImportTaskParams{
+		Title:     "Write report",
+		Status:    sql.NullString{String: "planning", Valid: true},
+		CreatedAt: "2024-11-01 09:00:00",
+		LastMod:   "2024-11-01 09:00:00",
+	})
+	if err != nil {
+		t.Fatalf("ImportTask() error = %v", err)
+	}
+	otherID, err := queries.ImportTask(ctx, sqlc.ImportTaskParams{Title: "Book flights", CreatedAt: "2024-11-01 09:00:00", LastMod: "2024-11-01 09:00:00"})
+	if err != nil {
+		t.Fatalf("ImportTask() error = %v", err)
+	}
+
+	changes := []struct {
+		source string
+		change func() error
+	}{
+		{source: db.SourceCLI, change: func() error {
+			_, err := queries.UpdateTaskStatus(ctx, sqlc.UpdateTaskStatusParams{Status: sql.NullString{String: "doing", Valid: true}, ID: taskID})
+			return err
+		}},
+		{source: db.SourceTUI, change: func() error {
+			_, err := queries.UpdateTaskArea(ctx, sqlc.UpdateTaskAreaParams{AreaID: sql.NullInt64{Int64: areaID, Valid: true}, ID: taskID})
+			return err
+		}},
+		{source: db.SourceTUI, change: func() error {
+			// Marking it archived again changes nothing and records nothing
+			for i := 0; i < 2; i++ {
+				if _, err := queries.UpdateTaskArchived(ctx, sqlc.UpdateTaskArchivedParams{Archived: true, ID: taskID}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+		{source: db.SourceCLI, change: func() error {
+			_, err := queries.DeleteTask(ctx, otherID)
+			return err
+		}},
+	}
+	t.Cleanup(func() { db.EventSource = db.SourceCLI })
+	for _, tt := range changes {
+		db.EventSource = tt.source
+		if err := tt.change(); err != nil {
+			t.Fatalf("change error = %v", err)
+		}
+	}
+
+	events, err := ReadEvents(ctx, queries, taskID, time.Time{})
+	if err != nil {
+		t.Fatalf("ReadEvents() error = %v", err)
+	}
+	var got []string
+	for _, event := range events {
+		if event.Entity != "task" || event.EntityID != taskID || event.Title != "Write report" {
+			t.Errorf("ReadEvents() returned %+v, want only events of task %d", event, taskID)
+		}
+		got = append(got, event.Source+" "+DescribeEvent(event))
+	}
+	want := []string{
+		"cli created as Write report",
+		"cli status: planning -> doing",
+		"tui area_id: none -> 1",
+		"tui archived: false -> true",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadEvents() of task %d =\n%q\nwant\n%q", taskID, got, want)
+	}
+
+	// Without a task every change is read, a deleted task keeps its title
+	all, err := ReadEvents(ctx, queries, 0, time.Now().Add(-time.Hour))
+	if err != nil || len(all) != 7 {
+		t.Fatalf("ReadEvents() of everything = %+v, %v, want 7 events", all, err)
+	}
+	if last := all[len(all)-1]; last.EntityID != otherID || last.Field != EventDeleted || last.Title != "Book flights" {
+		t.Errorf("last event = %+v, want the deleted task %d", last, otherID)
+	}
+	if later, err := ReadEvents(ctx, queries, 0, time.Now().Add(time.Hour)); err != nil || len(later) != 0 {
+		t.Errorf("ReadEvents() since a later time = %+v, %v, want none", later, err)
+	}
+
+	// A new task that gets the ID of the deleted one starts with an empty history
+	reusedID, err := queries.ImportTask(ctx, sqlc.ImportTaskParams{Title: "Renew passport", CreatedAt: "2024-11-02 09:00:00", LastMod: "2024-11-02 09:00:00"})
+	if err != nil || reusedID != otherID {
+		t.Fatalf("ImportTask() = %d, %v, want the reused ID %d", reusedID, err, otherID)
+	}
+	reused, err := ReadEvents(ctx, queries, reusedID, time.Time{})
+	if err != nil || len(reused) != 1 || DescribeEvent(reused[0]) != "created as Renew passport" {
+		t.Errorf("ReadEvents() of the reused ID = %+v, %v, want only its creation", reused, err)
+	}
+	all, err = ReadEvents(ctx, queries, 0, time.Time{})
+	if err != nil {
+		t.Fatalf("ReadEvents() of everything error = %v", err)
+	}
+	var titles []string
+	for _, event := range all {
+		if event.EntityID == otherID && event.Entity == "task" {
+			titles = append(titles, event.Field+" "+event.Title)
+		}
+	}
+	if want := []string{"created Book flights", "deleted Book flights", "created Renew passport"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("titles of task %d = %q, want %q", otherID, titles, want)
+	}
+}
@@ -0,0 +1,551 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akthe-at/go_task/utils"
+)
+
+var (
+	// ErrFilterSyntax is returned for filter expressions that can not be split into terms
+	ErrFilterSyntax = errors.New("invalid filter")
+	// ErrUnknownFilterField is returned for terms that name a field the filter language does not know
+	ErrUnknownFilterField = errors.New("unknown filter field")
+	// ErrBadFilterOperator is returned when an operator is not supported by the field it is used with
+	ErrBadFilterOperator = errors.New("unsupported filter operator")
+	// ErrBadFilterValue is returned when the value of a term does not fit its field
+	ErrBadFilterValue = errors.New("invalid filter value")
+)
+
+// FilterHelp is a short description of the filter language for command help and forms
+const FilterHelp = `Filters are made of space separated terms that all have to match, e.g. status:doing priority>=high due<7d -archived
+Fields: id, title, status, priority, area, tag, due, repo, parent, archived, blocked
+Operators: field:value (is, or contains for title), =, !=, <, <=, >, >=
+Prefix a term with - to negate it, a bare word matches task titles, and none matches an empty area, due date, repo or parent`
+
+// filterOperators are checked longest first so that >= is not read as >
+var filterOperators = []string{">=", "<=", "!=", ":", "=", ">", "<"}
+
+// FilterEnv holds what relative filter values are resolved against
+type FilterEnv struct {
+	// Now resolves relative due dates such as today, fri, or 7d
+	Now time.Time
+	// WorkDir is the programming project that repo:. points at
+	WorkDir string
+}
+
+// CurrentFilterEnv resolves filters against the current time and the git repository of the working directory
+func CurrentFilterEnv() FilterEnv {
+	env := FilterEnv{Now: time.Now()}
+	if ok, projectDir, err := utils.CheckIfProjDir(); err == nil && ok {
+		env.WorkDir = projectDir
+	}
+	return env
+}
+
+// FilterTerm is a single condition of a filter expression
+type FilterTerm struct {
+	Field    string
+	Operator string
+	Value    string
+	Negated  bool
+}
+
+// TaskFilter is a filter expression compiled into a parameterized WHERE clause over the tasks table
+type TaskFilter struct {
+	Expression string
+	Where      string
+	Args       []interface{}
+}
+
+// filterField compiles one term into SQL, the operator has already been checked against operators
+type filterField struct {
+	operators []string
+	boolean   bool
+	compile   func(term FilterTerm, env FilterEnv) (string, []interface{}, error)
+}
+
+var filterFields map[string]filterField
+
+func init() {
+	equality := []string{":", "=", "!="}
+	ordered := []string{":", "=", "!=", "<", "<=", ">", ">="}
+
+	filterFields = map[string]filterField{
+		"id":       {operators: ordered, compile: compileIDTerm},
+		"title":    {operators: equality, compile: compileTitleTerm},
+		"status":   {operators: equality, compile: compileStatusTerm},
+		"priority": {operators: ordered, compile: compilePriorityTerm},
+		"area":     {operators: equality, compile: compileAreaTerm},
+		"tag":      {operators: equality, compile: compileTagTerm},
+		"due":      {operators: ordered, compile: compileDueTerm},
+		"repo":     {operators: equality, compile: compileRepoTerm},
+		"parent":   {operators: equality, compile: compileParentTerm},
+		"archived": {operators: equality, boolean: true, compile: compileArchivedTerm},
+		"blocked":  {operators: equality, boolean: true, compile: compileBlockedTerm},
+	}
+}
+
+// FilterFields lists the fields the filter language knows, in alphabetical order
+func FilterFields() []string {
+	fields := make([]string, 0, len(filterFields))
+	for name := range filterFields {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+/*
+ParseFilter splits a filter expression into terms.
+ 1. Terms are separated by spaces, double quotes keep spaces inside a value, e.g. area:"Deep Work"
+ 2. A leading - negates the term, e.g. -archived or -tag:home
+ 3. A field followed by an operator and a value is a condition, e.g. priority>=high
+ 4. A boolean field on its own means true, any other bare word is matched against task titles
+*/
+func ParseFilter(expression string) ([]FilterTerm, error) {
+	words, err := splitFilterWords(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	var terms []FilterTerm
+	for _, word := range words {
+		term, err := parseFilterTerm(word)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	return terms, nil
+}
+
+/*
+CompileFilter turns a filter expression into a WHERE clause and its arguments.
+ 1. Every value is passed as an argument, user input never ends up in the SQL text
+ 2. The clause can reference tasks and areas, see MatchingTaskIDs for the FROM clause it expects
+ 3. An empty expression matches every task
+*/
+func CompileFilter(expression string, env FilterEnv) (TaskFilter, error) {
+	filter := TaskFilter{Expression: strings.TrimSpace(expression)}
+	terms, err := ParseFilter(expression)
+	if err != nil {
+		return filter, err
+	}
+	if len(terms) == 0 {
+		filter.Where = "1 = 1"
+		return filter, nil
+	}
+
+	var clauses []string
+	for _, term := range terms {
+		field := filterFields[term.Field]
+		clause, args, err := field.compile(term, env)
+		if err != nil {
+			return filter, err
+		}
+		if term.Negated {
+			// NULL columns count as not matching, so negating them has to match
+			clause = fmt.Sprintf("NOT COALESCE((%s), 0)", clause)
+		}
+		clauses = append(clauses, "("+clause+")")
+		filter.Args = append(filter.Args, args...)
+	}
+	filter.Where = strings.Join(clauses, " AND ")
+	return filter, nil
+}
+
+// MatchingTaskIDs runs a compiled filter and returns the IDs of the tasks it matches
+func MatchingTaskIDs(ctx context.Context, conn *sql.DB, filter TaskFilter) (map[int64]bool, error) {
+	query := `SELECT tasks.id FROM tasks LEFT OUTER JOIN areas ON tasks.area_id = areas.id WHERE ` + filter.Where
+	rows, err := conn.QueryContext(ctx, query, filter.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run filter %q: %w", filter.Expression, err)
+	}
+	defer rows.Close()
+
+	ids := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to read filtered task: %w", err)
+		}
+		ids[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read filtered tasks: %w", err)
+	}
+	return ids, nil
+}
+
+// FilterTaskIDs compiles a filter expression and returns the IDs of the tasks it matches
+func FilterTaskIDs(ctx context.Context, conn *sql.DB, expression string, env FilterEnv) (map[int64]bool, error) {
+	filter, err := CompileFilter(expression, env)
+	if err != nil {
+		return nil, err
+	}
+	return MatchingTaskIDs(ctx, conn, filter)
+}
+
+// CombineFilters joins filter expressions so that all of them have to match
+func CombineFilters(expressions ...string) string {
+	var parts []string
+	for _, expression := range expressions {
+		if trimmed := strings.TrimSpace(expression); trimmed != "" {
+			parts = append(parts, trimmed)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+/*
+JoinFilterArgs joins command line arguments into one expression.
+ 1. An argument holding several conditions, e.g. 'status:doing -archived', is kept as it is and split into its terms
+ 2. An argument that is one condition or bare words with spaces had its quotes removed by the shell, e.g. area:"Deep Work",
+    its value is quoted again
+*/
+func JoinFilterArgs(args []string) string {
+	words := make([]string, 0, len(args))
+	for _, arg := range args {
+		if !strings.ContainsAny(arg, " \t") || strings.Contains(arg, `"`) {
+			words = append(words, arg)
+			continue
+		}
+		if parts, err := splitFilterWords(arg); err == nil && slices.ContainsFunc(parts[1:], isFilterCondition) {
+			words = append(words, arg)
+			continue
+		}
+		field := strings.TrimPrefix(arg, "-")
+		prefix := arg[:len(arg)-len(field)]
+		quoted := false
+		for i := 0; i < len(field) && isFieldNameByte(field[i]); i++ {
+			if _, ok := filterFields[strings.ToLower(field[:i+1])]; !ok {
+				continue
+			}
+			for _, operator := range filterOperators {
+				if strings.HasPrefix(field[i+1:], operator) {
+					value := field[i+1+len(operator):]
+					words = append(words, prefix+field[:i+1]+operator+`"`+value+`"`)
+					quoted = true
+					break
+				}
+			}
+			if quoted {
+				break
+			}
+		}
+		if !quoted {
+			words = append(words, prefix+`"`+field+`"`)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func splitFilterWords(expression string) ([]string, error) {
+	var words []string
+	var current strings.Builder
+	inQuotes := false
+	for _, r := range expression {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case (r == ' ' || r == '\t') && !inQuotes:
+			if current.Len() > 0 {
+				words = append(words, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("%w: missing closing quote in %q", ErrFilterSyntax, expression)
+	}
+	if current.Len() > 0 {
+		words = append(words, current.String())
+	}
+	return words, nil
+}
+
+func parseFilterTerm(word string) (FilterTerm, error) {
+	var term FilterTerm
+	if strings.HasPrefix(word, "-") && len(word) > 1 {
+		term.Negated = true
+		word = word[1:]
+	}
+
+	nameEnd := 0
+	for nameEnd < len(word) && isFieldNameByte(word[nameEnd]) {
+		nameEnd++
+	}
+	name, rest := strings.ToLower(word[:nameEnd]), word[nameEnd:]
+
+	if rest == "" {
+		if field, ok := filterFields[name]; ok && field.boolean {
+			term.Field, term.Operator, term.Value = name, ":", "true"
+			return term, nil
+		}
+		term.Field, term.Operator, term.Value = "title", ":", word
+		return term, nil
+	}
+
+	operator := ""
+	for _, candidate := range filterOperators {
+		if strings.HasPrefix(rest, candidate) {
+			operator = candidate
+			break
+		}
+	}
+	if operator == "" {
+		// Words such as "v2.0" or "don't" are not conditions, they are searched for in titles
+		if _, ok := filterFields[name]; !ok {
+			term.Field, term.Operator, term.Value = "title", ":", word
+			return term, nil
+		}
+		return term, fmt.Errorf("%w: %q in %q, use one of %s", ErrBadFilterOperator, rest[:1], word, strings.Join(filterOperators, " "))
+	}
+
+	field, ok := filterFields[name]
+	if !ok {
+		return term, fmt.Errorf("%w: %q in %q, valid fields are %s", ErrUnknownFilterField, name, word, strings.Join(FilterFields(), ", "))
+	}
+	if !containsString(field.operators, operator) {
+		return term, fmt.Errorf("%w: %s can not be used with %s, use one of %s", ErrBadFilterOperator, operator, name, strings.Join(field.operators, " "))
+	}
+
+	term.Field, term.Operator, term.Value = name, operator, rest[len(operator):]
+	if term.Value == "" {
+		return term, fmt.Errorf("%w: %q has no value", ErrBadFilterValue, word)
+	}
+	return term, nil
+}
+
+// isFilterCondition reports whether a word is a condition on a field rather than a bare word searched for in titles
+func isFilterCondition(word string) bool {
+	term, err := parseFilterTerm(word)
+	return err == nil && term.Value != strings.TrimPrefix(word, "-")
+}
+
+func isFieldNameByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b == '_'
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlOperator maps the filter operators onto SQL, ':' means the same as '='
+func sqlOperator(operator string) string {
+	if operator == ":" {
+		return "="
+	}
+	return operator
+}
+
+// isNone reports whether a value asks for an empty column
+func isNone(value string) bool {
+	return strings.EqualFold(value, "none")
+}
+
+// nullCheck compiles field:none and field!=none
+func nullCheck(column string, term FilterTerm) string {
+	if term.Operator == "!=" {
+		return column + " IS NOT NULL"
+	}
+	return column + " IS NULL"
+}
+
+func compileIDTerm(term FilterTerm, env FilterEnv) (string, []interface{}, error) {
+	id, err := strconv.ParseInt(term.Value, 10, 64)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: id %q is not a number", ErrBadFilterValue, term.Value)
+	}
+	return "tasks.id " + sqlOperator(term.Operator) + " ?", []interface{}{id}, nil
+}
+
+func compileTitleTerm(term FilterTerm, env FilterEnv) (string, []interface{}, error) {
+	switch term.Operator {
+	case ":":
+		// LIKE is case-insensitive for ASCII, the wildcards in the value are escaped
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term.Value)
+		return `tasks.title LIKE ? ESCAPE '\'`, []interface{}{"%" + escaped + "%"}, nil
+	default:
+		return "tasks.title " + sqlOperator(term.Operator) + " ? COLLATE NOCASE", []interface{}{term.Value}, nil
+	}
+}
+
+func compileStatusTerm(term FilterTerm, env FilterEnv) (string, []interface{}, error) {
+	var args []interface{}
+	for _, value := range strings.Split(term.Value, ",") {
+		status, err := StringToStatusType(strings.ToLower(value))
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %v", ErrBadFilterValue, err)
+		}
+		args = append(args, string(status))
+	}
+	clause := "tasks.status IN (" + placeholders(len(args)) + ")"
+	if term.Operator == "!=" {
+		clause = "tasks.status NOT IN (" + placeholders(len(args)) + ")"
+	}
+	return clause, args, nil
+}
+
+// priorityRank orders priorities so that priority>=high can be compared in SQL
+const priorityRank = `CASE tasks.priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 WHEN 'urgent' THEN 4 END`
+
+func compilePriorityTerm(term FilterTerm, env FilterEnv) (string, []interface{}, error) {
+	priority, err := StringToPriorityType(strings.ToLower(term.Value))
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrBadFilterValue, err)
+	}
+	rank := map[PriorityType]int{
+		PriorityTypeLow:    1,
+		PriorityTypeMedium: 2,
+		PriorityTypeHigh:   3,
+		PriorityTypeUrgent: 4,
+	}[priority]
+	return priorityRank + " " + sqlOperator(term.Operator) + " ?", []interface{}{rank}, nil
+}
+
+func compileAreaTerm(term FilterTerm, env FilterEnv) (string, []interface{}, error) {
+	if isNone(term.Value) {
+		return nullCheck("tasks.area_id", term), nil, nil
+	}
+	return "areas.title " + sqlOperator(term.Operator) + " ? COLLATE NOCASE", []interface{}{term.Value}, nil
+}
+
+func compileTagTerm(term FilterTerm, env FilterEnv) (string, []interface{}, error) {
+	if isNone(term.Value) {
+		clause := "EXISTS (SELECT 1 FROM task_tags WHERE task_tags.task_id = tasks.id)"
+		if term.Operator != "!=" {
+			clause = "NOT " + clause
+		}
+		return clause, nil, nil
+	}
+	clause := `EXISTS (SELECT 1 FROM task_tags JOIN tags ON tags.id = task_tags.tag_id
+		WHERE task_tags.task_id = tasks.id AND tags.name = ?)`
+	if term.Operator == "!=" {
+		clause = "NOT " + clause
+	}
+	return clause, []interface{}{strings.TrimPrefix(term.Value, "#")}, nil
+}
+
+/*
+compileDueTerm compares due dates as YYYY-MM-DD strings.
+ 1. Dates are resolved like the --due flag: today, fri, next monday, +3d, eom, 2024-11-05
+ 2. A bare window such as 7d or 2w means that many days from today, so due<7d is due within the week
+ 3. Tasks without a due date never match a comparison, use due:none to find them
+*/
+func compileDueTerm(term FilterTerm, env FilterEnv) (string, []interface{}, error) {
+	if isNone(term.Value) {
+		if term.Operator != ":" && term.Operator != "=" && term.Operator != "!=" {
+			return "", nil, fmt.Errorf("%w: due%snone, none can only be used with : = or !=", ErrBadFilterOperator, term.Operator)
+		}
+		return nullCheck("tasks.due_date", term), nil, nil
+	}
+
+	due, err := ResolveDate(term.Value, env.Now)
+	if err != nil {
+		days, windowErr := ParseDayWindow(term.Value)
+		if windowErr != nil || !strings.ContainsAny(strings.ToLower(term.Value), "dw") {
+			return "", nil, fmt.Errorf("%w: due date %q, %v", ErrBadFilterValue, term.Value, err)
+		}
+		due = startOfDay(env.Now).AddDate(0, 0, days)
+	}
+	return "tasks.due_date " + sqlOperator(term.Operator) + " ?", []interface{}{due.Format(DueDateLayout)}, nil
+}
+
+func compileRepoTerm(term FilterTerm, env FilterEnv) (string, []interface{}, error) {
+	exists := `EXISTS (SELECT 1 FROM prog_project_links
+		JOIN programming_projects ON programming_projects.id = prog_project_links.project_id
+		WHERE prog_project_links.parent_task_id = tasks.id`
+	var clause string
+	var args []interface{}
+	switch {
+	case isNone(term.Value):
+		clause = exists + ")"
+		if term.Operator != "!=" {
+			return "NOT " + clause, nil, nil
+		}
+		return clause, nil, nil
+	case term.Value == ".":
+		if env.WorkDir == "" {
+			return "", nil, fmt.Errorf("%w: repo:. only works inside a git repository", ErrBadFilterValue)
+		}
+		clause = exists + " AND programming_projects.path = ?)"
+		args = []interface{}{env.WorkDir}
+	case strings.ContainsRune(term.Value, filepath.Separator) || strings.ContainsRune(term.Value, '/'):
+		clause = exists + " AND programming_projects.path = ?)"
+		args = []interface{}{filepath.Clean(term.Value)}
+	default:
+		// A bare name matches the last folder of the repository path
+		clause = exists + " AND (programming_projects.path = ? OR programming_projects.path LIKE ?))"
+		args = []interface{}{term.Value, "%" + string(filepath.Separator) + term.Value}
+	}
+	if term.Operator == "!=" {
+		clause = "NOT " + clause
+	}
+	return clause, args, nil
+}
+
+func compileParentTerm(term FilterTerm, env FilterEnv) (string, []interface{}, error) {
+	if isNone(term.Value) {
+		return nullCheck("tasks.parent_task_id", term), nil, nil
+	}
+	id, err := strconv.ParseInt(term.Value, 10, 64)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: parent %q is not a task ID", ErrBadFilterValue, term.Value)
+	}
+	return "tasks.parent_task_id " + sqlOperator(term.Operator) + " ?", []interface{}{id}, nil
+}
+
+func parseFilterBool(term FilterTerm) (bool, error) {
+	value, err := strconv.ParseBool(term.Value)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s %q must be true or false", ErrBadFilterValue, term.Field, term.Value)
+	}
+	if term.Operator == "!=" {
+		value = !value
+	}
+	return value, nil
+}
+
+func compileArchivedTerm(term FilterTerm, env FilterEnv) (string, []interface{}, error) {
+	archived, err := parseFilterBool(term)
+	if err != nil {
+		return "", nil, err
+	}
+	return "tasks.archived = ?", []interface{}{archived}, nil
+}
+
+func compileBlockedTerm(term FilterTerm, env FilterEnv) (string, []interface{}, error) {
+	blocked, err := parseFilterBool(term)
+	if err != nil {
+		return "", nil, err
+	}
+	clause := `EXISTS (SELECT 1 FROM task_dependencies
+		JOIN tasks blocker ON blocker.id = task_dependencies.blocker_id
+		WHERE task_dependencies.blocked_id = tasks.id AND IFNULL(blocker.status, '') != 'done')`
+	if !blocked {
+		clause = "NOT " + clause
+	}
+	return clause, nil, nil
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
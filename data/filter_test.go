@@ -0,0 +1,235 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []FilterTerm
+		wantErr error
+	}{
+		{
+			name:  "field and operator",
+			input: "status:doing priority>=high",
+			want: []FilterTerm{
+				{Field: "status", Operator: ":", Value: "doing"},
+				{Field: "priority", Operator: ">=", Value: "high"},
+			},
+		},
+		{
+			name:  "negated boolean",
+			input: "-archived blocked",
+			want: []FilterTerm{
+				{Field: "archived", Operator: ":", Value: "true", Negated: true},
+				{Field: "blocked", Operator: ":", Value: "true"},
+			},
+		},
+		{
+			name:  "quoted value",
+			input: `area:"Deep Work" -tag:home`,
+			want: []FilterTerm{
+				{Field: "area", Operator: ":", Value: "Deep Work"},
+				{Field: "tag", Operator: ":", Value: "home", Negated: true},
+			},
+		},
+		{
+			name:  "bare words search titles",
+			input: `report "v2.0"`,
+			want: []FilterTerm{
+				{Field: "title", Operator: ":", Value: "report"},
+				{Field: "title", Operator: ":", Value: "v2.0"},
+			},
+		},
+		{name: "field names ignore case", input: "Due<7d", want: []FilterTerm{{Field: "due", Operator: "<", Value: "7d"}}},
+		{name: "empty", input: "   ", want: nil},
+		{name: "unknown field", input: "colour:red", wantErr: ErrUnknownFilterField},
+		{name: "operator not allowed for field", input: "status>=doing", wantErr: ErrBadFilterOperator},
+		{name: "unknown operator", input: "priority~high", wantErr: ErrBadFilterOperator},
+		{name: "missing value", input: "area:", wantErr: ErrBadFilterValue},
+		{name: "unclosed quote", input: `area:"Deep Work`, wantErr: ErrFilterSyntax},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFilter(tt.input)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseFilter(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFilter(%q) unexpected error: %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseFilter(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoinFilterArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"status:doing", "priority>=high"}, want: "status:doing priority>=high"},
+		{args: []string{"area:Deep Work"}, want: `area:"Deep Work"`},
+		{args: []string{"-Tag:day off"}, want: `-Tag:"day off"`},
+		{args: []string{"quarterly report"}, want: `"quarterly report"`},
+		{args: []string{`area:"Deep Work"`}, want: `area:"Deep Work"`},
+		{args: []string{"area:Deep Work", "-archived"}, want: `area:"Deep Work" -archived`},
+		// The whole expression quoted as one argument is split into its terms
+		{args: []string{"status:doing priority>=high area:Work due<7d -archived repo:."}, want: "status:doing priority>=high area:Work due<7d -archived repo:."},
+	}
+	for _, tt := range tests {
+		if got := JoinFilterArgs(tt.args); got != tt.want {
+			t.Errorf("JoinFilterArgs(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+
+	// go_task list tasks 'status:doing priority>=high area:Work due<7d -archived repo:.'
+	expression := JoinFilterArgs([]string{"status:doing priority>=high area:Work due<7d -archived repo:."})
+	filter, err := CompileFilter(expression, FilterEnv{Now: testNow, WorkDir: "/src/go_task"})
+	if err != nil {
+		t.Fatalf("CompileFilter(%q) error = %v", expression, err)
+	}
+	if len(filter.Args) == 0 {
+		t.Errorf("CompileFilter(%q) has no arguments", expression)
+	}
+}
+
+func TestCompileFilterValues(t *testing.T) {
+	env := FilterEnv{Now: testNow}
+	tests := []struct {
+		input   string
+		wantErr error
+	}{
+		{input: "status:someday", wantErr: ErrBadFilterValue},
+		{input: "priority>=critical", wantErr: ErrBadFilterValue},
+		{input: "due<soonish", wantErr: ErrBadFilterValue},
+		{input: "due<none", wantErr: ErrBadFilterOperator},
+		{input: "id>ten", wantErr: ErrBadFilterValue},
+		{input: "archived:maybe", wantErr: ErrBadFilterValue},
+		{input: "repo:.", wantErr: ErrBadFilterValue},
+	}
+	for _, tt := range tests {
+		_, err := CompileFilter(tt.input, env)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("CompileFilter(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+		}
+	}
+
+	// Values are always passed as arguments
+	filter, err := CompileFilter(`title:"'; DROP TABLE tasks; --"`, env)
+	if err != nil {
+		t.Fatalf("CompileFilter() unexpected error: %v", err)
+	}
+	if strings.Contains(filter.Where, "DROP") {
+		t.Errorf("CompileFilter() put user input in the SQL: %s", filter.Where)
+	}
+}
+
+func TestMatchingTaskIDs(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+
+	workID, err := queries.CreateArea(ctx, sqlc.CreateAreaParams{ID: 1, Title: "Work"})
+	if err != nil {
+		t.Fatalf("CreateArea() error = %v", err)
+	}
+	tasks := []sqlc.CreateTaskParams{
+		{ID: 1, Title: "Write quarterly report", Priority: sql.NullString{String: "high", Valid: true}, Status: sql.NullString{String: "doing", Valid: true}, DueDate: sql.NullString{String: "2024-11-08", Valid: true}, AreaID: sql.NullInt64{Int64: workID, Valid: true}},
+		{ID: 2, Title: "Review 100% of PRs", Priority: sql.NullString{String: "urgent", Valid: true}, Status: sql.NullString{String: "todo", Valid: true}, DueDate: sql.NullString{String: "2024-12-01", Valid: true}, AreaID: sql.NullInt64{Int64: workID, Valid: true}},
+		{ID: 3, Title: "Buy milk", Priority: sql.NullString{String: "low", Valid: true}, Status: sql.NullString{String: "doing", Valid: true}, Archived: true},
+		{ID: 4, Title: "Water plants", Priority: sql.NullString{String: "medium", Valid: true}, Status: sql.NullString{String: "done", Valid: true}, ParentTaskID: sql.NullInt64{Int64: 3, Valid: true}},
+	}
+	for _, task := range tasks {
+		if _, err := queries.CreateTask(ctx, task); err != nil {
+			t.Fatalf("CreateTask() error = %v", err)
+		}
+	}
+	if err := AddTaskTags(ctx, queries, 1, []string{"finance"}); err != nil {
+		t.Fatalf("AddTaskTags() error = %v", err)
+	}
+	if err := LinkTasks(ctx, conn, 2, 1); err != nil {
+		t.Fatalf("LinkTasks() error = %v", err)
+	}
+	projectID, err := queries.InsertProgProject(ctx, "/src/go_task")
+	if err != nil {
+		t.Fatalf("InsertProgProject() error = %v", err)
+	}
+	err = queries.CreateProjectTaskLink(ctx, sqlc.CreateProjectTaskLinkParams{
+		ProjectID:    sql.NullInt64{Int64: projectID, Valid: true},
+		ParentCat:    sql.NullInt64{Int64: int64(TaskNoteType), Valid: true},
+		ParentTaskID: sql.NullInt64{Int64: 2, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateProjectTaskLink() error = %v", err)
+	}
+
+	env := FilterEnv{Now: testNow, WorkDir: "/src/go_task"}
+	tests := []struct {
+		filter string
+		want   []int64
+	}{
+		{filter: "", want: []int64{1, 2, 3, 4}},
+		{filter: "status:doing", want: []int64{1, 3}},
+		{filter: "status:todo,done", want: []int64{2, 4}},
+		{filter: "status!=done", want: []int64{1, 2, 3}},
+		{filter: "priority>=high", want: []int64{1, 2}},
+		{filter: "priority<medium", want: []int64{3}},
+		{filter: "area:work", want: []int64{1, 2}},
+		{filter: "area:none", want: []int64{3, 4}},
+		{filter: "-area:Work", want: []int64{3, 4}},
+		{filter: "due<7d", want: []int64{1}},
+		{filter: "due>=2024-11-08", want: []int64{1, 2}},
+		{filter: "due:none", want: []int64{3, 4}},
+		{filter: "-archived", want: []int64{1, 2, 4}},
+		{filter: "archived", want: []int64{3}},
+		{filter: "tag:Finance", want: []int64{1}},
+		{filter: "-tag:finance", want: []int64{2, 3, 4}},
+		{filter: "blocked", want: []int64{1}},
+		{filter: "repo:.", want: []int64{2}},
+		{filter: "repo:go_task", want: []int64{2}},
+		{filter: "repo:none", want: []int64{1, 3, 4}},
+		{filter: "parent:3", want: []int64{4}},
+		{filter: "report", want: []int64{1}},
+		{filter: "100%", want: []int64{2}},
+		{filter: "title=buy milk", want: nil},
+		{filter: `title="buy milk"`, want: []int64{3}},
+		{filter: "id>=3 -archived", want: []int64{4}},
+		{filter: "status:doing priority>=high area:Work due<7d -archived", want: []int64{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			filter, err := CompileFilter(tt.filter, env)
+			if err != nil {
+				t.Fatalf("CompileFilter(%q) error = %v", tt.filter, err)
+			}
+			ids, err := MatchingTaskIDs(ctx, conn, filter)
+			if err != nil {
+				t.Fatalf("MatchingTaskIDs(%q) error = %v", tt.filter, err)
+			}
+			var got []int64
+			for id := range ids {
+				got = append(got, id)
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filter %q matched %v, want %v", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
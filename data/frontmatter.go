@@ -0,0 +1,198 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/akthe-at/go_task/sqlc"
+	"github.com/akthe-at/go_task/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// NoteStateStats describes what a frontmatter sync changed
+type NoteStateStats struct {
+	Updated   []string
+	Unchanged int
+	// Skipped are notes without a frontmatter block, they were not generated by go_task
+	Skipped int
+	// Missing are notes whose file was moved or deleted
+	Missing []string
+}
+
+// frontmatterProperty is a key go_task owns in the frontmatter of a note
+type frontmatterProperty struct {
+	key   string
+	value *yaml.Node
+}
+
+// noteStateProperties are the properties describing the task or area a note belongs to, in the order they are written
+func noteStateProperties(state sqlc.ReadNoteStatesRow) []frontmatterProperty {
+	if NoteType(state.ParentCat.Int64) == TaskNoteType {
+		return []frontmatterProperty{
+			{key: "task_id", value: yamlInt(state.TaskID)},
+			{key: "status", value: yamlString(state.TaskStatus)},
+			{key: "priority", value: yamlString(state.TaskPriority)},
+			{key: "area", value: yamlString(state.AreaTitle)},
+			{key: "archived", value: yamlBool(state.TaskArchived)},
+		}
+	}
+	return []frontmatterProperty{
+		{key: "area_id", value: yamlInt(state.AreaID)},
+		{key: "status", value: yamlString(state.AreaStatus)},
+		{key: "area", value: yamlString(state.AreaTitle)},
+		{key: "archived", value: yamlBool(state.AreaArchived)},
+	}
+}
+
+func yamlNull() *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+}
+
+func yamlInt(value sql.NullInt64) *yaml.Node {
+	if !value.Valid {
+		return yamlNull()
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(value.Int64, 10)}
+}
+
+func yamlString(value sql.NullString) *yaml.Node {
+	if !value.Valid || value.String == "" {
+		return yamlNull()
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value.String}
+}
+
+func yamlBool(value sql.NullBool) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(value.Bool)}
+}
+
+/*
+writeFrontmatterProperties sets properties in the frontmatter of a note.
+ 1. Existing keys keep their place, missing keys are appended after the other keys
+ 2. Every other key and the body of the note are left as they were
+ 3. Files without a frontmatter block, or with one that is not a YAML mapping, were not generated by go_task and are left alone
+ 4. The file is only written when a property changed
+*/
+func writeFrontmatterProperties(path string, properties []frontmatterProperty) (changed, generated bool, err error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, false, err
+	}
+	frontmatter, body, ok := splitFrontmatter(content)
+	if !ok {
+		return false, false, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(frontmatter, &doc); err != nil {
+		return false, false, nil
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return false, false, nil
+	}
+
+	for _, property := range properties {
+		found := false
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if mapping.Content[i].Value == property.key {
+				mapping.Content[i+1] = property.value
+				found = true
+				break
+			}
+		}
+		if !found {
+			mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: property.key}, property.value)
+		}
+	}
+
+	updated, err := yaml.Marshal(&doc)
+	if err != nil {
+		return false, true, fmt.Errorf("failed to marshal YAML front matter: %w", err)
+	}
+	var out bytes.Buffer
+	out.WriteString(frontmatterDelimiter + "\n")
+	out.Write(updated)
+	out.WriteString(frontmatterDelimiter + "\n")
+	out.Write(body)
+	if bytes.Equal(out.Bytes(), content) {
+		return false, true, nil
+	}
+
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		return false, true, fmt.Errorf("failed to write note %s: %w", path, err)
+	}
+	return true, true, nil
+}
+
+// syncNoteStates writes the state of their task or area into the frontmatter of the notes picked by keep
+func syncNoteStates(ctx context.Context, conn *sql.DB, keep func(sqlc.ReadNoteStatesRow) bool) (NoteStateStats, error) {
+	var stats NoteStateStats
+	states, err := sqlc.New(conn).ReadNoteStates(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("failed to read the state of the notes: %w", err)
+	}
+
+	for _, state := range states {
+		if !keep(state) {
+			continue
+		}
+		path, err := utils.ExpandPath(state.Path)
+		if err != nil {
+			return stats, fmt.Errorf("failed to expand the path of note %d: %w", state.ID, err)
+		}
+		changed, generated, err := writeFrontmatterProperties(path, noteStateProperties(state))
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			stats.Missing = append(stats.Missing, path)
+		case err != nil:
+			return stats, fmt.Errorf("failed to update the frontmatter of note %d: %w", state.ID, err)
+		case !generated:
+			stats.Skipped++
+		case changed:
+			stats.Updated = append(stats.Updated, path)
+			refreshNoteIndexQuietly(ctx, conn, state.ID, path)
+		default:
+			stats.Unchanged++
+		}
+	}
+	return stats, nil
+}
+
+// SyncTaskNoteStates updates the frontmatter of the notes of tasks after their status, priority, area or archive flag changed
+func SyncTaskNoteStates(ctx context.Context, conn *sql.DB, taskIDs ...int64) ([]string, error) {
+	stats, err := syncNoteStates(ctx, conn, func(state sqlc.ReadNoteStatesRow) bool {
+		return NoteType(state.ParentCat.Int64) == TaskNoteType && slices.Contains(taskIDs, state.TaskID.Int64)
+	})
+	return stats.Updated, err
+}
+
+// SyncAreaNoteStates updates the frontmatter of the notes of an area and of its tasks after the area changed
+func SyncAreaNoteStates(ctx context.Context, conn *sql.DB, areaIDs ...int64) ([]string, error) {
+	stats, err := syncNoteStates(ctx, conn, func(state sqlc.ReadNoteStatesRow) bool {
+		return state.AreaID.Valid && slices.Contains(areaIDs, state.AreaID.Int64)
+	})
+	return stats.Updated, err
+}
+
+// SyncNoteStates writes the state of their task or area into the frontmatter of notes that were just added to one
+func SyncNoteStates(ctx context.Context, conn *sql.DB, noteIDs ...int64) ([]string, error) {
+	stats, err := syncNoteStates(ctx, conn, func(state sqlc.ReadNoteStatesRow) bool {
+		return slices.Contains(noteIDs, state.ID)
+	})
+	return stats.Updated, err
+}
+
+// ResyncNoteStates repairs the frontmatter of every note that belongs to a task or an area
+func ResyncNoteStates(ctx context.Context, conn *sql.DB) (NoteStateStats, error) {
+	return syncNoteStates(ctx, conn, func(sqlc.ReadNoteStatesRow) bool { return true })
+}
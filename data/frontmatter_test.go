@@ -0,0 +1,153 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+func TestSyncNoteStates(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+
+	areaID, err := queries.ImportArea(ctx, sqlc.ImportAreaParams{
+		Title: "Home", Status: sql.NullString{String: "doing", Valid: true}, CreatedAt: "2024-11-01 09:00:00", LastMod: "2024-11-01 09:00:00",
+	})
+	if err != nil {
+		t.Fatalf("ImportArea() error = %v", err)
+	}
+	taskID, err := queries.ImportTask(ctx, sqlc.ImportTaskParams{
+		Title: "Paint", Status: sql.NullString{String: "todo", Valid: true}, CreatedAt: "2024-11-01 09:00:00", LastMod: "2024-11-01 09:00:00",
+		AreaID: sql.NullInt64{Int64: areaID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("ImportTask() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	notes := []struct {
+		file, content string
+		bridge        sqlc.ImportBridgeNoteParams
+	}{
+		{
+			file:    "paint.md",
+			content: "---\nTitle: Paint\n# picked by hand\nstatus: stale\n---\n# Paint\n\nstatus: not frontmatter\n",
+			bridge:  sqlc.ImportBridgeNoteParams{ParentCat: sql.NullInt64{Int64: int64(TaskNoteType), Valid: true}, ParentTaskID: sql.NullInt64{Int64: taskID, Valid: true}},
+		},
+		{
+			file:    "home.md",
+			content: "---\ntitle: Home\n---\nAll about home\n",
+			bridge:  sqlc.ImportBridgeNoteParams{ParentCat: sql.NullInt64{Int64: int64(AreaNoteType), Valid: true}, ParentAreaID: sql.NullInt64{Int64: areaID, Valid: true}},
+		},
+		{
+			file:    "plain.md",
+			content: "# No frontmatter\n",
+			bridge:  sqlc.ImportBridgeNoteParams{ParentCat: sql.NullInt64{Int64: int64(TaskNoteType), Valid: true}, ParentTaskID: sql.NullInt64{Int64: taskID, Valid: true}},
+		},
+		{
+			file:   "missing.md",
+			bridge: sqlc.ImportBridgeNoteParams{ParentCat: sql.NullInt64{Int64: int64(AreaNoteType), Valid: true}, ParentAreaID: sql.NullInt64{Int64: areaID, Valid: true}},
+		},
+	}
+	for _, note := range notes {
+		path := filepath.Join(dir, note.file)
+		if note.content != "" {
+			if err := os.WriteFile(path, []byte(note.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		noteID, err := queries.ImportNote(ctx, sqlc.ImportNoteParams{Title: note.file, Path: path})
+		if err != nil {
+			t.Fatalf("ImportNote() error = %v", err)
+		}
+		note.bridge.NoteID = noteID
+		if err := queries.ImportBridgeNote(ctx, note.bridge); err != nil {
+			t.Fatalf("ImportBridgeNote() error = %v", err)
+		}
+	}
+	paint, home := filepath.Join(dir, "paint.md"), filepath.Join(dir, "home.md")
+
+	stats, err := ResyncNoteStates(ctx, conn)
+	if err != nil {
+		t.Fatalf("ResyncNoteStates() error = %v", err)
+	}
+	want := NoteStateStats{Updated: []string{paint, home}, Skipped: 1, Missing: []string{filepath.Join(dir, "missing.md")}}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("ResyncNoteStates() = %+v, want %+v", stats, want)
+	}
+	assertFileContent(t, paint, "---\nTitle: Paint\n# picked by hand\nstatus: todo\ntask_id: 1\npriority: null\narea: Home\narchived: false\n---\n# Paint\n\nstatus: not frontmatter\n")
+	assertFileContent(t, home, "---\ntitle: Home\narea_id: 1\nstatus: doing\narea: Home\narchived: false\n---\nAll about home\n")
+	assertFileContent(t, filepath.Join(dir, "plain.md"), "# No frontmatter\n")
+
+	// Finishing the task updates its notes right away, a resync afterwards has nothing to do
+	if _, err := SetTaskStatus(ctx, conn, taskID, StatusDone, testNow); err != nil {
+		t.Fatalf("SetTaskStatus() error = %v", err)
+	}
+	assertFileContent(t, paint, "---\nTitle: Paint\n# picked by hand\nstatus: done\ntask_id: 1\npriority: null\narea: Home\narchived: false\n---\n# Paint\n\nstatus: not frontmatter\n")
+
+	// Renaming the area reaches the notes of its tasks as well
+	if _, err := queries.UpdateAreaTitle(ctx, sqlc.UpdateAreaTitleParams{Title: "House", ID: areaID}); err != nil {
+		t.Fatalf("UpdateAreaTitle() error = %v", err)
+	}
+	updated, err := SyncAreaNoteStates(ctx, conn, areaID)
+	if err != nil || !reflect.DeepEqual(updated, []string{paint, home}) {
+		t.Errorf("SyncAreaNoteStates() = %v, %v, want [%s %s]", updated, err, paint, home)
+	}
+
+	stats, err = ResyncNoteStates(ctx, conn)
+	if err != nil {
+		t.Fatalf("ResyncNoteStates() error = %v", err)
+	}
+	if stats.Updated != nil || stats.Unchanged != 2 {
+		t.Errorf("ResyncNoteStates() after a sync = %+v, want 2 unchanged notes", stats)
+	}
+}
+
+func TestSyncNoteStatesOfNewNote(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+
+	taskID, err := queries.ImportTask(ctx, sqlc.ImportTaskParams{
+		Title: "Paint", Status: sql.NullString{String: "doing", Valid: true}, Priority: sql.NullString{String: "high", Valid: true},
+		CreatedAt: "2024-11-01 09:00:00", LastMod: "2024-11-01 09:00:00",
+	})
+	if err != nil {
+		t.Fatalf("ImportTask() error = %v", err)
+	}
+
+	// A generated note carries the state of its task from the start, other notes of the task are left for the update sync
+	dir := t.TempDir()
+	var paths []string
+	var noteIDs []int64
+	for _, title := range []string{"new", "other"} {
+		path, err := GenerateMarkdownFile(NoteContent{Metadata: NoteMetadata{Title: title, ID: title}, Body: "# " + title + "\n"}, dir)
+		if err != nil {
+			t.Fatalf("GenerateMarkdownFile() error = %v", err)
+		}
+		noteID, err := queries.ImportNote(ctx, sqlc.ImportNoteParams{Title: title, Path: path})
+		if err != nil {
+			t.Fatalf("ImportNote() error = %v", err)
+		}
+		err = queries.ImportBridgeNote(ctx, sqlc.ImportBridgeNoteParams{
+			NoteID: noteID, ParentCat: sql.NullInt64{Int64: int64(TaskNoteType), Valid: true}, ParentTaskID: sql.NullInt64{Int64: taskID, Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("ImportBridgeNote() error = %v", err)
+		}
+		paths, noteIDs = append(paths, path), append(noteIDs, noteID)
+	}
+
+	updated, err := SyncNoteStates(ctx, conn, noteIDs[0])
+	if err != nil || !reflect.DeepEqual(updated, paths[:1]) {
+		t.Errorf("SyncNoteStates() = %v, %v, want %v", updated, err, paths[:1])
+	}
+	assertFileContent(t, paths[0], "---\nTitle: new\nID: new\nAliases: []\nTags: []\ntask_id: 1\nstatus: doing\npriority: high\narea: null\narchived: false\n---\n# new\n")
+	assertFileContent(t, paths[1], "---\nTitle: other\nID: other\nAliases: []\nTags: []\n---\n# other\n")
+}
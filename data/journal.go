@@ -0,0 +1,158 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+// JournalFolder is the folder below the notes path the daily journal notes are written to
+const JournalFolder = "journal"
+
+// journalSections are the sections of a journal note in the order they are written, keyed by the section ReadJournalTasks returns
+var journalSections = []struct{ key, heading string }{
+	{key: "done", heading: "Done"},
+	{key: "doing", heading: "Doing"},
+	{key: "due", heading: "Due"},
+}
+
+// Journal is the daily note OpenJournal found or wrote
+type Journal struct {
+	NoteID int64
+	Path   string
+	// Created is set when the file was written by this call, an existing file is reopened as it is
+	Created bool
+	// TaskIDs are the tasks the note was linked to by this call, in the order the note lists them
+	TaskIDs []int64
+}
+
+/*
+OpenJournal returns the daily journal note of day, it is written the first time it is asked for.
+ 1. The note is journal/YYYY-MM-DD.md below notesPath, so every later call for that day finds the same file
+ 2. A new note lists the tasks moved to done that day, the tasks that are doing right now, and the open tasks due that day
+ 3. The note is linked to every task it lists
+ 4. An existing file is never rewritten, a file no note points at anymore is added back with the tasks of the day
+*/
+func OpenJournal(ctx context.Context, conn *sql.DB, notesPath string, day time.Time) (Journal, error) {
+	var journal Journal
+	if notesPath == "" {
+		return journal, errors.New("no notes_path is set in the [selected] section of your config")
+	}
+	folder, err := absNotePath(filepath.Join(notesPath, JournalFolder))
+	if err != nil {
+		return journal, fmt.Errorf("failed to resolve the journal folder: %w", err)
+	}
+	date := day.Format(DueDateLayout)
+	title := "Journal " + FormatResolvedDate(day)
+	journal.Path = filepath.Join(folder, date+".md")
+
+	queries := sqlc.New(conn)
+	notes, err := queries.ExportNotes(ctx)
+	if err != nil {
+		return journal, fmt.Errorf("failed to read notes: %w", err)
+	}
+	for _, note := range notes {
+		if path, err := absNotePath(note.Path); err == nil && path == journal.Path {
+			journal.NoteID = note.ID
+			break
+		}
+	}
+
+	_, err = os.Stat(journal.Path)
+	if err == nil && journal.NoteID != 0 {
+		return journal, nil
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return journal, fmt.Errorf("failed to check %s: %w", journal.Path, err)
+	}
+	journal.Created = err != nil
+
+	tasks, err := queries.ReadJournalTasks(ctx, date)
+	if err != nil {
+		return journal, fmt.Errorf("failed to read the tasks of %s: %w", date, err)
+	}
+	for _, section := range journalSections {
+		for _, task := range tasks {
+			if task.Section == section.key && !slices.Contains(journal.TaskIDs, task.ID) {
+				journal.TaskIDs = append(journal.TaskIDs, task.ID)
+			}
+		}
+	}
+
+	if journal.Created {
+		if err := os.MkdirAll(folder, 0755); err != nil {
+			return journal, fmt.Errorf("failed to create the journal folder: %w", err)
+		}
+		note := NoteContent{
+			Metadata: NoteMetadata{Title: title, ID: date, Tags: []string{JournalFolder}},
+			Body:     journalBody(title, tasks),
+		}
+		if _, err := GenerateMarkdownFile(note, folder); err != nil {
+			return journal, fmt.Errorf("failed to write the journal of %s: %w", date, err)
+		}
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return journal, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := queries.WithTx(tx)
+
+	if journal.NoteID == 0 {
+		journal.NoteID, err = qtx.GetNoteID(ctx)
+		if err != nil {
+			return journal, fmt.Errorf("failed to get an ID for the journal of %s: %w", date, err)
+		}
+		if err := qtx.CreateNote(ctx, sqlc.CreateNoteParams{ID: journal.NoteID, Title: title, Path: journal.Path}); err != nil {
+			return journal, fmt.Errorf("failed to add the journal of %s: %w", date, err)
+		}
+		if err := AddNoteTags(ctx, qtx, journal.NoteID, []string{JournalFolder}); err != nil {
+			return journal, err
+		}
+	}
+	for _, taskID := range journal.TaskIDs {
+		_, err := qtx.CreateNoteLink(ctx, sqlc.CreateNoteLinkParams{
+			NoteID:       journal.NoteID,
+			ParentCat:    sql.NullInt64{Int64: int64(TaskNoteType), Valid: true},
+			ParentTaskID: sql.NullInt64{Int64: taskID, Valid: true},
+		})
+		if err != nil {
+			return journal, fmt.Errorf("failed to link the journal of %s to task %d: %w", date, taskID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return journal, fmt.Errorf("failed to commit the journal of %s: %w", date, err)
+	}
+
+	refreshNoteIndexQuietly(ctx, conn, journal.NoteID, journal.Path)
+	return journal, nil
+}
+
+// journalBody renders the sections of a journal note, tasks are plain list items so 'notes sync' does not turn them into new tasks
+func journalBody(title string, tasks []sqlc.ReadJournalTasksRow) string {
+	var body strings.Builder
+	body.WriteString("# " + title + "\n")
+	for _, section := range journalSections {
+		body.WriteString("\n## " + section.heading + "\n\n")
+		listed := false
+		for _, task := range tasks {
+			if task.Section != section.key {
+				continue
+			}
+			body.WriteString(fmt.Sprintf("- %s (task %d)\n", task.Title, task.ID))
+			listed = true
+		}
+		if !listed {
+			body.WriteString("- none\n")
+		}
+	}
+	return body.String()
+}
@@ -0,0 +1,115 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+func TestOpenJournal(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+
+	tasks := []struct {
+		title, status, lastMod, due string
+		archived                    bool
+	}{
+		{title: "Fix the fence", status: "done", lastMod: "2024-11-05 11:00:00"},
+		{title: "Paint the shed", status: "done", lastMod: "2024-11-04 18:00:00", due: "2024-11-05"},
+		{title: "Plan the garden", status: "doing", lastMod: "2024-11-01 09:00:00", due: "2024-11-05"},
+		{title: "Order seeds", status: "todo", lastMod: "2024-11-01 09:00:00", due: "2024-11-05"},
+		{title: "Old chores", status: "doing", lastMod: "2024-11-01 09:00:00", archived: true},
+		{title: "Mow the lawn", status: "todo", lastMod: "2024-11-05 09:00:00", due: "2024-11-06"},
+	}
+	var taskIDs []int64
+	for _, task := range tasks {
+		taskID, err := queries.ImportTask(ctx, sqlc.ImportTaskParams{
+			Title:     task.title,
+			Status:    sql.NullString{String: task.status, Valid: true},
+			Archived:  task.archived,
+			CreatedAt: "2024-11-01 09:00:00",
+			LastMod:   task.lastMod,
+			DueDate:   sql.NullString{String: task.due, Valid: task.due != ""},
+		})
+		if err != nil {
+			t.Fatalf("ImportTask() error = %v", err)
+		}
+		taskIDs = append(taskIDs, taskID)
+	}
+
+	notesPath := t.TempDir()
+	journal, err := OpenJournal(ctx, conn, notesPath, testNow)
+	if err != nil {
+		t.Fatalf("OpenJournal() error = %v", err)
+	}
+	want := Journal{NoteID: 1, Path: filepath.Join(notesPath, JournalFolder, "2024-11-05.md"), Created: true, TaskIDs: []int64{taskIDs[0], taskIDs[2], taskIDs[3]}}
+	if !reflect.DeepEqual(journal, want) {
+		t.Errorf("OpenJournal() = %+v, want %+v", journal, want)
+	}
+	assertFileContent(t, journal.Path, `---
+Title: Journal Tue 2024-11-05
+ID: "2024-11-05"
+Aliases: []
+Tags:
+    - journal
+---
+# Journal Tue 2024-11-05
+
+## Done
+
+- Fix the fence (task 1)
+
+## Doing
+
+- Plan the garden (task 3)
+
+## Due
+
+- Plan the garden (task 3)
+- Order seeds (task 4)
+`)
+
+	notes, err := queries.ReadAllNotes(ctx)
+	if err != nil || len(notes) != 1 || notes[0].AreaOrTaskTitle != "Fix the fence, Plan the garden, Order seeds" {
+		t.Errorf("ReadAllNotes() = %+v, %v, want the journal linked to the tasks it lists", notes, err)
+	}
+
+	// Running it again the same day reopens the file, even after the day's work changed
+	if _, err := queries.UpdateTaskStatus(ctx, sqlc.UpdateTaskStatusParams{Status: sql.NullString{String: "doing", Valid: true}, ID: taskIDs[5]}); err != nil {
+		t.Fatalf("UpdateTaskStatus() error = %v", err)
+	}
+	again, err := OpenJournal(ctx, conn, notesPath, testNow.Add(3*time.Hour))
+	if want := (Journal{NoteID: 1, Path: want.Path}); err != nil || !reflect.DeepEqual(again, want) {
+		t.Errorf("second OpenJournal() = %+v, %v, want %+v", again, err, want)
+	}
+	if links, err := queries.ExportBridgeNotes(ctx); err != nil || len(links) != 3 {
+		t.Errorf("ExportBridgeNotes() = %+v, %v, want the 3 links of the first run", links, err)
+	}
+
+	// Moving a task to done stamps it with the current day, reopening it clears the stamp
+	var completedAt sql.NullString
+	for _, status := range []string{"done", "done", "todo"} {
+		if _, err := queries.UpdateTaskStatus(ctx, sqlc.UpdateTaskStatusParams{Status: sql.NullString{String: status, Valid: true}, ID: taskIDs[3]}); err != nil {
+			t.Fatalf("UpdateTaskStatus() error = %v", err)
+		}
+		if err := conn.QueryRow("SELECT date(completed_at) FROM tasks WHERE id = ?", taskIDs[3]).Scan(&completedAt); err != nil {
+			t.Fatalf("reading completed_at: %v", err)
+		}
+		if today := time.Now().Format(DueDateLayout); status == "done" && completedAt.String != today {
+			t.Errorf("completed_at after moving to %s = %v, want %s", status, completedAt, today)
+		}
+	}
+	if completedAt.Valid {
+		t.Errorf("completed_at after reopening = %v, want NULL", completedAt)
+	}
+
+	if _, err := OpenJournal(ctx, conn, "", testNow); err == nil {
+		t.Error("OpenJournal() without a notes path did not fail")
+	}
+}
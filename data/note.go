@@ -2,14 +2,18 @@ package data
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
 	"fmt"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/akthe-at/go_task/config"
+	"github.com/akthe-at/go_task/sqlc"
 	"gopkg.in/yaml.v3"
 )
 
@@ -20,15 +24,42 @@ type NoteMetadata struct {
 	Tags    []string `yaml:"Tags"`
 }
 
+// ObsidianProperties is the frontmatter written when use_obsidian is set, Obsidian only knows lower case property names
+type ObsidianProperties struct {
+	Title   string   `yaml:"title"`
+	ID      string   `yaml:"id"`
+	Aliases []string `yaml:"aliases"`
+	Tags    []string `yaml:"tags"`
+	Created string   `yaml:"created"`
+	Up      []string `yaml:"up,omitempty"`
+}
+
 type NoteContent struct {
 	Body     string
 	Metadata NoteMetadata
+	// ParentLinks are wikilinks to the notes of the task or area the note belongs to, they are only written for Obsidian
+	ParentLinks []string
+}
+
+// frontmatter returns the properties of a note in the style of the configured editor
+func (note NoteContent) frontmatter() interface{} {
+	if !config.UserSettings.Selected.UseObsidian {
+		return note.Metadata
+	}
+	return ObsidianProperties{
+		Title:   note.Metadata.Title,
+		ID:      note.Metadata.ID,
+		Aliases: note.Metadata.Aliases,
+		Tags:    note.Metadata.Tags,
+		Created: time.Now().Format("2006-01-02T15:04:05"),
+		Up:      note.ParentLinks,
+	}
 }
 
 func GenerateMarkdownFile(note NoteContent, outputPath string) (string, error) {
 	var content bytes.Buffer
 
-	yamlFrontMatter, err := yaml.Marshal(note.Metadata)
+	yamlFrontMatter, err := yaml.Marshal(note.frontmatter())
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal YAML front matter: %w", err)
 	}
@@ -44,7 +75,7 @@ func GenerateMarkdownFile(note NoteContent, outputPath string) (string, error) {
 	return outputPath, os.WriteFile(outputPath, content.Bytes(), 0644)
 }
 
-func TemplateMarkdownNote(Title, ID, body string, aliases []string, tags []string) (string, error) {
+func TemplateMarkdownNote(Title, ID, body string, aliases []string, tags []string, parentLinks []string) (string, error) {
 	note := NoteContent{
 		Metadata: NoteMetadata{
 			Title:   Title,
@@ -52,7 +83,8 @@ func TemplateMarkdownNote(Title, ID, body string, aliases []string, tags []strin
 			Aliases: aliases,
 			Tags:    tags,
 		},
-		Body: body,
+		Body:        body,
+		ParentLinks: parentLinks,
 	}
 	notesPath := config.UserSettings.Selected.NotesPath
 	output, err := GenerateMarkdownFile(note, notesPath)
@@ -83,3 +115,55 @@ func GenerateNoteID(title string) string {
 	}
 	return fmt.Sprintf("%d-%s", time.Now().Unix(), suffix)
 }
+
+// ObsidianWikilink links to a note by its file name, the title is shown when it differs from the file name
+func ObsidianWikilink(path, title string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if title == "" || title == name {
+		return fmt.Sprintf("[[%s]]", name)
+	}
+	return fmt.Sprintf("[[%s|%s]]", name, title)
+}
+
+/*
+ParentNoteLinks returns wikilinks to the notes a new note should point back to.
+ 1. A note of a task links to the other notes of that task, the notes of its parent task, and the notes of its area
+ 2. A note of an area links to the other notes of that area
+ 3. Every note is linked once, in that order
+*/
+func ParentNoteLinks(ctx context.Context, queries *sqlc.Queries, noteType NoteType, parentID int64) ([]string, error) {
+	var params []sqlc.ReadParentNotesParams
+	switch noteType {
+	case TaskNoteType:
+		task, err := queries.ReadTaskForRecurrence(ctx, parentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read task %d: %w", parentID, err)
+		}
+		params = append(params, sqlc.ReadParentNotesParams{TaskID: sql.NullInt64{Int64: parentID, Valid: true}})
+		if task.ParentTaskID.Valid {
+			params = append(params, sqlc.ReadParentNotesParams{TaskID: task.ParentTaskID})
+		}
+		if task.AreaID.Valid {
+			params = append(params, sqlc.ReadParentNotesParams{AreaID: task.AreaID})
+		}
+	case AreaNoteType:
+		params = append(params, sqlc.ReadParentNotesParams{AreaID: sql.NullInt64{Int64: parentID, Valid: true}})
+	}
+
+	var links []string
+	seen := make(map[int64]bool)
+	for _, param := range params {
+		notes, err := queries.ReadParentNotes(ctx, param)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read parent notes: %w", err)
+		}
+		for _, note := range notes {
+			if seen[note.ID] {
+				continue
+			}
+			seen[note.ID] = true
+			links = append(links, ObsidianWikilink(note.Path, note.Title))
+		}
+	}
+	return links, nil
+}
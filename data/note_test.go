@@ -1,8 +1,16 @@
 package data
 
 import (
+	"context"
+	"database/sql"
+	"os"
+	"reflect"
 	"regexp"
+	"strings"
 	"testing"
+
+	"github.com/akthe-at/go_task/config"
+	"github.com/akthe-at/go_task/sqlc"
 )
 
 func TestGenerateNoteID(t *testing.T) {
@@ -39,3 +47,123 @@ func TestGenerateNoteID(t *testing.T) {
 		})
 	}
 }
+
+func TestObsidianWikilink(t *testing.T) {
+	tests := []struct {
+		path, title string
+		want        string
+	}{
+		{path: "/vault/1657296016-my-note.md", title: "My note", want: "[[1657296016-my-note|My note]]"},
+		{path: "/vault/Plan.md", title: "Plan", want: "[[Plan]]"},
+		{path: "/vault/Plan.md", want: "[[Plan]]"},
+	}
+	for _, tt := range tests {
+		if got := ObsidianWikilink(tt.path, tt.title); got != tt.want {
+			t.Errorf("ObsidianWikilink(%q, %q) = %q, want %q", tt.path, tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateMarkdownFileObsidian(t *testing.T) {
+	saved := config.UserSettings.Selected.UseObsidian
+	t.Cleanup(func() { config.UserSettings.Selected.UseObsidian = saved })
+
+	note := NoteContent{
+		Metadata:    NoteMetadata{Title: "Plan", ID: "1-plan", Aliases: []string{"plan"}, Tags: []string{"work"}},
+		Body:        "body\n",
+		ParentLinks: []string{"[[0-area|Area]]"},
+	}
+	for _, tt := range []struct {
+		obsidian bool
+		want     []string
+		wantNot  []string
+	}{
+		{obsidian: false, want: []string{"Title: Plan\n", "Tags:\n    - work\n"}, wantNot: []string{"up:", "created:"}},
+		{obsidian: true, want: []string{"title: Plan\n", "tags:\n    - work\n", "created: ", "up:\n    - '[[0-area|Area]]'\n"}},
+	} {
+		config.UserSettings.Selected.UseObsidian = tt.obsidian
+		path, err := GenerateMarkdownFile(note, t.TempDir())
+		if err != nil {
+			t.Fatalf("GenerateMarkdownFile() error = %v", err)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(string(content), want) {
+				t.Errorf("obsidian=%t note is missing %q:\n%s", tt.obsidian, want, content)
+			}
+		}
+		for _, wantNot := range tt.wantNot {
+			if strings.Contains(string(content), wantNot) {
+				t.Errorf("obsidian=%t note contains %q:\n%s", tt.obsidian, wantNot, content)
+			}
+		}
+	}
+}
+
+func TestParentNoteLinks(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+
+	areaID, err := queries.ImportArea(ctx, sqlc.ImportAreaParams{Title: "Home", CreatedAt: "2024-11-01 09:00:00", LastMod: "2024-11-01 09:00:00"})
+	if err != nil {
+		t.Fatalf("ImportArea() error = %v", err)
+	}
+	parentID, err := queries.ImportTask(ctx, sqlc.ImportTaskParams{
+		Title: "Renovate", CreatedAt: "2024-11-01 09:00:00", LastMod: "2024-11-01 09:00:00",
+		AreaID: sql.NullInt64{Int64: areaID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("ImportTask() error = %v", err)
+	}
+	taskID, err := queries.ImportTask(ctx, sqlc.ImportTaskParams{
+		Title: "Paint", CreatedAt: "2024-11-01 09:00:00", LastMod: "2024-11-01 09:00:00",
+		AreaID: sql.NullInt64{Int64: areaID, Valid: true}, ParentTaskID: sql.NullInt64{Int64: parentID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("ImportTask() error = %v", err)
+	}
+	notes := []struct {
+		title, path string
+		bridge      sqlc.ImportBridgeNoteParams
+	}{
+		{"Home", "/vault/home.md", sqlc.ImportBridgeNoteParams{ParentCat: sql.NullInt64{Int64: int64(AreaNoteType), Valid: true}, ParentAreaID: sql.NullInt64{Int64: areaID, Valid: true}}},
+		{"Renovation plan", "/vault/renovation.md", sqlc.ImportBridgeNoteParams{ParentCat: sql.NullInt64{Int64: int64(TaskNoteType), Valid: true}, ParentTaskID: sql.NullInt64{Int64: parentID, Valid: true}}},
+		{"Colors", "/vault/Colors.md", sqlc.ImportBridgeNoteParams{ParentCat: sql.NullInt64{Int64: int64(TaskNoteType), Valid: true}, ParentTaskID: sql.NullInt64{Int64: taskID, Valid: true}}},
+	}
+	for _, note := range notes {
+		noteID, err := queries.ImportNote(ctx, sqlc.ImportNoteParams{Title: note.title, Path: note.path})
+		if err != nil {
+			t.Fatalf("ImportNote() error = %v", err)
+		}
+		note.bridge.NoteID = noteID
+		if err := queries.ImportBridgeNote(ctx, note.bridge); err != nil {
+			t.Fatalf("ImportBridgeNote() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		noteType NoteType
+		parentID int64
+		want     []string
+	}{
+		{name: "subtask", noteType: TaskNoteType, parentID: taskID, want: []string{"[[Colors]]", "[[renovation|Renovation plan]]", "[[home|Home]]"}},
+		{name: "task", noteType: TaskNoteType, parentID: parentID, want: []string{"[[renovation|Renovation plan]]", "[[home|Home]]"}},
+		{name: "area", noteType: AreaNoteType, parentID: areaID, want: []string{"[[home|Home]]"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParentNoteLinks(ctx, queries, tt.noteType, tt.parentID)
+			if err != nil {
+				t.Fatalf("ParentNoteLinks() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParentNoteLinks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
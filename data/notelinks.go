@@ -0,0 +1,79 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+/*
+LinkNote links an existing note to more tasks and areas, a note can belong to any number of them.
+ 1. The note and every task and area have to exist, nothing is linked otherwise
+ 2. Links that already exist are skipped, the returned count only covers the new links
+ 3. The parent a note was created under stays first, its state is what the frontmatter of the note shows
+*/
+func LinkNote(ctx context.Context, conn *sql.DB, noteID int64, taskIDs, areaIDs []int64) (int, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	queries := sqlc.New(tx)
+
+	if _, err := queries.ReadNoteByID(ctx, noteID); err != nil {
+		return 0, fmt.Errorf("could not find note %d: %w", noteID, err)
+	}
+
+	var links []sqlc.CreateNoteLinkParams
+	for _, taskID := range taskIDs {
+		if _, err := queries.ReadTaskForRecurrence(ctx, taskID); err != nil {
+			return 0, fmt.Errorf("could not find task %d: %w", taskID, err)
+		}
+		links = append(links, sqlc.CreateNoteLinkParams{
+			NoteID:       noteID,
+			ParentCat:    sql.NullInt64{Int64: int64(TaskNoteType), Valid: true},
+			ParentTaskID: sql.NullInt64{Int64: taskID, Valid: true},
+		})
+	}
+	for _, areaID := range areaIDs {
+		if _, err := queries.ReadArea(ctx, areaID); err != nil {
+			return 0, fmt.Errorf("could not find area %d: %w", areaID, err)
+		}
+		links = append(links, sqlc.CreateNoteLinkParams{
+			NoteID:       noteID,
+			ParentCat:    sql.NullInt64{Int64: int64(AreaNoteType), Valid: true},
+			ParentAreaID: sql.NullInt64{Int64: areaID, Valid: true},
+		})
+	}
+
+	linked := 0
+	for _, link := range links {
+		added, err := queries.CreateNoteLink(ctx, link)
+		if err != nil {
+			return 0, fmt.Errorf("failed to link note %d: %w", noteID, err)
+		}
+		linked += int(added)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit the note links: %w", err)
+	}
+	return linked, nil
+}
+
+// UnsharedNoteIDs returns the notes that belong only to the given tasks and areas, they can go when those are deleted
+func UnsharedNoteIDs(ctx context.Context, queries *sqlc.Queries, taskIDs, areaIDs []int64) ([]int64, error) {
+	var params sqlc.ReadUnsharedNoteIDsParams
+	for _, id := range taskIDs {
+		params.TaskIds = append(params.TaskIds, sql.NullInt64{Int64: id, Valid: true})
+	}
+	for _, id := range areaIDs {
+		params.AreaIds = append(params.AreaIds, sql.NullInt64{Int64: id, Valid: true})
+	}
+	noteIDs, err := queries.ReadUnsharedNoteIDs(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the notes of tasks %v and areas %v: %w", taskIDs, areaIDs, err)
+	}
+	return noteIDs, nil
+}
@@ -0,0 +1,117 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+func TestLinkNote(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+
+	areaID, err := queries.ImportArea(ctx, sqlc.ImportAreaParams{Title: "Platform", CreatedAt: "2024-11-01 09:00:00", LastMod: "2024-11-01 09:00:00"})
+	if err != nil {
+		t.Fatalf("ImportArea() error = %v", err)
+	}
+	var taskIDs []int64
+	for _, title := range []string{"Auth", "Billing", "Search"} {
+		taskID, err := queries.ImportTask(ctx, sqlc.ImportTaskParams{Title: title, CreatedAt: "2024-11-01 09:00:00", LastMod: "2024-11-01 09:00:00"})
+		if err != nil {
+			t.Fatalf("ImportTask() error = %v", err)
+		}
+		taskIDs = append(taskIDs, taskID)
+	}
+	var noteIDs []int64
+	for i, title := range []string{"Design doc", "Billing notes"} {
+		noteID, err := queries.ImportNote(ctx, sqlc.ImportNoteParams{Title: title, Path: "/notes/" + title + ".md"})
+		if err != nil {
+			t.Fatalf("ImportNote() error = %v", err)
+		}
+		err = queries.ImportBridgeNote(ctx, sqlc.ImportBridgeNoteParams{
+			NoteID: noteID, ParentCat: sql.NullInt64{Int64: int64(TaskNoteType), Valid: true}, ParentTaskID: sql.NullInt64{Int64: taskIDs[i], Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("ImportBridgeNote() error = %v", err)
+		}
+		noteIDs = append(noteIDs, noteID)
+	}
+	designDoc := noteIDs[0]
+
+	tests := []struct {
+		name       string
+		noteID     int64
+		taskIDs    []int64
+		areaIDs    []int64
+		wantLinked int
+		wantErr    bool
+	}{
+		{name: "more tasks and an area, the first parent is skipped", noteID: designDoc, taskIDs: []int64{taskIDs[1], taskIDs[2], taskIDs[0]}, areaIDs: []int64{areaID}, wantLinked: 3},
+		{name: "linking again changes nothing", noteID: designDoc, taskIDs: []int64{taskIDs[1]}},
+		{name: "missing task links nothing", noteID: noteIDs[1], taskIDs: []int64{taskIDs[2], 99}, wantErr: true},
+		{name: "missing area", noteID: noteIDs[1], areaIDs: []int64{99}, wantErr: true},
+		{name: "missing note", noteID: 99, taskIDs: []int64{taskIDs[0]}, wantErr: true},
+	}
+	for _, tt := range tests {
+		linked, err := LinkNote(ctx, conn, tt.noteID, tt.taskIDs, tt.areaIDs)
+		if (err != nil) != tt.wantErr || linked != tt.wantLinked {
+			t.Errorf("%s: LinkNote() = %d, %v, want %d, wantErr %v", tt.name, linked, err, tt.wantLinked, tt.wantErr)
+		}
+	}
+
+	notes, err := queries.ReadAllNotes(ctx)
+	if err != nil {
+		t.Fatalf("ReadAllNotes() error = %v", err)
+	}
+	want := []sqlc.ReadAllNotesRow{
+		{ID: designDoc, Title: "Design doc", Path: "/notes/Design doc.md", AreaOrTaskTitle: "Auth, Billing, Search, Platform", ParentType: "Task, Task, Task, Area", Tags: ""},
+		{ID: noteIDs[1], Title: "Billing notes", Path: "/notes/Billing notes.md", AreaOrTaskTitle: "Billing", ParentType: "Task", Tags: ""},
+	}
+	if !reflect.DeepEqual(notes, want) {
+		t.Errorf("ReadAllNotes() =\n%+v\nwant\n%+v", notes, want)
+	}
+
+	tasks, err := queries.ReadTasks(ctx)
+	if err != nil {
+		t.Fatalf("ReadTasks() error = %v", err)
+	}
+	var titles []string
+	for _, task := range tasks {
+		titles = append(titles, task.NoteTitles.(string))
+	}
+	if want := []string{"Design doc", "Billing notes, Design doc", "Design doc"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("note titles of ReadTasks() = %q, want %q", titles, want)
+	}
+
+	// The frontmatter keeps showing the task the note was created under
+	states, err := queries.ReadNoteStates(ctx)
+	if err != nil || len(states) != 2 || states[0].TaskID.Int64 != taskIDs[0] {
+		t.Errorf("ReadNoteStates() = %+v, %v, want one row per note with the design doc under task %d", states, err, taskIDs[0])
+	}
+	opened, err := queries.ReadNoteByIDs(ctx, []int64{designDoc})
+	if err != nil || len(opened) != 1 {
+		t.Errorf("ReadNoteByIDs() = %+v, %v, want the design doc once", opened, err)
+	}
+
+	unshared := []struct {
+		name    string
+		taskIDs []int64
+		areaIDs []int64
+		want    []int64
+	}{
+		{name: "shared note is kept", taskIDs: []int64{taskIDs[0]}},
+		{name: "note only on the task", taskIDs: []int64{taskIDs[1]}, want: []int64{noteIDs[1]}},
+		{name: "every parent of the shared note", taskIDs: taskIDs, areaIDs: []int64{areaID}, want: noteIDs},
+		{name: "nothing", want: nil},
+	}
+	for _, tt := range unshared {
+		got, err := UnsharedNoteIDs(ctx, queries, tt.taskIDs, tt.areaIDs)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: UnsharedNoteIDs() = %v, %v, want %v", tt.name, got, err, tt.want)
+		}
+	}
+}
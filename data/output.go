@@ -0,0 +1,269 @@
+package data
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnknownOutputFormat is returned for an --output value that is not supported
+var ErrUnknownOutputFormat = errors.New("unknown output format")
+
+type OutputFormat string
+
+const (
+	OutputTable OutputFormat = "table"
+	OutputJSON  OutputFormat = "json"
+	OutputJSONL OutputFormat = "jsonl"
+	OutputCSV   OutputFormat = "csv"
+	OutputTSV   OutputFormat = "tsv"
+	OutputYAML  OutputFormat = "yaml"
+)
+
+// OutputFormats lists the supported output formats in the order they are documented
+var OutputFormats = []OutputFormat{OutputTable, OutputJSON, OutputJSONL, OutputCSV, OutputTSV, OutputYAML}
+
+// ParseOutputFormat checks an --output value, the comparison ignores case
+func ParseOutputFormat(input string) (OutputFormat, error) {
+	for _, format := range OutputFormats {
+		if strings.EqualFold(strings.TrimSpace(input), string(format)) {
+			return format, nil
+		}
+	}
+	var names []string
+	for _, format := range OutputFormats {
+		names = append(names, string(format))
+	}
+	return "", fmt.Errorf("%w: %q, valid formats are %s", ErrUnknownOutputFormat, input, strings.Join(names, ", "))
+}
+
+// Field is a single named value of a Record
+type Field struct {
+	Name  string
+	Value interface{}
+}
+
+// Record is a row prepared for machine-readable output, fields keep the order of the struct they came from
+type Record []Field
+
+/*
+ToRecords converts a slice of sqlc rows into records.
+ 1. Field names come from the json tags of the row struct, brackets sqlc copies from the query are dropped
+ 2. sql.Null* values become their plain value or nil, so JSON does not show {"String": ..., "Valid": ...}
+ 3. Aggregated columns that the driver returns as bytes become strings and times are formatted as RFC 3339
+*/
+func ToRecords(rows interface{}) []Record {
+	value := reflect.ValueOf(rows)
+	if value.Kind() != reflect.Slice {
+		return []Record{ToRecord(rows)}
+	}
+	records := make([]Record, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		records = append(records, ToRecord(value.Index(i).Interface()))
+	}
+	return records
+}
+
+// ToRecord converts a single sqlc row into a record, see ToRecords
+func ToRecord(row interface{}) Record {
+	value := reflect.Indirect(reflect.ValueOf(row))
+	rowType := value.Type()
+
+	var record Record
+	for i := 0; i < rowType.NumField(); i++ {
+		field := rowType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		name = strings.Trim(name, "[]")
+		if name == "" {
+			name = field.Name
+		}
+		record = append(record, Field{Name: name, Value: plainValue(value.Field(i).Interface())})
+	}
+	return record
+}
+
+func plainValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case sql.NullString:
+		if !v.Valid {
+			return nil
+		}
+		return v.String
+	case sql.NullInt64:
+		if !v.Valid {
+			return nil
+		}
+		return v.Int64
+	case sql.NullFloat64:
+		if !v.Valid {
+			return nil
+		}
+		return v.Float64
+	case sql.NullBool:
+		if !v.Valid {
+			return nil
+		}
+		return v.Bool
+	case sql.NullTime:
+		if !v.Valid {
+			return nil
+		}
+		return v.Time.Format(time.RFC3339)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case []byte:
+		return string(v)
+	}
+	return value
+}
+
+// MarshalJSON writes the record as an object with its fields in order
+func (r Record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", field.Name, err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// MarshalYAML writes the record as a mapping with its fields in order
+func (r Record) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, field := range r {
+		value := &yaml.Node{}
+		if err := value.Encode(field.Value); err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", field.Name, err)
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: field.Name}, value)
+	}
+	return node, nil
+}
+
+// WriteRows converts a slice of sqlc rows, or a single row, into records and writes them, see WriteRecords
+func WriteRows(w io.Writer, format OutputFormat, rows interface{}) error {
+	var header []string
+	rowType := reflect.TypeOf(rows)
+	if rowType.Kind() == reflect.Slice {
+		rowType = rowType.Elem()
+	}
+	for _, field := range ToRecord(reflect.New(rowType).Elem().Interface()) {
+		header = append(header, field.Name)
+	}
+	return WriteRecords(w, format, header, ToRecords(rows))
+}
+
+/*
+WriteRecords writes records in a machine-readable format.
+ 1. json is a single indented array, jsonl is one compact object per line
+ 2. csv and tsv start with the header row, even when there are no records, empty values are written as empty cells
+ 3. tsv replaces tabs and newlines inside values with spaces so every record stays on one line
+ 4. The table format is drawn by the caller, it is rejected here
+*/
+func WriteRecords(w io.Writer, format OutputFormat, header []string, records []Record) error {
+	if records == nil {
+		records = []Record{}
+	}
+
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(records)
+	case OutputJSONL:
+		encoder := json.NewEncoder(w)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	case OutputYAML:
+		if len(records) == 0 {
+			_, err := io.WriteString(w, "[]\n")
+			return err
+		}
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(records); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case OutputCSV:
+		writer := csv.NewWriter(w)
+		for _, line := range recordLines(header, records) {
+			if err := writer.Write(line); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	case OutputTSV:
+		replacer := strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+		for _, line := range recordLines(header, records) {
+			for i := range line {
+				line[i] = replacer.Replace(line[i])
+			}
+			if _, err := io.WriteString(w, strings.Join(line, "\t")+"\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %q can not be written as records", ErrUnknownOutputFormat, format)
+}
+
+// recordLines turns records into the header line followed by one line of cells per record
+func recordLines(header []string, records []Record) [][]string {
+	lines := [][]string{header}
+	for _, record := range records {
+		var line []string
+		for _, field := range record {
+			line = append(line, cellValue(field.Value))
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func cellValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", value)
+}
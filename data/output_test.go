@@ -0,0 +1,113 @@
+package data
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+type outputTestRow struct {
+	ID       int64          `json:"id"`
+	Title    string         `json:"title"`
+	Priority sql.NullString `json:"priority"`
+	Parent   string         `json:"[parent_type]"`
+	Tags     interface{}    `json:"tags"`
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    OutputFormat
+		wantErr error
+	}{
+		{input: "json", want: OutputJSON},
+		{input: " YAML ", want: OutputYAML},
+		{input: "table", want: OutputTable},
+		{input: "xml", wantErr: ErrUnknownOutputFormat},
+	}
+	for _, tt := range tests {
+		got, err := ParseOutputFormat(tt.input)
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("ParseOutputFormat(%q) error = %v, want %v", tt.input, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("ParseOutputFormat(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestWriteRows(t *testing.T) {
+	rows := []outputTestRow{
+		{ID: 1, Title: "Write report", Priority: sql.NullString{String: "high", Valid: true}, Parent: "task", Tags: []byte("work")},
+		{ID: 2, Title: "Tab\there, \"quoted\"", Parent: "area"},
+	}
+	tests := []struct {
+		format OutputFormat
+		rows   interface{}
+		want   string
+	}{
+		{
+			format: OutputJSONL,
+			rows:   rows,
+			want: `{"id":1,"title":"Write report","priority":"high","parent_type":"task","tags":"work"}
+{"id":2,"title":"Tab\there, \"quoted\"","priority":null,"parent_type":"area","tags":null}
+`,
+		},
+		{
+			format: OutputCSV,
+			rows:   rows,
+			want: `id,title,priority,parent_type,tags
+1,Write report,high,task,work
+2,"Tab	here, ""quoted""",,area,
+`,
+		},
+		{
+			format: OutputTSV,
+			rows:   rows,
+			want:   "id\ttitle\tpriority\tparent_type\ttags\n1\tWrite report\thigh\ttask\twork\n2\tTab here, \"quoted\"\t\tarea\t\n",
+		},
+		{
+			format: OutputYAML,
+			rows:   rows[:1],
+			want: `- id: 1
+  title: Write report
+  priority: high
+  parent_type: task
+  tags: work
+`,
+		},
+		{
+			format: OutputJSON,
+			rows:   rows[0],
+			want: `[
+  {
+    "id": 1,
+    "title": "Write report",
+    "priority": "high",
+    "parent_type": "task",
+    "tags": "work"
+  }
+]
+`,
+		},
+		{format: OutputJSON, rows: []outputTestRow(nil), want: "[]\n"},
+		{format: OutputYAML, rows: []outputTestRow{}, want: "[]\n"},
+		{format: OutputTSV, rows: []outputTestRow{}, want: "id\ttitle\tpriority\tparent_type\ttags\n"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteRows(&buf, tt.format, tt.rows); err != nil {
+				t.Fatalf("WriteRows() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("WriteRows() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+
+	if err := WriteRows(&bytes.Buffer{}, OutputTable, rows); !errors.Is(err, ErrUnknownOutputFormat) {
+		t.Errorf("WriteRows(table) error = %v, want %v", err, ErrUnknownOutputFormat)
+	}
+}
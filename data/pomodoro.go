@@ -0,0 +1,110 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+const (
+	PomodoroWork  PomodoroPhase = "work"
+	PomodoroBreak PomodoroPhase = "break"
+)
+
+// DefaultPomodoroWork and DefaultPomodoroBreak are the intervals used when the [pomodoro] section of the config leaves them out
+const (
+	DefaultPomodoroWork  = 25 * time.Minute
+	DefaultPomodoroBreak = 5 * time.Minute
+)
+
+// PomodoroPhase is the interval a pomodoro counts down
+type PomodoroPhase string
+
+// Pomodoro counts down alternating work and break intervals
+type Pomodoro struct {
+	Work  time.Duration
+	Break time.Duration
+	Phase PomodoroPhase
+	// StartedAt is when the current interval started, EndsAt is when it runs out unless it is paused
+	StartedAt time.Time
+	EndsAt    time.Time
+	Paused    bool
+	// Finished counts the work intervals that ran out
+	Finished int
+	// remaining is what was left of the interval when it was paused
+	remaining time.Duration
+}
+
+// NewPomodoro starts a pomodoro with a work interval at now
+func NewPomodoro(work, brk time.Duration, now time.Time) Pomodoro {
+	p := Pomodoro{Work: work, Break: brk}
+	p.begin(PomodoroWork, now)
+	return p
+}
+
+func (p *Pomodoro) begin(phase PomodoroPhase, now time.Time) {
+	p.Phase = phase
+	p.StartedAt = now
+	p.EndsAt = now.Add(p.interval())
+	p.Paused = false
+	p.remaining = 0
+}
+
+func (p Pomodoro) interval() time.Duration {
+	if p.Phase == PomodoroBreak {
+		return p.Break
+	}
+	return p.Work
+}
+
+// Remaining is the time left of the current interval at now
+func (p Pomodoro) Remaining(now time.Time) time.Duration {
+	if p.Paused {
+		return p.remaining
+	}
+	if now.After(p.EndsAt) {
+		return 0
+	}
+	return p.EndsAt.Sub(now)
+}
+
+// TogglePause pauses the countdown, or resumes it where it was paused
+func (p *Pomodoro) TogglePause(now time.Time) {
+	if p.Paused {
+		p.EndsAt = now.Add(p.remaining)
+		p.Paused = false
+		p.remaining = 0
+		return
+	}
+	p.remaining = p.Remaining(now)
+	p.Paused = true
+}
+
+// Tick moves on to the next interval once the current one ran out, finished is set when a work interval ran out
+func (p *Pomodoro) Tick(now time.Time) (finished bool) {
+	if p.Paused || now.Before(p.EndsAt) {
+		return false
+	}
+	if p.Phase == PomodoroWork {
+		p.Finished++
+		p.begin(PomodoroBreak, now)
+		return true
+	}
+	p.begin(PomodoroWork, now)
+	return false
+}
+
+// Skip moves on to the next interval right away, a skipped work interval does not count as finished
+func (p *Pomodoro) Skip(now time.Time) {
+	if p.Phase == PomodoroWork {
+		p.begin(PomodoroBreak, now)
+		return
+	}
+	p.begin(PomodoroWork, now)
+}
+
+// LogPomodoro logs a finished work interval of a pomodoro on a task, from when it started
+func LogPomodoro(ctx context.Context, queries *sqlc.Queries, taskID int64, startedAt time.Time, work time.Duration) (TimeEntry, error) {
+	return logTime(ctx, queries, taskID, startedAt, work)
+}
@@ -0,0 +1,72 @@
+package data
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+func TestPomodoro(t *testing.T) {
+	p := NewPomodoro(25*time.Minute, 5*time.Minute, testNow)
+
+	steps := []struct {
+		name          string
+		at            time.Duration
+		action        func(p *Pomodoro, now time.Time) bool
+		wantFinished  bool
+		wantPhase     PomodoroPhase
+		wantRemaining time.Duration
+		wantCount     int
+	}{
+		{name: "counting down", at: 10 * time.Minute, wantPhase: PomodoroWork, wantRemaining: 15 * time.Minute},
+		{name: "paused", at: 10 * time.Minute, action: pause, wantPhase: PomodoroWork, wantRemaining: 15 * time.Minute},
+		{name: "a paused interval does not run out", at: time.Hour, wantPhase: PomodoroWork, wantRemaining: 15 * time.Minute},
+		{name: "resumed", at: time.Hour, action: pause, wantPhase: PomodoroWork, wantRemaining: 15 * time.Minute},
+		{name: "work ran out", at: 75 * time.Minute, wantFinished: true, wantPhase: PomodoroBreak, wantRemaining: 5 * time.Minute, wantCount: 1},
+		{name: "break ran out", at: 80 * time.Minute, wantPhase: PomodoroWork, wantRemaining: 25 * time.Minute, wantCount: 1},
+		{name: "skipped work", at: 90 * time.Minute, action: skip, wantPhase: PomodoroBreak, wantRemaining: 5 * time.Minute, wantCount: 1},
+		{name: "skipped break", at: 91 * time.Minute, action: skip, wantPhase: PomodoroWork, wantRemaining: 25 * time.Minute, wantCount: 1},
+	}
+	for _, tt := range steps {
+		now := testNow.Add(tt.at)
+		action := tt.action
+		if action == nil {
+			action = (*Pomodoro).Tick
+		}
+		finished := action(&p, now)
+		if finished != tt.wantFinished || p.Phase != tt.wantPhase || p.Remaining(now) != tt.wantRemaining || p.Finished != tt.wantCount {
+			t.Errorf("%s: finished %v, phase %s, remaining %v, count %d, want %v, %s, %v, %d",
+				tt.name, finished, p.Phase, p.Remaining(now), p.Finished, tt.wantFinished, tt.wantPhase, tt.wantRemaining, tt.wantCount)
+		}
+	}
+
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+	taskID, err := queries.ImportTask(ctx, sqlc.ImportTaskParams{Title: "Write report", CreatedAt: "2024-11-01 09:00:00", LastMod: "2024-11-01 09:00:00"})
+	if err != nil {
+		t.Fatalf("ImportTask() error = %v", err)
+	}
+	entry, err := LogPomodoro(ctx, queries, taskID, testNow, 25*time.Minute)
+	if err != nil || entry.StartedAt != testNow || entry.Duration != 25*time.Minute {
+		t.Errorf("LogPomodoro() = %+v, %v, want 25m from %v", entry, err, testNow)
+	}
+	if task, err := queries.ReadTask(ctx, taskID); err != nil || task.TimeLogged != 25*60 {
+		t.Errorf("ReadTask() time logged = %d, %v, want 25m", task.TimeLogged, err)
+	}
+	if _, err := LogPomodoro(ctx, queries, 99, testNow, 25*time.Minute); err == nil {
+		t.Error("LogPomodoro() on a missing task did not fail")
+	}
+}
+
+func pause(p *Pomodoro, now time.Time) bool {
+	p.TogglePause(now)
+	return false
+}
+
+func skip(p *Pomodoro, now time.Time) bool {
+	p.Skip(now)
+	return false
+}
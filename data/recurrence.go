@@ -0,0 +1,332 @@
+package data
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RecurrenceHelp describes the recurrence rules understood by ParseRecurrence
+const RecurrenceHelp = "daily, weekly, monthly, yearly, weekdays, every 3 days, every 2 weeks or an RRULE such as FREQ=WEEKLY;BYDAY=MO,WE"
+
+type Frequency string
+
+const (
+	FrequencyDaily   Frequency = "DAILY"
+	FrequencyWeekly  Frequency = "WEEKLY"
+	FrequencyMonthly Frequency = "MONTHLY"
+	FrequencyYearly  Frequency = "YEARLY"
+)
+
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+var recurrenceUnits = map[string]Frequency{
+	"day": FrequencyDaily, "days": FrequencyDaily,
+	"week": FrequencyWeekly, "weeks": FrequencyWeekly,
+	"month": FrequencyMonthly, "months": FrequencyMonthly,
+	"year": FrequencyYearly, "years": FrequencyYearly,
+}
+
+// Recurrence is a recurrence rule for a task, it is a subset of the RRULE format from RFC 5545.
+// ByDay is only used by weekly rules and ByMonthDay only by monthly rules, -1 being the last day of the month.
+type Recurrence struct {
+	Frequency  Frequency
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay int
+}
+
+/*
+ParseRecurrence parses a recurrence rule for a task.
+ 1. "daily", "weekly", "monthly", "yearly" and "weekdays"
+ 2. "every N days", "every N weeks", "every N months" and "every N years"
+ 3. RRULEs using FREQ, INTERVAL, BYDAY (weekly only) and BYMONTHDAY (monthly only),
+    with or without the leading "RRULE:"
+*/
+func ParseRecurrence(input string) (Recurrence, error) {
+	normalized := strings.Join(strings.Fields(strings.ToLower(input)), " ")
+
+	switch normalized {
+	case "":
+		return Recurrence{}, fmt.Errorf("no recurrence given, expected one of: %s", RecurrenceHelp)
+	case "daily", "every day":
+		return Recurrence{Frequency: FrequencyDaily, Interval: 1}, nil
+	case "weekly", "every week":
+		return Recurrence{Frequency: FrequencyWeekly, Interval: 1}, nil
+	case "monthly", "every month":
+		return Recurrence{Frequency: FrequencyMonthly, Interval: 1}, nil
+	case "yearly", "annually", "every year":
+		return Recurrence{Frequency: FrequencyYearly, Interval: 1}, nil
+	case "weekdays", "every weekday":
+		return Recurrence{
+			Frequency: FrequencyWeekly,
+			Interval:  1,
+			ByDay:     []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		}, nil
+	}
+
+	if rest, ok := strings.CutPrefix(normalized, "every "); ok {
+		fields := strings.Fields(rest)
+		if len(fields) == 2 {
+			interval, err := strconv.Atoi(fields[0])
+			frequency, ok := recurrenceUnits[fields[1]]
+			if err == nil && ok && interval > 0 {
+				return Recurrence{Frequency: frequency, Interval: interval}, nil
+			}
+		}
+		return Recurrence{}, fmt.Errorf("invalid recurrence ( %s ) must look like 'every 3 days' or 'every 2 weeks'", input)
+	}
+
+	if strings.Contains(normalized, "freq=") {
+		return parseRRule(strings.ToUpper(normalized))
+	}
+
+	return Recurrence{}, fmt.Errorf("invalid recurrence ( %s ) expected one of: %s", input, RecurrenceHelp)
+}
+
+func parseRRule(rule string) (Recurrence, error) {
+	rule = strings.TrimPrefix(strings.ReplaceAll(rule, " ", ""), "RRULE:")
+	recurrence := Recurrence{Interval: 1}
+
+	for _, part := range strings.Split(strings.Trim(rule, ";"), ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return Recurrence{}, fmt.Errorf("invalid RRULE part ( %s ) must look like KEY=VALUE", part)
+		}
+		switch key {
+		case "FREQ":
+			switch Frequency(value) {
+			case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+				recurrence.Frequency = Frequency(value)
+			default:
+				return Recurrence{}, fmt.Errorf("unsupported RRULE frequency ( %s ) must be DAILY, WEEKLY, MONTHLY, or YEARLY", value)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return Recurrence{}, fmt.Errorf("invalid RRULE interval ( %s ) must be a positive number", value)
+			}
+			recurrence.Interval = interval
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := rruleWeekdays[day]
+				if !ok {
+					return Recurrence{}, fmt.Errorf("unsupported RRULE day ( %s ) must be one of MO, TU, WE, TH, FR, SA, SU", day)
+				}
+				recurrence.ByDay = append(recurrence.ByDay, weekday)
+			}
+		case "BYMONTHDAY":
+			day, err := strconv.Atoi(value)
+			if err != nil || day == 0 || day < -1 || day > 31 {
+				return Recurrence{}, fmt.Errorf("invalid RRULE month day ( %s ) must be between 1 and 31, or -1 for the last day", value)
+			}
+			recurrence.ByMonthDay = day
+		default:
+			return Recurrence{}, fmt.Errorf("unsupported RRULE part ( %s ) only FREQ, INTERVAL, BYDAY, and BYMONTHDAY are supported", key)
+		}
+	}
+
+	if recurrence.Frequency == "" {
+		return Recurrence{}, fmt.Errorf("invalid RRULE ( %s ) FREQ is required", rule)
+	}
+	if len(recurrence.ByDay) > 0 && recurrence.Frequency != FrequencyWeekly {
+		return Recurrence{}, fmt.Errorf("unsupported RRULE ( %s ) BYDAY can only be used with FREQ=WEEKLY", rule)
+	}
+	if recurrence.ByMonthDay != 0 && recurrence.Frequency != FrequencyMonthly {
+		return Recurrence{}, fmt.Errorf("unsupported RRULE ( %s ) BYMONTHDAY can only be used with FREQ=MONTHLY", rule)
+	}
+	recurrence.ByDay = sortedWeekdays(recurrence.ByDay)
+	return recurrence, nil
+}
+
+// String returns the rule in the RRULE form it is stored with in tasks.recurrence
+func (r Recurrence) String() string {
+	parts := []string{"FREQ=" + string(r.Frequency)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		var days []string
+		for _, weekday := range r.ByDay {
+			days = append(days, strings.ToUpper(weekday.String()[:2]))
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.ByMonthDay != 0 {
+		parts = append(parts, "BYMONTHDAY="+strconv.Itoa(r.ByMonthDay))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Describe renders the rule for people, e.g. "every 2 weeks on Mon, Wed"
+func (r Recurrence) Describe() string {
+	units := map[Frequency]string{
+		FrequencyDaily:   "day",
+		FrequencyWeekly:  "week",
+		FrequencyMonthly: "month",
+		FrequencyYearly:  "year",
+	}
+	description := "every " + units[r.Frequency]
+	if r.Interval > 1 {
+		description = fmt.Sprintf("every %d %ss", r.Interval, units[r.Frequency])
+	}
+
+	if len(r.ByDay) > 0 {
+		var days []string
+		for _, weekday := range r.ByDay {
+			days = append(days, weekday.String()[:3])
+		}
+		description += " on " + strings.Join(days, ", ")
+	}
+	switch {
+	case r.ByMonthDay == -1:
+		description += " on the last day"
+	case r.ByMonthDay > 0:
+		description += fmt.Sprintf(" on day %d", r.ByMonthDay)
+	}
+	return description
+}
+
+// Next returns the first occurrence of the rule after the given date
+func (r Recurrence) Next(after time.Time) time.Time {
+	after = startOfDay(after)
+	interval := max(r.Interval, 1)
+
+	switch r.Frequency {
+	case FrequencyDaily:
+		return after.AddDate(0, 0, interval)
+	case FrequencyWeekly:
+		if len(r.ByDay) == 0 {
+			return after.AddDate(0, 0, 7*interval)
+		}
+		// Later days in the same week come first, then the first day of the next week in the interval
+		for day := after.AddDate(0, 0, 1); day.Weekday() != time.Monday; day = day.AddDate(0, 0, 1) {
+			if r.hasDay(day.Weekday()) {
+				return day
+			}
+		}
+		weekStart := endOfWeek(after).AddDate(0, 0, 1+7*(interval-1))
+		for day := weekStart; ; day = day.AddDate(0, 0, 1) {
+			if r.hasDay(day.Weekday()) {
+				return day
+			}
+		}
+	case FrequencyMonthly:
+		if r.ByMonthDay == 0 {
+			return addMonthsClamped(after, interval)
+		}
+		if candidate := monthDay(after, r.ByMonthDay); candidate.After(after) {
+			return candidate
+		}
+		return monthDay(addMonthsClamped(time.Date(after.Year(), after.Month(), 1, 0, 0, 0, 0, after.Location()), interval), r.ByMonthDay)
+	case FrequencyYearly:
+		return addMonthsClamped(after, 12*interval)
+	}
+	return after.AddDate(0, 0, interval)
+}
+
+// NextDueDate works out the due date of the next instance of a recurring task.
+// The rule is applied to the current due date, or to today when the task had none,
+// and keeps moving forward until the new due date is no longer in the past.
+// A monthly rule without a day keeps to the day of the month it started from, see anchor.
+func (r Recurrence) NextDueDate(due sql.NullString, now time.Time) time.Time {
+	today := startOfDay(now)
+	base := recurrenceStart(due, today)
+	rule := r.anchor(base)
+
+	next := rule.Next(base)
+	for next.Before(today) {
+		next = rule.Next(next)
+	}
+	return next
+}
+
+// anchor pins a monthly rule without BYMONTHDAY to the day of the month of start. Every occurrence is then
+// that day clamped to the length of its month, so Jan 31 is followed by Feb 28 and Mar 31 instead of
+// the clamped Feb 28 moving every later occurrence to the 28th. Other rules are returned as they are.
+func (r Recurrence) anchor(start time.Time) Recurrence {
+	if r.Frequency == FrequencyMonthly && r.ByMonthDay == 0 {
+		r.ByMonthDay = start.Day()
+	}
+	return r
+}
+
+// recurrenceStart is the date a rule is applied to, the due date or today when there is none
+func recurrenceStart(due sql.NullString, today time.Time) time.Time {
+	if due.Valid {
+		if dueDate, err := ParseDueDate(due.String); err == nil {
+			return dueDate
+		}
+	}
+	return today
+}
+
+func (r Recurrence) hasDay(weekday time.Weekday) bool {
+	for _, day := range r.ByDay {
+		if day == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// monthDay returns the given day of t's month, clamped to the length of the month
+func monthDay(t time.Time, day int) time.Time {
+	lastDay := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+	if day == -1 || day > lastDay {
+		day = lastDay
+	}
+	return time.Date(t.Year(), t.Month(), day, 0, 0, 0, 0, t.Location())
+}
+
+// sortedWeekdays sorts and deduplicates weekdays so Monday comes first
+func sortedWeekdays(days []time.Weekday) []time.Weekday {
+	seen := make(map[time.Weekday]bool)
+	var unique []time.Weekday
+	for _, day := range days {
+		if !seen[day] {
+			seen[day] = true
+			unique = append(unique, day)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		return (unique[i]+6)%7 < (unique[j]+6)%7
+	})
+	return unique
+}
+
+// ToNullRecurrence converts user input into the value stored in tasks.recurrence.
+// Blank input means the task does not recur.
+func ToNullRecurrence(input string) (sql.NullString, error) {
+	if strings.TrimSpace(input) == "" {
+		return sql.NullString{}, nil
+	}
+	recurrence, err := ParseRecurrence(input)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: recurrence.String(), Valid: true}, nil
+}
+
+// DescribeRecurrence renders a stored recurrence rule for people, "none" when it is unset
+func DescribeRecurrence(recurrence sql.NullString) string {
+	if !recurrence.Valid {
+		return "none"
+	}
+	rule, err := ParseRecurrence(recurrence.String)
+	if err != nil {
+		return recurrence.String
+	}
+	return rule.Describe()
+}
@@ -0,0 +1,165 @@
+package data
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "daily", input: "daily", want: "FREQ=DAILY"},
+		{name: "weekly", input: "Weekly", want: "FREQ=WEEKLY"},
+		{name: "monthly", input: "monthly", want: "FREQ=MONTHLY"},
+		{name: "yearly", input: "yearly", want: "FREQ=YEARLY"},
+		{name: "weekdays", input: "weekdays", want: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"},
+		{name: "every n days", input: "every 3 days", want: "FREQ=DAILY;INTERVAL=3"},
+		{name: "every n weeks", input: "every  2 weeks", want: "FREQ=WEEKLY;INTERVAL=2"},
+		{name: "every 1 month", input: "every 1 month", want: "FREQ=MONTHLY"},
+		{name: "rrule", input: "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=WE,MO", want: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE"},
+		{name: "rrule without prefix", input: "freq=monthly;bymonthday=-1", want: "FREQ=MONTHLY;BYMONTHDAY=-1"},
+		{name: "rrule sunday sorts last", input: "FREQ=WEEKLY;BYDAY=SU,MO,MO", want: "FREQ=WEEKLY;BYDAY=MO,SU"},
+		{name: "empty", input: "", wantErr: true},
+		{name: "every zero days", input: "every 0 days", wantErr: true},
+		{name: "every unknown unit", input: "every 2 fortnights", wantErr: true},
+		{name: "rrule missing freq", input: "INTERVAL=2;FREQ=", wantErr: true},
+		{name: "rrule unsupported freq", input: "FREQ=HOURLY", wantErr: true},
+		{name: "rrule unsupported part", input: "FREQ=DAILY;COUNT=3", wantErr: true},
+		{name: "rrule byday on monthly", input: "FREQ=MONTHLY;BYDAY=MO", wantErr: true},
+		{name: "rrule bad month day", input: "FREQ=MONTHLY;BYMONTHDAY=32", wantErr: true},
+		{name: "garbage", input: "sometimes", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRecurrence(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRecurrence(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.String() != tt.want {
+				t.Errorf("ParseRecurrence(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+			// The stored form has to parse back into the same rule
+			again, err := ParseRecurrence(got.String())
+			if err != nil || again.String() != got.String() {
+				t.Errorf("ParseRecurrence(%q) does not round trip: %v, %v", got.String(), again, err)
+			}
+		})
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	date := func(value string) time.Time {
+		parsed, err := time.Parse(DueDateLayout, value)
+		if err != nil {
+			t.Fatalf("bad test date %s: %v", value, err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name  string
+		rule  string
+		after string
+		want  string
+	}{
+		{name: "daily", rule: "daily", after: "2024-11-05", want: "2024-11-06"},
+		{name: "every 3 days", rule: "every 3 days", after: "2024-11-30", want: "2024-12-03"},
+		{name: "weekly", rule: "weekly", after: "2024-11-05", want: "2024-11-12"},
+		{name: "weekly by day later this week", rule: "FREQ=WEEKLY;BYDAY=MO,FR", after: "2024-11-05", want: "2024-11-08"},
+		{name: "weekly by day next week", rule: "FREQ=WEEKLY;BYDAY=MO,TU", after: "2024-11-05", want: "2024-11-11"},
+		{name: "biweekly by day skips a week", rule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", after: "2024-11-04", want: "2024-11-18"},
+		{name: "weekdays over the weekend", rule: "weekdays", after: "2024-11-08", want: "2024-11-11"},
+		{name: "weekly by day from a sunday", rule: "FREQ=WEEKLY;BYDAY=SU", after: "2024-11-10", want: "2024-11-17"},
+		{name: "monthly", rule: "monthly", after: "2024-11-05", want: "2024-12-05"},
+		{name: "monthly clamps", rule: "monthly", after: "2025-01-31", want: "2025-02-28"},
+		{name: "monthly by day later this month", rule: "FREQ=MONTHLY;BYMONTHDAY=15", after: "2024-11-05", want: "2024-11-15"},
+		{name: "monthly by day next month", rule: "FREQ=MONTHLY;BYMONTHDAY=15", after: "2024-11-15", want: "2024-12-15"},
+		{name: "monthly last day", rule: "FREQ=MONTHLY;BYMONTHDAY=-1", after: "2024-01-31", want: "2024-02-29"},
+		{name: "quarterly", rule: "every 3 months", after: "2024-11-05", want: "2025-02-05"},
+		{name: "yearly from a leap day", rule: "yearly", after: "2024-02-29", want: "2025-02-28"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseRecurrence(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrence(%q) unexpected error: %v", tt.rule, err)
+			}
+			got := rule.Next(date(tt.after)).Format(DueDateLayout)
+			if got != tt.want {
+				t.Errorf("%s.Next(%s) = %s, want %s", rule, tt.after, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecurrenceNextDueDateMonthEnd(t *testing.T) {
+	monthly := Recurrence{Frequency: FrequencyMonthly, Interval: 1}
+	due := sql.NullString{String: "2025-01-31", Valid: true}
+
+	// Catching up keeps to the 31st instead of staying on the 28th after February
+	tests := []struct {
+		now  time.Time
+		want string
+	}{
+		{now: time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC), want: "2025-02-28"},
+		{now: time.Date(2025, 3, 29, 9, 0, 0, 0, time.UTC), want: "2025-03-31"},
+		{now: time.Date(2025, 4, 10, 9, 0, 0, 0, time.UTC), want: "2025-04-30"},
+	}
+	for _, tt := range tests {
+		if got := monthly.NextDueDate(due, tt.now).Format(DueDateLayout); got != tt.want {
+			t.Errorf("NextDueDate(%s) at %s = %s, want %s", due.String, tt.now.Format(DueDateLayout), got, tt.want)
+		}
+	}
+}
+
+func TestRecurrenceNextDueDate(t *testing.T) {
+	now := time.Date(2024, 11, 5, 15, 30, 0, 0, time.UTC)
+	weekly := Recurrence{Frequency: FrequencyWeekly, Interval: 1}
+
+	tests := []struct {
+		name string
+		due  sql.NullString
+		want string
+	}{
+		{name: "no due date starts from today", due: sql.NullString{}, want: "2024-11-12"},
+		{name: "due today", due: sql.NullString{String: "2024-11-05", Valid: true}, want: "2024-11-12"},
+		{name: "due in the future", due: sql.NullString{String: "2024-11-08", Valid: true}, want: "2024-11-15"},
+		{name: "long overdue catches up", due: sql.NullString{String: "2024-10-01", Valid: true}, want: "2024-11-05"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := weekly.NextDueDate(tt.due, now).Format(DueDateLayout)
+			if got != tt.want {
+				t.Errorf("NextDueDate(%v) = %s, want %s", tt.due, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeRecurrence(t *testing.T) {
+	tests := []struct {
+		stored string
+		want   string
+	}{
+		{stored: "FREQ=DAILY", want: "every day"},
+		{stored: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE", want: "every 2 weeks on Mon, Wed"},
+		{stored: "FREQ=MONTHLY;BYMONTHDAY=-1", want: "every month on the last day"},
+	}
+	for _, tt := range tests {
+		got := DescribeRecurrence(sql.NullString{String: tt.stored, Valid: true})
+		if got != tt.want {
+			t.Errorf("DescribeRecurrence(%s) = %s, want %s", tt.stored, got, tt.want)
+		}
+	}
+	if got := DescribeRecurrence(sql.NullString{}); got != "none" {
+		t.Errorf("DescribeRecurrence(null) = %s, want none", got)
+	}
+}
@@ -0,0 +1,447 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// SearchEntity is the kind of thing a search hit points at
+type SearchEntity string
+
+const (
+	SearchEntityTask SearchEntity = "task"
+	SearchEntityArea SearchEntity = "area"
+	SearchEntityNote SearchEntity = "note"
+	// SearchEntityFile is a markdown file in the notes path that is not linked to a task or area
+	SearchEntityFile SearchEntity = "file"
+)
+
+// SearchResult is a single ranked hit from Search
+type SearchResult struct {
+	Entity  SearchEntity
+	ID      int64
+	Title   string
+	Path    string
+	Snippet string
+	Rank    float64
+}
+
+// ReindexStats counts what was written to the search index by Reindex
+type ReindexStats struct {
+	Tasks        int
+	Areas        int
+	Notes        int
+	Files        int
+	MissingNotes []string
+}
+
+// searchTableFTS5 is the TEMP copy of search_index that Search ranks with when SQLite has FTS5.
+// The index itself is a plain table created by a migration, FTS5 is an optional SQLite module that
+// go-sqlite3 only compiles in with -tags sqlite_fts5, and a schema that needs it could not be
+// opened or written by a go_task built without it. sqlc can not parse FTS5 virtual tables, which
+// is why these statements are written out here.
+const searchTableFTS5 = `CREATE VIRTUAL TABLE temp.search_fts USING fts5(
+	entity_type UNINDEXED,
+	entity_id UNINDEXED,
+	path UNINDEXED,
+	title,
+	body,
+	tokenize = 'porter unicode61'
+)`
+
+const fillSearchTableFTS5 = `INSERT INTO temp.search_fts (entity_type, entity_id, path, title, body)
+SELECT entity_type, entity_id, path, title, body FROM search_index`
+
+const dropSearchTableFTS5 = `DROP TABLE IF EXISTS temp.search_fts`
+
+// searchQuery ranks titles ten times higher than note bodies, bm25 scores are negative so lower is better
+const searchQuery = `
+SELECT entity_type, entity_id, title, path,
+	snippet(search_fts, -1, '[', ']', '...', 12),
+	bm25(search_fts, 0.0, 0.0, 0.0, 10.0, 1.0) AS rank
+FROM temp.search_fts
+WHERE search_fts MATCH ?
+ORDER BY rank
+LIMIT ?`
+
+// SearchIndexBuilt reports whether the search index has been filled by a first search or a reindex
+func SearchIndexBuilt(ctx context.Context, conn *sql.DB) (bool, error) {
+	var built bool
+	err := conn.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM search_index)`).Scan(&built)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up the search index: %w", err)
+	}
+	return built, nil
+}
+
+/*
+EnsureSearchIndex fills the search index the first time it is needed.
+ 1. The index is empty until then, the note files have to be read to fill it
+ 2. Triggers keep task, area and note titles in the index up to date from then on
+ 3. The returned stats are nil when the index was already built
+*/
+func EnsureSearchIndex(ctx context.Context, conn *sql.DB, notesPath string) (*ReindexStats, error) {
+	built, err := SearchIndexBuilt(ctx, conn)
+	if err != nil || built {
+		return nil, err
+	}
+	stats, err := Reindex(ctx, conn, notesPath)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+/*
+Reindex rebuilds the search index from scratch.
+ 1. Task, area and note titles are read from the database
+ 2. Note bodies are read from the markdown files the notes point at,
+    notes whose file is gone are indexed by title and reported in MissingNotes
+ 3. Markdown files under notesPath that are not linked to a note are indexed as files
+*/
+func Reindex(ctx context.Context, conn *sql.DB, notesPath string) (ReindexStats, error) {
+	var stats ReindexStats
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return stats, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM search_index`); err != nil {
+		return stats, fmt.Errorf("failed to clear the search index: %w", err)
+	}
+
+	insert := func(entity SearchEntity, id int64, path, title, body string) error {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO search_index (entity_type, entity_id, path, title, body) VALUES (?, ?, ?, ?, ?)`,
+			string(entity), id, path, title, body)
+		if err != nil {
+			return fmt.Errorf("failed to index %s %d: %w", entity, id, err)
+		}
+		return nil
+	}
+
+	titles := []struct {
+		entity SearchEntity
+		query  string
+		count  *int
+	}{
+		{entity: SearchEntityTask, query: `SELECT id, title FROM tasks`, count: &stats.Tasks},
+		{entity: SearchEntityArea, query: `SELECT id, title FROM areas`, count: &stats.Areas},
+	}
+	for _, source := range titles {
+		rows, err := readIDTitles(ctx, tx, source.query)
+		if err != nil {
+			return stats, err
+		}
+		for _, row := range rows {
+			if err := insert(source.entity, row.id, "", row.title, ""); err != nil {
+				return stats, err
+			}
+			*source.count++
+		}
+	}
+
+	notes, err := readNotePaths(ctx, tx)
+	if err != nil {
+		return stats, err
+	}
+	linked := make(map[string]bool)
+	for _, note := range notes {
+		linked[filepath.Clean(note.path)] = true
+		body, err := os.ReadFile(note.path)
+		if err != nil {
+			stats.MissingNotes = append(stats.MissingNotes, note.path)
+		}
+		if err := insert(SearchEntityNote, note.id, note.path, note.title, string(body)); err != nil {
+			return stats, err
+		}
+		stats.Notes++
+	}
+
+	if notesPath != "" {
+		err = filepath.WalkDir(notesPath, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				// Hidden folders such as .obsidian and .git are not notes
+				if path != notesPath && strings.HasPrefix(entry.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !strings.EqualFold(filepath.Ext(path), ".md") || linked[filepath.Clean(path)] {
+				return nil
+			}
+			body, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			title := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+			if err := insert(SearchEntityFile, 0, path, title, string(body)); err != nil {
+				return err
+			}
+			stats.Files++
+			return nil
+		})
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return stats, fmt.Errorf("failed to index the notes in %s: %w", notesPath, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return stats, fmt.Errorf("failed to commit the search index: %w", err)
+	}
+	return stats, nil
+}
+
+// RefreshNoteIndex re-reads the body of a note go_task just wrote, it does nothing until the search index is built
+func RefreshNoteIndex(ctx context.Context, conn *sql.DB, noteID int64, path string) error {
+	built, err := SearchIndexBuilt(ctx, conn)
+	if err != nil || !built {
+		return err
+	}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read note %s: %w", path, err)
+	}
+	_, err = conn.ExecContext(ctx,
+		`UPDATE search_index SET body = ? WHERE entity_type = 'note' AND entity_id = ?`,
+		string(body), noteID)
+	if err != nil {
+		return fmt.Errorf("failed to index note %d: %w", noteID, err)
+	}
+	return nil
+}
+
+// refreshNoteIndexQuietly refreshes the index for a note written as part of a bigger change.
+// A stale search index is fixed by 'db reindex', so a failure is only logged instead of failing that change.
+func refreshNoteIndexQuietly(ctx context.Context, conn *sql.DB, noteID int64, path string) {
+	if err := RefreshNoteIndex(ctx, conn, noteID, path); err != nil {
+		log.Warnf("The search index was not updated for %s, run 'go_task db reindex': %v", path, err)
+	}
+}
+
+/*
+Search runs a full-text search over the index and returns the best hits first.
+ 1. When SQLite has FTS5 the index is copied into a TEMP FTS5 table on one connection and ranked with bm25
+ 2. Without FTS5 the index is matched with LIKE by searchLike
+*/
+func Search(ctx context.Context, conn *sql.DB, input string, limit int) ([]SearchResult, error) {
+	return search(ctx, conn, input, limit, true)
+}
+
+func search(ctx context.Context, conn *sql.DB, input string, limit int, tryFTS5 bool) ([]SearchResult, error) {
+	match := ToMatchQuery(input)
+	if match == "" {
+		return nil, fmt.Errorf("search query is empty")
+	}
+	if !tryFTS5 {
+		return searchLike(ctx, conn, input, limit)
+	}
+
+	// TEMP tables only exist on the connection that created them
+	c, err := conn.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a database connection: %w", err)
+	}
+	defer c.Close()
+	if _, err := c.ExecContext(ctx, dropSearchTableFTS5); err != nil {
+		return nil, fmt.Errorf("failed to prepare the search: %w", err)
+	}
+	if _, err := c.ExecContext(ctx, searchTableFTS5); err != nil {
+		if !strings.Contains(err.Error(), "no such module: fts5") {
+			return nil, fmt.Errorf("failed to prepare the search: %w", err)
+		}
+		return searchLike(ctx, conn, input, limit)
+	}
+	defer c.ExecContext(ctx, dropSearchTableFTS5)
+	if _, err := c.ExecContext(ctx, fillSearchTableFTS5); err != nil {
+		return nil, fmt.Errorf("failed to prepare the search: %w", err)
+	}
+
+	rows, err := c.QueryContext(ctx, searchQuery, match, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for %q: %w", input, err)
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var result SearchResult
+		var entity string
+		if err := rows.Scan(&entity, &result.ID, &result.Title, &result.Path, &result.Snippet, &result.Rank); err != nil {
+			return nil, fmt.Errorf("failed to read search result: %w", err)
+		}
+		result.Entity = SearchEntity(entity)
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read search results: %w", err)
+	}
+	return results, nil
+}
+
+/*
+searchLike is the search used when SQLite has no FTS5.
+ 1. Every word has to appear somewhere in the title or the body, in any order
+ 2. The rank mirrors the FTS5 one, a word found in the title counts ten times as much as one in the body
+ 3. The snippet is cut from the body around the first match, or is the title when only the title matched
+*/
+func searchLike(ctx context.Context, conn *sql.DB, input string, limit int) ([]SearchResult, error) {
+	words := strings.Fields(input)
+	var where, titleHits, bodyHits []string
+	var whereArgs, titleArgs, bodyArgs []any
+	for _, word := range words {
+		pattern := "%" + escapeLike(word) + "%"
+		where = append(where, `(title LIKE ? ESCAPE '\' OR body LIKE ? ESCAPE '\')`)
+		whereArgs = append(whereArgs, pattern, pattern)
+		titleHits = append(titleHits, `(title LIKE ? ESCAPE '\')`)
+		titleArgs = append(titleArgs, pattern)
+		bodyHits = append(bodyHits, `(body LIKE ? ESCAPE '\')`)
+		bodyArgs = append(bodyArgs, pattern)
+	}
+	query := fmt.Sprintf(`
+SELECT entity_type, entity_id, title, path, body,
+	-(10.0 * (%s) + (%s)) AS rank
+FROM search_index
+WHERE %s
+ORDER BY rank, title
+LIMIT ?`, strings.Join(titleHits, " + "), strings.Join(bodyHits, " + "), strings.Join(where, " AND "))
+	args := append(append(append(titleArgs, bodyArgs...), whereArgs...), limit)
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for %q: %w", input, err)
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var result SearchResult
+		var entity, body string
+		if err := rows.Scan(&entity, &result.ID, &result.Title, &result.Path, &body, &result.Rank); err != nil {
+			return nil, fmt.Errorf("failed to read search result: %w", err)
+		}
+		result.Entity = SearchEntity(entity)
+		result.Snippet = likeSnippet(body, words)
+		if result.Snippet == "" {
+			result.Snippet = likeSnippet(result.Title, words)
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read search results: %w", err)
+	}
+	return results, nil
+}
+
+// escapeLike escapes the LIKE wildcards in a word so '_' and '%' are matched as they are typed
+func escapeLike(word string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(word)
+}
+
+// likeSnippet marks the words of text that contain a search word the way the FTS5 snippet does,
+// keeping twelve words from just before the first match, it is empty when nothing matches
+func likeSnippet(text string, words []string) string {
+	const snippetWords = 12
+	fields := strings.Fields(text)
+	first := -1
+	marked := make([]string, len(fields))
+	for i, field := range fields {
+		marked[i] = field
+		lower := strings.ToLower(field)
+		for _, word := range words {
+			if strings.Contains(lower, strings.ToLower(word)) {
+				marked[i] = "[" + field + "]"
+				if first < 0 {
+					first = i
+				}
+				break
+			}
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+	start := max(0, first-2)
+	end := min(len(marked), start+snippetWords)
+	snippet := strings.Join(marked[start:end], " ")
+	if start > 0 {
+		snippet = "..." + snippet
+	}
+	if end < len(marked) {
+		snippet += "..."
+	}
+	return snippet
+}
+
+/*
+ToMatchQuery turns what the user typed into an FTS5 MATCH expression.
+ 1. Every word is quoted so punctuation such as '-' or ':' is not read as FTS5 syntax
+ 2. Every word is matched as a prefix, "rep" finds "report"
+ 3. All words have to match, in any order
+*/
+func ToMatchQuery(input string) string {
+	var terms []string
+	for _, word := range strings.Fields(input) {
+		word = strings.ReplaceAll(word, `"`, `""`)
+		terms = append(terms, `"`+word+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+type idTitle struct {
+	id    int64
+	title string
+}
+
+func readIDTitles(ctx context.Context, tx *sql.Tx, query string) ([]idTitle, error) {
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read titles to index: %w", err)
+	}
+	defer rows.Close()
+	var items []idTitle
+	for rows.Next() {
+		var item idTitle
+		if err := rows.Scan(&item.id, &item.title); err != nil {
+			return nil, fmt.Errorf("failed to read title to index: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+type notePath struct {
+	id    int64
+	title string
+	path  string
+}
+
+func readNotePaths(ctx context.Context, tx *sql.Tx) ([]notePath, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id, title, path FROM notes`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notes to index: %w", err)
+	}
+	defer rows.Close()
+	var items []notePath
+	for rows.Next() {
+		var item notePath
+		if err := rows.Scan(&item.id, &item.title, &item.path); err != nil {
+			return nil, fmt.Errorf("failed to read note to index: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
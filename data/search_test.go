@@ -0,0 +1,165 @@
+package data
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+func TestToMatchQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "report", want: `"report"*`},
+		{input: "  weekly   report ", want: `"weekly"* "report"*`},
+		{input: "go-task AND", want: `"go-task"* "AND"*`},
+		{input: `say "hi"`, want: `"say"* """hi"""*`},
+		{input: "   ", want: ""},
+	}
+	for _, tt := range tests {
+		if got := ToMatchQuery(tt.input); got != tt.want {
+			t.Errorf("ToMatchQuery(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLikeSnippet(t *testing.T) {
+	tests := []struct {
+		text  string
+		words []string
+		want  string
+	}{
+		{text: "Meet the accountant about quarterly taxes", words: []string{"tax"}, want: "...about quarterly [taxes]"},
+		{text: "Taxes are due in April", words: []string{"april", "tax"}, want: "[Taxes] are due in [April]"},
+		{text: "one two three four five six seven eight nine ten eleven twelve thirteen", words: []string{"one"}, want: "[one] two three four five six seven eight nine ten eleven twelve..."},
+		{text: "nothing here", words: []string{"tax"}, want: ""},
+	}
+	for _, tt := range tests {
+		if got := likeSnippet(tt.text, tt.words); got != tt.want {
+			t.Errorf("likeSnippet(%q, %q) = %q, want %q", tt.text, tt.words, got, tt.want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	// Without -tags sqlite_fts5 both runs use the LIKE fallback
+	for _, tt := range []struct {
+		name    string
+		tryFTS5 bool
+	}{
+		{name: "fts5", tryFTS5: true},
+		{name: "like", tryFTS5: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			testSearch(t, tt.tryFTS5)
+		})
+	}
+}
+
+func testSearch(t *testing.T, tryFTS5 bool) {
+	ctx := context.Background()
+	notesPath := t.TempDir()
+	notePath := filepath.Join(notesPath, "1-plan.md")
+	if err := os.WriteFile(notePath, []byte("---\nTitle: Plan\n---\nMeet the accountant about quarterly taxes\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(notesPath, "loose.md"), []byte("Taxes are due in April\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(notesPath, ".obsidian"), 0755); err != nil {
+		t.Fatalf("MkdirAll() error = %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(notesPath, ".obsidian", "taxes.md"), []byte("taxes\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+	// Rows created before the index is built are picked up by the first reindex
+	if _, err := queries.CreateTask(ctx, sqlc.CreateTaskParams{ID: 1, Title: "File taxes"}); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	if built, err := SearchIndexBuilt(ctx, conn); err != nil || built {
+		t.Fatalf("SearchIndexBuilt() = %v, %v, want an empty index before the first search", built, err)
+	}
+	stats, err := EnsureSearchIndex(ctx, conn, notesPath)
+	if err != nil {
+		t.Fatalf("EnsureSearchIndex() error = %v", err)
+	}
+	if stats == nil || stats.Tasks != 1 || stats.Files != 2 {
+		t.Fatalf("EnsureSearchIndex() stats = %+v, want 1 task and 2 files", stats)
+	}
+	if stats, err := EnsureSearchIndex(ctx, conn, notesPath); err != nil || stats != nil {
+		t.Fatalf("second EnsureSearchIndex() = %+v, %v, want nil stats", stats, err)
+	}
+
+	// Rows created afterwards are kept in the index by triggers
+	if _, err := queries.CreateArea(ctx, sqlc.CreateAreaParams{ID: 1, Title: "Taxes and finance"}); err != nil {
+		t.Fatalf("CreateArea() error = %v", err)
+	}
+	if err := queries.CreateNote(ctx, sqlc.CreateNoteParams{ID: 1, Title: "Plan", Path: notePath}); err != nil {
+		t.Fatalf("CreateNote() error = %v", err)
+	}
+
+	// Once the file belongs to a note it is indexed as that note instead of as a loose file
+	reindexed, err := Reindex(ctx, conn, notesPath)
+	if err != nil {
+		t.Fatalf("Reindex() error = %v", err)
+	}
+	wantStats := ReindexStats{Tasks: 1, Areas: 1, Notes: 1, Files: 1}
+	if reindexed.Tasks != wantStats.Tasks || reindexed.Areas != wantStats.Areas ||
+		reindexed.Notes != wantStats.Notes || reindexed.Files != wantStats.Files {
+		t.Errorf("Reindex() stats = %+v, want %+v", reindexed, wantStats)
+	}
+
+	results, err := search(ctx, conn, "tax", 10, tryFTS5)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	got := make(map[SearchEntity]int)
+	for _, result := range results {
+		got[result.Entity]++
+	}
+	want := map[SearchEntity]int{SearchEntityTask: 1, SearchEntityArea: 1, SearchEntityNote: 1, SearchEntityFile: 1}
+	for entity, count := range want {
+		if got[entity] != count {
+			t.Errorf("Search(tax) found %d %s hits, want %d: %+v", got[entity], entity, count, results)
+		}
+	}
+	// Title hits rank above body hits
+	if len(results) > 0 && results[0].Entity == SearchEntityNote {
+		t.Errorf("Search(tax) ranked a body hit first: %+v", results)
+	}
+
+	if _, err := queries.DeleteTask(ctx, 1); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+	results, err = search(ctx, conn, "file taxes", 10, tryFTS5)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	for _, result := range results {
+		if result.Entity == SearchEntityTask {
+			t.Errorf("Search() still finds deleted task: %+v", result)
+		}
+	}
+
+	// Notes written by go_task are refreshed without a full reindex
+	if err := os.WriteFile(notePath, []byte("Bring the receipts\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := RefreshNoteIndex(ctx, conn, 1, notePath); err != nil {
+		t.Fatalf("RefreshNoteIndex() error = %v", err)
+	}
+	results, err = search(ctx, conn, "receipts", 10, tryFTS5)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Entity != SearchEntityNote || results[0].ID != 1 {
+		t.Errorf("Search(receipts) = %+v, want note 1", results)
+	}
+}
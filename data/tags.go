@@ -0,0 +1,223 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/akthe-at/go_task/config"
+	"github.com/akthe-at/go_task/sqlc"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrTagNotFound is returned when removing a tag that was never added
+var ErrTagNotFound = errors.New("tag not found")
+
+const frontmatterDelimiter = "---"
+
+/*
+ParseTags turns raw tag input into a clean list of tag names.
+ 1. Every input is split on commas and whitespace, so "work, home" and "work home" are the same
+ 2. A leading '#' is dropped so Obsidian style tags can be passed as-is
+ 3. Duplicates are dropped regardless of case, the first spelling wins
+*/
+func ParseTags(inputs ...string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, input := range inputs {
+		fields := strings.FieldsFunc(input, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\n'
+		})
+		for _, field := range fields {
+			tag := strings.TrimLeft(strings.TrimSpace(field), "#")
+			if tag == "" || seen[strings.ToLower(tag)] {
+				continue
+			}
+			seen[strings.ToLower(tag)] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// HasAllTags reports whether a comma separated tag list, as read from the database, contains every wanted tag
+func HasAllTags(tagList string, wanted []string) bool {
+	have := make(map[string]bool)
+	for _, tag := range ParseTags(tagList) {
+		have[strings.ToLower(tag)] = true
+	}
+	for _, tag := range wanted {
+		if !have[strings.ToLower(tag)] {
+			return false
+		}
+	}
+	return true
+}
+
+// AddTaskTags tags a task, tags that do not exist yet are created
+func AddTaskTags(ctx context.Context, queries *sqlc.Queries, taskID int64, tags []string) error {
+	for _, tag := range tags {
+		tagID, err := queries.UpsertTag(ctx, tag)
+		if err != nil {
+			return fmt.Errorf("failed to save tag %s: %w", tag, err)
+		}
+		err = queries.AddTaskTag(ctx, sqlc.AddTaskTagParams{TaskID: taskID, TagID: tagID})
+		if err != nil {
+			return fmt.Errorf("failed to tag task %d with %s: %w", taskID, tag, err)
+		}
+	}
+	return nil
+}
+
+// AddAreaTags tags an area, tags that do not exist yet are created
+func AddAreaTags(ctx context.Context, queries *sqlc.Queries, areaID int64, tags []string) error {
+	for _, tag := range tags {
+		tagID, err := queries.UpsertTag(ctx, tag)
+		if err != nil {
+			return fmt.Errorf("failed to save tag %s: %w", tag, err)
+		}
+		err = queries.AddAreaTag(ctx, sqlc.AddAreaTagParams{AreaID: areaID, TagID: tagID})
+		if err != nil {
+			return fmt.Errorf("failed to tag area %d with %s: %w", areaID, tag, err)
+		}
+	}
+	return nil
+}
+
+// AddNoteTags tags a note, tags that do not exist yet are created
+func AddNoteTags(ctx context.Context, queries *sqlc.Queries, noteID int64, tags []string) error {
+	for _, tag := range tags {
+		tagID, err := queries.UpsertTag(ctx, tag)
+		if err != nil {
+			return fmt.Errorf("failed to save tag %s: %w", tag, err)
+		}
+		err = queries.AddNoteTag(ctx, sqlc.AddNoteTagParams{NoteID: noteID, TagID: tagID})
+		if err != nil {
+			return fmt.Errorf("failed to tag note %d with %s: %w", noteID, tag, err)
+		}
+	}
+	return nil
+}
+
+// RemoveTaskTags removes tags from a task and cleans up tags that are no longer used anywhere
+func RemoveTaskTags(ctx context.Context, queries *sqlc.Queries, taskID int64, tags []string) error {
+	for _, tag := range tags {
+		removed, err := queries.RemoveTaskTag(ctx, sqlc.RemoveTaskTagParams{TaskID: taskID, Name: tag})
+		if err != nil {
+			return fmt.Errorf("failed to remove tag %s from task %d: %w", tag, taskID, err)
+		}
+		if removed == 0 {
+			return fmt.Errorf("%w: task %d is not tagged with %s", ErrTagNotFound, taskID, tag)
+		}
+	}
+	if _, err := queries.DeleteUnusedTags(ctx); err != nil {
+		return fmt.Errorf("failed to clean up unused tags: %w", err)
+	}
+	return nil
+}
+
+// RemoveNoteTags removes tags from a note and cleans up tags that are no longer used anywhere
+func RemoveNoteTags(ctx context.Context, queries *sqlc.Queries, noteID int64, tags []string) error {
+	for _, tag := range tags {
+		removed, err := queries.RemoveNoteTag(ctx, sqlc.RemoveNoteTagParams{NoteID: noteID, Name: tag})
+		if err != nil {
+			return fmt.Errorf("failed to remove tag %s from note %d: %w", tag, noteID, err)
+		}
+		if removed == 0 {
+			return fmt.Errorf("%w: note %d is not tagged with %s", ErrTagNotFound, noteID, tag)
+		}
+	}
+	if _, err := queries.DeleteUnusedTags(ctx); err != nil {
+		return fmt.Errorf("failed to clean up unused tags: %w", err)
+	}
+	return nil
+}
+
+/*
+WriteFrontmatterTags replaces the Tags of a markdown note that go_task generated.
+ 1. Only the Tags key is touched, the other frontmatter keys keep their order and values
+ 2. The body of the note is left exactly as it was
+ 3. Files without a frontmatter block were not generated by go_task and are left alone,
+    the returned bool reports whether the file was rewritten
+*/
+func WriteFrontmatterTags(path string, tags []string) (bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read note %s: %w", path, err)
+	}
+
+	frontmatter, body, ok := splitFrontmatter(content)
+	if !ok {
+		return false, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(frontmatter, &doc); err != nil {
+		return false, fmt.Errorf("failed to parse the frontmatter of %s: %w", path, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return false, nil
+	}
+	setMappingTags(doc.Content[0], tags)
+
+	updated, err := yaml.Marshal(&doc)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal YAML front matter: %w", err)
+	}
+
+	var out bytes.Buffer
+	out.WriteString(frontmatterDelimiter + "\n")
+	out.Write(updated)
+	out.WriteString(frontmatterDelimiter + "\n")
+	out.Write(body)
+
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		return false, fmt.Errorf("failed to write note %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// splitFrontmatter separates the YAML between the leading --- lines from the rest of the note
+func splitFrontmatter(content []byte) (frontmatter, body []byte, ok bool) {
+	opening := []byte(frontmatterDelimiter + "\n")
+	if !bytes.HasPrefix(content, opening) {
+		return nil, nil, false
+	}
+	rest := content[len(opening):]
+
+	closing := []byte("\n" + frontmatterDelimiter + "\n")
+	if bytes.HasPrefix(rest, opening) {
+		return nil, rest[len(opening):], true
+	}
+	end := bytes.Index(rest, closing)
+	if end == -1 {
+		return nil, nil, false
+	}
+	return rest[:end+1], rest[end+len(closing):], true
+}
+
+func setMappingTags(mapping *yaml.Node, tags []string) {
+	sequence := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, tag := range tags {
+		sequence.Content = append(sequence.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: tag})
+	}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		// Obsidian properties are lower case, notes written without use_obsidian use Tags
+		if strings.EqualFold(mapping.Content[i].Value, "Tags") {
+			mapping.Content[i+1] = sequence
+			return
+		}
+	}
+	key := "Tags"
+	if config.UserSettings.Selected.UseObsidian {
+		key = "tags"
+	}
+	mapping.Content = append(mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		sequence,
+	)
+}
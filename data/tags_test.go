@@ -0,0 +1,168 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs []string
+		want   []string
+	}{
+		{name: "spaces", inputs: []string{"work home"}, want: []string{"work", "home"}},
+		{name: "commas", inputs: []string{"work, home,errands"}, want: []string{"work", "home", "errands"}},
+		{name: "hash prefix", inputs: []string{"#work #home"}, want: []string{"work", "home"}},
+		{name: "duplicates ignore case", inputs: []string{"Work", "work", "WORK home"}, want: []string{"Work", "home"}},
+		{name: "blank", inputs: []string{"", " , "}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseTags(tt.inputs...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTags(%q) = %q, want %q", tt.inputs, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasAllTags(t *testing.T) {
+	tests := []struct {
+		tagList string
+		wanted  []string
+		want    bool
+	}{
+		{tagList: "home, work", wanted: []string{"work"}, want: true},
+		{tagList: "home, work", wanted: []string{"Work", "home"}, want: true},
+		{tagList: "home, work", wanted: []string{"work", "errands"}, want: false},
+		{tagList: "", wanted: []string{"work"}, want: false},
+		{tagList: "", wanted: nil, want: true},
+	}
+	for _, tt := range tests {
+		if got := HasAllTags(tt.tagList, tt.wanted); got != tt.want {
+			t.Errorf("HasAllTags(%q, %q) = %v, want %v", tt.tagList, tt.wanted, got, tt.want)
+		}
+	}
+}
+
+func TestTaskTags(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+
+	for _, id := range []int64{1, 2} {
+		if _, err := queries.CreateTask(ctx, sqlc.CreateTaskParams{ID: id, Title: "Task"}); err != nil {
+			t.Fatalf("CreateTask() error = %v", err)
+		}
+	}
+
+	if err := AddTaskTags(ctx, queries, 1, []string{"work", "Home"}); err != nil {
+		t.Fatalf("AddTaskTags() error = %v", err)
+	}
+	// Tag names are shared and matched regardless of case
+	if err := AddTaskTags(ctx, queries, 2, []string{"WORK"}); err != nil {
+		t.Fatalf("AddTaskTags() error = %v", err)
+	}
+
+	got, err := queries.ReadTaskTags(ctx, 1)
+	if err != nil {
+		t.Fatalf("ReadTaskTags() error = %v", err)
+	}
+	if want := []string{"Home", "work"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadTaskTags(1) = %q, want %q", got, want)
+	}
+
+	if err := RemoveTaskTags(ctx, queries, 1, []string{"home"}); err != nil {
+		t.Fatalf("RemoveTaskTags() error = %v", err)
+	}
+	err = RemoveTaskTags(ctx, queries, 1, []string{"home"})
+	if !errors.Is(err, ErrTagNotFound) {
+		t.Errorf("RemoveTaskTags() twice error = %v, want ErrTagNotFound", err)
+	}
+
+	// Home is no longer used anywhere and is cleaned up, work is still used by both tasks
+	var tagCount int
+	if err := conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM tags").Scan(&tagCount); err != nil {
+		t.Fatalf("counting tags: %v", err)
+	}
+	if tagCount != 1 {
+		t.Errorf("tags left = %d, want 1", tagCount)
+	}
+
+	tasks, err := queries.ReadTasks(ctx)
+	if err != nil {
+		t.Fatalf("ReadTasks() error = %v", err)
+	}
+	for _, task := range tasks {
+		if tags, _ := task.Tags.(string); tags != "work" {
+			t.Errorf("task %d tags = %q, want work", task.ID, tags)
+		}
+	}
+}
+
+func TestWriteFrontmatterTags(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		tags      []string
+		want      string
+		rewritten bool
+	}{
+		{
+			name:      "replaces tags",
+			content:   "---\nTitle: Plan\nID: 1-plan\nAliases: []\nTags:\n    - old\n---\n# Plan\n\nbody\n",
+			tags:      []string{"work", "home"},
+			want:      "---\nTitle: Plan\nID: 1-plan\nAliases: []\nTags:\n    - work\n    - home\n---\n# Plan\n\nbody\n",
+			rewritten: true,
+		},
+		{
+			name:      "adds missing tags",
+			content:   "---\nTitle: Plan\n---\nbody\n",
+			tags:      []string{"work"},
+			want:      "---\nTitle: Plan\nTags:\n    - work\n---\nbody\n",
+			rewritten: true,
+		},
+		{
+			name:      "clears tags",
+			content:   "---\nTitle: Plan\nTags:\n    - work\n---\nbody\n",
+			tags:      nil,
+			want:      "---\nTitle: Plan\nTags: []\n---\nbody\n",
+			rewritten: true,
+		},
+		{
+			name:    "no frontmatter",
+			content: "# Plan\n",
+			tags:    []string{"work"},
+			want:    "# Plan\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "note.md")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			rewritten, err := WriteFrontmatterTags(path, tt.tags)
+			if err != nil {
+				t.Fatalf("WriteFrontmatterTags() error = %v", err)
+			}
+			if rewritten != tt.rewritten {
+				t.Errorf("WriteFrontmatterTags() rewritten = %v, want %v", rewritten, tt.rewritten)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("WriteFrontmatterTags() file =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
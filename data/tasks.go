@@ -0,0 +1,196 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/akthe-at/go_task/config"
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+// ParentDonePolicy decides what happens when a task with open subtasks is marked done
+type ParentDonePolicy string
+
+const (
+	// ParentDoneWarn marks only the parent done and reports the subtasks that are still open
+	ParentDoneWarn ParentDonePolicy = "warn"
+	// ParentDoneCascade marks every open subtask done along with the parent
+	ParentDoneCascade ParentDonePolicy = "cascade"
+)
+
+// StringToParentDonePolicy converts the tasks.on_parent_done setting, blank means warn
+func StringToParentDonePolicy(s string) (ParentDonePolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", string(ParentDoneWarn):
+		return ParentDoneWarn, nil
+	case string(ParentDoneCascade):
+		return ParentDoneCascade, nil
+	default:
+		return "", fmt.Errorf("invalid on_parent_done setting ( %s ) must be either warn or cascade", s)
+	}
+}
+
+// StatusChange describes everything that happened when the status of a task changed
+type StatusChange struct {
+	// NextTaskIDs are the new instances created for recurring tasks that were finished
+	NextTaskIDs []int64
+	// CascadedIDs are the subtasks that were marked done along with their parent
+	CascadedIDs []int64
+	// OpenSubtasks are the subtasks left open when a parent was marked done with the warn policy
+	OpenSubtasks []sqlc.ReadSubtaskTreeRow
+	// UpdatedNotes are the notes whose checkbox of the task was ticked or unticked
+	UpdatedNotes []string
+}
+
+/*
+SetTaskStatus updates the status of a task and takes care of recurring tasks and subtasks.
+ 1. When a recurring task moves to done, the next instance is created with the same
+    title, priority, area, parent task, programming project links and recurrence rule
+ 2. The due date of the next instance is moved forward with the recurrence rule
+ 3. The rule moves over to the new instance, so reopening the finished task does not spawn duplicates
+ 4. When a task with open subtasks is marked done, the tasks.on_parent_done setting decides
+    whether the subtasks are marked done as well or are only reported back
+ 5. A recurring subtask that is marked done along with its parent does not spawn its next instance,
+    which would leave the finished parent with an open subtask. It keeps its rule, reopening it resumes the series
+ 6. Tasks created from a checkbox line of a note get their box ticked, or unticked when they are reopened
+ 7. The frontmatter of the notes of the task and of the cascaded subtasks gets the new status
+*/
+func SetTaskStatus(ctx context.Context, conn *sql.DB, taskID int64, status StatusType, now time.Time) (StatusChange, error) {
+	var change StatusChange
+
+	policy, err := StringToParentDonePolicy(config.UserSettings.Tasks.OnParentDone)
+	if err != nil {
+		return change, err
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return change, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	queries := sqlc.New(tx)
+
+	if status == StatusDone {
+		subtasks, err := queries.ReadSubtaskTree(ctx, sql.NullInt64{Int64: taskID, Valid: true})
+		if err != nil {
+			return change, fmt.Errorf("failed to read the subtasks of task %d: %w", taskID, err)
+		}
+		for _, subtask := range subtasks {
+			if subtask.Status.String == string(StatusDone) {
+				continue
+			}
+			if policy == ParentDoneWarn {
+				change.OpenSubtasks = append(change.OpenSubtasks, subtask)
+				continue
+			}
+			if err := setStatus(ctx, queries, subtask.ID, status, now, false, &change); err != nil {
+				return change, err
+			}
+			change.CascadedIDs = append(change.CascadedIDs, subtask.ID)
+		}
+	}
+
+	if err := setStatus(ctx, queries, taskID, status, now, true, &change); err != nil {
+		return change, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return change, fmt.Errorf("failed to commit the status of task %d: %w", taskID, err)
+	}
+
+	change.UpdatedNotes, err = updateNoteCheckboxes(ctx, conn, append([]int64{taskID}, change.CascadedIDs...), status == StatusDone)
+	if err != nil {
+		return change, fmt.Errorf("the status of task %d was updated but its note checkbox was not: %w", taskID, err)
+	}
+	if _, err := SyncTaskNoteStates(ctx, conn, append([]int64{taskID}, change.CascadedIDs...)...); err != nil {
+		return change, fmt.Errorf("the status of task %d was updated but the frontmatter of its notes was not: %w", taskID, err)
+	}
+	return change, nil
+}
+
+// setStatus updates the status of one task, spawnNext decides whether a finished recurring task creates its next instance
+func setStatus(ctx context.Context, queries *sqlc.Queries, taskID int64, status StatusType, now time.Time, spawnNext bool, change *StatusChange) error {
+	task, err := queries.ReadTaskForRecurrence(ctx, taskID)
+	if err != nil {
+		return fmt.Errorf("failed to read task %d: %w", taskID, err)
+	}
+
+	_, err = queries.UpdateTaskStatus(ctx, sqlc.UpdateTaskStatusParams{
+		Status: sql.NullString{String: string(status), Valid: true},
+		ID:     taskID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update the status of task %d: %w", taskID, err)
+	}
+
+	// Only a transition into done spawns the next instance, so re-marking a done task is harmless
+	if spawnNext && task.Recurrence.Valid && status == StatusDone && task.Status.String != string(StatusDone) {
+		nextID, err := createNextInstance(ctx, queries, task, now)
+		if err != nil {
+			return err
+		}
+		change.NextTaskIDs = append(change.NextTaskIDs, nextID)
+	}
+	return nil
+}
+
+func createNextInstance(ctx context.Context, queries *sqlc.Queries, task sqlc.ReadTaskForRecurrenceRow, now time.Time) (int64, error) {
+	rule, err := ParseRecurrence(task.Recurrence.String)
+	if err != nil {
+		return 0, fmt.Errorf("task %d has an invalid recurrence rule: %w", task.ID, err)
+	}
+	nextDue := rule.NextDueDate(task.DueDate, now)
+	// The next instance keeps the day this one was anchored to, its own due date may have been clamped
+	recurrence := task.Recurrence
+	if anchored := rule.anchor(recurrenceStart(task.DueDate, startOfDay(now))); anchored.ByMonthDay != rule.ByMonthDay {
+		recurrence = sql.NullString{String: anchored.String(), Valid: true}
+	}
+
+	newTaskID, err := queries.GetTaskID(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get an ID for the next instance of task %d: %w", task.ID, err)
+	}
+
+	nextID, err := queries.CreateTask(ctx, sqlc.CreateTaskParams{
+		ID:           newTaskID,
+		Title:        task.Title,
+		Priority:     task.Priority,
+		Status:       sql.NullString{String: string(StatusToDo), Valid: true},
+		DueDate:      sql.NullString{String: nextDue.Format(DueDateLayout), Valid: true},
+		AreaID:       task.AreaID,
+		Recurrence:   recurrence,
+		ParentTaskID: task.ParentTaskID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create the next instance of task %d: %w", task.ID, err)
+	}
+
+	err = queries.CopyProjectTaskLinks(ctx, sqlc.CopyProjectTaskLinksParams{
+		NewTaskID: nextID,
+		TaskID:    sql.NullInt64{Int64: task.ID, Valid: true},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to copy the project links of task %d: %w", task.ID, err)
+	}
+
+	_, err = queries.UpdateTaskRecurrence(ctx, sqlc.UpdateTaskRecurrenceParams{
+		Recurrence: sql.NullString{},
+		ID:         task.ID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to hand the recurrence of task %d over to task %d: %w", task.ID, nextID, err)
+	}
+	return nextID, nil
+}
+
+// FormatSubtaskProgress renders subtask progress for parent tasks, e.g. "3/5 done".
+// Tasks without subtasks get an empty string.
+func FormatSubtaskProgress(done, total int64) string {
+	if total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d done", done, total)
+}
@@ -0,0 +1,335 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/akthe-at/go_task/config"
+	"github.com/akthe-at/go_task/db"
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+// testNow is a fixed clock for tests that do not care about dates
+var testNow = time.Date(2024, 11, 5, 15, 30, 0, 0, time.Local)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	conn, err := db.OpenPath(filepath.Join(t.TempDir(), "taskdb.db"))
+	if err != nil {
+		t.Fatalf("OpenPath() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if _, err := db.Migrate(conn); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	return conn
+}
+
+func TestSetTaskStatusRecurring(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+	now := time.Date(2024, 11, 5, 15, 30, 0, 0, time.Local)
+
+	areaID, err := queries.CreateArea(ctx, sqlc.CreateAreaParams{ID: 1, Title: "Work"})
+	if err != nil {
+		t.Fatalf("CreateArea() error = %v", err)
+	}
+	taskID, err := queries.CreateTask(ctx, sqlc.CreateTaskParams{
+		ID:         1,
+		Title:      "Weekly report",
+		Priority:   sql.NullString{String: string(PriorityTypeHigh), Valid: true},
+		Status:     sql.NullString{String: string(StatusDoing), Valid: true},
+		DueDate:    sql.NullString{String: "2024-11-04", Valid: true},
+		AreaID:     sql.NullInt64{Int64: areaID, Valid: true},
+		Recurrence: sql.NullString{String: "FREQ=WEEKLY", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	projectID, err := queries.InsertProgProject(ctx, "/src/report")
+	if err != nil {
+		t.Fatalf("InsertProgProject() error = %v", err)
+	}
+	err = queries.CreateProjectTaskLink(ctx, sqlc.CreateProjectTaskLinkParams{
+		ProjectID:    sql.NullInt64{Int64: projectID, Valid: true},
+		ParentCat:    sql.NullInt64{Int64: int64(TaskNoteType), Valid: true},
+		ParentTaskID: sql.NullInt64{Int64: taskID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateProjectTaskLink() error = %v", err)
+	}
+
+	change, err := SetTaskStatus(ctx, conn, taskID, StatusDone, now)
+	if err != nil {
+		t.Fatalf("SetTaskStatus() error = %v", err)
+	}
+	if len(change.NextTaskIDs) != 1 || change.NextTaskIDs[0] == taskID {
+		t.Fatalf("SetTaskStatus() next IDs = %v, want a single new task", change.NextTaskIDs)
+	}
+	nextID := change.NextTaskIDs[0]
+
+	next, err := queries.ReadTaskForRecurrence(ctx, nextID)
+	if err != nil {
+		t.Fatalf("ReadTaskForRecurrence() error = %v", err)
+	}
+	if next.Title != "Weekly report" || next.Priority.String != string(PriorityTypeHigh) || next.AreaID.Int64 != areaID {
+		t.Errorf("next instance = %+v, want the same title, priority and area", next)
+	}
+	if next.Status.String != string(StatusToDo) {
+		t.Errorf("next instance status = %s, want %s", next.Status.String, StatusToDo)
+	}
+	if next.DueDate.String != "2024-11-11" {
+		t.Errorf("next instance due date = %s, want 2024-11-11", next.DueDate.String)
+	}
+	if next.Recurrence.String != "FREQ=WEEKLY" {
+		t.Errorf("next instance recurrence = %s, want FREQ=WEEKLY", next.Recurrence.String)
+	}
+
+	project, err := queries.FindProgProjectsForTask(ctx, sql.NullInt64{Int64: nextID, Valid: true})
+	if err != nil || project.Path != "/src/report" {
+		t.Errorf("next instance project = %v (%v), want /src/report", project.Path, err)
+	}
+
+	done, err := queries.ReadTaskForRecurrence(ctx, taskID)
+	if err != nil {
+		t.Fatalf("ReadTaskForRecurrence() error = %v", err)
+	}
+	if done.Status.String != string(StatusDone) || done.Recurrence.Valid {
+		t.Errorf("finished task = %+v, want it done without a recurrence rule", done)
+	}
+
+	// Marking the finished task done again must not spawn another instance
+	again, err := SetTaskStatus(ctx, conn, taskID, StatusDone, now)
+	if err != nil || len(again.NextTaskIDs) != 0 {
+		t.Errorf("SetTaskStatus() on a done task = %v, %v, want no new tasks", again.NextTaskIDs, err)
+	}
+}
+
+func TestSetTaskStatusRecurringMonthEnd(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+
+	taskID, err := queries.CreateTask(ctx, sqlc.CreateTaskParams{
+		ID:         1,
+		Title:      "Pay rent",
+		Status:     sql.NullString{String: string(StatusToDo), Valid: true},
+		DueDate:    sql.NullString{String: "2025-01-31", Valid: true},
+		Recurrence: sql.NullString{String: "FREQ=MONTHLY", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+
+	// February is clamped to the 28th, the instance after it goes back to the 31st
+	for _, want := range []struct{ due, recurrence string }{
+		{due: "2025-02-28", recurrence: "FREQ=MONTHLY;BYMONTHDAY=31"},
+		{due: "2025-03-31", recurrence: "FREQ=MONTHLY;BYMONTHDAY=31"},
+	} {
+		task, err := queries.ReadTaskForRecurrence(ctx, taskID)
+		if err != nil {
+			t.Fatalf("ReadTaskForRecurrence() error = %v", err)
+		}
+		now, err := ParseDueDate(task.DueDate.String)
+		if err != nil {
+			t.Fatalf("ParseDueDate() error = %v", err)
+		}
+		change, err := SetTaskStatus(ctx, conn, taskID, StatusDone, now)
+		if err != nil || len(change.NextTaskIDs) != 1 {
+			t.Fatalf("SetTaskStatus() = %v, %v, want a single new task", change.NextTaskIDs, err)
+		}
+		taskID = change.NextTaskIDs[0]
+		next, err := queries.ReadTaskForRecurrence(ctx, taskID)
+		if err != nil {
+			t.Fatalf("ReadTaskForRecurrence() error = %v", err)
+		}
+		if next.DueDate.String != want.due || next.Recurrence.String != want.recurrence {
+			t.Errorf("next instance = %s %s, want %s %s", next.DueDate.String, next.Recurrence.String, want.due, want.recurrence)
+		}
+	}
+}
+
+func TestSetTaskStatusNotRecurring(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+
+	taskID, err := queries.CreateTask(ctx, sqlc.CreateTaskParams{
+		ID:     1,
+		Title:  "One off",
+		Status: sql.NullString{String: string(StatusToDo), Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+
+	change, err := SetTaskStatus(ctx, conn, taskID, StatusDone, time.Now())
+	if err != nil || len(change.NextTaskIDs) != 0 {
+		t.Errorf("SetTaskStatus() = %v, %v, want no new tasks", change.NextTaskIDs, err)
+	}
+	if _, err := SetTaskStatus(ctx, conn, 42, StatusDone, time.Now()); err == nil {
+		t.Errorf("SetTaskStatus() on a missing task should fail")
+	}
+}
+
+// createSubtaskTree creates a parent with two children and a grandchild under the first child
+func createSubtaskTree(t *testing.T, queries *sqlc.Queries) (parent, child, grandchild, sibling int64) {
+	t.Helper()
+	ctx := context.Background()
+	create := func(id int64, title string, status StatusType, parentID int64) int64 {
+		taskID, err := queries.CreateTask(ctx, sqlc.CreateTaskParams{
+			ID:           id,
+			Title:        title,
+			Status:       sql.NullString{String: string(status), Valid: true},
+			ParentTaskID: sql.NullInt64{Int64: parentID, Valid: parentID != 0},
+		})
+		if err != nil {
+			t.Fatalf("CreateTask(%s) error = %v", title, err)
+		}
+		return taskID
+	}
+	parent = create(1, "Release", StatusDoing, 0)
+	child = create(2, "Write changelog", StatusToDo, parent)
+	grandchild = create(3, "Collect PRs", StatusDoing, child)
+	sibling = create(4, "Tag release", StatusDone, parent)
+	return parent, child, grandchild, sibling
+}
+
+func TestSetTaskStatusParentDone(t *testing.T) {
+	tests := []struct {
+		name         string
+		policy       string
+		wantOpen     int
+		wantCascaded int
+		wantStatus   StatusType
+	}{
+		{name: "warn by default", policy: "", wantOpen: 2, wantStatus: StatusToDo},
+		{name: "warn", policy: "warn", wantOpen: 2, wantStatus: StatusToDo},
+		{name: "cascade", policy: "cascade", wantCascaded: 2, wantStatus: StatusDone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			conn := openTestDB(t)
+			queries := sqlc.New(conn)
+			config.UserSettings.Tasks.OnParentDone = tt.policy
+			t.Cleanup(func() { config.UserSettings.Tasks.OnParentDone = "" })
+
+			parent, child, _, _ := createSubtaskTree(t, queries)
+
+			change, err := SetTaskStatus(ctx, conn, parent, StatusDone, time.Now())
+			if err != nil {
+				t.Fatalf("SetTaskStatus() error = %v", err)
+			}
+			if len(change.OpenSubtasks) != tt.wantOpen {
+				t.Errorf("OpenSubtasks = %v, want %d", change.OpenSubtasks, tt.wantOpen)
+			}
+			if len(change.CascadedIDs) != tt.wantCascaded {
+				t.Errorf("CascadedIDs = %v, want %d", change.CascadedIDs, tt.wantCascaded)
+			}
+
+			parentTask, err := queries.ReadTaskForRecurrence(ctx, parent)
+			if err != nil || parentTask.Status.String != string(StatusDone) {
+				t.Errorf("parent status = %s (%v), want done", parentTask.Status.String, err)
+			}
+			childTask, err := queries.ReadTaskForRecurrence(ctx, child)
+			if err != nil || childTask.Status.String != string(tt.wantStatus) {
+				t.Errorf("child status = %s (%v), want %s", childTask.Status.String, err, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSetTaskStatusCascadeRecurring(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+	config.UserSettings.Tasks.OnParentDone = "cascade"
+	t.Cleanup(func() { config.UserSettings.Tasks.OnParentDone = "" })
+
+	parent, child, _, _ := createSubtaskTree(t, queries)
+	weekly := sql.NullString{String: "weekly", Valid: true}
+	if _, err := queries.UpdateTaskRecurrence(ctx, sqlc.UpdateTaskRecurrenceParams{Recurrence: weekly, ID: child}); err != nil {
+		t.Fatalf("UpdateTaskRecurrence() error = %v", err)
+	}
+
+	// The recurring child finishes with its parent instead of leaving a new open subtask behind
+	change, err := SetTaskStatus(ctx, conn, parent, StatusDone, testNow)
+	if err != nil || len(change.NextTaskIDs) != 0 || len(change.CascadedIDs) != 2 {
+		t.Fatalf("SetTaskStatus() = %+v, %v, want 2 cascaded subtasks and no new tasks", change, err)
+	}
+	subtasks, err := queries.ReadSubtaskTree(ctx, sql.NullInt64{Int64: parent, Valid: true})
+	if err != nil {
+		t.Fatalf("ReadSubtaskTree() error = %v", err)
+	}
+	for _, subtask := range subtasks {
+		if subtask.Status.String != string(StatusDone) {
+			t.Errorf("subtask %d status = %s, want done", subtask.ID, subtask.Status.String)
+		}
+	}
+	childTask, err := queries.ReadTaskForRecurrence(ctx, child)
+	if err != nil || childTask.Recurrence != weekly {
+		t.Errorf("child recurrence = %v (%v), want it kept", childTask.Recurrence, err)
+	}
+}
+
+func TestSetTaskStatusInvalidPolicy(t *testing.T) {
+	conn := openTestDB(t)
+	config.UserSettings.Tasks.OnParentDone = "explode"
+	t.Cleanup(func() { config.UserSettings.Tasks.OnParentDone = "" })
+
+	if _, err := SetTaskStatus(context.Background(), conn, 1, StatusDone, time.Now()); err == nil {
+		t.Errorf("SetTaskStatus() with an invalid on_parent_done setting should fail")
+	}
+}
+
+func TestReadSubtaskTree(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+	parent, child, grandchild, sibling := createSubtaskTree(t, queries)
+
+	tree, err := queries.ReadSubtaskTree(ctx, sql.NullInt64{Int64: parent, Valid: true})
+	if err != nil {
+		t.Fatalf("ReadSubtaskTree() error = %v", err)
+	}
+	var got []int64
+	for _, subtask := range tree {
+		got = append(got, subtask.ID)
+	}
+	want := []int64{child, sibling, grandchild}
+	if len(got) != len(want) {
+		t.Fatalf("ReadSubtaskTree() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ReadSubtaskTree() = %v, want %v", got, want)
+		}
+	}
+	if tree[2].Depth != 2 || tree[2].ParentTaskID != child {
+		t.Errorf("grandchild = %+v, want depth 2 under task %d", tree[2], child)
+	}
+
+	tasks, err := queries.ReadTasks(ctx)
+	if err != nil {
+		t.Fatalf("ReadTasks() error = %v", err)
+	}
+	for _, task := range tasks {
+		if task.ID == parent && FormatSubtaskProgress(task.SubtasksDone, task.SubtaskCount) != "1/2 done" {
+			t.Errorf("parent progress = %d/%d, want 1/2", task.SubtasksDone, task.SubtaskCount)
+		}
+	}
+}
+
+func TestFormatSubtaskProgress(t *testing.T) {
+	if got := FormatSubtaskProgress(0, 0); got != "" {
+		t.Errorf("FormatSubtaskProgress(0, 0) = %q, want empty", got)
+	}
+	if got := FormatSubtaskProgress(3, 5); got != "3/5 done" {
+		t.Errorf("FormatSubtaskProgress(3, 5) = %q, want 3/5 done", got)
+	}
+}
@@ -0,0 +1,592 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akthe-at/go_task/sqlc"
+	"github.com/charmbracelet/log"
+)
+
+// ErrInvalidTaskwarrior is returned when a Taskwarrior export can not be read
+var ErrInvalidTaskwarrior = errors.New("invalid Taskwarrior export")
+
+const (
+	// taskwarriorTimeLayout is the UTC timestamp format Taskwarrior uses for entry, due, and friends
+	taskwarriorTimeLayout = "20060102T150405Z"
+	// annotationTimeLayout prefixes every annotation line in the notes written for imported annotations
+	annotationTimeLayout = "2006-01-02 15:04"
+)
+
+var annotationLine = regexp.MustCompile(`^- (\d{4}-\d{2}-\d{2} \d{2}:\d{2}) (.+)$`)
+
+// TaskwarriorTask is a task in the JSON format of 'task export' and 'task import'
+type TaskwarriorTask struct {
+	UUID        string                  `json:"uuid,omitempty"`
+	Description string                  `json:"description"`
+	Status      string                  `json:"status"`
+	Entry       string                  `json:"entry,omitempty"`
+	Modified    string                  `json:"modified,omitempty"`
+	Start       string                  `json:"start,omitempty"`
+	End         string                  `json:"end,omitempty"`
+	Due         string                  `json:"due,omitempty"`
+	Priority    string                  `json:"priority,omitempty"`
+	Project     string                  `json:"project,omitempty"`
+	Tags        []string                `json:"tags,omitempty"`
+	Recur       string                  `json:"recur,omitempty"`
+	Parent      string                  `json:"parent,omitempty"`
+	Depends     TaskwarriorDepends      `json:"depends,omitempty"`
+	Annotations []TaskwarriorAnnotation `json:"annotations,omitempty"`
+}
+
+type TaskwarriorAnnotation struct {
+	Entry       string `json:"entry"`
+	Description string `json:"description"`
+}
+
+// TaskwarriorDepends holds the UUIDs a task waits on, older Taskwarrior versions write them as one comma separated string
+type TaskwarriorDepends []string
+
+func (d *TaskwarriorDepends) UnmarshalJSON(raw []byte) error {
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		*d = list
+		return nil
+	}
+	var joined string
+	if err := json.Unmarshal(raw, &joined); err != nil {
+		return fmt.Errorf("depends must be a list or a comma separated string: %w", err)
+	}
+	*d = nil
+	for _, uuid := range strings.Split(joined, ",") {
+		if uuid = strings.TrimSpace(uuid); uuid != "" {
+			*d = append(*d, uuid)
+		}
+	}
+	return nil
+}
+
+// TaskwarriorImportStats counts what ImportTaskwarrior added and what it had to leave out
+type TaskwarriorImportStats struct {
+	Tasks        int
+	AreasCreated int
+	Tags         int
+	Notes        int
+	Dependencies int
+	// SkippedInstances are pending copies of recurring tasks, go_task creates the next one itself
+	SkippedInstances int
+	// SkippedAnnotations could not be written because no notes path is configured
+	SkippedAnnotations int
+	// DroppedRecurrences lists tasks whose recurrence has no go_task equivalent
+	DroppedRecurrences []string
+}
+
+// ReadTaskwarrior reads the JSON array written by 'task export', one object per line as older versions wrote it works as well
+func ReadTaskwarrior(r io.Reader) ([]TaskwarriorTask, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Taskwarrior export: %w", err)
+	}
+	raw = bytes.TrimSpace(raw)
+
+	var tasks []TaskwarriorTask
+	if bytes.HasPrefix(raw, []byte("[")) {
+		if err := json.Unmarshal(raw, &tasks); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTaskwarrior, err)
+		}
+		return tasks, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	for decoder.More() {
+		var task TaskwarriorTask
+		if err := decoder.Decode(&task); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTaskwarrior, err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+/*
+ImportTaskwarrior adds Taskwarrior tasks to the database inside a single transaction.
+ 1. description, priority (H/M/L), status, due, entry, modified, recur, and tags map onto the task,
+    a started pending task becomes doing and a deleted task is imported as done and archived
+ 2. A project becomes the area with the same title, the area is created when it does not exist yet
+ 3. The annotations of a task are written to one markdown note in notesPath that is linked to the task
+ 4. depends becomes task dependencies, pending instances of recurring tasks are skipped
+    because the recurring task itself is imported and go_task spawns its next instance
+*/
+func ImportTaskwarrior(ctx context.Context, conn *sql.DB, tasks []TaskwarriorTask, notesPath string, now time.Time) (TaskwarriorImportStats, error) {
+	var stats TaskwarriorImportStats
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return stats, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	queries := sqlc.New(tx)
+
+	// Note files are written before the commit and removed again when the import fails
+	var writtenNotes []string
+	committed := false
+	defer func() {
+		if !committed {
+			for _, path := range writtenNotes {
+				os.Remove(path)
+			}
+		}
+	}()
+
+	areaIDs := make(map[string]int64)
+	taskIDs := make(map[string]int64)
+	notesToIndex := make(map[int64]string)
+	for _, tw := range tasks {
+		if tw.Parent != "" && (tw.Status == "pending" || tw.Status == "waiting") {
+			stats.SkippedInstances++
+			continue
+		}
+
+		areaID, areaCreated, err := findOrCreateArea(ctx, queries, areaIDs, tw.Project, now)
+		if err != nil {
+			return stats, err
+		}
+		if areaCreated {
+			stats.AreasCreated++
+		}
+		due, err := taskwarriorDueDate(tw.Due)
+		if err != nil {
+			return stats, fmt.Errorf("%w: due date of %q: %v", ErrInvalidTaskwarrior, tw.Description, err)
+		}
+		var recurrence sql.NullString
+		if tw.Recur != "" {
+			var ok bool
+			if recurrence, ok = taskwarriorRecurrence(tw.Recur); !ok {
+				stats.DroppedRecurrences = append(stats.DroppedRecurrences, fmt.Sprintf("%s (%s)", tw.Description, tw.Recur))
+			}
+		}
+		status, archived := taskwarriorStatus(tw)
+		created := taskwarriorStoredTime(tw.Entry, now)
+		modified := created
+		if tw.Modified != "" {
+			modified = taskwarriorStoredTime(tw.Modified, now)
+		}
+
+		taskID, err := queries.ImportTask(ctx, sqlc.ImportTaskParams{
+			Title:      tw.Description,
+			Priority:   taskwarriorPriority(tw.Priority),
+			Status:     sql.NullString{String: string(status), Valid: true},
+			Archived:   archived,
+			CreatedAt:  created,
+			LastMod:    modified,
+			DueDate:    due,
+			AreaID:     areaID,
+			Recurrence: recurrence,
+		})
+		if err != nil {
+			return stats, fmt.Errorf("failed to import task %q: %w", tw.Description, err)
+		}
+		if tw.UUID != "" {
+			taskIDs[tw.UUID] = taskID
+		}
+		stats.Tasks++
+
+		tags := ParseTags(tw.Tags...)
+		if err := AddTaskTags(ctx, queries, taskID, tags); err != nil {
+			return stats, err
+		}
+		stats.Tags += len(tags)
+
+		if len(tw.Annotations) == 0 {
+			continue
+		}
+		if notesPath == "" {
+			stats.SkippedAnnotations += len(tw.Annotations)
+			continue
+		}
+		notePath, err := writeAnnotationNote(notesPath, tw, tags, now)
+		if err != nil {
+			return stats, err
+		}
+		writtenNotes = append(writtenNotes, notePath)
+		noteID, err := queries.ImportNote(ctx, sqlc.ImportNoteParams{Title: tw.Description, Path: notePath})
+		if err != nil {
+			return stats, fmt.Errorf("failed to add the annotations of %q: %w", tw.Description, err)
+		}
+		err = queries.ImportBridgeNote(ctx, sqlc.ImportBridgeNoteParams{
+			NoteID:       noteID,
+			ParentCat:    sql.NullInt64{Int64: int64(TaskNoteType), Valid: true},
+			ParentTaskID: sql.NullInt64{Int64: taskID, Valid: true},
+		})
+		if err != nil {
+			return stats, fmt.Errorf("failed to link the annotations of %q: %w", tw.Description, err)
+		}
+		if err := AddNoteTags(ctx, queries, noteID, tags); err != nil {
+			return stats, err
+		}
+		notesToIndex[noteID] = notePath
+		stats.Notes++
+	}
+
+	for _, tw := range tasks {
+		blockedID, ok := taskIDs[tw.UUID]
+		if !ok {
+			continue
+		}
+		for _, uuid := range tw.Depends {
+			// Blockers that were skipped or are not part of the export are left out
+			blockerID, ok := taskIDs[uuid]
+			if !ok {
+				continue
+			}
+			err := queries.CreateTaskDependency(ctx, sqlc.CreateTaskDependencyParams{BlockerID: blockerID, BlockedID: blockedID})
+			if err != nil {
+				return stats, fmt.Errorf("failed to import a dependency of %q: %w", tw.Description, err)
+			}
+			stats.Dependencies++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return stats, fmt.Errorf("failed to commit import: %w", err)
+	}
+	committed = true
+
+	// The import is committed by now, a note without the state of its task is fixed by 'notes resync'
+	var noteIDs []int64
+	for noteID := range notesToIndex {
+		noteIDs = append(noteIDs, noteID)
+	}
+	if _, err := SyncNoteStates(ctx, conn, noteIDs...); err != nil {
+		log.Warnf("The state of the imported tasks was not written to their notes, run 'go_task notes resync': %v", err)
+	}
+	for noteID, path := range notesToIndex {
+		refreshNoteIndexQuietly(ctx, conn, noteID, path)
+	}
+	return stats, nil
+}
+
+func taskwarriorStatus(tw TaskwarriorTask) (StatusType, bool) {
+	switch tw.Status {
+	case "completed":
+		return StatusDone, false
+	case "deleted":
+		return StatusDone, true
+	case "waiting":
+		return StatusPlanning, false
+	}
+	if tw.Start != "" {
+		return StatusDoing, false
+	}
+	return StatusToDo, false
+}
+
+func taskwarriorPriority(priority string) sql.NullString {
+	switch strings.ToUpper(priority) {
+	case "H":
+		return sql.NullString{String: string(PriorityTypeHigh), Valid: true}
+	case "M":
+		return sql.NullString{String: string(PriorityTypeMedium), Valid: true}
+	case "L":
+		return sql.NullString{String: string(PriorityTypeLow), Valid: true}
+	}
+	return sql.NullString{}
+}
+
+// taskwarriorDueDate turns a UTC due timestamp into the local day it falls on
+func taskwarriorDueDate(due string) (sql.NullString, error) {
+	if due == "" {
+		return sql.NullString{}, nil
+	}
+	parsed, err := time.Parse(taskwarriorTimeLayout, due)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: parsed.Local().Format(DueDateLayout), Valid: true}, nil
+}
+
+// taskwarriorStoredTime converts a Taskwarrior timestamp for tasks.created_at, falling back to now when it is missing or broken
+func taskwarriorStoredTime(timestamp string, now time.Time) string {
+	parsed, err := time.Parse(taskwarriorTimeLayout, timestamp)
+	if err != nil {
+		return now.Format(storedTimeLayout)
+	}
+	return parsed.Local().Format(storedTimeLayout)
+}
+
+var taskwarriorRecurrenceNames = map[string]string{
+	"daily": "daily", "day": "daily",
+	"weekly": "weekly", "week": "weekly", "sennight": "weekly",
+	"weekdays": "weekdays",
+	"biweekly": "every 2 weeks", "fortnight": "every 2 weeks",
+	"monthly": "monthly", "month": "monthly",
+	"bimonthly":  "every 2 months",
+	"quarterly":  "every 3 months",
+	"semiannual": "every 6 months",
+	"yearly":     "yearly", "year": "yearly", "annual": "yearly",
+	"biannual": "every 2 years", "biyearly": "every 2 years",
+}
+
+var taskwarriorRecurrenceUnits = map[string]string{
+	"d": "days", "day": "days", "days": "days",
+	"w": "weeks", "wk": "weeks", "wks": "weeks", "week": "weeks", "weeks": "weeks",
+	"mo": "months", "mos": "months", "mth": "months", "mths": "months", "month": "months", "months": "months",
+	"y": "years", "yr": "years", "yrs": "years", "year": "years", "years": "years",
+}
+
+var taskwarriorRecurrencePattern = regexp.MustCompile(`^(\d+)\s*([a-z]+)$`)
+
+// taskwarriorRecurrence maps a Taskwarrior recur value such as weekly, 3d, or quarterly onto a go_task rule
+func taskwarriorRecurrence(recur string) (sql.NullString, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(recur))
+	input, ok := taskwarriorRecurrenceNames[normalized]
+	if !ok {
+		match := taskwarriorRecurrencePattern.FindStringSubmatch(normalized)
+		if match == nil {
+			return sql.NullString{}, false
+		}
+		unit, ok := taskwarriorRecurrenceUnits[match[2]]
+		if !ok {
+			return sql.NullString{}, false
+		}
+		input = fmt.Sprintf("every %s %s", match[1], unit)
+	}
+	recurrence, err := ToNullRecurrence(input)
+	if err != nil {
+		return sql.NullString{}, false
+	}
+	return recurrence, true
+}
+
+// writeAnnotationNote writes the annotations of a task to a new markdown note, one "- <time> <text>" line each
+func writeAnnotationNote(notesPath string, tw TaskwarriorTask, tags []string, now time.Time) (string, error) {
+	var body strings.Builder
+	body.WriteString("# " + tw.Description + "\n\nImported from Taskwarrior\n\n")
+	for _, annotation := range tw.Annotations {
+		entry := taskwarriorStoredTime(annotation.Entry, now)
+		stamp, _ := time.ParseInLocation(storedTimeLayout, entry, time.Local)
+		text := strings.Join(strings.Fields(annotation.Description), " ")
+		body.WriteString(fmt.Sprintf("- %s %s\n", stamp.Format(annotationTimeLayout), text))
+	}
+
+	baseID := GenerateNoteID(tw.Description)
+	noteID := baseID
+	for n := 2; noteFileExists(filepath.Join(notesPath, noteID+".md")); n++ {
+		noteID = fmt.Sprintf("%s-%d", baseID, n)
+	}
+	path, err := GenerateMarkdownFile(NoteContent{
+		Body:     body.String(),
+		Metadata: NoteMetadata{Title: tw.Description, ID: noteID, Tags: tags},
+	}, notesPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to write the annotations of %q: %w", tw.Description, err)
+	}
+	return path, nil
+}
+
+func noteFileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+/*
+ExportTaskwarrior converts every task into the format 'task import' reads.
+ 1. Each task gets a UUID derived from its ID and creation time, so exporting twice updates the same Taskwarrior tasks
+    while a new task that reuses the ID of a deleted one does not overwrite the Taskwarrior task of the old one
+ 2. todo and planning become pending, doing becomes a started pending task, done becomes completed,
+    and archived tasks that are not done become deleted
+ 3. The area becomes the project, priorities map onto H, M, and L with urgent as H
+ 4. Linked notes become annotations, lines written by ImportTaskwarrior are turned back into the original annotations
+    and any other note is referenced by its title and path
+ 5. A recurring task with a due date becomes a recurring Taskwarrior task, Taskwarrior needs the due date to recur
+*/
+func ExportTaskwarrior(ctx context.Context, conn *sql.DB, now time.Time) ([]TaskwarriorTask, error) {
+	bundle, err := ExportBundle(ctx, conn, now)
+	if err != nil {
+		return nil, err
+	}
+
+	areaTitles := make(map[int64]string)
+	for _, area := range bundle.Areas {
+		areaTitles[area.ID] = area.Title
+	}
+	taskTags := make(map[int64][]string)
+	for _, tag := range bundle.TaskTags {
+		taskTags[tag.ID] = append(taskTags[tag.ID], tag.Tag)
+	}
+	notes := make(map[int64]BundleNote)
+	for _, note := range bundle.Notes {
+		notes[note.ID] = note
+	}
+	taskNotes := make(map[int64][]BundleNote)
+	for _, bridge := range bundle.BridgeNotes {
+		if bridge.ParentTaskID == nil {
+			continue
+		}
+		if note, ok := notes[bridge.NoteID]; ok {
+			taskNotes[*bridge.ParentTaskID] = append(taskNotes[*bridge.ParentTaskID], note)
+		}
+	}
+	uuids := make(map[int64]string)
+	for _, task := range bundle.Tasks {
+		uuids[task.ID] = TaskwarriorUUID(task.ID, task.CreatedAt)
+	}
+	depends := make(map[int64][]string)
+	for _, dependency := range bundle.TaskDependencies {
+		if uuid, ok := uuids[dependency.BlockerID]; ok {
+			depends[dependency.BlockedID] = append(depends[dependency.BlockedID], uuid)
+		}
+	}
+
+	tasks := make([]TaskwarriorTask, 0, len(bundle.Tasks))
+	for _, task := range bundle.Tasks {
+		tw := TaskwarriorTask{
+			UUID:        uuids[task.ID],
+			Description: task.Title,
+			Entry:       taskwarriorTimestamp(task.CreatedAt),
+			Modified:    taskwarriorTimestamp(task.LastMod),
+			Tags:        taskTags[task.ID],
+			Depends:     depends[task.ID],
+		}
+		if task.AreaID != nil {
+			tw.Project = areaTitles[*task.AreaID]
+		}
+		if task.Priority != nil {
+			switch PriorityType(*task.Priority) {
+			case PriorityTypeUrgent, PriorityTypeHigh:
+				tw.Priority = "H"
+			case PriorityTypeMedium:
+				tw.Priority = "M"
+			case PriorityTypeLow:
+				tw.Priority = "L"
+			}
+		}
+		if task.DueDate != nil {
+			if due, err := time.ParseInLocation(DueDateLayout, *task.DueDate, time.Local); err == nil {
+				tw.Due = due.UTC().Format(taskwarriorTimeLayout)
+			}
+		}
+
+		var status string
+		if task.Status != nil {
+			status = *task.Status
+		}
+		switch {
+		case StatusType(status) == StatusDone:
+			tw.Status, tw.End = "completed", tw.Modified
+		case task.Archived:
+			tw.Status, tw.End = "deleted", tw.Modified
+		default:
+			tw.Status = "pending"
+			if StatusType(status) == StatusDoing {
+				tw.Start = tw.Modified
+			}
+			if task.Recurrence != nil && tw.Due != "" {
+				if recur, ok := recurrenceToTaskwarrior(*task.Recurrence); ok {
+					tw.Status, tw.Recur = "recurring", recur
+				}
+			}
+		}
+
+		for _, note := range taskNotes[task.ID] {
+			tw.Annotations = append(tw.Annotations, noteAnnotations(note, tw.Entry)...)
+		}
+		tasks = append(tasks, tw)
+	}
+	return tasks, nil
+}
+
+// WriteTaskwarrior writes tasks as the JSON array 'task import' expects
+func WriteTaskwarrior(w io.Writer, tasks []TaskwarriorTask) error {
+	if tasks == nil {
+		tasks = []TaskwarriorTask{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(tasks)
+}
+
+// TaskwarriorUUID derives a stable name based UUID (version 5 layout) from a task ID and the time the task was created
+func TaskwarriorUUID(taskID int64, createdAt string) string {
+	sum := sha1.Sum([]byte("go_task/task/" + strconv.FormatInt(taskID, 10) + "/" + createdAt))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+func taskwarriorTimestamp(stored string) string {
+	parsed, err := time.ParseInLocation(storedTimeLayout, stored, time.Local)
+	if err != nil {
+		return ""
+	}
+	return parsed.UTC().Format(taskwarriorTimeLayout)
+}
+
+// recurrenceToTaskwarrior maps a stored rule onto a recur value, weekdays of weekly rules other than Mon-Fri
+// and days of monthly rules have no Taskwarrior equivalent and are dropped
+func recurrenceToTaskwarrior(stored string) (string, bool) {
+	rule, err := ParseRecurrence(stored)
+	if err != nil {
+		return "", false
+	}
+	if rule.Frequency == FrequencyWeekly && rule.Interval == 1 && len(rule.ByDay) == 5 {
+		weekdays := true
+		for _, day := range rule.ByDay {
+			weekdays = weekdays && day != time.Saturday && day != time.Sunday
+		}
+		if weekdays {
+			return "weekdays", true
+		}
+	}
+	names := map[Frequency][2]string{
+		FrequencyDaily:   {"daily", "d"},
+		FrequencyWeekly:  {"weekly", "w"},
+		FrequencyMonthly: {"monthly", "mo"},
+		FrequencyYearly:  {"yearly", "y"},
+	}
+	name, ok := names[rule.Frequency]
+	if !ok {
+		return "", false
+	}
+	if rule.Interval <= 1 {
+		return name[0], true
+	}
+	return fmt.Sprintf("%d%s", rule.Interval, name[1]), true
+}
+
+// noteAnnotations reads the annotation lines of a note, a note without them becomes a single annotation pointing at it
+func noteAnnotations(note BundleNote, fallbackEntry string) []TaskwarriorAnnotation {
+	var annotations []TaskwarriorAnnotation
+	if content, err := os.ReadFile(note.Path); err == nil {
+		for _, line := range strings.Split(string(content), "\n") {
+			match := annotationLine.FindStringSubmatch(strings.TrimRight(line, "\r"))
+			if match == nil {
+				continue
+			}
+			stamp, err := time.ParseInLocation(annotationTimeLayout, match[1], time.Local)
+			if err != nil {
+				continue
+			}
+			annotations = append(annotations, TaskwarriorAnnotation{
+				Entry:       stamp.UTC().Format(taskwarriorTimeLayout),
+				Description: match[2],
+			})
+		}
+	}
+	if len(annotations) > 0 {
+		return annotations
+	}
+	return []TaskwarriorAnnotation{{Entry: fallbackEntry, Description: fmt.Sprintf("Note: %s (%s)", note.Title, note.Path)}}
+}
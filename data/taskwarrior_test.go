@@ -0,0 +1,195 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+const taskwarriorSample = `[
+{"id":1,"description":"Write report","status":"pending","entry":"20241101T090000Z","modified":"20241102T090000Z","start":"20241102T090000Z","uuid":"aaaaaaaa-0000-4000-8000-000000000001","priority":"H","project":"Work","tags":["finance","q4"],"due":"20241108T120000Z","annotations":[{"entry":"20241102T100000Z","description":"ask Sam for the numbers"}]},
+{"id":2,"description":"Send report","status":"waiting","entry":"20241101T090000Z","uuid":"aaaaaaaa-0000-4000-8000-000000000002","priority":"L","project":"work","depends":"aaaaaaaa-0000-4000-8000-000000000001"},
+{"id":0,"description":"Old idea","status":"deleted","entry":"20241001T090000Z","uuid":"aaaaaaaa-0000-4000-8000-000000000003"},
+{"id":0,"description":"Water plants","status":"recurring","recur":"2w","entry":"20241001T090000Z","due":"20241101T120000Z","uuid":"aaaaaaaa-0000-4000-8000-000000000004"},
+{"id":3,"description":"Water plants","status":"pending","parent":"aaaaaaaa-0000-4000-8000-000000000004","due":"20241115T120000Z","uuid":"aaaaaaaa-0000-4000-8000-000000000005"},
+{"id":4,"description":"Odd schedule","status":"pending","recur":"P1DT12H","uuid":"aaaaaaaa-0000-4000-8000-000000000006"}
+]`
+
+func TestReadTaskwarrior(t *testing.T) {
+	array, err := ReadTaskwarrior(strings.NewReader(taskwarriorSample))
+	if err != nil {
+		t.Fatalf("ReadTaskwarrior(array) error = %v", err)
+	}
+	if len(array) != 6 {
+		t.Fatalf("ReadTaskwarrior(array) read %d tasks, want 6", len(array))
+	}
+	if want := (TaskwarriorDepends{"aaaaaaaa-0000-4000-8000-000000000001"}); !reflect.DeepEqual(array[1].Depends, want) {
+		t.Errorf("depends = %v, want %v", array[1].Depends, want)
+	}
+
+	lines := `{"description":"one","status":"pending","depends":["a","b"]}
+{"description":"two","status":"completed"}`
+	perLine, err := ReadTaskwarrior(strings.NewReader(lines))
+	if err != nil {
+		t.Fatalf("ReadTaskwarrior(lines) error = %v", err)
+	}
+	if len(perLine) != 2 || len(perLine[0].Depends) != 2 {
+		t.Errorf("ReadTaskwarrior(lines) = %+v", perLine)
+	}
+
+	if _, err := ReadTaskwarrior(strings.NewReader(`[{"description": 3}]`)); err == nil {
+		t.Error("ReadTaskwarrior() accepted a broken export")
+	}
+}
+
+func TestTaskwarriorRecurrence(t *testing.T) {
+	tests := []struct {
+		recur string
+		want  string
+		ok    bool
+	}{
+		{recur: "weekly", want: "FREQ=WEEKLY", ok: true},
+		{recur: "2w", want: "FREQ=WEEKLY;INTERVAL=2", ok: true},
+		{recur: "3 days", want: "FREQ=DAILY;INTERVAL=3", ok: true},
+		{recur: "quarterly", want: "FREQ=MONTHLY;INTERVAL=3", ok: true},
+		{recur: "weekdays", want: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", ok: true},
+		{recur: "P1DT12H"},
+		{recur: "3h"},
+	}
+	for _, tt := range tests {
+		got, ok := taskwarriorRecurrence(tt.recur)
+		if ok != tt.ok || got.String != tt.want {
+			t.Errorf("taskwarriorRecurrence(%q) = %q, %t, want %q, %t", tt.recur, got.String, ok, tt.want, tt.ok)
+		}
+		if !ok {
+			continue
+		}
+		back, ok := recurrenceToTaskwarrior(got.String)
+		if !ok {
+			t.Errorf("recurrenceToTaskwarrior(%q) failed", got.String)
+			continue
+		}
+		if again, _ := taskwarriorRecurrence(back); again != got {
+			t.Errorf("recurrence %q did not survive a round trip, got %q back", got.String, again.String)
+		}
+	}
+}
+
+func TestTaskwarriorRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	notesPath := t.TempDir()
+
+	tasks, err := ReadTaskwarrior(strings.NewReader(taskwarriorSample))
+	if err != nil {
+		t.Fatalf("ReadTaskwarrior() error = %v", err)
+	}
+	stats, err := ImportTaskwarrior(ctx, conn, tasks, notesPath, testNow)
+	if err != nil {
+		t.Fatalf("ImportTaskwarrior() error = %v", err)
+	}
+	wantStats := TaskwarriorImportStats{
+		Tasks:              5,
+		AreasCreated:       1,
+		Tags:               2,
+		Notes:              1,
+		Dependencies:       1,
+		SkippedInstances:   1,
+		DroppedRecurrences: []string{"Odd schedule (P1DT12H)"},
+	}
+	if !reflect.DeepEqual(stats, wantStats) {
+		t.Errorf("ImportTaskwarrior() stats = %+v, want %+v", stats, wantStats)
+	}
+
+	queries := sqlc.New(conn)
+	rows, err := queries.ExportTasks(ctx)
+	if err != nil {
+		t.Fatalf("ExportTasks() error = %v", err)
+	}
+	type imported struct {
+		Title, Priority, Status, Due, Recurrence string
+		Archived, HasArea                        bool
+	}
+	var got []imported
+	for _, row := range rows {
+		got = append(got, imported{
+			Title: row.Title, Priority: row.Priority.String, Status: row.Status.String, Due: row.DueDate.String,
+			Recurrence: row.Recurrence.String, Archived: row.Archived, HasArea: row.AreaID.Valid,
+		})
+	}
+	want := []imported{
+		{Title: "Write report", Priority: "high", Status: "doing", Due: localDay("20241108T120000Z"), HasArea: true},
+		{Title: "Send report", Priority: "low", Status: "planning", HasArea: true},
+		{Title: "Old idea", Status: "done", Archived: true},
+		{Title: "Water plants", Status: "todo", Due: localDay("20241101T120000Z"), Recurrence: "FREQ=WEEKLY;INTERVAL=2"},
+		{Title: "Odd schedule", Status: "todo"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("imported tasks = %+v\nwant %+v", got, want)
+	}
+
+	notes, err := queries.ExportNotes(ctx)
+	if err != nil || len(notes) != 1 {
+		t.Fatalf("ExportNotes() = %v, %v, want one note", notes, err)
+	}
+	content, err := os.ReadFile(notes[0].Path)
+	if err != nil {
+		t.Fatalf("reading annotation note: %v", err)
+	}
+	if !strings.Contains(string(content), "ask Sam for the numbers") {
+		t.Errorf("annotation note is missing the annotation:\n%s", content)
+	}
+
+	exported, err := ExportTaskwarrior(ctx, conn, testNow)
+	if err != nil {
+		t.Fatalf("ExportTaskwarrior() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteTaskwarrior(&buf, exported); err != nil {
+		t.Fatalf("WriteTaskwarrior() error = %v", err)
+	}
+	back, err := ReadTaskwarrior(&buf)
+	if err != nil {
+		t.Fatalf("ReadTaskwarrior(exported) error = %v", err)
+	}
+	if len(back) != 5 {
+		t.Fatalf("exported %d tasks, want 5", len(back))
+	}
+	report, send, idea, plants := back[0], back[1], back[2], back[3]
+	if report.Status != "pending" || report.Start == "" || report.Priority != "H" || report.Project != "Work" || localDay(report.Due) != localDay("20241108T120000Z") {
+		t.Errorf("exported report = %+v", report)
+	}
+	if !reflect.DeepEqual(report.Tags, []string{"finance", "q4"}) {
+		t.Errorf("exported tags = %v", report.Tags)
+	}
+	wantAnnotation := TaskwarriorAnnotation{Entry: "20241102T100000Z", Description: "ask Sam for the numbers"}
+	if len(report.Annotations) != 1 || report.Annotations[0] != wantAnnotation {
+		t.Errorf("exported annotations = %+v, want %+v", report.Annotations, wantAnnotation)
+	}
+	if !reflect.DeepEqual(send.Depends, TaskwarriorDepends{report.UUID}) {
+		t.Errorf("exported depends = %v, want %v", send.Depends, report.UUID)
+	}
+	if idea.Status != "completed" {
+		t.Errorf("exported deleted task has status %s, want completed", idea.Status)
+	}
+	if plants.Status != "recurring" || plants.Recur != "2w" {
+		t.Errorf("exported recurring task = %+v", plants)
+	}
+	if report.UUID != TaskwarriorUUID(1, rows[0].CreatedAt) || TaskwarriorUUID(1, rows[0].CreatedAt) == TaskwarriorUUID(2, rows[0].CreatedAt) {
+		t.Errorf("task UUIDs are not stable: %s", report.UUID)
+	}
+	// A task that reuses the ID of a deleted one is a different Taskwarrior task
+	if TaskwarriorUUID(1, rows[0].CreatedAt) == TaskwarriorUUID(1, "2030-01-01 00:00:00") {
+		t.Errorf("TaskwarriorUUID() does not depend on the creation time")
+	}
+}
+
+func localDay(timestamp string) string {
+	due, _ := taskwarriorDueDate(timestamp)
+	return due.String
+}
@@ -0,0 +1,273 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/akthe-at/go_task/config"
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+// NoteTemplateExt is the file extension of note templates in the templates directory
+const NoteTemplateExt = ".md.tmpl"
+
+// NoNoteTemplate skips the default template of an area
+const NoNoteTemplate = "none"
+
+var ErrNoteTemplateNotFound = errors.New("note template not found")
+
+//go:embed templates/*.md.tmpl
+var defaultNoteTemplates embed.FS
+
+// noteTemplateFuncs are the functions available to note templates on top of the text/template builtins
+var noteTemplateFuncs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// NoteTemplateData is what a note template is executed with
+type NoteTemplateData struct {
+	Title string
+	// Date is the day the note is generated on as YYYY-MM-DD, Now holds the full time for other layouts
+	Date     string
+	Now      time.Time
+	Tags     []string
+	Body     string
+	RepoPath string
+	// Task is nil for area notes, Area is nil for notes of tasks without an area
+	Task *TemplateTask
+	Area *TemplateArea
+}
+
+type TemplateTask struct {
+	ID         int64
+	Title      string
+	Priority   string
+	Status     string
+	DueDate    string
+	Recurrence string
+	Tags       []string
+}
+
+type TemplateArea struct {
+	ID     int64
+	Title  string
+	Status string
+	Tags   []string
+}
+
+// NoteTemplateRequest describes the note a template is rendered for
+type NoteTemplateRequest struct {
+	// Dir is the templates directory, templates missing from it fall back to the built-in ones
+	Dir string
+	// Name is blank to use the default template of the area, or NoNoteTemplate to use none
+	Name     string
+	NoteType NoteType
+	ParentID int64
+	Title    string
+	Body     string
+	Tags     []string
+	// RepoPath falls back to the programming project linked to the task or area
+	RepoPath string
+}
+
+// InstallNoteTemplates writes the built-in templates to dir the first time it is used,
+// an existing directory is left alone so deleted or edited templates stay that way
+func InstallNoteTemplates(dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to check the templates directory %s: %w", dir, err)
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create the templates directory %s: %w", dir, err)
+	}
+
+	entries, err := defaultNoteTemplates.ReadDir("templates")
+	if err != nil {
+		return fmt.Errorf("failed to read the built-in templates: %w", err)
+	}
+	for _, entry := range entries {
+		content, err := defaultNoteTemplates.ReadFile("templates/" + entry.Name())
+		if err != nil {
+			return fmt.Errorf("failed to read the built-in template %s: %w", entry.Name(), err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, entry.Name()), content, 0644); err != nil {
+			return fmt.Errorf("failed to write template %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// NoteTemplateNames lists the templates in dir together with the built-in ones, sorted by name
+func NoteTemplateNames(dir string) ([]string, error) {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(entries []fs.DirEntry) {
+		for _, entry := range entries {
+			name, ok := strings.CutSuffix(entry.Name(), NoteTemplateExt)
+			if !ok || entry.IsDir() || seen[name] {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read the templates directory %s: %w", dir, err)
+	}
+	add(entries)
+	entries, err = defaultNoteTemplates.ReadDir("templates")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the built-in templates: %w", err)
+	}
+	add(entries)
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// LoadNoteTemplate parses the template called name from dir, falling back to the built-in template with that name
+func LoadNoteTemplate(dir, name string) (*template.Template, error) {
+	if name == "" || name != filepath.Base(name) {
+		return nil, fmt.Errorf("%w: %q", ErrNoteTemplateNotFound, name)
+	}
+	fileName := name + NoteTemplateExt
+
+	content, err := os.ReadFile(filepath.Join(dir, fileName))
+	if errors.Is(err, os.ErrNotExist) {
+		content, err = defaultNoteTemplates.ReadFile("templates/" + fileName)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q, templates live in %s", ErrNoteTemplateNotFound, name, dir)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+	}
+
+	tmpl, err := template.New(fileName).Funcs(noteTemplateFuncs).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+/*
+RenderNoteBody renders the body of a new note from a template.
+ 1. A blank template name uses the default template of the area of the task, or of the area itself for area notes
+ 2. Without a template, or with NoNoteTemplate, the body is returned as it was given
+ 3. The template is executed with the fields of the task and area the note belongs to, see NoteTemplateData
+*/
+func RenderNoteBody(ctx context.Context, queries *sqlc.Queries, req NoteTemplateRequest, now time.Time) (string, error) {
+	data := NoteTemplateData{
+		Title:    req.Title,
+		Date:     now.Format(DueDateLayout),
+		Now:      now,
+		Tags:     req.Tags,
+		Body:     req.Body,
+		RepoPath: req.RepoPath,
+	}
+
+	var areaID sql.NullInt64
+	switch req.NoteType {
+	case TaskNoteType:
+		task, err := queries.ReadTaskForRecurrence(ctx, req.ParentID)
+		if err != nil {
+			return "", fmt.Errorf("failed to read task %d: %w", req.ParentID, err)
+		}
+		tags, err := queries.ReadTaskTags(ctx, task.ID)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the tags of task %d: %w", task.ID, err)
+		}
+		data.Task = &TemplateTask{
+			ID:         task.ID,
+			Title:      task.Title,
+			Priority:   task.Priority.String,
+			Status:     task.Status.String,
+			DueDate:    task.DueDate.String,
+			Recurrence: task.Recurrence.String,
+			Tags:       tags,
+		}
+		areaID = task.AreaID
+		if data.RepoPath == "" {
+			project, err := queries.FindProgProjectsForTask(ctx, sql.NullInt64{Int64: task.ID, Valid: true})
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return "", fmt.Errorf("failed to read the project of task %d: %w", task.ID, err)
+			}
+			data.RepoPath = project.Path
+		}
+	case AreaNoteType:
+		areaID = sql.NullInt64{Int64: req.ParentID, Valid: true}
+	}
+
+	name := req.Name
+	if areaID.Valid {
+		area, err := queries.ReadArea(ctx, areaID.Int64)
+		if err != nil {
+			return "", fmt.Errorf("failed to read area %d: %w", areaID.Int64, err)
+		}
+		tags, err := queries.ReadAreaTags(ctx, area.ID)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the tags of area %d: %w", area.ID, err)
+		}
+		data.Area = &TemplateArea{ID: area.ID, Title: area.Title, Status: area.Status.String, Tags: tags}
+
+		if name == "" {
+			defaultName, err := queries.ReadAreaNoteTemplate(ctx, area.ID)
+			if err != nil {
+				return "", fmt.Errorf("failed to read the note template of area %d: %w", area.ID, err)
+			}
+			name = defaultName.String
+		}
+		if data.RepoPath == "" {
+			projects, err := queries.FindProgProjectsForArea(ctx, areaID)
+			if err != nil {
+				return "", fmt.Errorf("failed to read the projects of area %d: %w", area.ID, err)
+			}
+			if len(projects) > 0 {
+				data.RepoPath = projects[0].Path
+			}
+		}
+	}
+	if name == "" || name == NoNoteTemplate {
+		return req.Body, nil
+	}
+
+	tmpl, err := LoadNoteTemplate(req.Dir, name)
+	if err != nil {
+		return "", err
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return body.String(), nil
+}
+
+// GenerateTemplatedNote writes a new note rendered from a template to the notes path and returns its path,
+// notes written for Obsidian link back to the notes of their task and area
+func GenerateTemplatedNote(ctx context.Context, queries *sqlc.Queries, req NoteTemplateRequest, now time.Time) (string, error) {
+	body, err := RenderNoteBody(ctx, queries, req, now)
+	if err != nil {
+		return "", err
+	}
+	var parentLinks []string
+	if config.UserSettings.Selected.UseObsidian {
+		parentLinks, err = ParentNoteLinks(ctx, queries, req.NoteType, req.ParentID)
+		if err != nil {
+			return "", err
+		}
+	}
+	return TemplateMarkdownNote(req.Title, GenerateNoteID(req.Title), body, nil, req.Tags, parentLinks)
+}
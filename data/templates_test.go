@@ -0,0 +1,145 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+func TestNoteTemplates(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "templates")
+	if err := InstallNoteTemplates(dir); err != nil {
+		t.Fatalf("InstallNoteTemplates() error = %v", err)
+	}
+	builtIn := []string{"bug", "daily", "design-doc", "meeting"}
+	names, err := NoteTemplateNames(dir)
+	if err != nil || !reflect.DeepEqual(names, builtIn) {
+		t.Errorf("NoteTemplateNames() = %v, %v, want %v", names, err, builtIn)
+	}
+
+	// A deleted template is not installed again but still falls back to the built-in one
+	if err := os.Remove(filepath.Join(dir, "bug"+NoteTemplateExt)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "retro"+NoteTemplateExt), []byte("# {{.Title}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := InstallNoteTemplates(dir); err != nil {
+		t.Fatalf("InstallNoteTemplates() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bug"+NoteTemplateExt)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("bug template was installed again, stat error = %v", err)
+	}
+	names, err = NoteTemplateNames(dir)
+	if want := []string{"bug", "daily", "design-doc", "meeting", "retro"}; err != nil || !reflect.DeepEqual(names, want) {
+		t.Errorf("NoteTemplateNames() = %v, %v, want %v", names, err, want)
+	}
+
+	tests := []struct {
+		name    string
+		wantErr error
+	}{
+		{name: "bug"},
+		{name: "retro"},
+		{name: "standup", wantErr: ErrNoteTemplateNotFound},
+		{name: "../config", wantErr: ErrNoteTemplateNotFound},
+	}
+	for _, tt := range tests {
+		if _, err := LoadNoteTemplate(dir, tt.name); !errors.Is(err, tt.wantErr) {
+			t.Errorf("LoadNoteTemplate(%q) error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRenderNoteBody(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+
+	areaID, err := queries.ImportArea(ctx, sqlc.ImportAreaParams{
+		Title: "Work", CreatedAt: "2024-11-01 09:00:00", LastMod: "2024-11-01 09:00:00",
+		NoteTemplate: sql.NullString{String: "standup", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("ImportArea() error = %v", err)
+	}
+	taskID, err := queries.ImportTask(ctx, sqlc.ImportTaskParams{
+		Title: "Fix login", Priority: sql.NullString{String: "high", Valid: true}, DueDate: sql.NullString{String: "2024-11-08", Valid: true},
+		CreatedAt: "2024-11-01 09:00:00", LastMod: "2024-11-01 09:00:00", AreaID: sql.NullInt64{Int64: areaID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("ImportTask() error = %v", err)
+	}
+	if err := AddTaskTags(ctx, queries, taskID, []string{"auth", "backend"}); err != nil {
+		t.Fatalf("AddTaskTags() error = %v", err)
+	}
+	projectID, err := queries.InsertProgProject(ctx, "/src/webapp")
+	if err != nil {
+		t.Fatalf("InsertProgProject() error = %v", err)
+	}
+	err = queries.CreateProjectAreaLink(ctx, sqlc.CreateProjectAreaLinkParams{
+		ProjectID:    sql.NullInt64{Int64: projectID, Valid: true},
+		ParentCat:    sql.NullInt64{Int64: int64(AreaNoteType), Valid: true},
+		ParentAreaID: sql.NullInt64{Int64: areaID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateProjectAreaLink() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	standup := "{{.Date}} {{.Area.Title}}{{with .Task}} #{{.ID}} {{.Title}} [{{join .Tags \",\"}}]{{end}} {{.RepoPath}}\n{{.Body}}"
+	if err := os.WriteFile(filepath.Join(dir, "standup"+NoteTemplateExt), []byte(standup), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		req  NoteTemplateRequest
+		want string
+	}{
+		{
+			name: "area default for a task note",
+			req:  NoteTemplateRequest{NoteType: TaskNoteType, ParentID: taskID, Body: "notes"},
+			want: "2024-11-05 Work #1 Fix login [auth,backend] /src/webapp\nnotes",
+		},
+		{
+			name: "area default for an area note, the repo path passed in wins",
+			req:  NoteTemplateRequest{NoteType: AreaNoteType, ParentID: areaID, RepoPath: "/src/other"},
+			want: "2024-11-05 Work /src/other\n",
+		},
+		{
+			name: "none skips the area default",
+			req:  NoteTemplateRequest{Name: NoNoteTemplate, NoteType: TaskNoteType, ParentID: taskID, Body: "just text"},
+			want: "just text",
+		},
+		{
+			name: "built-in template",
+			req:  NoteTemplateRequest{Name: "bug", NoteType: TaskNoteType, ParentID: taskID, Title: "Login loops", Tags: []string{"bug"}},
+			want: "# Login loops\n\nReported: 2024-11-05\nRepository: /src/webapp\nTask: Fix login (#1), high priority\nTags: bug\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Dir = dir
+			got, err := RenderNoteBody(ctx, queries, tt.req, testNow)
+			if err != nil {
+				t.Fatalf("RenderNoteBody() error = %v", err)
+			}
+			if !strings.HasPrefix(got, tt.want) {
+				t.Errorf("RenderNoteBody() =\n%s\nwant it to start with\n%s", got, tt.want)
+			}
+		})
+	}
+
+	_, err = RenderNoteBody(ctx, queries, NoteTemplateRequest{Dir: dir, Name: "missing", NoteType: AreaNoteType, ParentID: areaID}, testNow)
+	if !errors.Is(err, ErrNoteTemplateNotFound) {
+		t.Errorf("RenderNoteBody() with a missing template error = %v, want ErrNoteTemplateNotFound", err)
+	}
+}
@@ -0,0 +1,273 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+const (
+	TimeByArea TimeReportGroup = "area"
+	TimeByTask TimeReportGroup = "task"
+	TimeByRepo TimeReportGroup = "repo"
+)
+
+// TimeReportGroup is what the rows of a time report add up
+type TimeReportGroup string
+
+// ErrTimerRunning is returned by StartTimer when the timer already runs on the task
+var ErrTimerRunning = errors.New("the timer is already running on this task")
+
+// TimeEntry is a stretch of time logged on a task, Duration is zero while its timer runs
+type TimeEntry struct {
+	ID        int64
+	TaskID    int64
+	TaskTitle string
+	StartedAt time.Time
+	Duration  time.Duration
+}
+
+// Elapsed is how long the timer of a running entry has been going at now
+func (e TimeEntry) Elapsed(now time.Time) time.Duration {
+	if now.Before(e.StartedAt) {
+		return 0
+	}
+	return now.Sub(e.StartedAt).Truncate(time.Second)
+}
+
+// TimeReportRow is the time logged on one task, area, or repo
+type TimeReportRow struct {
+	Name     string
+	Duration time.Duration
+}
+
+// StringToTimeReportGroup converts a string to a TimeReportGroup
+func StringToTimeReportGroup(s string) (TimeReportGroup, error) {
+	switch s {
+	case string(TimeByArea):
+		return TimeByArea, nil
+	case string(TimeByTask):
+		return TimeByTask, nil
+	case string(TimeByRepo):
+		return TimeByRepo, nil
+	default:
+		return "", fmt.Errorf("invalid report group ( %s ) must be one of area, task, or repo", s)
+	}
+}
+
+// ParseTimeSpent parses the time spent on a task, e.g. 1h30m, 45m, or 2h
+func ParseTimeSpent(input string) (time.Duration, error) {
+	spent, err := time.ParseDuration(strings.ToLower(strings.TrimSpace(input)))
+	if err != nil || spent < time.Second {
+		return 0, fmt.Errorf("invalid time ( %s ) must be a positive duration such as 1h30m, 45m, or 2h", input)
+	}
+	return spent.Truncate(time.Second), nil
+}
+
+// FormatTimeSpent renders logged time in hours and minutes, e.g. 1h30m or 45m
+func FormatTimeSpent(d time.Duration) string {
+	minutes := int64(d / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
+
+// ResolveSince turns the --since input of a report into the start of a day, a window such as 7d or 2w counts back from today
+func ResolveSince(input string, now time.Time) (time.Time, error) {
+	if strings.TrimSpace(input) == "" {
+		return time.Time{}, nil
+	}
+	if days, err := ParseDayWindow(input); err == nil {
+		return startOfDay(now).AddDate(0, 0, -days), nil
+	}
+	since, err := ResolveDate(input, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid since ( %s ) must be a window such as 7d or 2w, or a date such as yesterday or YYYY-MM-DD", input)
+	}
+	return since, nil
+}
+
+// RunningTimer returns the entry whose timer runs, ok is false when no timer runs
+func RunningTimer(ctx context.Context, queries *sqlc.Queries) (TimeEntry, bool, error) {
+	running, err := queries.ReadRunningTimeEntry(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return TimeEntry{}, false, nil
+	} else if err != nil {
+		return TimeEntry{}, false, fmt.Errorf("failed to read the running timer: %w", err)
+	}
+	startedAt, err := time.ParseInLocation(storedTimeLayout, running.StartedAt, time.Local)
+	if err != nil {
+		return TimeEntry{}, false, fmt.Errorf("the timer of task %d has an invalid start ( %s ): %w", running.TaskID, running.StartedAt, err)
+	}
+	return TimeEntry{ID: running.ID, TaskID: running.TaskID, TaskTitle: running.Title, StartedAt: startedAt}, true, nil
+}
+
+/*
+StartTimer starts the timer on a task.
+ 1. Only one timer runs at a time, a timer running on another task is stopped first and returned as stopped
+ 2. Starting the timer on the task it already runs on fails with ErrTimerRunning
+*/
+func StartTimer(ctx context.Context, conn *sql.DB, taskID int64, now time.Time) (TimeEntry, []TimeEntry, error) {
+	var stopped []TimeEntry
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return TimeEntry{}, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	queries := sqlc.New(tx)
+
+	task, err := queries.ReadTaskForRecurrence(ctx, taskID)
+	if err != nil {
+		return TimeEntry{}, nil, fmt.Errorf("could not find task %d: %w", taskID, err)
+	}
+	running, ok, err := RunningTimer(ctx, queries)
+	if err != nil {
+		return TimeEntry{}, nil, err
+	}
+	if ok && running.TaskID == taskID {
+		return running, nil, ErrTimerRunning
+	}
+	if ok {
+		running, err = stopEntry(ctx, queries, running, now)
+		if err != nil {
+			return TimeEntry{}, nil, err
+		}
+		stopped = append(stopped, running)
+	}
+
+	started := TimeEntry{TaskID: taskID, TaskTitle: task.Title, StartedAt: now.Truncate(time.Second)}
+	started.ID, err = queries.CreateTimeEntry(ctx, sqlc.CreateTimeEntryParams{TaskID: taskID, StartedAt: now.Format(storedTimeLayout)})
+	if err != nil {
+		return TimeEntry{}, nil, fmt.Errorf("failed to start the timer on task %d: %w", taskID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return TimeEntry{}, nil, fmt.Errorf("failed to commit the timer of task %d: %w", taskID, err)
+	}
+	return started, stopped, nil
+}
+
+// StopTimer stops the running timer and logs the time since it started, ok is false when no timer runs
+func StopTimer(ctx context.Context, conn *sql.DB, now time.Time) (TimeEntry, bool, error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return TimeEntry{}, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	queries := sqlc.New(tx)
+
+	running, ok, err := RunningTimer(ctx, queries)
+	if err != nil || !ok {
+		return TimeEntry{}, false, err
+	}
+	stopped, err := stopEntry(ctx, queries, running, now)
+	if err != nil {
+		return TimeEntry{}, false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return TimeEntry{}, false, fmt.Errorf("failed to commit the timer of task %d: %w", running.TaskID, err)
+	}
+	return stopped, true, nil
+}
+
+func stopEntry(ctx context.Context, queries *sqlc.Queries, running TimeEntry, now time.Time) (TimeEntry, error) {
+	running.Duration = running.Elapsed(now)
+	_, err := queries.StopTimeEntry(ctx, sqlc.StopTimeEntryParams{
+		Seconds: sql.NullInt64{Int64: int64(running.Duration / time.Second), Valid: true},
+		ID:      running.ID,
+	})
+	if err != nil {
+		return running, fmt.Errorf("failed to stop the timer of task %d: %w", running.TaskID, err)
+	}
+	return running, nil
+}
+
+// AddTime logs time spent on a task on the given day, for work that was not timed
+func AddTime(ctx context.Context, queries *sqlc.Queries, taskID int64, spent time.Duration, day time.Time) (TimeEntry, error) {
+	return logTime(ctx, queries, taskID, startOfDay(day), spent)
+}
+
+func logTime(ctx context.Context, queries *sqlc.Queries, taskID int64, startedAt time.Time, spent time.Duration) (TimeEntry, error) {
+	if spent < time.Second {
+		return TimeEntry{}, fmt.Errorf("invalid time ( %s ) must be positive", spent)
+	}
+	task, err := queries.ReadTaskForRecurrence(ctx, taskID)
+	if err != nil {
+		return TimeEntry{}, fmt.Errorf("could not find task %d: %w", taskID, err)
+	}
+	entry := TimeEntry{TaskID: taskID, TaskTitle: task.Title, StartedAt: startedAt.Truncate(time.Second), Duration: spent.Truncate(time.Second)}
+	entry.ID, err = queries.CreateTimeEntry(ctx, sqlc.CreateTimeEntryParams{
+		TaskID:    taskID,
+		StartedAt: entry.StartedAt.Format(storedTimeLayout),
+		Seconds:   sql.NullInt64{Int64: int64(entry.Duration / time.Second), Valid: true},
+	})
+	if err != nil {
+		return TimeEntry{}, fmt.Errorf("failed to log time on task %d: %w", taskID, err)
+	}
+	return entry, nil
+}
+
+/*
+TimeReport adds up the time logged since the given day.
+ 1. Rows are grouped by area, task, or repo, tasks without an area or repo are reported as "(no area)" and "(no repo)"
+ 2. Time of a task linked to several repos counts for each of them
+ 3. Only stopped timers count, the running timer is logged once it stops
+ 4. Rows are ordered by the most time logged, then by name
+*/
+func TimeReport(ctx context.Context, queries *sqlc.Queries, by TimeReportGroup, since time.Time) ([]TimeReportRow, error) {
+	var startedAt string
+	if !since.IsZero() {
+		startedAt = since.Format(storedTimeLayout)
+	}
+	logged, err := queries.ReadTimeByTask(ctx, startedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the logged time: %w", err)
+	}
+
+	totals := make(map[string]time.Duration)
+	seen := make(map[string]bool)
+	for _, row := range logged {
+		var name string
+		switch by {
+		case TimeByArea:
+			name = row.AreaTitle.String
+			if !row.AreaTitle.Valid {
+				name = "(no area)"
+			}
+		case TimeByTask:
+			name = fmt.Sprintf("%d %s", row.ID, row.Title)
+		case TimeByRepo:
+			name = row.Repo.String
+			if !row.Repo.Valid {
+				name = "(no repo)"
+			}
+		default:
+			return nil, fmt.Errorf("invalid report group ( %s ) must be one of area, task, or repo", by)
+		}
+		// A task with several repos comes back once per repo, the other groups count its time once
+		key := fmt.Sprintf("%s\x00%d", name, row.ID)
+		if by != TimeByRepo && seen[key] {
+			continue
+		}
+		seen[key] = true
+		totals[name] += time.Duration(row.Seconds) * time.Second
+	}
+
+	report := make([]TimeReportRow, 0, len(totals))
+	for name, total := range totals {
+		report = append(report, TimeReportRow{Name: name, Duration: total})
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Duration != report[j].Duration {
+			return report[i].Duration > report[j].Duration
+		}
+		return report[i].Name < report[j].Name
+	})
+	return report, nil
+}
@@ -0,0 +1,180 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+func TestParseTimeSpent(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "1h30m", want: 90 * time.Minute},
+		{input: " 45M ", want: 45 * time.Minute},
+		{input: "1.5h", want: 90 * time.Minute},
+		{input: "0m", wantErr: true},
+		{input: "-1h", wantErr: true},
+		{input: "90", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimeSpent(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseTimeSpent(%q) = %v, %v, want %v, wantErr %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFormatTimeSpent(t *testing.T) {
+	tests := []struct {
+		spent time.Duration
+		want  string
+	}{
+		{spent: 0, want: "0m"},
+		{spent: 59 * time.Second, want: "0m"},
+		{spent: 45 * time.Minute, want: "45m"},
+		{spent: 90 * time.Minute, want: "1h30m"},
+		{spent: 26*time.Hour + 5*time.Minute, want: "26h05m"},
+	}
+	for _, tt := range tests {
+		if got := FormatTimeSpent(tt.spent); got != tt.want {
+			t.Errorf("FormatTimeSpent(%v) = %q, want %q", tt.spent, got, tt.want)
+		}
+	}
+}
+
+func TestResolveSince(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{input: ""},
+		{input: "7d", want: time.Date(2024, 10, 29, 0, 0, 0, 0, time.Local)},
+		{input: "2w", want: time.Date(2024, 10, 22, 0, 0, 0, 0, time.Local)},
+		{input: "yesterday", want: time.Date(2024, 11, 4, 0, 0, 0, 0, time.Local)},
+		{input: "2024-11-01", want: time.Date(2024, 11, 1, 0, 0, 0, 0, time.Local)},
+		{input: "last year", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ResolveSince(tt.input, testNow)
+		if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+			t.Errorf("ResolveSince(%q) = %v, %v, want %v, wantErr %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestTimeTracking(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+
+	areaID, err := queries.ImportArea(ctx, sqlc.ImportAreaParams{Title: "Clients", CreatedAt: "2024-11-01 09:00:00", LastMod: "2024-11-01 09:00:00"})
+	if err != nil {
+		t.Fatalf("ImportArea() error = %v", err)
+	}
+	var taskIDs []int64
+	for _, title := range []string{"Invoice", "Website", "Errands"} {
+		params := sqlc.ImportTaskParams{Title: title, CreatedAt: "2024-11-01 09:00:00", LastMod: "2024-11-01 09:00:00"}
+		if title != "Errands" {
+			params.AreaID = sql.NullInt64{Int64: areaID, Valid: true}
+		}
+		taskID, err := queries.ImportTask(ctx, params)
+		if err != nil {
+			t.Fatalf("ImportTask() error = %v", err)
+		}
+		taskIDs = append(taskIDs, taskID)
+	}
+	for _, path := range []string{"/src/site", "/src/shop"} {
+		projectID, err := queries.InsertProgProject(ctx, path)
+		if err != nil {
+			t.Fatalf("InsertProgProject() error = %v", err)
+		}
+		err = queries.CreateProjectTaskLink(ctx, sqlc.CreateProjectTaskLinkParams{
+			ProjectID:    sql.NullInt64{Int64: projectID, Valid: true},
+			ParentCat:    sql.NullInt64{Int64: int64(TaskNoteType), Valid: true},
+			ParentTaskID: sql.NullInt64{Int64: taskIDs[1], Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("CreateProjectTaskLink() error = %v", err)
+		}
+	}
+
+	// The timer runs on the invoice for 25 minutes, then moves over to the website
+	if _, _, err := StartTimer(ctx, conn, taskIDs[0], testNow); err != nil {
+		t.Fatalf("StartTimer() error = %v", err)
+	}
+	if _, _, err := StartTimer(ctx, conn, taskIDs[0], testNow.Add(time.Minute)); !errors.Is(err, ErrTimerRunning) {
+		t.Errorf("StartTimer() on the running task error = %v, want ErrTimerRunning", err)
+	}
+	started, stopped, err := StartTimer(ctx, conn, taskIDs[1], testNow.Add(25*time.Minute))
+	if err != nil {
+		t.Fatalf("StartTimer() error = %v", err)
+	}
+	if len(stopped) != 1 || stopped[0].TaskID != taskIDs[0] || stopped[0].Duration != 25*time.Minute {
+		t.Errorf("StartTimer() stopped %+v, want 25m on the invoice", stopped)
+	}
+	running, ok, err := RunningTimer(ctx, queries)
+	if err != nil || !ok || running != started || running.TaskTitle != "Website" {
+		t.Errorf("RunningTimer() = %+v, %v, %v, want %+v", running, ok, err, started)
+	}
+	entry, ok, err := StopTimer(ctx, conn, testNow.Add(2*time.Hour))
+	if err != nil || !ok || entry.Duration != 95*time.Minute {
+		t.Errorf("StopTimer() = %+v, %v, %v, want 1h35m on the website", entry, ok, err)
+	}
+	if _, ok, err := StopTimer(ctx, conn, testNow.Add(3*time.Hour)); err != nil || ok {
+		t.Errorf("StopTimer() without a running timer = %v, %v, want false", ok, err)
+	}
+	if _, _, err := StartTimer(ctx, conn, 99, testNow); err == nil {
+		t.Error("StartTimer() on a missing task did not fail")
+	}
+
+	// Time added by hand is logged at the start of its day
+	added := []struct {
+		taskID int64
+		spent  time.Duration
+		day    time.Time
+	}{
+		{taskID: taskIDs[0], spent: 30 * time.Minute, day: testNow.AddDate(0, 0, -10)},
+		{taskID: taskIDs[2], spent: 2 * time.Hour, day: testNow.AddDate(0, 0, -1)},
+	}
+	for _, tt := range added {
+		if _, err := AddTime(ctx, queries, tt.taskID, tt.spent, tt.day); err != nil {
+			t.Fatalf("AddTime() error = %v", err)
+		}
+	}
+	if _, err := AddTime(ctx, queries, 99, time.Hour, testNow); err == nil {
+		t.Error("AddTime() on a missing task did not fail")
+	}
+
+	task, err := queries.ReadTask(ctx, taskIDs[0])
+	if err != nil || task.TimeLogged != int64((55*time.Minute)/time.Second) {
+		t.Errorf("ReadTask() time logged = %d, %v, want 55m", task.TimeLogged, err)
+	}
+
+	weekAgo, _ := ResolveSince("7d", testNow)
+	reports := []struct {
+		by    TimeReportGroup
+		since time.Time
+		want  []TimeReportRow
+	}{
+		{by: TimeByTask, want: []TimeReportRow{{Name: "3 Errands", Duration: 2 * time.Hour}, {Name: "2 Website", Duration: 95 * time.Minute}, {Name: "1 Invoice", Duration: 55 * time.Minute}}},
+		{by: TimeByTask, since: weekAgo, want: []TimeReportRow{{Name: "3 Errands", Duration: 2 * time.Hour}, {Name: "2 Website", Duration: 95 * time.Minute}, {Name: "1 Invoice", Duration: 25 * time.Minute}}},
+		{by: TimeByArea, want: []TimeReportRow{{Name: "Clients", Duration: 150 * time.Minute}, {Name: "(no area)", Duration: 2 * time.Hour}}},
+		{by: TimeByRepo, want: []TimeReportRow{{Name: "(no repo)", Duration: 175 * time.Minute}, {Name: "/src/shop", Duration: 95 * time.Minute}, {Name: "/src/site", Duration: 95 * time.Minute}}},
+		{by: TimeByArea, since: testNow.AddDate(0, 0, 1), want: []TimeReportRow{}},
+	}
+	for _, tt := range reports {
+		got, err := TimeReport(ctx, queries, tt.by, tt.since)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TimeReport(%s, %v) = %+v, %v, want %+v", tt.by, tt.since, got, err, tt.want)
+		}
+	}
+}
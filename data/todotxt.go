@@ -0,0 +1,457 @@
+package data
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+// ErrInvalidTodoTxt is returned when a line of a todo.txt file can not be read
+var ErrInvalidTodoTxt = errors.New("invalid todo.txt line")
+
+var (
+	todoTxtPriority = regexp.MustCompile(`^\(([A-Z])\)$`)
+	todoTxtDate     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+)
+
+// TodoTxtTask is a single line of a todo.txt file
+type TodoTxtTask struct {
+	Done bool
+	// Priority is the letter of the (A) prefix, or of the pri: key that apps write on completed lines
+	Priority  string
+	Completed string
+	Created   string
+	// Text is the description without the projects, contexts, and keys go_task understands
+	Text     string
+	Projects []string
+	Contexts []string
+	Due      string
+	// ID is the id: key written by ExportTodoTxt, it is 0 for lines that were added outside go_task
+	ID int64
+}
+
+type TodoTxtImportStats struct {
+	Tasks        int
+	Updated      int
+	AreasCreated int
+	Tags         int
+	// NextInstances are recurring tasks spawned because their line was marked done
+	NextInstances int
+	// Skipped counts lines of existing tasks that were left alone because updates were turned off
+	Skipped int
+	// ReusedIDs are id: keys that now belong to a different task, their lines were added as new tasks
+	ReusedIDs []int64
+}
+
+/*
+ParseTodoTxtLine reads one line of a todo.txt file.
+ 1. A line starting with "x " is done, it may be followed by the completion date and the creation date
+ 2. An open line may start with a priority like (A) followed by the creation date
+ 3. +project and @context words are collected, due:YYYY-MM-DD and id:N are read as keys,
+    every other word, including unknown key:value pairs, stays in the text
+*/
+func ParseTodoTxtLine(line string) (TodoTxtTask, error) {
+	var task TodoTxtTask
+	words := strings.Fields(line)
+
+	if len(words) > 0 && words[0] == "x" {
+		task.Done = true
+		words = words[1:]
+		if len(words) > 0 && todoTxtDate.MatchString(words[0]) {
+			task.Completed = words[0]
+			words = words[1:]
+		}
+	} else if len(words) > 0 && todoTxtPriority.MatchString(words[0]) {
+		task.Priority = words[0][1:2]
+		words = words[1:]
+	}
+	if len(words) > 0 && todoTxtDate.MatchString(words[0]) {
+		task.Created = words[0]
+		words = words[1:]
+	}
+
+	var text []string
+	for _, word := range words {
+		key, value, isKey := strings.Cut(word, ":")
+		switch {
+		case len(word) > 1 && word[0] == '+':
+			task.Projects = append(task.Projects, word[1:])
+		case len(word) > 1 && word[0] == '@':
+			task.Contexts = append(task.Contexts, word[1:])
+		case isKey && key == "due":
+			if _, err := ParseDueDate(value); err != nil {
+				return task, fmt.Errorf("%w: %v", ErrInvalidTodoTxt, err)
+			}
+			task.Due = value
+		case isKey && key == "pri" && len(value) == 1 && value[0] >= 'A' && value[0] <= 'Z':
+			task.Priority = value
+		case isKey && key == "id":
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || id <= 0 {
+				text = append(text, word)
+				continue
+			}
+			task.ID = id
+		default:
+			text = append(text, word)
+		}
+	}
+	task.Text = strings.Join(text, " ")
+	if task.Text == "" {
+		return task, fmt.Errorf("%w: %q has no description", ErrInvalidTodoTxt, line)
+	}
+	return task, nil
+}
+
+// String formats the task as a todo.txt line, completed lines keep their priority in a pri: key
+func (t TodoTxtTask) String() string {
+	var words []string
+	if t.Done {
+		words = append(words, "x")
+		if t.Completed != "" {
+			words = append(words, t.Completed)
+		}
+	} else if t.Priority != "" {
+		words = append(words, "("+t.Priority+")")
+	}
+	// The creation date can only follow the completion date on a completed line
+	if t.Created != "" && (!t.Done || t.Completed != "") {
+		words = append(words, t.Created)
+	}
+	words = append(words, t.Text)
+	for _, project := range t.Projects {
+		words = append(words, "+"+project)
+	}
+	for _, context := range t.Contexts {
+		words = append(words, "@"+context)
+	}
+	if t.Due != "" {
+		words = append(words, "due:"+t.Due)
+	}
+	if t.Done && t.Priority != "" {
+		words = append(words, "pri:"+t.Priority)
+	}
+	if t.ID != 0 {
+		words = append(words, "id:"+strconv.FormatInt(t.ID, 10))
+	}
+	return strings.Join(words, " ")
+}
+
+// ReadTodoTxt reads every task of a todo.txt file, blank lines are skipped
+func ReadTodoTxt(r io.Reader) ([]TodoTxtTask, error) {
+	var tasks []TodoTxtTask
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		task, err := ParseTodoTxtLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read todo.txt: %w", err)
+	}
+	return tasks, nil
+}
+
+// WriteTodoTxt writes one line per task
+func WriteTodoTxt(w io.Writer, tasks []TodoTxtTask) error {
+	for _, task := range tasks {
+		if _, err := io.WriteString(w, task.String()+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// todoTxtPriorities maps the todo.txt priority letters onto the go_task priorities, E to Z are treated as low
+var todoTxtPriorities = map[string]PriorityType{
+	"A": PriorityTypeUrgent,
+	"B": PriorityTypeHigh,
+	"C": PriorityTypeMedium,
+	"D": PriorityTypeLow,
+}
+
+func todoTxtPriorityType(letter string) sql.NullString {
+	if letter == "" {
+		return sql.NullString{}
+	}
+	priority, ok := todoTxtPriorities[letter]
+	if !ok {
+		priority = PriorityTypeLow
+	}
+	return sql.NullString{String: string(priority), Valid: true}
+}
+
+func priorityToTodoTxt(priority string) string {
+	for letter, mapped := range todoTxtPriorities {
+		if string(mapped) == priority {
+			return letter
+		}
+	}
+	return ""
+}
+
+// todoTxtProject turns an area title into a project name, projects can not contain spaces
+func todoTxtProject(title string) string {
+	return strings.Join(strings.Fields(title), "-")
+}
+
+/*
+ImportTodoTxt adds the tasks of a todo.txt file to the database inside a single transaction.
+ 1. Priorities (A) to (D) become urgent, high, medium, and low, lines marked x are done and other lines are todo
+ 2. The first +project becomes the area, matched against area titles with spaces written as dashes,
+    the area is created when it does not exist yet, @contexts and further projects become tags
+ 3. A line with the id: key of an existing task updates that task instead of adding a new one, so a file
+    written by ExportTodoTxt can be edited elsewhere and imported again. The status only changes when the line
+    is checked or unchecked, so a task that is doing stays doing and finishing a recurring task spawns the next one
+ 4. The task only counts as the same one when its title or creation date matches the line, IDs of deleted tasks
+    are reused and an old file must not overwrite the task that got the ID since. Other lines are added as new tasks
+ 5. With noUpdate lines of existing tasks are skipped, only the lines that are new to go_task are added
+*/
+func ImportTodoTxt(ctx context.Context, conn *sql.DB, tasks []TodoTxtTask, noUpdate bool, now time.Time) (TodoTxtImportStats, error) {
+	var stats TodoTxtImportStats
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return stats, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	queries := sqlc.New(tx)
+
+	areas, err := queries.ExportAreas(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("failed to read areas: %w", err)
+	}
+	areaIDs := make(map[string]int64)
+	for _, area := range areas {
+		key := strings.ToLower(todoTxtProject(area.Title))
+		if _, ok := areaIDs[key]; !ok {
+			areaIDs[key] = area.ID
+		}
+	}
+
+	for _, line := range tasks {
+		var project string
+		tags := ParseTags(line.Contexts...)
+		if len(line.Projects) > 0 {
+			project = line.Projects[0]
+			tags = ParseTags(append(tags, line.Projects[1:]...)...)
+		}
+		areaID, areaCreated, err := findOrCreateArea(ctx, queries, areaIDs, project, now)
+		if err != nil {
+			return stats, err
+		}
+		if areaCreated {
+			stats.AreasCreated++
+		}
+		due := sql.NullString{String: line.Due, Valid: line.Due != ""}
+
+		var existing sqlc.ReadTaskForRecurrenceRow
+		found := false
+		if line.ID != 0 {
+			existing, err = queries.ReadTaskForRecurrence(ctx, line.ID)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return stats, fmt.Errorf("failed to read task %d: %w", line.ID, err)
+			}
+			found = err == nil
+			if found && !todoTxtLineMatches(existing, line) {
+				stats.ReusedIDs = append(stats.ReusedIDs, line.ID)
+				found = false
+			}
+		}
+
+		if found && noUpdate {
+			stats.Skipped++
+			continue
+		}
+		if found {
+			added, err := updateTodoTxtTask(ctx, queries, existing, line, areaID, due, tags, now, &stats)
+			if err != nil {
+				return stats, err
+			}
+			stats.Tags += added
+			stats.Updated++
+			continue
+		}
+
+		status := StatusToDo
+		if line.Done {
+			status = StatusDone
+		}
+		created := now.Format(storedTimeLayout)
+		if line.Created != "" {
+			created = line.Created + " 00:00:00"
+		}
+		modified := now.Format(storedTimeLayout)
+		if line.Completed != "" {
+			modified = line.Completed + " 00:00:00"
+		}
+		taskID, err := queries.ImportTask(ctx, sqlc.ImportTaskParams{
+			Title:     line.Text,
+			Priority:  todoTxtPriorityType(line.Priority),
+			Status:    sql.NullString{String: string(status), Valid: true},
+			CreatedAt: created,
+			LastMod:   modified,
+			DueDate:   due,
+			AreaID:    areaID,
+		})
+		if err != nil {
+			return stats, fmt.Errorf("failed to import task %q: %w", line.Text, err)
+		}
+		if err := AddTaskTags(ctx, queries, taskID, tags); err != nil {
+			return stats, err
+		}
+		stats.Tags += len(tags)
+		stats.Tasks++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return stats, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return stats, nil
+}
+
+// todoTxtLineMatches reports whether a line with the id: key of a task still describes that task
+func todoTxtLineMatches(task sqlc.ReadTaskForRecurrenceRow, line TodoTxtTask) bool {
+	if strings.EqualFold(strings.Join(strings.Fields(task.Title), " "), strings.Join(strings.Fields(line.Text), " ")) {
+		return true
+	}
+	return line.Created != "" && strings.HasPrefix(task.CreatedAt, line.Created)
+}
+
+// updateTodoTxtTask makes an existing task match its todo.txt line and returns the number of tags added
+func updateTodoTxtTask(ctx context.Context, queries *sqlc.Queries, task sqlc.ReadTaskForRecurrenceRow, line TodoTxtTask, areaID sql.NullInt64, due sql.NullString, tags []string, now time.Time, stats *TodoTxtImportStats) (int, error) {
+	if _, err := queries.UpdateTaskTitle(ctx, sqlc.UpdateTaskTitleParams{Title: line.Text, ID: task.ID}); err != nil {
+		return 0, fmt.Errorf("failed to update the title of task %d: %w", task.ID, err)
+	}
+	priority := todoTxtPriorityType(line.Priority)
+	if _, err := queries.UpdateTaskPriority(ctx, sqlc.UpdateTaskPriorityParams{Priority: priority, ID: task.ID}); err != nil {
+		return 0, fmt.Errorf("failed to update the priority of task %d: %w", task.ID, err)
+	}
+	if _, err := queries.UpdateTaskDueDate(ctx, sqlc.UpdateTaskDueDateParams{DueDate: due, ID: task.ID}); err != nil {
+		return 0, fmt.Errorf("failed to update the due date of task %d: %w", task.ID, err)
+	}
+	if _, err := queries.UpdateTaskArea(ctx, sqlc.UpdateTaskAreaParams{AreaID: areaID, ID: task.ID}); err != nil {
+		return 0, fmt.Errorf("failed to update the area of task %d: %w", task.ID, err)
+	}
+
+	wasDone := task.Status.String == string(StatusDone)
+	if line.Done != wasDone {
+		status := StatusToDo
+		if line.Done {
+			status = StatusDone
+		}
+		var change StatusChange
+		if err := setStatus(ctx, queries, task.ID, status, now, true, &change); err != nil {
+			return 0, err
+		}
+		stats.NextInstances += len(change.NextTaskIDs)
+	}
+
+	current, err := queries.ReadTaskTags(ctx, task.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the tags of task %d: %w", task.ID, err)
+	}
+	have := make(map[string]bool)
+	for _, tag := range current {
+		have[strings.ToLower(tag)] = true
+	}
+	wanted := make(map[string]bool)
+	var added []string
+	for _, tag := range tags {
+		wanted[strings.ToLower(tag)] = true
+		if !have[strings.ToLower(tag)] {
+			added = append(added, tag)
+		}
+	}
+	var removed []string
+	for _, tag := range current {
+		if !wanted[strings.ToLower(tag)] {
+			removed = append(removed, tag)
+		}
+	}
+	if err := AddTaskTags(ctx, queries, task.ID, added); err != nil {
+		return 0, err
+	}
+	if len(removed) > 0 {
+		if err := RemoveTaskTags(ctx, queries, task.ID, removed); err != nil {
+			return 0, err
+		}
+	}
+	return len(added), nil
+}
+
+/*
+ExportTodoTxt converts the tasks that are not archived into todo.txt lines.
+ 1. urgent, high, medium, and low become (A) to (D), done tasks are completed lines dated by their last change
+ 2. The area becomes a +project with spaces written as dashes and the tags become @contexts
+ 3. Every line carries the id: key of its task, so ImportTodoTxt updates the task when the file comes back
+*/
+func ExportTodoTxt(ctx context.Context, conn *sql.DB, now time.Time) ([]TodoTxtTask, error) {
+	bundle, err := ExportBundle(ctx, conn, now)
+	if err != nil {
+		return nil, err
+	}
+
+	areaTitles := make(map[int64]string)
+	for _, area := range bundle.Areas {
+		areaTitles[area.ID] = area.Title
+	}
+	taskTags := make(map[int64][]string)
+	for _, tag := range bundle.TaskTags {
+		taskTags[tag.ID] = append(taskTags[tag.ID], tag.Tag)
+	}
+
+	var tasks []TodoTxtTask
+	for _, task := range bundle.Tasks {
+		if task.Archived {
+			continue
+		}
+		line := TodoTxtTask{
+			Text:     strings.Join(strings.Fields(task.Title), " "),
+			Created:  storedDate(task.CreatedAt),
+			Contexts: taskTags[task.ID],
+			ID:       task.ID,
+		}
+		if task.Priority != nil {
+			line.Priority = priorityToTodoTxt(*task.Priority)
+		}
+		if task.Status != nil && StatusType(*task.Status) == StatusDone {
+			line.Done = true
+			line.Completed = storedDate(task.LastMod)
+		}
+		if task.DueDate != nil {
+			line.Due = *task.DueDate
+		}
+		if task.AreaID != nil {
+			if project := todoTxtProject(areaTitles[*task.AreaID]); project != "" {
+				line.Projects = []string{project}
+			}
+		}
+		tasks = append(tasks, line)
+	}
+	return tasks, nil
+}
+
+// storedDate returns the date part of a created_at or last_mod value, or blank when it is not a date
+func storedDate(stored string) string {
+	if len(stored) >= len(DueDateLayout) && todoTxtDate.MatchString(stored[:len(DueDateLayout)]) {
+		return stored[:len(DueDateLayout)]
+	}
+	return ""
+}
@@ -0,0 +1,185 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+func TestParseTodoTxtLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    TodoTxtTask
+		wantErr bool
+	}{
+		{
+			name: "priority, creation date, project, context, and due date",
+			line: "(A) 2024-11-01 Call the bank +Deep-Work @phone due:2024-11-08",
+			want: TodoTxtTask{Priority: "A", Created: "2024-11-01", Text: "Call the bank", Projects: []string{"Deep-Work"}, Contexts: []string{"phone"}, Due: "2024-11-08"},
+		},
+		{
+			name: "completed with both dates and the id written by go_task",
+			line: "x 2024-11-05 2024-11-01 Pay rent pri:B id:12",
+			want: TodoTxtTask{Done: true, Priority: "B", Completed: "2024-11-05", Created: "2024-11-01", Text: "Pay rent", ID: 12},
+		},
+		{
+			name: "unknown keys and lone symbols stay in the text",
+			line: "Read https://example.com t:2024-11-02 + @",
+			want: TodoTxtTask{Text: "Read https://example.com t:2024-11-02 + @"},
+		},
+		{
+			name: "a priority later in the line is text",
+			line: "Fix (B) later",
+			want: TodoTxtTask{Text: "Fix (B) later"},
+		},
+		{
+			name:    "invalid due date",
+			line:    "Pay rent due:tomorrow",
+			wantErr: true,
+		},
+		{
+			name:    "no description",
+			line:    "(A) +Home @errands",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTodoTxtLine(tt.line)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTodoTxt) {
+					t.Errorf("ParseTodoTxtLine(%q) error = %v, want ErrInvalidTodoTxt", tt.line, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTodoTxtLine(%q) error = %v", tt.line, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTodoTxtLine(%q) = %+v, want %+v", tt.line, got, tt.want)
+			}
+			again, err := ParseTodoTxtLine(got.String())
+			if err != nil || !reflect.DeepEqual(again, got) {
+				t.Errorf("ParseTodoTxtLine(%q) = %+v, %v, want %+v", got.String(), again, err, got)
+			}
+		})
+	}
+}
+
+func TestTodoTxtRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+
+	file := `(A) 2024-11-01 Call the bank +Deep-Work @phone due:2024-11-08
+(E) Buy stamps +Errands +Home @town
+x 2024-11-04 2024-11-01 Pay rent pri:B
+
+`
+	tasks, err := ReadTodoTxt(strings.NewReader(file))
+	if err != nil {
+		t.Fatalf("ReadTodoTxt() error = %v", err)
+	}
+	stats, err := ImportTodoTxt(ctx, conn, tasks, false, testNow)
+	if err != nil {
+		t.Fatalf("ImportTodoTxt() error = %v", err)
+	}
+	if want := (TodoTxtImportStats{Tasks: 3, AreasCreated: 2, Tags: 3}); !reflect.DeepEqual(stats, want) {
+		t.Errorf("ImportTodoTxt() stats = %+v, want %+v", stats, want)
+	}
+
+	var out bytes.Buffer
+	exported, err := ExportTodoTxt(ctx, conn, testNow)
+	if err != nil {
+		t.Fatalf("ExportTodoTxt() error = %v", err)
+	}
+	if err := WriteTodoTxt(&out, exported); err != nil {
+		t.Fatalf("WriteTodoTxt() error = %v", err)
+	}
+	want := `(A) 2024-11-01 Call the bank +Deep-Work @phone due:2024-11-08 id:1
+(D) 2024-11-05 Buy stamps +Errands @Home @town id:2
+x 2024-11-04 2024-11-01 Pay rent pri:B id:3
+`
+	if out.String() != want {
+		t.Errorf("exported todo.txt =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	// Editing the file elsewhere and importing it again updates the tasks instead of adding new ones
+	edited := `x 2024-11-06 2024-11-01 Call the bank about fees +Deep-Work @phone due:2024-11-08 pri:A id:1
+(C) 2024-11-05 Buy stamps @town id:2
+2024-11-01 Pay rent id:3
+(B) Water plants +deep-work id:99
+`
+	tasks, err = ReadTodoTxt(strings.NewReader(edited))
+	if err != nil {
+		t.Fatalf("ReadTodoTxt() error = %v", err)
+	}
+	stats, err = ImportTodoTxt(ctx, conn, tasks, false, testNow)
+	if err != nil {
+		t.Fatalf("ImportTodoTxt() error = %v", err)
+	}
+	if want := (TodoTxtImportStats{Tasks: 1, Updated: 3}); !reflect.DeepEqual(stats, want) {
+		t.Errorf("ImportTodoTxt() stats = %+v, want %+v", stats, want)
+	}
+
+	rows, err := sqlc.New(conn).ExportTasks(ctx)
+	if err != nil {
+		t.Fatalf("ExportTasks() error = %v", err)
+	}
+	type imported struct {
+		Title, Priority, Status, Due string
+		AreaID                       int64
+	}
+	var got []imported
+	for _, row := range rows {
+		got = append(got, imported{Title: row.Title, Priority: row.Priority.String, Status: row.Status.String, Due: row.DueDate.String, AreaID: row.AreaID.Int64})
+	}
+	wantTasks := []imported{
+		{Title: "Call the bank about fees", Priority: "urgent", Status: "done", Due: "2024-11-08", AreaID: 1},
+		{Title: "Buy stamps", Priority: "medium", Status: "todo"},
+		{Title: "Pay rent", Status: "todo"},
+		{Title: "Water plants", Priority: "high", Status: "todo", AreaID: 1},
+	}
+	if !reflect.DeepEqual(got, wantTasks) {
+		t.Errorf("tasks after the second import = %+v\nwant %+v", got, wantTasks)
+	}
+	tags, err := sqlc.New(conn).ReadTaskTags(ctx, 2)
+	if err != nil || !reflect.DeepEqual(tags, []string{"town"}) {
+		t.Errorf("tags of task 2 = %v, %v, want [town]", tags, err)
+	}
+
+	// A new task that reuses the ID of a deleted one is not overwritten by an old line,
+	// and with noUpdate the lines of existing tasks are left alone
+	queries := sqlc.New(conn)
+	if _, err := queries.DeleteTask(ctx, 4); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+	if _, err := queries.CreateTask(ctx, sqlc.CreateTaskParams{ID: 4, Title: "Renew passport"}); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	stale := `(B) Water plants +deep-work id:4
+(A) 2024-11-01 Call the bank +Deep-Work id:1
+`
+	tasks, err = ReadTodoTxt(strings.NewReader(stale))
+	if err != nil {
+		t.Fatalf("ReadTodoTxt() error = %v", err)
+	}
+	stats, err = ImportTodoTxt(ctx, conn, tasks, true, testNow)
+	if err != nil {
+		t.Fatalf("ImportTodoTxt() error = %v", err)
+	}
+	if want := (TodoTxtImportStats{Tasks: 1, Skipped: 1, ReusedIDs: []int64{4}}); !reflect.DeepEqual(stats, want) {
+		t.Errorf("ImportTodoTxt() stats = %+v, want %+v", stats, want)
+	}
+	for id, title := range map[int64]string{1: "Call the bank about fees", 4: "Renew passport", 5: "Water plants"} {
+		task, err := queries.ReadTaskForRecurrence(ctx, id)
+		if err != nil || task.Title != title {
+			t.Errorf("task %d = %q, %v, want %q", id, task.Title, err, title)
+		}
+	}
+}
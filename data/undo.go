@@ -0,0 +1,154 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/akthe-at/go_task/db"
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+// MaxUndoSteps is how many steps the undo_log keeps, older steps can not be undone
+const MaxUndoSteps = 100
+
+// ErrNothingToUndo is returned when no change was recorded that could be undone
+var ErrNothingToUndo = errors.New("there is nothing to undo")
+
+// ReadUndoSteps reads the latest steps that can be undone, newest first
+func ReadUndoSteps(ctx context.Context, queries *sqlc.Queries, limit int64) ([]sqlc.ReadUndoStepsRow, error) {
+	steps, err := queries.ReadUndoSteps(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the undo steps: %w", err)
+	}
+	return steps, nil
+}
+
+// UndoLast reverts the latest n steps and returns them, newest first
+func UndoLast(ctx context.Context, conn *sql.DB, n int) ([]sqlc.ReadUndoStepsRow, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("can not undo %d steps", n)
+	}
+	steps, err := ReadUndoSteps(ctx, sqlc.New(conn), int64(n))
+	if err != nil {
+		return nil, err
+	}
+	if len(steps) == 0 {
+		return nil, ErrNothingToUndo
+	}
+	return UndoThrough(ctx, conn, steps[len(steps)-1].ID)
+}
+
+/*
+UndoThrough reverts the step stepID and every step made after it, and returns them, newest first.
+ 1. The statements run newest first in one transaction with the foreign keys checked at the end, so a deleted task comes back with its notes, project links, tags, and time entries
+ 2. A statement that fails, e.g. because the row it restores was created again since, rolls everything back
+ 3. The reverted steps are removed, undoing again reverts the step before them
+ 4. Nothing is recorded for undo while the steps are reverted, and last_mod and completed_at get back their old values
+ 5. The checkboxes and frontmatter of the notes of the tasks and areas that changed are updated afterwards
+*/
+func UndoThrough(ctx context.Context, conn *sql.DB, stepID int64) ([]sqlc.ReadUndoStepsRow, error) {
+	ctx = db.WithoutUndo(ctx)
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	queries := sqlc.New(tx)
+
+	all, err := ReadUndoSteps(ctx, queries, MaxUndoSteps)
+	if err != nil {
+		return nil, err
+	}
+	var steps []sqlc.ReadUndoStepsRow
+	for _, step := range all {
+		if step.ID >= stepID {
+			steps = append(steps, step)
+		}
+	}
+	if len(steps) == 0 || steps[len(steps)-1].ID != stepID {
+		return nil, fmt.Errorf("step %d: %w", stepID, ErrNothingToUndo)
+	}
+
+	if _, err := tx.ExecContext(ctx, "PRAGMA defer_foreign_keys = ON"); err != nil {
+		return nil, fmt.Errorf("failed to defer foreign keys: %w", err)
+	}
+	statements, err := queries.ReadUndoStatements(ctx, stepID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the changes to undo: %w", err)
+	}
+	latestEventID, err := queries.ReadLatestEventID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the history: %w", err)
+	}
+	if err := queries.BeginUndoReplay(ctx); err != nil {
+		return nil, fmt.Errorf("failed to begin the undo: %w", err)
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return nil, fmt.Errorf("the changes can no longer be undone: %w", err)
+		}
+	}
+	if err := queries.EndUndoReplay(ctx); err != nil {
+		return nil, fmt.Errorf("failed to end the undo: %w", err)
+	}
+	// A task, area, or note undo brings back keeps its history, ReadEvents starts a history at the latest creation
+	if err := queries.MarkRestoredEvents(ctx, latestEventID); err != nil {
+		return nil, fmt.Errorf("failed to record the restored rows: %w", err)
+	}
+	if _, err := queries.DeleteUndoSteps(ctx, stepID); err != nil {
+		return nil, fmt.Errorf("failed to remove the undone steps: %w", err)
+	}
+	tasks, err := queries.ReadChangedTasks(ctx, latestEventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the undone tasks: %w", err)
+	}
+	areaIDs, err := queries.ReadChangedAreaIDs(ctx, latestEventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the undone areas: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("the changes can no longer be undone: %w", err)
+	}
+
+	if err := syncUndoneNotes(ctx, conn, tasks, areaIDs); err != nil {
+		return steps, fmt.Errorf("the changes were undone but the notes were not updated: %w", err)
+	}
+	return steps, nil
+}
+
+// syncUndoneNotes ticks the checkboxes of the undone tasks to match their status and updates the frontmatter of their notes
+func syncUndoneNotes(ctx context.Context, conn *sql.DB, tasks []sqlc.ReadChangedTasksRow, areaIDs []int64) error {
+	var taskIDs, doneIDs, openIDs []int64
+	for _, task := range tasks {
+		taskIDs = append(taskIDs, task.ID)
+		if task.Done {
+			doneIDs = append(doneIDs, task.ID)
+		} else {
+			openIDs = append(openIDs, task.ID)
+		}
+	}
+	if len(doneIDs) > 0 {
+		if _, err := updateNoteCheckboxes(ctx, conn, doneIDs, true); err != nil {
+			return err
+		}
+	}
+	if len(openIDs) > 0 {
+		if _, err := updateNoteCheckboxes(ctx, conn, openIDs, false); err != nil {
+			return err
+		}
+	}
+	if len(taskIDs) > 0 {
+		if _, err := SyncTaskNoteStates(ctx, conn, taskIDs...); err != nil {
+			return err
+		}
+	}
+	if len(areaIDs) > 0 {
+		if _, err := SyncAreaNoteStates(ctx, conn, areaIDs...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
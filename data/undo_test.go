@@ -0,0 +1,275 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/akthe-at/go_task/db"
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+func TestUndo(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+	t.Cleanup(db.EndUndoStep)
+
+	for _, area := range []sqlc.CreateAreaParams{{ID: 1, Title: "Work"}, {ID: 2, Title: "Home"}} {
+		if _, err := queries.CreateArea(ctx, area); err != nil {
+			t.Fatalf("CreateArea() error = %v", err)
+		}
+	}
+	task := sqlc.CreateTaskParams{
+		ID:       5,
+		Title:    "Write report",
+		Priority: sql.NullString{String: "low", Valid: true},
+		Status:   sql.NullString{String: "planning", Valid: true},
+		AreaID:   sql.NullInt64{Int64: 1, Valid: true},
+	}
+	if _, err := queries.CreateTask(ctx, task); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	// Deleting the task sets the parent of its subtask to null, undo puts it back
+	subtask := sqlc.CreateTaskParams{ID: 6, Title: "Draft outline", ParentTaskID: sql.NullInt64{Int64: 5, Valid: true}}
+	if _, err := queries.CreateTask(ctx, subtask); err != nil {
+		t.Fatalf("CreateTask() error = %v", err)
+	}
+	if err := queries.CreateNote(ctx, sqlc.CreateNoteParams{ID: 4, Title: "report notes", Path: "/notes/report.md"}); err != nil {
+		t.Fatalf("CreateNote() error = %v", err)
+	}
+	_, err := queries.CreateTaskBridgeNote(ctx, sqlc.CreateTaskBridgeNoteParams{
+		NoteID:       4,
+		ParentCat:    sql.NullInt64{Int64: int64(TaskNoteType), Valid: true},
+		ParentTaskID: sql.NullInt64{Int64: 5, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateTaskBridgeNote() error = %v", err)
+	}
+	projectID, err := queries.InsertProgProject(ctx, "/src/go_task")
+	if err != nil {
+		t.Fatalf("InsertProgProject() error = %v", err)
+	}
+	err = queries.CreateProjectTaskLink(ctx, sqlc.CreateProjectTaskLinkParams{
+		ProjectID:    sql.NullInt64{Int64: projectID, Valid: true},
+		ParentCat:    sql.NullInt64{Int64: int64(TaskNoteType), Valid: true},
+		ParentTaskID: sql.NullInt64{Int64: 5, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateProjectTaskLink() error = %v", err)
+	}
+	if err := AddTaskTags(ctx, queries, 5, []string{"finance"}); err != nil {
+		t.Fatalf("AddTaskTags() error = %v", err)
+	}
+	if _, err := AddTime(ctx, queries, 5, 90*time.Minute, testNow); err != nil {
+		t.Fatalf("AddTime() error = %v", err)
+	}
+	// Nothing was changed under a step, so nothing can be undone
+	if _, err := UndoLast(ctx, conn, 1); !errors.Is(err, ErrNothingToUndo) {
+		t.Fatalf("UndoLast() without steps error = %v, want ErrNothingToUndo", err)
+	}
+	before := snapshotUndoTables(t, conn)
+
+	steps := []struct {
+		description string
+		change      func() error
+	}{
+		{description: "status", change: func() error {
+			_, err := SetTaskStatus(ctx, conn, 5, StatusDone, testNow)
+			return err
+		}},
+		{description: "priority", change: func() error {
+			_, err := queries.UpdateTaskPriority(ctx, sqlc.UpdateTaskPriorityParams{Priority: sql.NullString{String: "high", Valid: true}, ID: 5})
+			return err
+		}},
+		{description: "archive", change: func() error {
+			_, err := queries.UpdateTaskArchived(ctx, sqlc.UpdateTaskArchivedParams{Archived: true, ID: 5})
+			return err
+		}},
+		{description: "area", change: func() error {
+			_, err := queries.UpdateTaskArea(ctx, sqlc.UpdateTaskAreaParams{AreaID: sql.NullInt64{Int64: 2, Valid: true}, ID: 5})
+			return err
+		}},
+		{description: "delete", change: func() error {
+			_, err := queries.DeleteTask(ctx, 5)
+			return err
+		}},
+	}
+	for _, tt := range steps {
+		db.BeginUndoStep(tt.description)
+		if err := tt.change(); err != nil {
+			t.Fatalf("%s: change error = %v", tt.description, err)
+		}
+	}
+	db.EndUndoStep()
+	if _, err := queries.ReadTask(ctx, 5); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("ReadTask() after the delete error = %v, want sql.ErrNoRows", err)
+	}
+
+	listed, err := ReadUndoSteps(ctx, queries, 10)
+	if err != nil {
+		t.Fatalf("ReadUndoSteps() error = %v", err)
+	}
+	var got []string
+	for _, step := range listed {
+		got = append(got, step.Description)
+		if step.Source != db.SourceCLI || step.Changes == 0 {
+			t.Errorf("ReadUndoSteps() step %+v, want cli changes", step)
+		}
+	}
+	if want := []string{"delete", "area", "archive", "priority", "status"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadUndoSteps() = %q, want %q", got, want)
+	}
+
+	// The delete comes back with everything that was deleted along with the task
+	undone, err := UndoLast(ctx, conn, 1)
+	if err != nil || len(undone) != 1 || undone[0].Description != "delete" {
+		t.Fatalf("UndoLast(1) = %+v, %v, want the delete", undone, err)
+	}
+	restored, err := queries.ReadTask(ctx, 5)
+	if err != nil || restored.ParentArea.String != "Home" || !restored.Archived || restored.Priority.String != "high" || restored.Status.String != string(StatusDone) {
+		t.Errorf("ReadTask() after undoing the delete = %+v, %v, want the task as it was deleted", restored, err)
+	}
+
+	// The other steps are undone together, back to how the task started
+	undone, err = UndoThrough(ctx, conn, listed[len(listed)-1].ID)
+	if err != nil || len(undone) != 4 {
+		t.Fatalf("UndoThrough() = %+v, %v, want 4 steps", undone, err)
+	}
+	if after := snapshotUndoTables(t, conn); !reflect.DeepEqual(after, before) {
+		t.Errorf("rows after undo =\n%v\nwant\n%v", after, before)
+	}
+	if _, err := UndoLast(ctx, conn, 1); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("UndoLast() after undoing everything error = %v, want ErrNothingToUndo", err)
+	}
+}
+
+func TestUndoRestoresTimestampsAndNotes(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+	t.Cleanup(db.EndUndoStep)
+
+	taskID, err := queries.ImportTask(ctx, sqlc.ImportTaskParams{
+		Title:       "Paint",
+		Status:      sql.NullString{String: "done", Valid: true},
+		CreatedAt:   "2024-11-01 09:00:00",
+		LastMod:     "2024-11-02 10:00:00",
+		CompletedAt: sql.NullString{String: "2024-11-02 10:00:00", Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("ImportTask() error = %v", err)
+	}
+	notePath := filepath.Join(t.TempDir(), "paint.md")
+	if err := os.WriteFile(notePath, []byte("---\ntitle: Paint\n---\n- [x] Paint <!-- go_task:1 -->\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	noteID, err := queries.ImportNote(ctx, sqlc.ImportNoteParams{Title: "Paint", Path: notePath})
+	if err != nil {
+		t.Fatalf("ImportNote() error = %v", err)
+	}
+	err = queries.ImportBridgeNote(ctx, sqlc.ImportBridgeNoteParams{
+		NoteID: noteID, ParentCat: sql.NullInt64{Int64: int64(TaskNoteType), Valid: true}, ParentTaskID: sql.NullInt64{Int64: taskID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("ImportBridgeNote() error = %v", err)
+	}
+	if err := queries.UpsertNoteCheckbox(ctx, sqlc.UpsertNoteCheckboxParams{TaskID: taskID, NoteID: noteID}); err != nil {
+		t.Fatalf("UpsertNoteCheckbox() error = %v", err)
+	}
+	if _, err := ResyncNoteStates(ctx, conn); err != nil {
+		t.Fatalf("ResyncNoteStates() error = %v", err)
+	}
+	note, err := os.ReadFile(notePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Changes made without undo while a step is open, e.g. by the notes watcher, are left out of the step
+	db.BeginUndoStep("watcher")
+	if _, err := queries.UpdateNoteTitle(db.WithoutUndo(ctx), sqlc.UpdateNoteTitleParams{Title: "Painting", ID: noteID}); err != nil {
+		t.Fatalf("UpdateNoteTitle() error = %v", err)
+	}
+	db.BeginUndoStep("reopen")
+	if _, err := SetTaskStatus(ctx, conn, taskID, StatusDoing, testNow); err != nil {
+		t.Fatalf("SetTaskStatus() error = %v", err)
+	}
+	db.EndUndoStep()
+	if listed, err := ReadUndoSteps(ctx, queries, 10); err != nil || len(listed) != 1 || listed[0].Description != "reopen" {
+		t.Fatalf("ReadUndoSteps() = %+v, %v, want only the reopen", listed, err)
+	}
+
+	// Undo puts back the stamps of when the task was finished and the checkbox and frontmatter of its note
+	if _, err := UndoLast(ctx, conn, 1); err != nil {
+		t.Fatalf("UndoLast() error = %v", err)
+	}
+	var lastMod, completedAt string
+	if err := conn.QueryRow("SELECT last_mod, completed_at FROM tasks WHERE id = ?", taskID).Scan(&lastMod, &completedAt); err != nil {
+		t.Fatal(err)
+	}
+	if lastMod != "2024-11-02 10:00:00" || completedAt != "2024-11-02 10:00:00" {
+		t.Errorf("last_mod, completed_at after undo = %s, %s, want both 2024-11-02 10:00:00", lastMod, completedAt)
+	}
+	assertFileContent(t, notePath, string(note))
+	if _, err := UndoLast(ctx, conn, 1); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("UndoLast() after undoing error = %v, want ErrNothingToUndo, undo must not record itself", err)
+	}
+
+	// A task that undo brings back keeps its history
+	db.BeginUndoStep("delete")
+	if _, err := queries.DeleteTask(ctx, taskID); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+	db.EndUndoStep()
+	if _, err := UndoLast(ctx, conn, 1); err != nil {
+		t.Fatalf("UndoLast() error = %v", err)
+	}
+	events, err := ReadEvents(ctx, queries, taskID, time.Time{})
+	if err != nil {
+		t.Fatalf("ReadEvents() error = %v", err)
+	}
+	var got []string
+	for _, event := range events {
+		got = append(got, DescribeEvent(event))
+	}
+	want := []string{"created as Paint", "status: done -> doing", "status: doing -> done", "deleted", "restored as Paint"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadEvents() after undo =\n%q\nwant\n%q", got, want)
+	}
+}
+
+// snapshotUndoTables reads the rows undo restores, leaving out the timestamps it re-stamps
+func snapshotUndoTables(t *testing.T, conn *sql.DB) map[string][]string {
+	t.Helper()
+	queries := map[string]string{
+		"tasks":              "SELECT id || '|' || title || '|' || quote(priority) || '|' || quote(status) || '|' || archived || '|' || quote(area_id) || '|' || quote(parent_task_id) FROM tasks",
+		"bridge_notes":       "SELECT id || '|' || note_id || '|' || parent_cat || '|' || parent_task_id FROM bridge_notes",
+		"prog_project_links": "SELECT rowid || '|' || project_id || '|' || parent_task_id FROM prog_project_links",
+		"task_tags":          "SELECT task_id || '|' || tag_id FROM task_tags",
+		"time_entries":       "SELECT id || '|' || task_id || '|' || seconds FROM time_entries",
+	}
+	snapshot := map[string][]string{}
+	for table, query := range queries {
+		rows, err := conn.Query(query + " ORDER BY 1")
+		if err != nil {
+			t.Fatalf("reading %s error = %v", table, err)
+		}
+		for rows.Next() {
+			var row string
+			if err := rows.Scan(&row); err != nil {
+				t.Fatalf("reading %s error = %v", table, err)
+			}
+			snapshot[table] = append(snapshot[table], row)
+		}
+		rows.Close()
+		if len(snapshot[table]) == 0 {
+			t.Fatalf("%s is empty", table)
+		}
+	}
+	return snapshot
+}
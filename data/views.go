@@ -0,0 +1,58 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+// ErrViewNotFound is returned when a saved view does not exist
+var ErrViewNotFound = errors.New("saved view not found")
+
+// SaveView stores a named filter, the filter is checked first so broken views can not be saved
+func SaveView(ctx context.Context, queries *sqlc.Queries, name, expression string, env FilterEnv) error {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return fmt.Errorf("invalid view name ( %s ) must be a single word", name)
+	}
+	if strings.TrimSpace(expression) == "" {
+		return fmt.Errorf("view %s needs a filter", name)
+	}
+	if _, err := CompileFilter(expression, env); err != nil {
+		return err
+	}
+
+	err := queries.UpsertSavedView(ctx, sqlc.UpsertSavedViewParams{Name: name, Filter: strings.TrimSpace(expression)})
+	if err != nil {
+		return fmt.Errorf("failed to save view %s: %w", name, err)
+	}
+	return nil
+}
+
+// ViewFilter returns the filter expression of a saved view
+func ViewFilter(ctx context.Context, queries *sqlc.Queries, name string) (string, error) {
+	view, err := queries.ReadSavedView(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: %s", ErrViewNotFound, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read view %s: %w", name, err)
+	}
+	return view.Filter, nil
+}
+
+// DeleteView removes a saved view
+func DeleteView(ctx context.Context, queries *sqlc.Queries, name string) error {
+	removed, err := queries.DeleteSavedView(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete view %s: %w", name, err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("%w: %s", ErrViewNotFound, name)
+	}
+	return nil
+}
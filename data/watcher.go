@@ -0,0 +1,309 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akthe-at/go_task/db"
+	"github.com/akthe-at/go_task/sqlc"
+	"github.com/fsnotify/fsnotify"
+)
+
+// noteWatchDelay is how long the watcher waits for the file events of a save or a move to settle
+const noteWatchDelay = 250 * time.Millisecond
+
+// NoteChanges describes what the watcher changed after files under the notes path changed
+type NoteChanges struct {
+	Moved    []int64
+	Retitled []int64
+	// Deleted are the notes whose file was deleted, or moved somewhere the watcher could not find it
+	Deleted []int64
+	Err     error
+}
+
+// Empty reports whether no note changed
+func (c NoteChanges) Empty() bool {
+	return len(c.Moved) == 0 && len(c.Retitled) == 0 && len(c.Deleted) == 0 && c.Err == nil
+}
+
+// NoteWatcher keeps the notes in the database in step with their files while it runs
+type NoteWatcher struct {
+	conn      *sql.DB
+	watcher   *fsnotify.Watcher
+	changes   chan NoteChanges
+	done      chan struct{}
+	closeOnce sync.Once
+	// ids maps the path of every note to the ID in its frontmatter, the ID is what finds a note again once its old file is gone
+	ids map[string]string
+}
+
+/*
+WatchNotes watches notesPath and every folder below it, except hidden ones such as .obsidian and .git.
+ 1. Events are collected until the files stop changing for a moment, editors write files in several steps
+ 2. A note file that was renamed or moved is found again through the ID in its frontmatter and the note path is updated
+ 3. A note whose frontmatter title was edited gets the new title
+ 4. Notes whose file is gone are reported as deleted, they stay in the database until 'notes doctor --prune'
+ 5. Every batch that changed something is sent on Changes, the channel is closed by Close
+*/
+func WatchNotes(conn *sql.DB, notesPath string) (*NoteWatcher, error) {
+	root, err := absNotePath(notesPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the notes path %s: %w", notesPath, err)
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the file watcher: %w", err)
+	}
+	w := &NoteWatcher{
+		conn:    conn,
+		watcher: watcher,
+		changes: make(chan NoteChanges, 8),
+		done:    make(chan struct{}),
+		ids:     make(map[string]string),
+	}
+	if err := w.addFolders(root); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", root, err)
+	}
+	if err := w.readNoteIDs(context.Background()); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// Changes returns the channel the batches of note changes are sent on
+func (w *NoteWatcher) Changes() <-chan NoteChanges {
+	return w.changes
+}
+
+// Close stops watching the notes path
+func (w *NoteWatcher) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		err = w.watcher.Close()
+	})
+	return err
+}
+
+// addFolders watches root and the folders below it, fsnotify only reports changes in the folders it was given
+func (w *NoteWatcher) addFolders(root string) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return w.watcher.Add(path)
+	})
+}
+
+// readNoteIDs remembers the frontmatter ID of every note file that exists right now
+func (w *NoteWatcher) readNoteIDs(ctx context.Context) error {
+	notes, err := sqlc.New(w.conn).ExportNotes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read notes: %w", err)
+	}
+	for _, note := range notes {
+		path, err := absNotePath(note.Path)
+		if err != nil {
+			continue
+		}
+		if content, err := os.ReadFile(path); err == nil {
+			w.ids[path] = readNoteFrontmatter(content).id
+		}
+	}
+	return nil
+}
+
+func (w *NoteWatcher) run() {
+	defer close(w.changes)
+	touched := make(map[string]bool)
+	timer := time.NewTimer(noteWatchDelay)
+	timer.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() && !strings.HasPrefix(info.Name(), ".") {
+					if err := w.addFolders(event.Name); err != nil {
+						w.send(NoteChanges{Err: fmt.Errorf("failed to watch %s: %w", event.Name, err)})
+					}
+				}
+			}
+			touched[event.Name] = true
+			timer.Reset(noteWatchDelay)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.send(NoteChanges{Err: err})
+		case <-timer.C:
+			paths := make([]string, 0, len(touched))
+			for path := range touched {
+				paths = append(paths, path)
+			}
+			clear(touched)
+			// The files were changed outside go_task, not by the key press whose undo step may be open
+			changes, err := reconcileNoteFiles(db.WithoutUndo(context.Background()), w.conn, paths, w.ids)
+			changes.Err = err
+			if !changes.Empty() {
+				w.send(changes)
+			}
+		}
+	}
+}
+
+// send hands a batch to the reader of Changes unless the watcher is closing
+func (w *NoteWatcher) send(changes NoteChanges) {
+	select {
+	case w.changes <- changes:
+	case <-w.done:
+	}
+}
+
+/*
+reconcileNoteFiles updates the notes after the files or folders in touched changed.
+ 1. Folders in touched stand for every markdown file below them, a folder that was moved in brings its notes along
+ 2. A markdown file that no note points at takes over a missing note when its frontmatter ID is the ID
+    last seen in the file of that note, or the name of that file, GenerateNoteID names files after the ID
+ 3. The frontmatter title of a note file that changed replaces the title of the note
+ 4. Missing notes whose file or folder is in touched are reported as deleted
+ 5. ids is updated with the frontmatter IDs read along the way
+*/
+func reconcileNoteFiles(ctx context.Context, conn *sql.DB, touched []string, ids map[string]string) (NoteChanges, error) {
+	var changes NoteChanges
+	queries := sqlc.New(conn)
+	notes, err := queries.ExportNotes(ctx)
+	if err != nil {
+		return changes, fmt.Errorf("failed to read notes: %w", err)
+	}
+
+	byPath := make(map[string]sqlc.Note)
+	var missing []sqlc.Note
+	for _, note := range notes {
+		path, err := absNotePath(note.Path)
+		if err != nil {
+			return changes, fmt.Errorf("failed to resolve the path of note %d: %w", note.ID, err)
+		}
+		note.Path = path
+		byPath[path] = note
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			missing = append(missing, note)
+		}
+	}
+
+	files, err := touchedNoteFiles(touched)
+	if err != nil {
+		return changes, err
+	}
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return changes, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		properties := readNoteFrontmatter(content)
+
+		note, ok := byPath[path]
+		if !ok {
+			i := slices.IndexFunc(missing, func(note sqlc.Note) bool {
+				stem := strings.TrimSuffix(filepath.Base(note.Path), filepath.Ext(note.Path))
+				return properties.id != "" && (ids[note.Path] == properties.id || stem == properties.id)
+			})
+			if i < 0 {
+				continue
+			}
+			note = missing[i]
+			missing = slices.Delete(missing, i, i+1)
+			if _, err := queries.UpdateNotePath(ctx, sqlc.UpdateNotePathParams{Path: path, ID: note.ID}); err != nil {
+				return changes, fmt.Errorf("failed to update the path of note %d: %w", note.ID, err)
+			}
+			delete(ids, note.Path)
+			changes.Moved = append(changes.Moved, note.ID)
+		}
+		ids[path] = properties.id
+
+		if properties.title != "" && properties.title != note.Title {
+			if _, err := queries.UpdateNoteTitle(ctx, sqlc.UpdateNoteTitleParams{Title: properties.title, ID: note.ID}); err != nil {
+				return changes, fmt.Errorf("failed to update the title of note %d: %w", note.ID, err)
+			}
+			changes.Retitled = append(changes.Retitled, note.ID)
+		}
+		refreshNoteIndexQuietly(ctx, conn, note.ID, path)
+	}
+
+	for _, note := range missing {
+		if slices.ContainsFunc(touched, func(path string) bool {
+			return note.Path == path || strings.HasPrefix(note.Path, path+string(filepath.Separator))
+		}) {
+			changes.Deleted = append(changes.Deleted, note.ID)
+		}
+	}
+	return changes, nil
+}
+
+// touchedNoteFiles lists the markdown files among touched and below the folders in touched, skipping hidden folders
+func touchedNoteFiles(touched []string) ([]string, error) {
+	var files []string
+	for _, path := range touched {
+		info, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", path, err)
+		}
+		if !info.IsDir() {
+			if strings.EqualFold(filepath.Ext(path), ".md") {
+				files = append(files, path)
+			}
+			continue
+		}
+		err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() {
+				if file != path && strings.HasPrefix(entry.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.EqualFold(filepath.Ext(file), ".md") {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the notes in %s: %w", path, err)
+		}
+	}
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
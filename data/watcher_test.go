@@ -0,0 +1,140 @@
+package data
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/akthe-at/go_task/sqlc"
+)
+
+func TestReconcileNoteFiles(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+
+	dir := t.TempDir()
+	files := []struct{ name, title, id string }{
+		{name: "1730800000-plan.md", title: "Plan", id: "1730800000-plan"},
+		{name: "renamed-once.md", title: "Retro", id: "1730800001-retro"},
+		{name: "1730800002-budget.md", title: "Budget", id: "1730800002-budget"},
+		{name: "1730800003-trip.md", title: "Trip", id: "1730800003-trip"},
+	}
+	for _, file := range files {
+		content := "---\nTitle: " + file.title + "\nID: " + file.id + "\n---\n"
+		if err := os.WriteFile(filepath.Join(dir, file.name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := queries.ImportNote(ctx, sqlc.ImportNoteParams{Title: file.title, Path: filepath.Join(dir, file.name)}); err != nil {
+			t.Fatalf("ImportNote() error = %v", err)
+		}
+	}
+	ids := map[string]string{filepath.Join(dir, "renamed-once.md"): "1730800001-retro"}
+
+	// The plan is retitled, the retro moves into a new folder, the budget is renamed and the trip is deleted
+	move := func(from, to string) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, to)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(filepath.Join(dir, from), filepath.Join(dir, to)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "1730800000-plan.md"), []byte("---\nTitle: Master plan\nID: 1730800000-plan\n---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	move("renamed-once.md", "done/retro.md")
+	move("1730800002-budget.md", "budget-2025.md")
+	if err := os.Remove(filepath.Join(dir, "1730800003-trip.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "stray.md"), []byte("---\nID: 1730800009-stray\n---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	touched := []string{"1730800000-plan.md", "renamed-once.md", "done", "1730800002-budget.md", "budget-2025.md", "1730800003-trip.md", "stray.md"}
+	for i, name := range touched {
+		touched[i] = filepath.Join(dir, name)
+	}
+
+	changes, err := reconcileNoteFiles(ctx, conn, touched, ids)
+	if err != nil {
+		t.Fatalf("reconcileNoteFiles() error = %v", err)
+	}
+	want := NoteChanges{Moved: []int64{3, 2}, Retitled: []int64{1}, Deleted: []int64{4}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("reconcileNoteFiles() = %+v, want %+v", changes, want)
+	}
+
+	notes, err := queries.ExportNotes(ctx)
+	if err != nil {
+		t.Fatalf("ExportNotes() error = %v", err)
+	}
+	wantNotes := []sqlc.Note{
+		{ID: 1, Title: "Master plan", Path: filepath.Join(dir, "1730800000-plan.md")},
+		{ID: 2, Title: "Retro", Path: filepath.Join(dir, "done/retro.md")},
+		{ID: 3, Title: "Budget", Path: filepath.Join(dir, "budget-2025.md")},
+		{ID: 4, Title: "Trip", Path: filepath.Join(dir, "1730800003-trip.md")},
+	}
+	if !reflect.DeepEqual(notes, wantNotes) {
+		t.Errorf("notes after reconcileNoteFiles() = %+v, want %+v", notes, wantNotes)
+	}
+
+	// Saving a file without changing its title changes nothing
+	changes, err = reconcileNoteFiles(ctx, conn, []string{filepath.Join(dir, "budget-2025.md")}, ids)
+	if err != nil || !changes.Empty() {
+		t.Errorf("reconcileNoteFiles() = %+v, %v, want no changes", changes, err)
+	}
+}
+
+func TestWatchNotes(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	queries := sqlc.New(conn)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "1730800000-plan.md")
+	if err := os.WriteFile(path, []byte("---\nTitle: Plan\nID: 1730800000-plan\n---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	noteID, err := queries.ImportNote(ctx, sqlc.ImportNoteParams{Title: "Plan", Path: path})
+	if err != nil {
+		t.Fatalf("ImportNote() error = %v", err)
+	}
+
+	watcher, err := WatchNotes(conn, dir)
+	if err != nil {
+		t.Fatalf("WatchNotes() error = %v", err)
+	}
+	defer watcher.Close()
+
+	if err := os.Mkdir(filepath.Join(dir, "archive"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	moved := filepath.Join(dir, "archive", "plan.md")
+	if err := os.Rename(path, moved); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case changes := <-watcher.Changes():
+		if want := (NoteChanges{Moved: []int64{noteID}}); !reflect.DeepEqual(changes, want) {
+			t.Errorf("Changes() = %+v, want %+v", changes, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the watcher did not report the moved note")
+	}
+	notes, err := queries.ExportNotes(ctx)
+	if err != nil || len(notes) != 1 || notes[0].Path != moved {
+		t.Errorf("ExportNotes() = %+v, %v, want the note at %s", notes, err, moved)
+	}
+
+	if err := watcher.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if _, ok := <-watcher.Changes(); ok {
+		t.Error("Changes() is still open after Close()")
+	}
+}
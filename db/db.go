@@ -1,19 +1,82 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+)
 
-	_ "github.com/mattn/go-sqlite3"
+const (
+	SourceCLI = "cli"
+	SourceTUI = "tui"
 )
 
+// driverName is the sqlite3 driver whose connections carry the session schema, see session.sql
+const driverName = "sqlite3_go_task"
+
+// EventSource is recorded as the source of every change go_task makes in the events table, the TUI sets it to SourceTUI
+var EventSource = SourceCLI
+
+// undoStep is the step the changes are recorded under for undo, nil while no step is open.
+// Connections copy it into go_task_session whenever database/sql hands them out.
+type undoStep struct {
+	id          int64
+	description string
+}
+
+var currentUndoStep atomic.Pointer[undoStep]
+
+/*
+BeginUndoStep records every change made from now on as one step that undo reverts together.
+ 1. The step ends with EndUndoStep or when the next step begins
+ 2. Steps are numbered by the time they began, so they sort in the order they were made
+ 3. A step that changes nothing is never written to the database
+*/
+func BeginUndoStep(description string) {
+	id := time.Now().UnixNano()
+	if previous := currentUndoStep.Load(); previous != nil && id <= previous.id {
+		id = previous.id + 1
+	}
+	currentUndoStep.Store(&undoStep{id: id, description: description})
+}
+
+// EndUndoStep stops recording changes for undo, changes made without a step can not be undone
+func EndUndoStep() {
+	currentUndoStep.Store(nil)
+}
+
+func init() {
+	sql.Register(driverName, &sessionDriver{})
+}
+
 // ConnectDB opens a connection to a SQLite database.
 // It returns a pointer to the sql.DB object and an error if any occurs.
+// Pending migrations are applied first, the connection is refused if the database schema is newer
+// than the migrations embedded in this binary. Use Open to connect without touching the schema.
 func ConnectDB() (*sql.DB, string, error) {
+	db, completePath, err := Open()
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = migrateOnConnect(db)
+	if err != nil {
+		db.Close()
+		return nil, "", err
+	}
+	return db, completePath, nil
+}
+
+// Open opens a connection to the SQLite database without checking its schema version.
+// This is only meant for commands that manage the schema itself, such as 'db migrate'.
+func Open() (*sql.DB, string, error) {
 	var dbPath string
 	switch runtime.GOOS {
 	case "windows":
@@ -37,22 +100,29 @@ func ConnectDB() (*sql.DB, string, error) {
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to create directory: %w", err)
 	}
-	db, err := sql.Open("sqlite3", dbPath+"/taskdb.db")
+	completePath := dbPath + "/taskdb.db"
+
+	db, err := OpenPath(completePath)
 	if err != nil {
-		return nil, "", fmt.Errorf("invalid sql.Open() arguments: %w", err)
+		return nil, "", err
 	}
-	completePath := dbPath + "/taskdb.db"
+	return db, completePath, nil
+}
 
-	_, err = db.Exec("PRAGMA foreign_keys=ON;")
+// OpenPath opens the SQLite database at the given path with foreign keys enabled.
+// Foreign keys are enabled through the DSN so that every pooled connection gets them.
+// The connections also get the session schema, see session.sql, which records changes for undo.
+func OpenPath(dbPath string) (*sql.DB, error) {
+	db, err := sql.Open(driverName, "file:"+dbPath+"?_foreign_keys=on")
 	if err != nil {
-		log.Fatalf("Failed to enable foreign keys: %v", err)
+		return nil, fmt.Errorf("invalid sql.Open() arguments: %w", err)
 	}
 
 	err = db.Ping()
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to ping database: %w", err)
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
-	return db, completePath, nil
+	return db, nil
 }
 
 // FileExists This function checks if a file exists, if it does returns true.
@@ -83,111 +153,68 @@ func IsSetup(db *sql.DB) bool {
 }
 
 /*
-SetupDB Setup the Initial DB Schema
- 1. Creates the areas and tasks tables if they do not exist
+SetupDB Setup the DB Schema
+ 1. Applies every pending migration, creating the schema on a fresh database
  2. Returns an error if any occurs
- 3. Uses transactions for safety
- 4. Uses prepared statements for better performance
+ 3. Each migration runs in its own transaction for safety
 */
 func SetupDB(db *sql.DB) error {
-	query := `
-		PRAGMA journal_mode=WAL;
-		CREATE TABLE IF NOT EXISTS areas (
-			id INTEGER PRIMARY KEY,
-			title TEXT NOT NULL,
-			status TEXT,
-			archived BOOLEAN NOT NULL DEFAULT 0,
-			created_at TEXT NOT NULL DEFAULT (datetime(current_timestamp, 'localtime')),
-			last_mod TEXT NOT NULL DEFAULT (datetime(current_timestamp, 'localtime'))
-		);
-		CREATE TABLE IF NOT EXISTS tasks (
-			id INTEGER PRIMARY KEY,
-			title TEXT NOT NULL,
-			priority TEXT,
-			status TEXT,
-			archived BOOLEAN NOT NULL DEFAULT 0,
-			created_at TEXT NOT NULL DEFAULT (datetime(current_timestamp, 'localtime')),
-			last_mod TEXT NOT NULL DEFAULT (datetime(current_timestamp, 'localtime')),
-			due_date TEXT,
-			area_id INTEGER,
-			FOREIGN KEY(area_id) REFERENCES areas(id) ON DELETE SET NULL ON UPDATE CASCADE
-		);
-		CREATE TABLE IF NOT EXISTS notes (
-			id INTEGER PRIMARY KEY,
-			title TEXT NOT NULL,
-			path TEXT NOT NULL
-		);
-		CREATE TABLE IF NOT EXISTS bridge_notes (
-			note_id INTEGER PRIMARY KEY,
-			parent_cat INTEGER,
-			parent_task_id INTEGER,
-			parent_area_id INTEGER,
-			FOREIGN KEY(note_id) REFERENCES notes(id) ON DELETE CASCADE ON UPDATE CASCADE,
-			CHECK (parent_cat IN (1, 2)),
-			FOREIGN KEY(parent_task_id) REFERENCES tasks(id) ON DELETE CASCADE ON UPDATE CASCADE,
-			FOREIGN KEY(parent_area_id) REFERENCES areas(id) ON DELETE CASCADE ON UPDATE CASCADE
-		);
-		CREATE TABLE IF NOT EXISTS programming_projects (
-			id INTEGER PRIMARY KEY,
-			path TEXT NOT NULL UNIQUE
-		);
-		CREATE TABLE IF NOT EXISTS prog_project_links (
-			project_id INTEGER,
-			parent_cat INTEGER,
-			parent_task_id INTEGER,
-			parent_area_id INTEGER,
-			FOREIGN KEY(project_id) REFERENCES programming_projects(id) ON DELETE CASCADE,
-			CHECK (parent_cat IN (1, 2)),
-			FOREIGN KEY(parent_task_id) REFERENCES tasks(id) ON DELETE CASCADE,
-			FOREIGN KEY(parent_area_id) REFERENCES areas(id) ON DELETE CASCADE
-		);
-		CREATE TRIGGER IF NOT EXISTS update_last_mod_tasks
-		AFTER UPDATE ON tasks
-		BEGIN
-			UPDATE tasks 
-			SET last_mod = datetime(current_timestamp, 'localtime')
-			WHERE id = OLD.id;
-		END;
-		CREATE TRIGGER IF NOT EXISTS update_last_mod_areas
-		AFTER UPDATE ON areas
-		BEGIN
-			UPDATE areas 
-			SET last_mod = datetime(current_timestamp, 'localtime')
-			WHERE id = OLD.id;
-		END;
-	`
-
-	_, err := db.Exec(query)
+	_, err := Migrate(db)
 	if err != nil {
-		return fmt.Errorf("failed to execute query: %w", err)
+		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
 	return nil
 }
 
-// ResetDB drops all tables and recreates them.
+// ResetDB drops all tables, triggers, and views and recreates them from the migrations.
 func ResetDB(db *sql.DB) error {
-	queries := `
-		DROP TABLE IF EXISTS areas;
-		DROP TABLE IF EXISTS tasks;
-		DROP TABLE IF EXISTS notes;
-		DROP TABLE IF EXISTS bridge_notes;
-		DROP TABLE IF EXISTS programming_projects;
-		DROP TABLE IF EXISTS prog_project_links;
-		DROP TRIGGER IF EXISTS update_last_mod_tasks;
-		DROP TRIGGER IF EXISTS update_last_mod_areas;
-	`
-	tx, err := db.Begin()
+	ctx := context.Background()
+
+	// PRAGMAs are connection scoped, so the drops must happen on the same connection.
+	conn, err := db.Conn(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return fmt.Errorf("failed to get a database connection: %w", err)
 	}
-	_, err = tx.Exec(queries)
+	defer conn.Close()
+
+	rows, err := conn.QueryContext(ctx, `
+		SELECT type, name FROM sqlite_master
+		WHERE type IN ('table', 'trigger', 'view') AND name NOT LIKE 'sqlite_%'
+		ORDER BY CASE type WHEN 'trigger' THEN 0 WHEN 'view' THEN 1 ELSE 2 END`)
 	if err != nil {
-		err := tx.Rollback()
+		return fmt.Errorf("failed to list schema objects: %w", err)
+	}
+	var drops []string
+	for rows.Next() {
+		var objType, name string
+		if err := rows.Scan(&objType, &name); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan schema object: %w", err)
+		}
+		drops = append(drops, fmt.Sprintf("DROP %s IF EXISTS %q;", strings.ToUpper(objType), name))
+	}
+	rows.Close()
+
+	_, err = conn.ExecContext(ctx, "PRAGMA foreign_keys=OFF;")
+	if err != nil {
+		return fmt.Errorf("failed to disable foreign keys: %w", err)
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys=ON;")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	for _, drop := range drops {
+		_, err = tx.ExecContext(ctx, drop)
 		if err != nil {
-			fmt.Printf("failed to rollback transaction: %v", err)
+			rollbackErr := tx.Rollback()
+			if rollbackErr != nil {
+				fmt.Printf("failed to rollback transaction: %v", rollbackErr)
+			}
+			return fmt.Errorf("failed to drop schema objects: %w", err)
 		}
-		return fmt.Errorf("failed to drop tables: %w", err)
 	}
 	err = tx.Commit()
 	if err != nil {
@@ -0,0 +1,339 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var (
+	// ErrSchemaTooNew is returned when the database was migrated by a newer
+	// version of go_task than the one currently running.
+	ErrSchemaTooNew = errors.New("database schema is newer than this version of go_task")
+	// ErrMigrationsPending is returned when the database is missing migrations
+	// that this version of go_task expects to have been applied.
+	ErrMigrationsPending = errors.New("database schema is out of date")
+)
+
+// Migration is a single, ordered schema change that is embedded in the binary.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// AppliedMigration is a migration that has been recorded in the schema_version table.
+type AppliedMigration struct {
+	Version   int
+	Name      string
+	AppliedAt string
+}
+
+// Migrations returns every embedded migration sorted by version.
+// Migration files are named <version>_<name>.sql, e.g. 0001_initial_schema.sql
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+
+	var migrations []Migration
+	seen := make(map[int]string)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		version, name, err := parseMigrationName(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if existing, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", existing, entry.Name(), version)
+		}
+		seen[version] = entry.Name()
+
+		contents, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, Migration{Version: version, Name: name, SQL: string(contents)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func parseMigrationName(fileName string) (int, string, error) {
+	base := strings.TrimSuffix(fileName, ".sql")
+	rawVersion, name, ok := strings.Cut(base, "_")
+	if !ok {
+		return 0, "", fmt.Errorf("migration %s must be named <version>_<name>.sql", fileName)
+	}
+	version, err := strconv.Atoi(rawVersion)
+	if err != nil || version < 1 {
+		return 0, "", fmt.Errorf("migration %s has an invalid version: %q", fileName, rawVersion)
+	}
+	return version, name, nil
+}
+
+// LatestVersion returns the highest migration version known to this binary.
+func LatestVersion() (int, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// ensureVersionTable creates the schema_version table if it does not exist yet.
+func ensureVersionTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_version (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TEXT NOT NULL DEFAULT (datetime(current_timestamp, 'localtime'))
+		);`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_version table: %w", err)
+	}
+	return nil
+}
+
+/*
+CurrentVersion returns the version of the last migration applied to the database.
+ 1. Returns 0 if the schema_version table does not exist yet
+ 2. Returns the highest applied version otherwise
+*/
+func CurrentVersion(db *sql.DB) (int, error) {
+	var exists int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name='schema_version'`).Scan(&exists)
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up schema_version table: %w", err)
+	}
+	if exists == 0 {
+		return 0, nil
+	}
+
+	var version int
+	err = db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// AppliedMigrations returns every migration recorded in the schema_version table.
+func AppliedMigrations(db *sql.DB) ([]AppliedMigration, error) {
+	version, err := CurrentVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 {
+		return nil, nil
+	}
+
+	rows, err := db.Query(`SELECT version, name, applied_at FROM schema_version ORDER BY version`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var applied []AppliedMigration
+	for rows.Next() {
+		var m AppliedMigration
+		if err := rows.Scan(&m.Version, &m.Name, &m.AppliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied = append(applied, m)
+	}
+	return applied, rows.Err()
+}
+
+// PendingMigrations returns the embedded migrations that have not been applied yet.
+func PendingMigrations(db *sql.DB) ([]Migration, error) {
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, m := range migrations {
+		if m.Version > current {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// checkSchemaVersion makes sure the database matches the migrations embedded in the binary.
+func checkSchemaVersion(db *sql.DB) error {
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return err
+	}
+	latest, err := LatestVersion()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case current > latest:
+		return fmt.Errorf("%w: the database is at version %d but this binary only knows up to version %d, please upgrade go_task", ErrSchemaTooNew, current, latest)
+	case current < latest:
+		return fmt.Errorf("%w: the database is at version %d but this binary expects version %d, run 'go_task db migrate'", ErrMigrationsPending, current, latest)
+	}
+	return nil
+}
+
+/*
+migrateOnConnect brings the database up to date before a command uses it.
+ 1. Pending migrations are applied, so the first command after an upgrade migrates the database
+ 2. A database that is newer than the binary is refused with ErrSchemaTooNew
+ 3. The applied migrations are reported on stderr
+*/
+func migrateOnConnect(db *sql.DB) error {
+	err := checkSchemaVersion(db)
+	if !errors.Is(err, ErrMigrationsPending) {
+		return err
+	}
+
+	applied, err := Migrate(db)
+	for _, m := range applied {
+		fmt.Fprintf(os.Stderr, "Applied migration %04d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to apply the pending migrations: %w", err)
+	}
+	return nil
+}
+
+/*
+Migrate applies every pending migration to the database.
+ 1. Each migration runs in its own transaction and is recorded in schema_version
+ 2. Foreign keys are switched off while a migration runs so tables can be rebuilt,
+    and are checked for violations before the migration is committed
+ 3. Refuses to touch a database that is newer than the binary
+ 4. Returns the migrations that were applied
+*/
+func Migrate(db *sql.DB) ([]Migration, error) {
+	ctx := context.Background()
+
+	current, err := CurrentVersion(db)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := LatestVersion()
+	if err != nil {
+		return nil, err
+	}
+	if current > latest {
+		return nil, fmt.Errorf("%w: the database is at version %d but this binary only knows up to version %d", ErrSchemaTooNew, current, latest)
+	}
+
+	pending, err := PendingMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	// PRAGMAs are connection scoped, so every migration has to run on the same connection.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a database connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA journal_mode=WAL;"); err != nil {
+		return nil, fmt.Errorf("failed to enable WAL mode: %w", err)
+	}
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys=OFF;"); err != nil {
+		return nil, fmt.Errorf("failed to disable foreign keys: %w", err)
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys=ON;")
+
+	var applied []Migration
+	for _, m := range pending {
+		if err := applyMigration(ctx, conn, m); err != nil {
+			return applied, err
+		}
+		applied = append(applied, m)
+	}
+
+	return applied, nil
+}
+
+func applyMigration(ctx context.Context, conn *sql.Conn, m Migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+	defer tx.Rollback()
+
+	// Older databases may already contain dangling references, so only the
+	// violations introduced by the migration itself are treated as an error.
+	violationsBefore, err := countForeignKeyViolations(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("failed to check foreign keys before migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+		return fmt.Errorf("failed to apply migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+
+	violationsAfter, err := countForeignKeyViolations(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("failed to check foreign keys after migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+	if violationsAfter > violationsBefore {
+		return fmt.Errorf("migration %04d_%s introduced %d foreign key violation(s)", m.Version, m.Name, violationsAfter-violationsBefore)
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO schema_version (version, name) VALUES (?, ?)`, m.Version, m.Name)
+	if err != nil {
+		return fmt.Errorf("failed to record migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %04d_%s: %w", m.Version, m.Name, err)
+	}
+	return nil
+}
+
+func countForeignKeyViolations(ctx context.Context, tx *sql.Tx) (int, error) {
+	rows, err := tx.QueryContext(ctx, "PRAGMA foreign_key_check;")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	return count, rows.Err()
+}
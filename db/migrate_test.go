@@ -0,0 +1,414 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	conn, err := OpenPath(filepath.Join(t.TempDir(), "taskdb.db"))
+	if err != nil {
+		t.Fatalf("OpenPath() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Migrations() error = %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("Migrations() returned no migrations")
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %s has version %d, want %d", m.Name, m.Version, i+1)
+		}
+	}
+}
+
+func TestParseMigrationName(t *testing.T) {
+	tests := []struct {
+		name        string
+		fileName    string
+		wantVersion int
+		wantName    string
+		wantErr     bool
+	}{
+		{name: "valid name", fileName: "0001_initial_schema.sql", wantVersion: 1, wantName: "initial_schema"},
+		{name: "missing name", fileName: "0001.sql", wantErr: true},
+		{name: "invalid version", fileName: "abc_initial.sql", wantErr: true},
+		{name: "zero version", fileName: "0000_initial.sql", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, name, err := parseMigrationName(tt.fileName)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMigrationName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if version != tt.wantVersion || name != tt.wantName {
+				t.Errorf("parseMigrationName() = %d, %q, want %d, %q", version, name, tt.wantVersion, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestMigrateFreshDatabase(t *testing.T) {
+	conn := openTestDB(t)
+
+	if err := checkSchemaVersion(conn); !errors.Is(err, ErrMigrationsPending) {
+		t.Fatalf("checkSchemaVersion() on a fresh database error = %v, want %v", err, ErrMigrationsPending)
+	}
+
+	applied, err := Migrate(conn)
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	latest, _ := LatestVersion()
+	if len(applied) != latest {
+		t.Errorf("Migrate() applied %d migrations, want %d", len(applied), latest)
+	}
+	if err := checkSchemaVersion(conn); err != nil {
+		t.Errorf("checkSchemaVersion() after migrating error = %v", err)
+	}
+
+	applied, err = Migrate(conn)
+	if err != nil {
+		t.Fatalf("second Migrate() error = %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("second Migrate() applied %d migrations, want 0", len(applied))
+	}
+}
+
+func TestMigrateOnConnect(t *testing.T) {
+	conn := openTestDB(t)
+
+	// An outdated database is migrated instead of refused
+	if err := migrateOnConnect(conn); err != nil {
+		t.Fatalf("migrateOnConnect() on a fresh database error = %v", err)
+	}
+	if err := checkSchemaVersion(conn); err != nil {
+		t.Errorf("checkSchemaVersion() after connecting error = %v", err)
+	}
+	if err := migrateOnConnect(conn); err != nil {
+		t.Errorf("migrateOnConnect() on a current database error = %v", err)
+	}
+}
+
+func TestMigrateAdoptsLegacyDatabase(t *testing.T) {
+	conn := openTestDB(t)
+
+	// The tables as SetupDB used to create them, with data that must survive.
+	_, err := conn.Exec(`
+		CREATE TABLE areas (
+			id INTEGER PRIMARY KEY,
+			title TEXT NOT NULL,
+			status TEXT,
+			archived BOOLEAN NOT NULL DEFAULT 0,
+			created_at TEXT NOT NULL DEFAULT (datetime(current_timestamp, 'localtime')),
+			last_mod TEXT NOT NULL DEFAULT (datetime(current_timestamp, 'localtime'))
+		);
+		CREATE TABLE tasks (
+			id INTEGER PRIMARY KEY,
+			title TEXT NOT NULL,
+			priority TEXT,
+			status TEXT,
+			archived BOOLEAN NOT NULL DEFAULT 0,
+			created_at TEXT NOT NULL DEFAULT (datetime(current_timestamp, 'localtime')),
+			last_mod TEXT NOT NULL DEFAULT (datetime(current_timestamp, 'localtime')),
+			due_date TEXT,
+			area_id INTEGER,
+			FOREIGN KEY(area_id) REFERENCES areas(id) ON DELETE SET NULL ON UPDATE CASCADE
+		);
+		CREATE TRIGGER update_last_mod_tasks
+		BEFORE UPDATE ON tasks
+		FOR EACH ROW
+		BEGIN
+			UPDATE tasks SET last_mod = (datetime(current_timestamp, 'localtime')) WHERE id = OLD.id;
+		END;
+		INSERT INTO areas (id, title, status) VALUES (1, 'Work', 'doing');
+		INSERT INTO tasks (id, title, priority, status, area_id) VALUES (1, 'Write report', 'high', 'todo', 1);
+	`)
+	if err != nil {
+		t.Fatalf("failed to create legacy schema: %v", err)
+	}
+
+	if _, err := Migrate(conn); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	var title string
+	if err := conn.QueryRow(`SELECT title FROM tasks WHERE id = 1`).Scan(&title); err != nil {
+		t.Fatalf("failed to read legacy task: %v", err)
+	}
+	if title != "Write report" {
+		t.Errorf("legacy task title = %q, want %q", title, "Write report")
+	}
+
+	var triggerSQL string
+	err = conn.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'trigger' AND name = 'update_last_mod_tasks'`).Scan(&triggerSQL)
+	if err != nil {
+		t.Fatalf("failed to read trigger: %v", err)
+	}
+	if want := "AFTER UPDATE ON tasks"; !strings.Contains(triggerSQL, want) {
+		t.Errorf("update_last_mod_tasks trigger = %q, want it to contain %q", triggerSQL, want)
+	}
+}
+
+func TestConnectRefusesNewerDatabase(t *testing.T) {
+	conn := openTestDB(t)
+	if _, err := Migrate(conn); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	latest, _ := LatestVersion()
+	_, err := conn.Exec(`INSERT INTO schema_version (version, name) VALUES (?, 'from_the_future')`, latest+1)
+	if err != nil {
+		t.Fatalf("failed to insert future version: %v", err)
+	}
+
+	if err := checkSchemaVersion(conn); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("checkSchemaVersion() error = %v, want %v", err, ErrSchemaTooNew)
+	}
+	if _, err := Migrate(conn); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Migrate() error = %v, want %v", err, ErrSchemaTooNew)
+	}
+	if err := migrateOnConnect(conn); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("migrateOnConnect() error = %v, want %v", err, ErrSchemaTooNew)
+	}
+}
+
+func TestResetDB(t *testing.T) {
+	conn := openTestDB(t)
+	if _, err := Migrate(conn); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if _, err := conn.Exec(`INSERT INTO areas (id, title) VALUES (1, 'Work')`); err != nil {
+		t.Fatalf("failed to insert area: %v", err)
+	}
+
+	if err := ResetDB(conn); err != nil {
+		t.Fatalf("ResetDB() error = %v", err)
+	}
+
+	var count int
+	if err := conn.QueryRow(`SELECT COUNT(*) FROM areas`).Scan(&count); err != nil {
+		t.Fatalf("failed to count areas: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("areas after reset = %d, want 0", count)
+	}
+	if err := checkSchemaVersion(conn); err != nil {
+		t.Errorf("checkSchemaVersion() after reset error = %v", err)
+	}
+}
+
+func TestMigrateNoteLinks(t *testing.T) {
+	conn := openTestDB(t)
+	ctx := context.Background()
+
+	// Stop right before the migration that turns bridge_notes into a join table
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Migrations() error = %v", err)
+	}
+	c, err := conn.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ensureVersionTable(ctx, c); err != nil {
+		t.Fatalf("ensureVersionTable() error = %v", err)
+	}
+	for _, m := range migrations {
+		if m.Name == "note_links" {
+			break
+		}
+		if err := applyMigration(ctx, c, m); err != nil {
+			t.Fatalf("applyMigration(%s) error = %v", m.Name, err)
+		}
+	}
+	c.Close()
+
+	_, err = conn.Exec(`
+		INSERT INTO areas (id, title) VALUES (1, 'Work');
+		INSERT INTO tasks (id, title) VALUES (1, 'Write report');
+		INSERT INTO notes (id, title, path) VALUES (1, 'Outline', '/notes/outline.md'), (2, 'Goals', '/notes/goals.md'), (3, 'Lost', '/notes/lost.md'),
+			(4, 'Budget', '/notes/budget.md'), (5, 'Draft', '/notes/draft.md');
+		INSERT INTO bridge_notes (note_id, parent_cat, parent_task_id, parent_area_id) VALUES (1, 1, 1, NULL), (2, 2, NULL, 1), (3, 1, NULL, NULL),
+			(4, 1, NULL, 1), (5, 2, 1, NULL);
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert notes: %v", err)
+	}
+	if _, err := Migrate(conn); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	var links []string
+	rows, err := conn.Query(`SELECT id, note_id, parent_cat, IFNULL(parent_task_id, parent_area_id) FROM bridge_notes ORDER BY id`)
+	if err != nil {
+		t.Fatalf("failed to read note links: %v", err)
+	}
+	for rows.Next() {
+		var id, noteID, parentCat, parentID int64
+		if err := rows.Scan(&id, &noteID, &parentCat, &parentID); err != nil {
+			t.Fatal(err)
+		}
+		links = append(links, fmt.Sprintf("%d %d:%d:%d", id, noteID, parentCat, parentID))
+	}
+	rows.Close()
+	// A category that points at the empty parent column is repaired, a link without any parent is dropped
+	if want := []string{"1 1:1:1", "2 2:2:1", "3 4:2:1", "4 5:1:1"}; !reflect.DeepEqual(links, want) {
+		t.Errorf("note links after the migration = %v, want %v", links, want)
+	}
+
+	tests := []struct {
+		name    string
+		link    string
+		wantErr bool
+	}{
+		{name: "second parent", link: "(1, 2, NULL, 1)"},
+		{name: "duplicate link", link: "(1, 1, 1, NULL)", wantErr: true},
+		{name: "parent does not match the category", link: "(2, 1, NULL, 1)", wantErr: true},
+		{name: "two parents in one link", link: "(2, 1, 1, 1)", wantErr: true},
+	}
+	for _, tt := range tests {
+		_, err := conn.Exec(`INSERT INTO bridge_notes (note_id, parent_cat, parent_task_id, parent_area_id) VALUES ` + tt.link)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: insert error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestMigrateEvents(t *testing.T) {
+	conn := openTestDB(t)
+	ctx := context.Background()
+
+	// Stop right before the migration that adds the events table
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Migrations() error = %v", err)
+	}
+	c, err := conn.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ensureVersionTable(ctx, c); err != nil {
+		t.Fatalf("ensureVersionTable() error = %v", err)
+	}
+	for _, m := range migrations {
+		if m.Name == "events" {
+			break
+		}
+		if err := applyMigration(ctx, c, m); err != nil {
+			t.Fatalf("applyMigration(%s) error = %v", m.Name, err)
+		}
+	}
+	c.Close()
+
+	_, err = conn.Exec(`
+		INSERT INTO areas (id, title, created_at) VALUES (1, 'Work', '2024-10-01 08:00:00');
+		INSERT INTO tasks (id, title, status, created_at) VALUES (1, 'Write report', 'todo', '2024-10-02 09:00:00'), (2, 'Book flights', 'todo', '2024-10-03 10:00:00');
+		UPDATE tasks SET status = 'done' WHERE id = 2;
+		UPDATE tasks SET completed_at = '2024-10-04 11:00:00' WHERE id = 2;
+		INSERT INTO notes (id, title, path) VALUES (1, 'Outline', '/notes/outline.md');
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert rows: %v", err)
+	}
+	if _, err := Migrate(conn); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	// Changes made after the migration are recorded with the source of the connection
+	if _, err := conn.Exec(`UPDATE tasks SET status = 'doing' WHERE id = 1`); err != nil {
+		t.Fatalf("failed to update task: %v", err)
+	}
+
+	var events []string
+	rows, err := conn.Query(`SELECT entity, entity_id, field, IFNULL(old_value, ''), IFNULL(new_value, ''), source,
+		CASE WHEN source = 'migration' AND entity != 'note' THEN changed_at ELSE '' END
+		FROM events ORDER BY id`)
+	if err != nil {
+		t.Fatalf("failed to read events: %v", err)
+	}
+	for rows.Next() {
+		var entity, field, oldValue, newValue, source, changedAt string
+		var entityID int64
+		if err := rows.Scan(&entity, &entityID, &field, &oldValue, &newValue, &source, &changedAt); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, fmt.Sprintf("%s %d %s %s>%s %s %s", entity, entityID, field, oldValue, newValue, source, changedAt))
+	}
+	rows.Close()
+	want := []string{
+		"task 1 created >Write report migration 2024-10-02 09:00:00",
+		"task 2 created >Book flights migration 2024-10-03 10:00:00",
+		"task 2 status >done migration 2024-10-04 11:00:00",
+		"area 1 created >Work migration 2024-10-01 08:00:00",
+		"note 1 created >Outline migration ",
+		"task 1 status todo>doing cli ",
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events after the migration =\n%q\nwant\n%q", events, want)
+	}
+}
+
+func TestExternalClientWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "taskdb.db")
+	conn, err := OpenPath(path)
+	if err != nil {
+		t.Fatalf("OpenPath() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	if _, err := Migrate(conn); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+
+	// A plain SQLite client has none of the TEMP triggers go_task creates on its connections
+	external, err := sql.Open("sqlite3", "file:"+path+"?_foreign_keys=on")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { external.Close() })
+	BeginUndoStep("external")
+	defer EndUndoStep()
+	if _, err := external.Exec(`INSERT INTO tasks (title) VALUES ('Written elsewhere')`); err != nil {
+		t.Fatalf("insert through a plain client error = %v", err)
+	}
+	var logged int
+	if err := conn.QueryRow(`SELECT COUNT(*) FROM undo_log`).Scan(&logged); err != nil || logged != 0 {
+		t.Errorf("undo_log after a plain client wrote has %d rows, %v, want none", logged, err)
+	}
+	EndUndoStep()
+	if _, err := conn.Exec(`UPDATE tasks SET status = 'doing' WHERE title = 'Written elsewhere'`); err != nil {
+		t.Fatalf("update through go_task error = %v", err)
+	}
+
+	var sources []string
+	rows, err := conn.Query(`SELECT field || ' ' || source FROM events WHERE entity = 'task' ORDER BY id`)
+	if err != nil {
+		t.Fatalf("failed to read events: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var source string
+		if err := rows.Scan(&source); err != nil {
+			t.Fatal(err)
+		}
+		sources = append(sources, source)
+	}
+	if want := []string{"created external", "status cli"}; !reflect.DeepEqual(sources, want) {
+		t.Errorf("event sources = %q, want %q", sources, want)
+	}
+}
@@ -1,6 +1,6 @@
-PRAGMA foreign_keys=ON;
-PRAGMA journal_mode=WAL;
-
+-- The schema as it was created by the original SetupDB script. Every
+-- table is guarded with IF NOT EXISTS so that databases created before
+-- migrations existed can be adopted without losing any data.
 CREATE TABLE IF NOT EXISTS areas (
     id INTEGER PRIMARY KEY,
     title TEXT NOT NULL,
@@ -10,7 +10,6 @@ CREATE TABLE IF NOT EXISTS areas (
     last_mod TEXT NOT NULL DEFAULT (datetime(current_timestamp, 'localtime'))
 );
 
-
 CREATE TABLE IF NOT EXISTS tasks (
     id INTEGER PRIMARY KEY,
     title TEXT NOT NULL,
@@ -24,20 +23,6 @@ CREATE TABLE IF NOT EXISTS tasks (
     FOREIGN KEY(area_id) REFERENCES areas(id) ON DELETE SET NULL ON UPDATE CASCADE
 );
 
-CREATE TRIGGER update_last_mod_tasks
-BEFORE UPDATE ON tasks
-FOR EACH ROW
-BEGIN
-    UPDATE tasks SET last_mod = (datetime(current_timestamp, 'localtime')) WHERE id = OLD.id;
-END;
-
-CREATE TRIGGER update_last_mod_areas
-BEFORE UPDATE ON areas
-FOR EACH ROW
-BEGIN
-    UPDATE areas SET last_mod = (datetime(current_timestamp, 'localtime')) WHERE id = OLD.id;
-END;
-
 CREATE TABLE IF NOT EXISTS notes (
     id INTEGER PRIMARY KEY,
     title TEXT NOT NULL,
@@ -63,7 +48,7 @@ CREATE TABLE IF NOT EXISTS programming_projects (
 CREATE TABLE IF NOT EXISTS prog_project_links (
     project_id INTEGER,
     parent_cat INTEGER,
-    parent_task_id INTEGER, 
+    parent_task_id INTEGER,
     parent_area_id INTEGER,
     FOREIGN KEY(project_id) REFERENCES programming_projects(id) ON DELETE CASCADE,
     CHECK (parent_cat IN (1, 2)),
@@ -71,3 +56,22 @@ CREATE TABLE IF NOT EXISTS prog_project_links (
     FOREIGN KEY(parent_area_id) REFERENCES areas(id) ON DELETE CASCADE
 );
 
+-- Older databases were created from schema.sql, which used BEFORE UPDATE
+-- triggers, so the triggers are always recreated to get everyone in sync.
+DROP TRIGGER IF EXISTS update_last_mod_tasks;
+CREATE TRIGGER update_last_mod_tasks
+AFTER UPDATE ON tasks
+BEGIN
+    UPDATE tasks
+    SET last_mod = datetime(current_timestamp, 'localtime')
+    WHERE id = OLD.id;
+END;
+
+DROP TRIGGER IF EXISTS update_last_mod_areas;
+CREATE TRIGGER update_last_mod_areas
+AFTER UPDATE ON areas
+BEGIN
+    UPDATE areas
+    SET last_mod = datetime(current_timestamp, 'localtime')
+    WHERE id = OLD.id;
+END;
@@ -0,0 +1,3 @@
+-- Recurring tasks store their recurrence rule in the RRULE format,
+-- e.g. FREQ=WEEKLY;INTERVAL=2;BYDAY=MO. NULL means the task does not recur.
+ALTER TABLE tasks ADD COLUMN recurrence TEXT;
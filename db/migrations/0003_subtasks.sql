@@ -0,0 +1,5 @@
+-- Tasks can be broken down into subtasks. Deleting a parent task keeps its
+-- subtasks around as top level tasks instead of silently deleting them.
+ALTER TABLE tasks ADD COLUMN parent_task_id INTEGER REFERENCES tasks(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_tasks_parent_task_id ON tasks(parent_task_id);
@@ -0,0 +1,6 @@
+-- Saved views are named task filters, see data.CompileFilter for the filter language.
+CREATE TABLE IF NOT EXISTS saved_views (
+    name TEXT PRIMARY KEY COLLATE NOCASE,
+    filter TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,8 @@
+-- Checkbox lines of a note that were turned into tasks by 'notes sync'. The line in the
+-- note carries the task ID, this table finds the note again when the task changes status.
+CREATE TABLE IF NOT EXISTS note_checkboxes (
+    task_id INTEGER PRIMARY KEY,
+    note_id INTEGER NOT NULL,
+    FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY(note_id) REFERENCES notes(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
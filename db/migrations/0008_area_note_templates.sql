@@ -0,0 +1,3 @@
+-- Name of the note template used for new notes of an area and of its tasks when no template is picked.
+-- NULL means notes are generated without a template.
+ALTER TABLE areas ADD COLUMN note_template TEXT;
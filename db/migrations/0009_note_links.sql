@@ -0,0 +1,46 @@
+-- A note can belong to several tasks and areas, e.g. a design doc covering three tasks.
+-- bridge_notes used note_id as its primary key, it becomes a join table with one row per
+-- link. The first link of a note, the one with the lowest id, is the parent it was created
+-- under, go_task writes the state of that parent into the frontmatter of the note.
+CREATE TABLE bridge_notes_new (
+    id INTEGER PRIMARY KEY,
+    note_id INTEGER NOT NULL,
+    parent_cat INTEGER,
+    parent_task_id INTEGER,
+    parent_area_id INTEGER,
+    FOREIGN KEY(note_id) REFERENCES notes(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CHECK (parent_cat IN (1, 2)),
+    CHECK ((parent_cat = 1 AND parent_task_id IS NOT NULL AND parent_area_id IS NULL)
+        OR (parent_cat = 2 AND parent_area_id IS NOT NULL AND parent_task_id IS NULL)),
+    FOREIGN KEY(parent_task_id) REFERENCES tasks(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    FOREIGN KEY(parent_area_id) REFERENCES areas(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- The category of a link follows the parent column that is set, a link whose parent_cat
+-- points at the other, empty column is repaired rather than dropped. Only links without any
+-- parent are left out, they pointed at nothing and their note stays.
+INSERT INTO bridge_notes_new (note_id, parent_cat, parent_task_id, parent_area_id)
+SELECT note_id, parent_cat,
+       CASE WHEN parent_cat = 1 THEN parent_task_id END,
+       CASE WHEN parent_cat = 2 THEN parent_area_id END
+FROM (
+    SELECT note_id, parent_task_id, parent_area_id,
+           CASE
+               WHEN parent_cat = 1 AND parent_task_id IS NOT NULL THEN 1
+               WHEN parent_cat = 2 AND parent_area_id IS NOT NULL THEN 2
+               WHEN parent_task_id IS NOT NULL THEN 1
+               ELSE 2
+           END AS parent_cat
+    FROM bridge_notes
+    WHERE parent_task_id IS NOT NULL OR parent_area_id IS NOT NULL
+)
+ORDER BY note_id;
+
+DROP TABLE bridge_notes;
+ALTER TABLE bridge_notes_new RENAME TO bridge_notes;
+
+-- The composite key of a link. SQLite treats NULLs as distinct in a PRIMARY KEY, so the key
+-- is built on whichever parent column is set.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bridge_notes_link ON bridge_notes(note_id, parent_cat, COALESCE(parent_task_id, parent_area_id));
+CREATE INDEX IF NOT EXISTS idx_bridge_notes_parent_task_id ON bridge_notes(parent_task_id);
+CREATE INDEX IF NOT EXISTS idx_bridge_notes_parent_area_id ON bridge_notes(parent_area_id);
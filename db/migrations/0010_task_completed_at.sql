@@ -0,0 +1,16 @@
+-- The journal lists the tasks that were moved to done on a day. last_mod changes with
+-- every edit, so the moment a task was finished is kept on its own. Tasks finished before
+-- this column existed, or imported as done, fall back to their last_mod.
+ALTER TABLE tasks ADD COLUMN completed_at TEXT;
+
+-- Moving a task to done stamps it, reopening it clears the stamp. Marking a done task
+-- done again keeps the original stamp.
+DROP TRIGGER IF EXISTS update_completed_at_tasks;
+CREATE TRIGGER update_completed_at_tasks
+AFTER UPDATE OF status ON tasks
+WHEN NEW.status IS NOT OLD.status AND (NEW.status = 'done' OR OLD.status = 'done')
+BEGIN
+    UPDATE tasks
+    SET completed_at = CASE WHEN NEW.status = 'done' THEN datetime(current_timestamp, 'localtime') END
+    WHERE id = NEW.id;
+END;
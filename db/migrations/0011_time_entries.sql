@@ -0,0 +1,13 @@
+-- Time logged on tasks, either with the start/stop timer or added by hand afterwards.
+-- seconds is NULL while the timer of an entry runs, at most one timer runs at a time.
+CREATE TABLE IF NOT EXISTS time_entries (
+    id INTEGER PRIMARY KEY,
+    task_id INTEGER NOT NULL,
+    started_at TEXT NOT NULL,
+    seconds INTEGER,
+    FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    CHECK (seconds IS NULL OR seconds >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_time_entries_task_id ON time_entries(task_id);
+CREATE INDEX IF NOT EXISTS idx_time_entries_started_at ON time_entries(started_at);
@@ -0,0 +1,139 @@
+-- The history of every task, area, and note. The last_mod triggers only keep the latest
+-- timestamp, events keep one row per changed field with its old and new value. Events have
+-- no foreign keys so the history of a deleted task is kept.
+-- source is the part of go_task that made the change, cli or tui. The triggers only use
+-- built-in SQL so other SQLite clients can still write, they record every change as external
+-- and go_task rewrites the source of its own changes with a TEMP trigger, see db/session.sql.
+-- Events written by this migration from the existing data use migration.
+CREATE TABLE IF NOT EXISTS events (
+    id INTEGER PRIMARY KEY,
+    entity TEXT NOT NULL,
+    entity_id INTEGER NOT NULL,
+    field TEXT NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    changed_at TEXT NOT NULL DEFAULT (datetime(current_timestamp, 'localtime')),
+    source TEXT NOT NULL,
+    CHECK (entity IN ('task', 'area', 'note'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_entity ON events(entity, entity_id);
+CREATE INDEX IF NOT EXISTS idx_events_changed_at ON events(changed_at);
+
+-- Creating and deleting a row is recorded under the created and deleted fields with its title
+DROP TRIGGER IF EXISTS record_task_insert;
+CREATE TRIGGER record_task_insert
+AFTER INSERT ON tasks
+BEGIN
+    INSERT INTO events (entity, entity_id, field, new_value, source)
+    VALUES ('task', NEW.id, 'created', NEW.title, 'external');
+END;
+
+DROP TRIGGER IF EXISTS record_task_delete;
+CREATE TRIGGER record_task_delete
+AFTER DELETE ON tasks
+BEGIN
+    INSERT INTO events (entity, entity_id, field, old_value, source)
+    VALUES ('task', OLD.id, 'deleted', OLD.title, 'external');
+END;
+
+-- One event per field that changed. last_mod and completed_at follow from the other fields
+-- and are left out, so the updates made by their triggers do not add events.
+DROP TRIGGER IF EXISTS record_task_update;
+CREATE TRIGGER record_task_update
+AFTER UPDATE ON tasks
+BEGIN
+    INSERT INTO events (entity, entity_id, field, old_value, new_value, source)
+    SELECT 'task', NEW.id, field, old_value, new_value, 'external'
+    FROM (
+        SELECT 'title' AS field, OLD.title AS old_value, NEW.title AS new_value
+        UNION ALL SELECT 'priority', OLD.priority, NEW.priority
+        UNION ALL SELECT 'status', OLD.status, NEW.status
+        UNION ALL SELECT 'archived',
+            CASE WHEN OLD.archived THEN 'true' ELSE 'false' END,
+            CASE WHEN NEW.archived THEN 'true' ELSE 'false' END
+        UNION ALL SELECT 'due_date', OLD.due_date, NEW.due_date
+        UNION ALL SELECT 'area_id', OLD.area_id, NEW.area_id
+        UNION ALL SELECT 'recurrence', OLD.recurrence, NEW.recurrence
+        UNION ALL SELECT 'parent_task_id', OLD.parent_task_id, NEW.parent_task_id
+    )
+    WHERE old_value IS NOT new_value;
+END;
+
+DROP TRIGGER IF EXISTS record_area_insert;
+CREATE TRIGGER record_area_insert
+AFTER INSERT ON areas
+BEGIN
+    INSERT INTO events (entity, entity_id, field, new_value, source)
+    VALUES ('area', NEW.id, 'created', NEW.title, 'external');
+END;
+
+DROP TRIGGER IF EXISTS record_area_delete;
+CREATE TRIGGER record_area_delete
+AFTER DELETE ON areas
+BEGIN
+    INSERT INTO events (entity, entity_id, field, old_value, source)
+    VALUES ('area', OLD.id, 'deleted', OLD.title, 'external');
+END;
+
+DROP TRIGGER IF EXISTS record_area_update;
+CREATE TRIGGER record_area_update
+AFTER UPDATE ON areas
+BEGIN
+    INSERT INTO events (entity, entity_id, field, old_value, new_value, source)
+    SELECT 'area', NEW.id, field, old_value, new_value, 'external'
+    FROM (
+        SELECT 'title' AS field, OLD.title AS old_value, NEW.title AS new_value
+        UNION ALL SELECT 'status', OLD.status, NEW.status
+        UNION ALL SELECT 'archived',
+            CASE WHEN OLD.archived THEN 'true' ELSE 'false' END,
+            CASE WHEN NEW.archived THEN 'true' ELSE 'false' END
+        UNION ALL SELECT 'note_template', OLD.note_template, NEW.note_template
+    )
+    WHERE old_value IS NOT new_value;
+END;
+
+DROP TRIGGER IF EXISTS record_note_insert;
+CREATE TRIGGER record_note_insert
+AFTER INSERT ON notes
+BEGIN
+    INSERT INTO events (entity, entity_id, field, new_value, source)
+    VALUES ('note', NEW.id, 'created', NEW.title, 'external');
+END;
+
+DROP TRIGGER IF EXISTS record_note_delete;
+CREATE TRIGGER record_note_delete
+AFTER DELETE ON notes
+BEGIN
+    INSERT INTO events (entity, entity_id, field, old_value, source)
+    VALUES ('note', OLD.id, 'deleted', OLD.title, 'external');
+END;
+
+DROP TRIGGER IF EXISTS record_note_update;
+CREATE TRIGGER record_note_update
+AFTER UPDATE ON notes
+BEGIN
+    INSERT INTO events (entity, entity_id, field, old_value, new_value, source)
+    SELECT 'note', NEW.id, field, old_value, new_value, 'external'
+    FROM (
+        SELECT 'title' AS field, OLD.title AS old_value, NEW.title AS new_value
+        UNION ALL SELECT 'path', OLD.path, NEW.path
+    )
+    WHERE old_value IS NOT new_value;
+END;
+
+-- Backfill what the existing rows still tell about their past: when tasks and areas were
+-- created and when tasks were finished. Notes carry no timestamps, their history starts now.
+INSERT INTO events (entity, entity_id, field, new_value, changed_at, source)
+SELECT 'task', id, 'created', title, created_at, 'migration' FROM tasks ORDER BY id;
+
+INSERT INTO events (entity, entity_id, field, new_value, changed_at, source)
+SELECT 'task', id, 'status', 'done', completed_at, 'migration' FROM tasks
+WHERE status = 'done' AND completed_at IS NOT NULL
+ORDER BY id;
+
+INSERT INTO events (entity, entity_id, field, new_value, changed_at, source)
+SELECT 'area', id, 'created', title, created_at, 'migration' FROM areas ORDER BY id;
+
+INSERT INTO events (entity, entity_id, field, new_value, source)
+SELECT 'note', id, 'created', title, 'migration' FROM notes ORDER BY id;
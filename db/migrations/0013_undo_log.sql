@@ -0,0 +1,69 @@
+-- Undo reverts the changes of one or more steps. A step is everything one go_task command,
+-- or one key press in the TUI, changed. Triggers write the statement that reverts each change
+-- into undo_log, following https://www.sqlite.org/undoredo.html, and undo runs the statements
+-- of a step newest first.
+-- The triggers are TEMP triggers go_task creates on its own connections, see db/session.sql,
+-- they read the open step from go_task_session. Other SQLite clients write without them and
+-- nothing they change is recorded for undo.
+CREATE TABLE IF NOT EXISTS undo_steps (
+    id INTEGER PRIMARY KEY,
+    description TEXT NOT NULL,
+    source TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (datetime(current_timestamp, 'localtime'))
+);
+
+CREATE TABLE IF NOT EXISTS undo_log (
+    id INTEGER PRIMARY KEY,
+    step_id INTEGER NOT NULL,
+    statement TEXT NOT NULL,
+    FOREIGN KEY(step_id) REFERENCES undo_steps(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_undo_log_step_id ON undo_log(step_id);
+
+-- Only the latest 100 steps are kept
+DROP TRIGGER IF EXISTS undo_steps_prune;
+CREATE TRIGGER undo_steps_prune
+AFTER INSERT ON undo_steps
+BEGIN
+    DELETE FROM undo_steps WHERE id NOT IN (SELECT id FROM undo_steps ORDER BY id DESC LIMIT 100);
+END;
+
+-- undo_in_progress has a row while undo replays the statements of undo_log, in the same
+-- transaction, so no other connection ever sees it.
+CREATE TABLE IF NOT EXISTS undo_in_progress (
+    id INTEGER PRIMARY KEY CHECK (id = 1)
+);
+
+-- Undo restores last_mod and completed_at along with the other columns, the triggers keeping
+-- them current must not stamp the time of the undo over them.
+DROP TRIGGER IF EXISTS update_last_mod_tasks;
+CREATE TRIGGER update_last_mod_tasks
+AFTER UPDATE ON tasks
+WHEN NOT EXISTS (SELECT 1 FROM undo_in_progress)
+BEGIN
+    UPDATE tasks
+    SET last_mod = datetime(current_timestamp, 'localtime')
+    WHERE id = OLD.id;
+END;
+
+DROP TRIGGER IF EXISTS update_last_mod_areas;
+CREATE TRIGGER update_last_mod_areas
+AFTER UPDATE ON areas
+WHEN NOT EXISTS (SELECT 1 FROM undo_in_progress)
+BEGIN
+    UPDATE areas
+    SET last_mod = datetime(current_timestamp, 'localtime')
+    WHERE id = OLD.id;
+END;
+
+DROP TRIGGER IF EXISTS update_completed_at_tasks;
+CREATE TRIGGER update_completed_at_tasks
+AFTER UPDATE OF status ON tasks
+WHEN NEW.status IS NOT OLD.status AND (NEW.status = 'done' OR OLD.status = 'done')
+    AND NOT EXISTS (SELECT 1 FROM undo_in_progress)
+BEGIN
+    UPDATE tasks
+    SET completed_at = CASE WHEN NEW.status = 'done' THEN datetime(current_timestamp, 'localtime') END
+    WHERE id = NEW.id;
+END;
@@ -0,0 +1,58 @@
+-- The index behind 'go_task search', titles of tasks, areas, and notes plus the contents of the
+-- markdown files in the notes path. It is a plain table so the schema does not depend on SQLite's
+-- optional FTS5 module: go_task copies it into a TEMP FTS5 table to rank hits when it is built with
+-- -tags sqlite_fts5, and matches it with LIKE otherwise.
+-- The index is filled by the first search or by 'go_task db reindex', since the note files have to
+-- be read for it. Until then the insert triggers leave it empty, the first search would replace
+-- those rows anyway.
+CREATE TABLE IF NOT EXISTS search_index (
+    entity_type TEXT NOT NULL,
+    entity_id INTEGER NOT NULL,
+    path TEXT NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_index_entity ON search_index(entity_type, entity_id);
+
+CREATE TRIGGER IF NOT EXISTS search_tasks_insert AFTER INSERT ON tasks
+WHEN EXISTS (SELECT 1 FROM search_index)
+BEGIN
+    INSERT INTO search_index (entity_type, entity_id, path, title, body) VALUES ('task', new.id, '', new.title, '');
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_tasks_update AFTER UPDATE OF title ON tasks BEGIN
+    UPDATE search_index SET title = new.title WHERE entity_type = 'task' AND entity_id = new.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_tasks_delete AFTER DELETE ON tasks BEGIN
+    DELETE FROM search_index WHERE entity_type = 'task' AND entity_id = old.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_areas_insert AFTER INSERT ON areas
+WHEN EXISTS (SELECT 1 FROM search_index)
+BEGIN
+    INSERT INTO search_index (entity_type, entity_id, path, title, body) VALUES ('area', new.id, '', new.title, '');
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_areas_update AFTER UPDATE OF title ON areas BEGIN
+    UPDATE search_index SET title = new.title WHERE entity_type = 'area' AND entity_id = new.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_areas_delete AFTER DELETE ON areas BEGIN
+    DELETE FROM search_index WHERE entity_type = 'area' AND entity_id = old.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_notes_insert AFTER INSERT ON notes
+WHEN EXISTS (SELECT 1 FROM search_index)
+BEGIN
+    INSERT INTO search_index (entity_type, entity_id, path, title, body) VALUES ('note', new.id, new.path, new.title, '');
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_notes_update AFTER UPDATE OF title, path ON notes BEGIN
+    UPDATE search_index SET title = new.title, path = new.path WHERE entity_type = 'note' AND entity_id = new.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_notes_delete AFTER DELETE ON notes BEGIN
+    DELETE FROM search_index WHERE entity_type = 'note' AND entity_id = old.id;
+END;
@@ -0,0 +1,167 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	_ "embed"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+//go:embed session.sql
+var sessionSQL string
+
+// session is the state of go_task the TEMP triggers of a connection read from go_task_session
+type session struct {
+	source          string
+	undoStep        int64
+	undoDescription string
+}
+
+type withoutUndoKey struct{}
+
+// WithoutUndo returns a context whose database calls are not recorded for undo, even while a step is open.
+// Changes that do not come from the command or key press of the step, such as those of the notes watcher, use it.
+func WithoutUndo(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withoutUndoKey{}, true)
+}
+
+// currentSession is the session of a connection handed out for ctx
+func currentSession(ctx context.Context) session {
+	s := session{source: EventSource}
+	if ctx.Value(withoutUndoKey{}) != nil {
+		return s
+	}
+	if step := currentUndoStep.Load(); step != nil {
+		s.undoStep, s.undoDescription = step.id, step.description
+	}
+	return s
+}
+
+// sessionDriver is the sqlite3 driver with connections that keep go_task_session up to date
+type sessionDriver struct {
+	sqlite3.SQLiteDriver
+}
+
+func (d *sessionDriver) Open(dsn string) (driver.Conn, error) {
+	return (&sessionConnector{dsn: dsn, driver: d}).Connect(context.Background())
+}
+
+func (d *sessionDriver) OpenConnector(dsn string) (driver.Connector, error) {
+	return &sessionConnector{dsn: dsn, driver: d}, nil
+}
+
+type sessionConnector struct {
+	dsn    string
+	driver *sessionDriver
+}
+
+func (c *sessionConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.SQLiteDriver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	sc := &sessionConn{SQLiteConn: conn.(*sqlite3.SQLiteConn), schemaVersion: -1}
+	if err := sc.sync(ctx); err != nil {
+		sc.Close()
+		return nil, err
+	}
+	return sc, nil
+}
+
+func (c *sessionConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// sessionConn is a SQLite connection that syncs go_task_session every time database/sql hands it out
+type sessionConn struct {
+	*sqlite3.SQLiteConn
+	// schemaVersion is the schema cookie of the main database the TEMP schema was last checked against
+	schemaVersion int64
+	installed     bool
+	synced        *session
+}
+
+// ResetSession is called by database/sql with the context of the call before a pooled connection is used again
+func (c *sessionConn) ResetSession(ctx context.Context) error {
+	return c.sync(ctx)
+}
+
+/*
+sync brings the TEMP schema and go_task_session of the connection up to date.
+ 1. The TEMP schema is created once the database is at the latest migration, and created again
+    whenever the main schema changed, a migration that rebuilds a table drops the TEMP triggers on it
+ 2. The session row is only written when the state of go_task changed since the last sync
+*/
+func (c *sessionConn) sync(ctx context.Context) error {
+	schemaVersion, err := c.queryInt(ctx, "PRAGMA main.schema_version")
+	if err != nil {
+		return fmt.Errorf("failed to read the schema version: %w", err)
+	}
+	if schemaVersion != c.schemaVersion {
+		c.schemaVersion = schemaVersion
+		c.installed, c.synced = false, nil
+		upToDate, err := c.atLatestVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if upToDate {
+			if _, err := c.SQLiteConn.ExecContext(ctx, sessionSQL, nil); err != nil {
+				return fmt.Errorf("failed to create the session schema: %w", err)
+			}
+			c.installed = true
+		}
+	}
+	if !c.installed {
+		return nil
+	}
+
+	want := currentSession(ctx)
+	if c.synced != nil && *c.synced == want {
+		return nil
+	}
+	_, err = c.SQLiteConn.ExecContext(ctx,
+		`INSERT OR REPLACE INTO go_task_session (id, source, undo_step, undo_description) VALUES (1, ?, ?, ?)`,
+		[]driver.NamedValue{
+			{Ordinal: 1, Value: want.source},
+			{Ordinal: 2, Value: want.undoStep},
+			{Ordinal: 3, Value: want.undoDescription},
+		})
+	if err != nil {
+		return fmt.Errorf("failed to update the session: %w", err)
+	}
+	c.synced = &want
+	return nil
+}
+
+// atLatestVersion reports whether every migration embedded in the binary has been applied, and no newer one
+func (c *sessionConn) atLatestVersion(ctx context.Context) (bool, error) {
+	exists, err := c.queryInt(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_version'`)
+	if err != nil || exists == 0 {
+		return false, err
+	}
+	current, err := c.queryInt(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_version`)
+	if err != nil {
+		return false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	latest, err := LatestVersion()
+	if err != nil {
+		return false, err
+	}
+	return current == int64(latest), nil
+}
+
+func (c *sessionConn) queryInt(ctx context.Context, query string) (int64, error) {
+	rows, err := c.SQLiteConn.QueryContext(ctx, query, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	dest := make([]driver.Value, 1)
+	if err := rows.Next(dest); err != nil {
+		return 0, err
+	}
+	value, _ := dest[0].(int64)
+	return value, nil
+}
@@ -0,0 +1,477 @@
+-- The per-connection part of the schema. It is not a migration: TEMP tables and triggers only
+-- live as long as the connection that created them, so go_task creates them on every
+-- connection it opens, once the database is at the latest migration. Other SQLite clients never
+-- see them, so the schema they share with go_task only uses built-in SQL.
+-- go_task_session has a single row that go_task keeps up to date with the state of the
+-- command or key press using the connection. undo_step is 0 while no step is open, e.g. while
+-- an undo runs or the notes watcher writes, and nothing is recorded for undo.
+CREATE TEMP TABLE IF NOT EXISTS go_task_session (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    source TEXT NOT NULL,
+    undo_step INTEGER NOT NULL DEFAULT 0,
+    undo_description TEXT NOT NULL DEFAULT ''
+);
+
+-- The events triggers record every change as external, changes made through go_task are
+-- given the source of the connection instead, cli or tui.
+CREATE TEMP TRIGGER IF NOT EXISTS session_event_source
+AFTER INSERT ON events
+WHEN NEW.source = 'external'
+BEGIN
+    UPDATE events
+    SET source = COALESCE((SELECT source FROM go_task_session), NEW.source)
+    WHERE id = NEW.id;
+END;
+
+-- The undo triggers, see 0013_undo_log.sql for how undo_log is replayed.
+-- A migration adding a column to one of these tables has to update its triggers here.
+-- The step is created with the first change it records. It is not written with INSERT OR IGNORE,
+-- a change made by a foreign key cascade would turn the IGNORE into an ABORT.
+CREATE TEMP TRIGGER IF NOT EXISTS undo_log_step
+BEFORE INSERT ON undo_log
+WHEN NOT EXISTS (SELECT 1 FROM undo_steps WHERE id = NEW.step_id)
+BEGIN
+    INSERT INTO undo_steps (id, description, source)
+    SELECT NEW.step_id, undo_description, source FROM go_task_session;
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_tasks_insert
+AFTER INSERT ON tasks
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'DELETE FROM tasks WHERE rowid = ' || NEW.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_tasks_update
+AFTER UPDATE ON tasks
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'UPDATE tasks SET'
+        || ' id = ' || quote(OLD.id)
+        || ', title = ' || quote(OLD.title)
+        || ', priority = ' || quote(OLD.priority)
+        || ', status = ' || quote(OLD.status)
+        || ', archived = ' || quote(OLD.archived)
+        || ', created_at = ' || quote(OLD.created_at)
+        || ', last_mod = ' || quote(OLD.last_mod)
+        || ', due_date = ' || quote(OLD.due_date)
+        || ', area_id = ' || quote(OLD.area_id)
+        || ', recurrence = ' || quote(OLD.recurrence)
+        || ', parent_task_id = ' || quote(OLD.parent_task_id)
+        || ', completed_at = ' || quote(OLD.completed_at)
+        || ' WHERE rowid = ' || OLD.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_tasks_delete
+AFTER DELETE ON tasks
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'INSERT INTO tasks (id, title, priority, status, archived, created_at, last_mod, due_date, area_id, recurrence, parent_task_id, completed_at) VALUES ('
+        || quote(OLD.id)
+        || ', ' || quote(OLD.title)
+        || ', ' || quote(OLD.priority)
+        || ', ' || quote(OLD.status)
+        || ', ' || quote(OLD.archived)
+        || ', ' || quote(OLD.created_at)
+        || ', ' || quote(OLD.last_mod)
+        || ', ' || quote(OLD.due_date)
+        || ', ' || quote(OLD.area_id)
+        || ', ' || quote(OLD.recurrence)
+        || ', ' || quote(OLD.parent_task_id)
+        || ', ' || quote(OLD.completed_at)
+        || ')');
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_areas_insert
+AFTER INSERT ON areas
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'DELETE FROM areas WHERE rowid = ' || NEW.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_areas_update
+AFTER UPDATE ON areas
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'UPDATE areas SET'
+        || ' id = ' || quote(OLD.id)
+        || ', title = ' || quote(OLD.title)
+        || ', status = ' || quote(OLD.status)
+        || ', archived = ' || quote(OLD.archived)
+        || ', created_at = ' || quote(OLD.created_at)
+        || ', last_mod = ' || quote(OLD.last_mod)
+        || ', note_template = ' || quote(OLD.note_template)
+        || ' WHERE rowid = ' || OLD.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_areas_delete
+AFTER DELETE ON areas
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'INSERT INTO areas (id, title, status, archived, created_at, last_mod, note_template) VALUES ('
+        || quote(OLD.id)
+        || ', ' || quote(OLD.title)
+        || ', ' || quote(OLD.status)
+        || ', ' || quote(OLD.archived)
+        || ', ' || quote(OLD.created_at)
+        || ', ' || quote(OLD.last_mod)
+        || ', ' || quote(OLD.note_template)
+        || ')');
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_notes_insert
+AFTER INSERT ON notes
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'DELETE FROM notes WHERE rowid = ' || NEW.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_notes_update
+AFTER UPDATE ON notes
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'UPDATE notes SET'
+        || ' id = ' || quote(OLD.id)
+        || ', title = ' || quote(OLD.title)
+        || ', path = ' || quote(OLD.path)
+        || ' WHERE rowid = ' || OLD.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_notes_delete
+AFTER DELETE ON notes
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'INSERT INTO notes (id, title, path) VALUES ('
+        || quote(OLD.id)
+        || ', ' || quote(OLD.title)
+        || ', ' || quote(OLD.path)
+        || ')');
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_bridge_notes_insert
+AFTER INSERT ON bridge_notes
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'DELETE FROM bridge_notes WHERE rowid = ' || NEW.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_bridge_notes_update
+AFTER UPDATE ON bridge_notes
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'UPDATE bridge_notes SET'
+        || ' id = ' || quote(OLD.id)
+        || ', note_id = ' || quote(OLD.note_id)
+        || ', parent_cat = ' || quote(OLD.parent_cat)
+        || ', parent_task_id = ' || quote(OLD.parent_task_id)
+        || ', parent_area_id = ' || quote(OLD.parent_area_id)
+        || ' WHERE rowid = ' || OLD.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_bridge_notes_delete
+AFTER DELETE ON bridge_notes
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'INSERT INTO bridge_notes (id, note_id, parent_cat, parent_task_id, parent_area_id) VALUES ('
+        || quote(OLD.id)
+        || ', ' || quote(OLD.note_id)
+        || ', ' || quote(OLD.parent_cat)
+        || ', ' || quote(OLD.parent_task_id)
+        || ', ' || quote(OLD.parent_area_id)
+        || ')');
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_programming_projects_insert
+AFTER INSERT ON programming_projects
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'DELETE FROM programming_projects WHERE rowid = ' || NEW.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_programming_projects_update
+AFTER UPDATE ON programming_projects
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'UPDATE programming_projects SET'
+        || ' id = ' || quote(OLD.id)
+        || ', path = ' || quote(OLD.path)
+        || ' WHERE rowid = ' || OLD.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_programming_projects_delete
+AFTER DELETE ON programming_projects
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'INSERT INTO programming_projects (id, path) VALUES ('
+        || quote(OLD.id)
+        || ', ' || quote(OLD.path)
+        || ')');
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_prog_project_links_insert
+AFTER INSERT ON prog_project_links
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'DELETE FROM prog_project_links WHERE rowid = ' || NEW.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_prog_project_links_update
+AFTER UPDATE ON prog_project_links
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'UPDATE prog_project_links SET'
+        || ' project_id = ' || quote(OLD.project_id)
+        || ', parent_cat = ' || quote(OLD.parent_cat)
+        || ', parent_task_id = ' || quote(OLD.parent_task_id)
+        || ', parent_area_id = ' || quote(OLD.parent_area_id)
+        || ' WHERE rowid = ' || OLD.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_prog_project_links_delete
+AFTER DELETE ON prog_project_links
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'INSERT INTO prog_project_links (rowid, project_id, parent_cat, parent_task_id, parent_area_id) VALUES ('
+        || quote(OLD.rowid)
+        || ', ' || quote(OLD.project_id)
+        || ', ' || quote(OLD.parent_cat)
+        || ', ' || quote(OLD.parent_task_id)
+        || ', ' || quote(OLD.parent_area_id)
+        || ')');
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_tags_insert
+AFTER INSERT ON tags
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'DELETE FROM tags WHERE rowid = ' || NEW.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_tags_update
+AFTER UPDATE ON tags
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'UPDATE tags SET'
+        || ' id = ' || quote(OLD.id)
+        || ', name = ' || quote(OLD.name)
+        || ' WHERE rowid = ' || OLD.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_tags_delete
+AFTER DELETE ON tags
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'INSERT INTO tags (id, name) VALUES ('
+        || quote(OLD.id)
+        || ', ' || quote(OLD.name)
+        || ')');
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_task_tags_insert
+AFTER INSERT ON task_tags
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'DELETE FROM task_tags WHERE rowid = ' || NEW.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_task_tags_update
+AFTER UPDATE ON task_tags
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'UPDATE task_tags SET'
+        || ' task_id = ' || quote(OLD.task_id)
+        || ', tag_id = ' || quote(OLD.tag_id)
+        || ' WHERE rowid = ' || OLD.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_task_tags_delete
+AFTER DELETE ON task_tags
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'INSERT INTO task_tags (rowid, task_id, tag_id) VALUES ('
+        || quote(OLD.rowid)
+        || ', ' || quote(OLD.task_id)
+        || ', ' || quote(OLD.tag_id)
+        || ')');
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_area_tags_insert
+AFTER INSERT ON area_tags
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'DELETE FROM area_tags WHERE rowid = ' || NEW.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_area_tags_update
+AFTER UPDATE ON area_tags
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'UPDATE area_tags SET'
+        || ' area_id = ' || quote(OLD.area_id)
+        || ', tag_id = ' || quote(OLD.tag_id)
+        || ' WHERE rowid = ' || OLD.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_area_tags_delete
+AFTER DELETE ON area_tags
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'INSERT INTO area_tags (rowid, area_id, tag_id) VALUES ('
+        || quote(OLD.rowid)
+        || ', ' || quote(OLD.area_id)
+        || ', ' || quote(OLD.tag_id)
+        || ')');
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_note_tags_insert
+AFTER INSERT ON note_tags
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'DELETE FROM note_tags WHERE rowid = ' || NEW.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_note_tags_update
+AFTER UPDATE ON note_tags
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'UPDATE note_tags SET'
+        || ' note_id = ' || quote(OLD.note_id)
+        || ', tag_id = ' || quote(OLD.tag_id)
+        || ' WHERE rowid = ' || OLD.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_note_tags_delete
+AFTER DELETE ON note_tags
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'INSERT INTO note_tags (rowid, note_id, tag_id) VALUES ('
+        || quote(OLD.rowid)
+        || ', ' || quote(OLD.note_id)
+        || ', ' || quote(OLD.tag_id)
+        || ')');
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_task_dependencies_insert
+AFTER INSERT ON task_dependencies
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'DELETE FROM task_dependencies WHERE rowid = ' || NEW.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_task_dependencies_update
+AFTER UPDATE ON task_dependencies
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'UPDATE task_dependencies SET'
+        || ' blocker_id = ' || quote(OLD.blocker_id)
+        || ', blocked_id = ' || quote(OLD.blocked_id)
+        || ' WHERE rowid = ' || OLD.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_task_dependencies_delete
+AFTER DELETE ON task_dependencies
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'INSERT INTO task_dependencies (rowid, blocker_id, blocked_id) VALUES ('
+        || quote(OLD.rowid)
+        || ', ' || quote(OLD.blocker_id)
+        || ', ' || quote(OLD.blocked_id)
+        || ')');
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_note_checkboxes_insert
+AFTER INSERT ON note_checkboxes
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'DELETE FROM note_checkboxes WHERE rowid = ' || NEW.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_note_checkboxes_update
+AFTER UPDATE ON note_checkboxes
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'UPDATE note_checkboxes SET'
+        || ' task_id = ' || quote(OLD.task_id)
+        || ', note_id = ' || quote(OLD.note_id)
+        || ' WHERE rowid = ' || OLD.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_note_checkboxes_delete
+AFTER DELETE ON note_checkboxes
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'INSERT INTO note_checkboxes (task_id, note_id) VALUES ('
+        || quote(OLD.task_id)
+        || ', ' || quote(OLD.note_id)
+        || ')');
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_time_entries_insert
+AFTER INSERT ON time_entries
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'DELETE FROM time_entries WHERE rowid = ' || NEW.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_time_entries_update
+AFTER UPDATE ON time_entries
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'UPDATE time_entries SET'
+        || ' id = ' || quote(OLD.id)
+        || ', task_id = ' || quote(OLD.task_id)
+        || ', started_at = ' || quote(OLD.started_at)
+        || ', seconds = ' || quote(OLD.seconds)
+        || ' WHERE rowid = ' || OLD.rowid);
+END;
+
+CREATE TEMP TRIGGER IF NOT EXISTS undo_time_entries_delete
+AFTER DELETE ON time_entries
+WHEN (SELECT undo_step FROM go_task_session) != 0
+BEGIN
+    INSERT INTO undo_log (step_id, statement)
+    VALUES ((SELECT undo_step FROM go_task_session), 'INSERT INTO time_entries (id, task_id, started_at, seconds) VALUES ('
+        || quote(OLD.id)
+        || ', ' || quote(OLD.task_id)
+        || ', ' || quote(OLD.started_at)
+        || ', ' || quote(OLD.seconds)
+        || ')');
+END;
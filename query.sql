@@ -50,23 +50,39 @@ SELECT
     datetime(tasks.last_mod) AS last_mod,
     ROUND((julianday('now') - julianday(tasks.created_at)), 2) AS age_in_days,
     tasks.due_date,
+    tasks.recurrence,
     IFNULL(
         (SELECT GROUP_CONCAT(title, ', ') 
          FROM (SELECT DISTINCT notes.title 
                FROM notes 
                JOIN bridge_notes ON notes.id = bridge_notes.note_id 
-               WHERE bridge_notes.parent_task_id = tasks.id)
+               WHERE bridge_notes.parent_task_id = tasks.id
+               ORDER BY notes.title)
         ), 
         ''
     ) AS note_title,
     programming_projects.path AS prog_proj,
-    areas.title AS parent_area
+    areas.title AS parent_area,
+    IFNULL(
+        (SELECT GROUP_CONCAT(blocker.id || ' ' || blocker.title, ', ')
+         FROM task_dependencies
+         JOIN tasks blocker ON blocker.id = task_dependencies.blocker_id
+         WHERE task_dependencies.blocked_id = tasks.id
+         AND IFNULL(blocker.status, '') != 'done'),
+        ''
+    ) AS blocked_by,
+    IFNULL(
+        (SELECT GROUP_CONCAT(name, ', ')
+         FROM (SELECT tags.name
+               FROM tags
+               JOIN task_tags ON task_tags.tag_id = tags.id
+               WHERE task_tags.task_id = tasks.id
+               ORDER BY tags.name)),
+        ''
+    ) AS tags,
+    (SELECT CAST(IFNULL(SUM(seconds), 0) AS INTEGER) FROM time_entries WHERE time_entries.task_id = tasks.id) AS time_logged
 FROM
     tasks
-LEFT OUTER JOIN
-    bridge_notes ON tasks.id = bridge_notes.parent_task_id AND bridge_notes.parent_cat = 1
-LEFT OUTER JOIN
-    notes ON notes.id = bridge_notes.note_id
 LEFT OUTER JOIN
     prog_project_links ON tasks.id = prog_project_links.parent_task_id
 LEFT OUTER JOIN
@@ -85,23 +101,41 @@ SELECT
     tasks.status, 
     tasks.archived,
     ROUND((julianday('now') - julianday(tasks.created_at)), 2) AS age_in_days,
+    tasks.due_date,
     IFNULL(
         (SELECT GROUP_CONCAT(title, ', ') 
          FROM (SELECT DISTINCT notes.title 
                FROM notes 
                JOIN bridge_notes ON notes.id = bridge_notes.note_id 
-               WHERE bridge_notes.parent_task_id = tasks.id)
+               WHERE bridge_notes.parent_task_id = tasks.id
+               ORDER BY notes.title)
         ), 
         ''
     ) AS note_titles,
     pp.path, 
-    area.title AS parent_area
+    area.title AS parent_area,
+    (SELECT COUNT(*) FROM tasks sub WHERE sub.parent_task_id = tasks.id) AS subtask_count,
+    (SELECT COUNT(*) FROM tasks sub WHERE sub.parent_task_id = tasks.id AND sub.status = 'done') AS subtasks_done,
+    IFNULL(
+        (SELECT GROUP_CONCAT(blocker.id, ', ')
+         FROM task_dependencies
+         JOIN tasks blocker ON blocker.id = task_dependencies.blocker_id
+         WHERE task_dependencies.blocked_id = tasks.id
+         AND IFNULL(blocker.status, '') != 'done'),
+        ''
+    ) AS blocked_by,
+    IFNULL(
+        (SELECT GROUP_CONCAT(name, ', ')
+         FROM (SELECT tags.name
+               FROM tags
+               JOIN task_tags ON task_tags.tag_id = tags.id
+               WHERE task_tags.task_id = tasks.id
+               ORDER BY tags.name)),
+        ''
+    ) AS tags,
+    (SELECT CAST(IFNULL(SUM(seconds), 0) AS INTEGER) FROM time_entries WHERE time_entries.task_id = tasks.id) AS time_logged
 FROM 
     tasks
-LEFT OUTER JOIN 
-    bridge_notes ON tasks.id = bridge_notes.parent_task_id AND bridge_notes.parent_cat = 1
-LEFT OUTER JOIN 
-    notes ON bridge_notes.note_id = notes.id
 LEFT OUTER JOIN 
     prog_project_links pjl ON pjl.parent_task_id = tasks.id
 LEFT OUTER JOIN 
@@ -121,13 +155,16 @@ WHERE bridge_notes.note_id = ?;
 SELECT notes.id, notes.title, notes.path, bridge_notes.parent_cat as type
 FROM notes
 JOIN bridge_notes on notes.id = bridge_notes.note_id
-WHERE notes.id = ?;
+WHERE notes.id = ?
+ORDER BY bridge_notes.id
+LIMIT 1;
 
 
 -- name: ReadNoteByIDs :many
 SELECT notes.id, notes.title, notes.path, bridge_notes.parent_cat as type
 FROM notes
 JOIN bridge_notes on notes.id = bridge_notes.note_id
+    AND bridge_notes.id = (SELECT MIN(links.id) FROM bridge_notes links WHERE links.note_id = notes.id)
 WHERE notes.id in (sqlc.slice(ids));
 
 -- name: ReadAllTaskNotes :many
@@ -145,11 +182,37 @@ INNER JOIN areas ON areas.ID = bridge_notes.parent_area_id AND bridge_notes.pare
 
 
 -- name: ReadAllNotes :many
-SELECT notes.id, notes.title, notes.path, coalesce(tasks.title, areas.title, 'Unknown') [area_or_task_title], case when bridge_notes.parent_cat = 1 then 'Task' else 'Area' end as [parent_type]
+SELECT notes.id, notes.title, notes.path,
+    IFNULL(
+        (SELECT GROUP_CONCAT(parent_title, ', ')
+         FROM (SELECT coalesce(tasks.title, areas.title, 'Unknown') AS parent_title
+               FROM bridge_notes
+               LEFT JOIN tasks ON tasks.ID = bridge_notes.parent_task_id AND bridge_notes.parent_cat = 1
+               LEFT JOIN areas ON areas.ID = bridge_notes.parent_area_id AND bridge_notes.parent_cat = 2
+               WHERE bridge_notes.note_id = notes.id
+               ORDER BY bridge_notes.id)),
+        ''
+    ) [area_or_task_title],
+    IFNULL(
+        (SELECT GROUP_CONCAT(parent_type, ', ')
+         FROM (SELECT case when bridge_notes.parent_cat = 1 then 'Task' else 'Area' end AS parent_type
+               FROM bridge_notes
+               WHERE bridge_notes.note_id = notes.id
+               ORDER BY bridge_notes.id)),
+        ''
+    ) [parent_type],
+    IFNULL(
+        (SELECT GROUP_CONCAT(name, ', ')
+         FROM (SELECT tags.name
+               FROM tags
+               JOIN note_tags ON note_tags.tag_id = tags.id
+               WHERE note_tags.note_id = notes.id
+               ORDER BY tags.name)),
+        ''
+    ) AS tags
 FROM notes
-INNER JOIN bridge_notes ON bridge_notes.note_id = notes.id
-LEFT JOIN tasks ON tasks.ID = bridge_notes.parent_task_id AND bridge_notes.parent_cat = 1
-LEFT JOIN areas ON areas.ID = bridge_notes.parent_area_id AND bridge_notes.parent_cat = 2;
+WHERE EXISTS (SELECT 1 FROM bridge_notes WHERE bridge_notes.note_id = notes.id)
+ORDER BY notes.id;
 
 -- name: UpdateAreaStatus :execresult
 UPDATE areas SET status = ?  where id = ?
@@ -159,6 +222,12 @@ returning *;
 UPDATE areas SET archived = ?  where id = ?
 returning *;
 
+-- name: UpdateAreaNoteTemplate :execrows
+UPDATE areas SET note_template = ? WHERE id = ?;
+
+-- name: ReadAreaNoteTemplate :one
+SELECT note_template FROM areas WHERE id = ?;
+
 -- name: UpdateAreaTitle :execlastid
 UPDATE areas set title = ? where id = ?
 returning id;
@@ -184,6 +253,33 @@ returning *;
 UPDATE tasks set area_id = ? where id = ?
 returning *;
 
+-- name: UpdateTaskDueDate :execresult
+UPDATE tasks SET due_date = ? WHERE id = ?
+returning *;
+
+-- name: UpdateTaskRecurrence :execresult
+UPDATE tasks SET recurrence = ? WHERE id = ?
+returning *;
+
+-- name: ReadTaskForRecurrence :one
+SELECT id, title, priority, status, due_date, area_id, recurrence, parent_task_id, CAST(created_at AS TEXT) AS created_at
+FROM tasks
+WHERE id = ?;
+
+-- name: ReadSubtaskTree :many
+WITH RECURSIVE subtasks(id, title, priority, status, due_date, parent_task_id, depth) AS (
+    SELECT t.id, t.title, t.priority, t.status, t.due_date, t.parent_task_id, 1
+    FROM tasks t
+    WHERE t.parent_task_id = sqlc.arg(task_id)
+    UNION ALL
+    SELECT t.id, t.title, t.priority, t.status, t.due_date, t.parent_task_id, subtasks.depth + 1
+    FROM tasks t
+    JOIN subtasks ON t.parent_task_id = subtasks.id
+)
+SELECT id, title, priority, status, due_date, CAST(parent_task_id AS INTEGER) AS parent_task_id, CAST(depth AS INTEGER) AS depth
+FROM subtasks
+ORDER BY depth, id;
+
 
 -- name: DeleteNote :one
 DELETE FROM notes WHERE id = ?
@@ -254,11 +350,11 @@ returning *;
 
 -- name: CreateTask :execlastid
 INSERT INTO tasks (
-    id, title, priority, status, archived, due_date, area_id,
+    id, title, priority, status, archived, due_date, area_id, recurrence, parent_task_id,
     created_at, last_mod
 )
 VALUES (
-    ?, ?, ?, ?, ?, ?, ?,
+    ?, ?, ?, ?, ?, ?, ?, ?, ?,
     datetime(current_timestamp, 'localtime'),
     datetime(current_timestamp, 'localtime')
 )
@@ -319,7 +415,16 @@ AND bridge_notes.parent_cat = 2
 -- name: ReadAreas :many
 SELECT 
     areas.id, areas.title, areas.status, areas.archived,
-    IFNULL(GROUP_CONCAT(notes.title, ', '), '') AS note_titles, pp.path
+    IFNULL(GROUP_CONCAT(notes.title, ', '), '') AS note_titles, pp.path,
+    IFNULL(
+        (SELECT GROUP_CONCAT(name, ', ')
+         FROM (SELECT tags.name
+               FROM tags
+               JOIN area_tags ON area_tags.tag_id = tags.id
+               WHERE area_tags.area_id = areas.id
+               ORDER BY tags.name)),
+        ''
+    ) AS tags
 FROM 
     areas
 LEFT JOIN 
@@ -365,7 +470,371 @@ INSERT INTO prog_project_links (project_id, parent_cat, parent_task_id)
 VALUES (?, ?, ?)
 ;
 
+-- name: CopyProjectTaskLinks :exec
+INSERT INTO prog_project_links (project_id, parent_cat, parent_task_id)
+SELECT project_id, parent_cat, CAST(sqlc.arg(new_task_id) AS INTEGER)
+FROM prog_project_links
+WHERE parent_task_id = sqlc.arg(task_id)
+;
+
 -- name: CreateProjectAreaLink :exec
 INSERT INTO prog_project_links (project_id, parent_cat, parent_area_id)
 VALUES (?, ?, ?)
 ;
+
+-- name: CreateTaskDependency :exec
+INSERT INTO task_dependencies (blocker_id, blocked_id)
+VALUES (?, ?);
+
+-- name: DeleteTaskDependency :execrows
+DELETE FROM task_dependencies
+WHERE blocker_id = ? AND blocked_id = ?;
+
+-- name: DependencyPathExists :one
+WITH RECURSIVE reachable(id) AS (
+    SELECT blocked_id FROM task_dependencies WHERE blocker_id = sqlc.arg(from_id)
+    UNION
+    SELECT task_dependencies.blocked_id
+    FROM task_dependencies
+    JOIN reachable ON task_dependencies.blocker_id = reachable.id
+)
+SELECT CAST(EXISTS (SELECT 1 FROM reachable WHERE id = sqlc.arg(to_id)) AS INTEGER) AS path_exists;
+
+-- name: ReadTaskBlockers :many
+SELECT tasks.id, tasks.title, tasks.status
+FROM task_dependencies
+JOIN tasks ON tasks.id = task_dependencies.blocker_id
+WHERE task_dependencies.blocked_id = ?
+ORDER BY tasks.id;
+
+-- name: UpsertTag :one
+INSERT INTO tags (name) VALUES (?)
+ON CONFLICT (name) DO UPDATE SET name = tags.name
+RETURNING id;
+
+-- name: AddTaskTag :exec
+INSERT OR IGNORE INTO task_tags (task_id, tag_id) VALUES (?, ?);
+
+-- name: RemoveTaskTag :execrows
+DELETE FROM task_tags
+WHERE task_id = ? AND tag_id = (SELECT id FROM tags WHERE name = ?);
+
+-- name: AddAreaTag :exec
+INSERT OR IGNORE INTO area_tags (area_id, tag_id) VALUES (?, ?);
+
+-- name: AddNoteTag :exec
+INSERT OR IGNORE INTO note_tags (note_id, tag_id) VALUES (?, ?);
+
+-- name: RemoveNoteTag :execrows
+DELETE FROM note_tags
+WHERE note_id = ? AND tag_id = (SELECT id FROM tags WHERE name = ?);
+
+-- name: ReadAreaTags :many
+SELECT tags.name
+FROM tags
+JOIN area_tags ON area_tags.tag_id = tags.id
+WHERE area_tags.area_id = ?
+ORDER BY tags.name;
+
+-- name: ReadTaskTags :many
+SELECT tags.name
+FROM tags
+JOIN task_tags ON task_tags.tag_id = tags.id
+WHERE task_tags.task_id = ?
+ORDER BY tags.name;
+
+-- name: ReadNoteTags :many
+SELECT tags.name
+FROM tags
+JOIN note_tags ON note_tags.tag_id = tags.id
+WHERE note_tags.note_id = ?
+ORDER BY tags.name;
+
+-- name: DeleteUnusedTags :execrows
+DELETE FROM tags
+WHERE id NOT IN (
+    SELECT tag_id FROM task_tags
+    UNION SELECT tag_id FROM area_tags
+    UNION SELECT tag_id FROM note_tags
+);
+
+-- name: UpsertSavedView :exec
+INSERT INTO saved_views (name, filter) VALUES (?, ?)
+ON CONFLICT (name) DO UPDATE SET filter = excluded.filter;
+
+-- name: ReadSavedView :one
+SELECT name, filter, created_at FROM saved_views
+WHERE name = ?;
+
+-- name: ReadSavedViews :many
+SELECT name, filter, created_at FROM saved_views
+ORDER BY name;
+
+-- name: DeleteSavedView :execrows
+DELETE FROM saved_views WHERE name = ?;
+
+-- name: ExportAreas :many
+SELECT id, title, status, archived, CAST(created_at AS TEXT) AS created_at, CAST(last_mod AS TEXT) AS last_mod, note_template
+FROM areas
+ORDER BY id;
+
+-- name: ExportTasks :many
+SELECT id, title, priority, status, archived, CAST(created_at AS TEXT) AS created_at, CAST(last_mod AS TEXT) AS last_mod,
+       due_date, area_id, recurrence, parent_task_id, completed_at
+FROM tasks
+ORDER BY id;
+
+-- name: ExportNotes :many
+SELECT id, title, path
+FROM notes
+ORDER BY id;
+
+-- name: ExportBridgeNotes :many
+SELECT note_id, parent_cat, parent_task_id, parent_area_id
+FROM bridge_notes
+ORDER BY note_id, id;
+
+-- name: ExportProgProjects :many
+SELECT id, path
+FROM programming_projects
+ORDER BY id;
+
+-- name: ExportProgProjectLinks :many
+SELECT project_id, parent_cat, parent_task_id, parent_area_id
+FROM prog_project_links
+ORDER BY project_id, parent_task_id, parent_area_id;
+
+-- name: ExportTaskDependencies :many
+SELECT blocker_id, blocked_id
+FROM task_dependencies
+ORDER BY blocker_id, blocked_id;
+
+-- name: ExportTaskTags :many
+SELECT task_tags.task_id, tags.name
+FROM task_tags
+JOIN tags ON tags.id = task_tags.tag_id
+ORDER BY task_tags.task_id, tags.name;
+
+-- name: ExportAreaTags :many
+SELECT area_tags.area_id, tags.name
+FROM area_tags
+JOIN tags ON tags.id = area_tags.tag_id
+ORDER BY area_tags.area_id, tags.name;
+
+-- name: ExportNoteTags :many
+SELECT note_tags.note_id, tags.name
+FROM note_tags
+JOIN tags ON tags.id = note_tags.tag_id
+ORDER BY note_tags.note_id, tags.name;
+
+-- name: ImportArea :one
+INSERT INTO areas (id, title, status, archived, created_at, last_mod, note_template)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING id;
+
+-- name: ImportTask :one
+INSERT INTO tasks (id, title, priority, status, archived, created_at, last_mod, due_date, area_id, recurrence, parent_task_id, completed_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id;
+
+-- name: ImportNote :one
+INSERT INTO notes (id, title, path)
+VALUES (?, ?, ?)
+RETURNING id;
+
+-- name: ImportBridgeNote :exec
+INSERT INTO bridge_notes (note_id, parent_cat, parent_task_id, parent_area_id)
+VALUES (?, ?, ?, ?);
+
+-- name: ImportProgProjectLink :exec
+INSERT INTO prog_project_links (project_id, parent_cat, parent_task_id, parent_area_id)
+VALUES (?, ?, ?, ?);
+
+-- name: ReadAreaIDByTitle :one
+SELECT id
+FROM areas
+WHERE title = ? COLLATE NOCASE
+ORDER BY id
+LIMIT 1;
+
+-- name: ReadNoteParent :one
+SELECT note_id, parent_cat, parent_task_id, parent_area_id
+FROM bridge_notes
+WHERE note_id = ?
+ORDER BY id
+LIMIT 1;
+
+-- name: UpsertNoteCheckbox :exec
+INSERT INTO note_checkboxes (task_id, note_id)
+VALUES (?, ?)
+ON CONFLICT(task_id) DO UPDATE SET note_id = excluded.note_id;
+
+-- name: ReadCheckboxNotes :many
+SELECT note_checkboxes.task_id, notes.id AS note_id, notes.path
+FROM note_checkboxes
+JOIN notes ON notes.id = note_checkboxes.note_id
+WHERE note_checkboxes.task_id IN (sqlc.slice(ids))
+ORDER BY notes.id;
+
+-- name: ExportNoteCheckboxes :many
+SELECT task_id, note_id
+FROM note_checkboxes
+ORDER BY task_id;
+
+-- name: ReadParentNotes :many
+SELECT notes.id, notes.title, notes.path
+FROM notes
+JOIN bridge_notes ON bridge_notes.note_id = notes.id
+WHERE (bridge_notes.parent_cat = 1 AND bridge_notes.parent_task_id = sqlc.narg(task_id))
+   OR (bridge_notes.parent_cat = 2 AND bridge_notes.parent_area_id = sqlc.narg(area_id))
+ORDER BY notes.id;
+
+-- name: ReadNoteStates :many
+SELECT notes.id, notes.path, bridge_notes.parent_cat,
+       tasks.id AS task_id, tasks.status AS task_status, tasks.priority AS task_priority, tasks.archived AS task_archived,
+       areas.id AS area_id, areas.title AS area_title, areas.status AS area_status, areas.archived AS area_archived
+FROM notes
+JOIN bridge_notes ON bridge_notes.note_id = notes.id
+    AND bridge_notes.id = (SELECT MIN(links.id) FROM bridge_notes links WHERE links.note_id = notes.id)
+LEFT JOIN tasks ON tasks.id = bridge_notes.parent_task_id
+LEFT JOIN areas ON areas.id = COALESCE(bridge_notes.parent_area_id, tasks.area_id)
+ORDER BY notes.id;
+
+-- name: UpdateNotePath :execrows
+UPDATE notes SET path = ? WHERE id = ?;
+
+-- name: UpdateNoteTitle :execrows
+UPDATE notes SET title = ? WHERE id = ?;
+
+-- name: CreateNoteLink :execrows
+INSERT INTO bridge_notes (note_id, parent_cat, parent_task_id, parent_area_id)
+VALUES (?, ?, ?, ?)
+ON CONFLICT DO NOTHING;
+
+-- name: ReadUnsharedNoteIDs :many
+SELECT note_id
+FROM bridge_notes
+GROUP BY note_id
+HAVING SUM((parent_cat = 1 AND parent_task_id IN (sqlc.slice(task_ids)))
+        OR (parent_cat = 2 AND parent_area_id IN (sqlc.slice(area_ids)))) = COUNT(*)
+ORDER BY note_id;
+
+-- name: ReadJournalTasks :many
+WITH journal(day) AS (SELECT CAST(sqlc.arg(day) AS TEXT))
+SELECT CAST('done' AS TEXT) AS section, tasks.id, tasks.title
+FROM tasks, journal
+WHERE tasks.status = 'done' AND date(COALESCE(tasks.completed_at, tasks.last_mod)) = journal.day
+UNION ALL
+SELECT 'doing', tasks.id, tasks.title
+FROM tasks
+WHERE tasks.status = 'doing' AND tasks.archived = 0
+UNION ALL
+SELECT 'due', tasks.id, tasks.title
+FROM tasks, journal
+WHERE tasks.due_date = journal.day AND IFNULL(tasks.status, '') != 'done' AND tasks.archived = 0
+ORDER BY section, id;
+
+-- name: CreateTimeEntry :one
+INSERT INTO time_entries (task_id, started_at, seconds)
+VALUES (?, ?, ?)
+RETURNING id;
+
+-- name: ReadRunningTimeEntry :one
+SELECT time_entries.id, time_entries.task_id, tasks.title, time_entries.started_at
+FROM time_entries
+JOIN tasks ON tasks.id = time_entries.task_id
+WHERE time_entries.seconds IS NULL
+ORDER BY time_entries.id
+LIMIT 1;
+
+-- name: StopTimeEntry :execrows
+UPDATE time_entries SET seconds = ? WHERE id = ? AND seconds IS NULL;
+
+-- name: ReadTimeByTask :many
+SELECT tasks.id, tasks.title, areas.title AS area_title, programming_projects.path AS repo,
+       CAST(SUM(time_entries.seconds) AS INTEGER) AS seconds
+FROM time_entries
+JOIN tasks ON tasks.id = time_entries.task_id
+LEFT JOIN areas ON areas.id = tasks.area_id
+LEFT JOIN prog_project_links ON prog_project_links.parent_task_id = tasks.id
+LEFT JOIN programming_projects ON programming_projects.id = prog_project_links.project_id
+WHERE time_entries.seconds IS NOT NULL AND time_entries.started_at >= ?
+GROUP BY tasks.id, programming_projects.id
+ORDER BY tasks.id, repo;
+
+-- name: ExportTimeEntries :many
+SELECT id, task_id, started_at, seconds
+FROM time_entries
+WHERE seconds IS NOT NULL
+ORDER BY id;
+
+-- name: ImportTimeEntry :exec
+INSERT INTO time_entries (task_id, started_at, seconds)
+VALUES (?, ?, ?);
+
+-- name: ReadEvents :many
+WITH filter(task_id, changed_at) AS (SELECT CAST(sqlc.arg(task_id) AS INTEGER), CAST(sqlc.arg(changed_at) AS TEXT))
+SELECT events.id, events.entity, events.entity_id,
+       CAST(COALESCE(
+           CASE WHEN NOT EXISTS (SELECT 1 FROM events later
+                                 WHERE later.entity = events.entity AND later.entity_id = events.entity_id
+                                   AND later.field = 'created' AND later.id > events.id)
+                THEN COALESCE(tasks.title, areas.title, notes.title) END,
+           (SELECT deleted.old_value FROM events deleted
+            WHERE deleted.entity = events.entity AND deleted.entity_id = events.entity_id AND deleted.field = 'deleted'
+              AND deleted.id >= events.id
+            ORDER BY deleted.id LIMIT 1), '') AS TEXT) AS title,
+       events.field, events.old_value, events.new_value, events.changed_at, events.source
+FROM events
+JOIN filter
+LEFT JOIN tasks ON events.entity = 'task' AND tasks.id = events.entity_id
+LEFT JOIN areas ON events.entity = 'area' AND areas.id = events.entity_id
+LEFT JOIN notes ON events.entity = 'note' AND notes.id = events.entity_id
+WHERE (filter.task_id = 0 OR (events.entity = 'task' AND events.entity_id = filter.task_id
+       AND events.id >= (SELECT COALESCE(MAX(created.id), 0) FROM events created
+                         WHERE created.entity = 'task' AND created.entity_id = filter.task_id AND created.field = 'created')))
+  AND events.changed_at >= filter.changed_at
+ORDER BY events.changed_at, events.id;
+
+-- name: ReadUndoSteps :many
+SELECT undo_steps.id, undo_steps.description, undo_steps.source, undo_steps.created_at,
+       CAST(COUNT(undo_log.id) AS INTEGER) AS changes
+FROM undo_steps
+LEFT JOIN undo_log ON undo_log.step_id = undo_steps.id
+GROUP BY undo_steps.id
+ORDER BY undo_steps.id DESC
+LIMIT ?;
+
+-- name: ReadUndoStatements :many
+SELECT statement
+FROM undo_log
+WHERE step_id >= ?
+ORDER BY id DESC;
+
+-- name: DeleteUndoSteps :execrows
+DELETE FROM undo_steps WHERE id >= ?;
+
+-- name: BeginUndoReplay :exec
+INSERT INTO undo_in_progress (id) VALUES (1);
+
+-- name: EndUndoReplay :exec
+DELETE FROM undo_in_progress;
+
+-- name: MarkRestoredEvents :exec
+UPDATE events SET field = 'restored' WHERE id > ? AND field = 'created';
+
+-- name: ReadLatestEventID :one
+SELECT CAST(COALESCE(MAX(id), 0) AS INTEGER) AS latest_id FROM events;
+
+-- name: ReadChangedTasks :many
+SELECT DISTINCT tasks.id, CAST(COALESCE(tasks.status = 'done', 0) AS BOOLEAN) AS done
+FROM events
+JOIN tasks ON events.entity = 'task' AND tasks.id = events.entity_id
+WHERE events.id > ?
+ORDER BY tasks.id;
+
+-- name: ReadChangedAreaIDs :many
+SELECT DISTINCT entity_id
+FROM events
+WHERE entity = 'area' AND id > ?
+ORDER BY entity_id;
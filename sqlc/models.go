@@ -10,12 +10,18 @@ import (
 )
 
 type Area struct {
-	ID        int64          `json:"id"`
-	Title     string         `json:"title"`
-	Status    sql.NullString `json:"status"`
-	Archived  bool           `json:"archived"`
-	CreatedAt time.Time      `json:"created_at"`
-	LastMod   time.Time      `json:"last_mod"`
+	ID           int64          `json:"id"`
+	Title        string         `json:"title"`
+	Status       sql.NullString `json:"status"`
+	Archived     bool           `json:"archived"`
+	CreatedAt    time.Time      `json:"created_at"`
+	LastMod      time.Time      `json:"last_mod"`
+	NoteTemplate sql.NullString `json:"note_template"`
+}
+
+type AreaTag struct {
+	AreaID int64 `json:"area_id"`
+	TagID  int64 `json:"tag_id"`
 }
 
 type BridgeNote struct {
@@ -25,12 +31,33 @@ type BridgeNote struct {
 	ParentAreaID sql.NullInt64 `json:"parent_area_id"`
 }
 
+type Event struct {
+	ID        int64          `json:"id"`
+	Entity    string         `json:"entity"`
+	EntityID  int64          `json:"entity_id"`
+	Field     string         `json:"field"`
+	OldValue  sql.NullString `json:"old_value"`
+	NewValue  sql.NullString `json:"new_value"`
+	ChangedAt string         `json:"changed_at"`
+	Source    string         `json:"source"`
+}
+
 type Note struct {
 	ID    int64  `json:"id"`
 	Title string `json:"title"`
 	Path  string `json:"path"`
 }
 
+type NoteCheckbox struct {
+	TaskID int64 `json:"task_id"`
+	NoteID int64 `json:"note_id"`
+}
+
+type NoteTag struct {
+	NoteID int64 `json:"note_id"`
+	TagID  int64 `json:"tag_id"`
+}
+
 type ProgProjectLink struct {
 	ProjectID    sql.NullInt64 `json:"project_id"`
 	ParentCat    sql.NullInt64 `json:"parent_cat"`
@@ -43,14 +70,58 @@ type ProgrammingProject struct {
 	Path string `json:"path"`
 }
 
+type SavedView struct {
+	Name      string       `json:"name"`
+	Filter    string       `json:"filter"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
+type Tag struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
 type Task struct {
-	ID        int64          `json:"id"`
-	Title     string         `json:"title"`
-	Priority  sql.NullString `json:"priority"`
-	Status    sql.NullString `json:"status"`
-	Archived  bool           `json:"archived"`
-	CreatedAt time.Time      `json:"created_at"`
-	LastMod   time.Time      `json:"last_mod"`
-	DueDate   sql.NullTime   `json:"due_date"`
-	AreaID    sql.NullInt64  `json:"area_id"`
+	ID           int64          `json:"id"`
+	Title        string         `json:"title"`
+	Priority     sql.NullString `json:"priority"`
+	Status       sql.NullString `json:"status"`
+	Archived     bool           `json:"archived"`
+	CreatedAt    time.Time      `json:"created_at"`
+	LastMod      time.Time      `json:"last_mod"`
+	DueDate      sql.NullString `json:"due_date"`
+	AreaID       sql.NullInt64  `json:"area_id"`
+	Recurrence   sql.NullString `json:"recurrence"`
+	ParentTaskID sql.NullInt64  `json:"parent_task_id"`
+	CompletedAt  sql.NullString `json:"completed_at"`
+}
+
+type TaskDependency struct {
+	BlockerID int64 `json:"blocker_id"`
+	BlockedID int64 `json:"blocked_id"`
+}
+
+type TaskTag struct {
+	TaskID int64 `json:"task_id"`
+	TagID  int64 `json:"tag_id"`
+}
+
+type TimeEntry struct {
+	ID        int64         `json:"id"`
+	TaskID    int64         `json:"task_id"`
+	StartedAt string        `json:"started_at"`
+	Seconds   sql.NullInt64 `json:"seconds"`
+}
+
+type UndoLog struct {
+	ID        int64  `json:"id"`
+	StepID    int64  `json:"step_id"`
+	Statement string `json:"statement"`
+}
+
+type UndoStep struct {
+	ID          int64  `json:"id"`
+	Description string `json:"description"`
+	Source      string `json:"source"`
+	CreatedAt   string `json:"created_at"`
 }
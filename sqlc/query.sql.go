@@ -11,6 +11,57 @@ import (
 	"strings"
 )
 
+const addAreaTag = `-- name: AddAreaTag :exec
+INSERT OR IGNORE INTO area_tags (area_id, tag_id) VALUES (?, ?)
+`
+
+type AddAreaTagParams struct {
+	AreaID int64 `json:"area_id"`
+	TagID  int64 `json:"tag_id"`
+}
+
+func (q *Queries) AddAreaTag(ctx context.Context, arg AddAreaTagParams) error {
+	_, err := q.db.ExecContext(ctx, addAreaTag, arg.AreaID, arg.TagID)
+	return err
+}
+
+const addNoteTag = `-- name: AddNoteTag :exec
+INSERT OR IGNORE INTO note_tags (note_id, tag_id) VALUES (?, ?)
+`
+
+type AddNoteTagParams struct {
+	NoteID int64 `json:"note_id"`
+	TagID  int64 `json:"tag_id"`
+}
+
+func (q *Queries) AddNoteTag(ctx context.Context, arg AddNoteTagParams) error {
+	_, err := q.db.ExecContext(ctx, addNoteTag, arg.NoteID, arg.TagID)
+	return err
+}
+
+const addTaskTag = `-- name: AddTaskTag :exec
+INSERT OR IGNORE INTO task_tags (task_id, tag_id) VALUES (?, ?)
+`
+
+type AddTaskTagParams struct {
+	TaskID int64 `json:"task_id"`
+	TagID  int64 `json:"tag_id"`
+}
+
+func (q *Queries) AddTaskTag(ctx context.Context, arg AddTaskTagParams) error {
+	_, err := q.db.ExecContext(ctx, addTaskTag, arg.TaskID, arg.TagID)
+	return err
+}
+
+const beginUndoReplay = `-- name: BeginUndoReplay :exec
+INSERT INTO undo_in_progress (id) VALUES (1)
+`
+
+func (q *Queries) BeginUndoReplay(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, beginUndoReplay)
+	return err
+}
+
 const checkProgProjectExists = `-- name: CheckProgProjectExists :one
 SELECT
   COALESCE(pp.id, 0) AS prog_proj_exists
@@ -26,6 +77,23 @@ func (q *Queries) CheckProgProjectExists(ctx context.Context, path string) (int6
 	return prog_proj_exists, err
 }
 
+const copyProjectTaskLinks = `-- name: CopyProjectTaskLinks :exec
+INSERT INTO prog_project_links (project_id, parent_cat, parent_task_id)
+SELECT project_id, parent_cat, CAST(? AS INTEGER)
+FROM prog_project_links
+WHERE parent_task_id = ?
+`
+
+type CopyProjectTaskLinksParams struct {
+	NewTaskID int64         `json:"new_task_id"`
+	TaskID    sql.NullInt64 `json:"task_id"`
+}
+
+func (q *Queries) CopyProjectTaskLinks(ctx context.Context, arg CopyProjectTaskLinksParams) error {
+	_, err := q.db.ExecContext(ctx, copyProjectTaskLinks, arg.NewTaskID, arg.TaskID)
+	return err
+}
+
 const createArea = `-- name: CreateArea :execlastid
 INSERT INTO areas (id, title, status, archived)
 VALUES (?, ?, ?, ?)
@@ -85,6 +153,32 @@ func (q *Queries) CreateNote(ctx context.Context, arg CreateNoteParams) error {
 	return err
 }
 
+const createNoteLink = `-- name: CreateNoteLink :execrows
+INSERT INTO bridge_notes (note_id, parent_cat, parent_task_id, parent_area_id)
+VALUES (?, ?, ?, ?)
+ON CONFLICT DO NOTHING
+`
+
+type CreateNoteLinkParams struct {
+	NoteID       int64         `json:"note_id"`
+	ParentCat    sql.NullInt64 `json:"parent_cat"`
+	ParentTaskID sql.NullInt64 `json:"parent_task_id"`
+	ParentAreaID sql.NullInt64 `json:"parent_area_id"`
+}
+
+func (q *Queries) CreateNoteLink(ctx context.Context, arg CreateNoteLinkParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createNoteLink,
+		arg.NoteID,
+		arg.ParentCat,
+		arg.ParentTaskID,
+		arg.ParentAreaID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const createProjectAreaLink = `-- name: CreateProjectAreaLink :exec
 ;
 
@@ -121,11 +215,11 @@ func (q *Queries) CreateProjectTaskLink(ctx context.Context, arg CreateProjectTa
 
 const createTask = `-- name: CreateTask :execlastid
 INSERT INTO tasks (
-    id, title, priority, status, archived, due_date, area_id,
+    id, title, priority, status, archived, due_date, area_id, recurrence, parent_task_id,
     created_at, last_mod
 )
 VALUES (
-    ?, ?, ?, ?, ?, ?, ?,
+    ?, ?, ?, ?, ?, ?, ?, ?, ?,
     datetime(current_timestamp, 'localtime'),
     datetime(current_timestamp, 'localtime')
 )
@@ -133,13 +227,15 @@ returning id
 `
 
 type CreateTaskParams struct {
-	ID       int64          `json:"id"`
-	Title    string         `json:"title"`
-	Priority sql.NullString `json:"priority"`
-	Status   sql.NullString `json:"status"`
-	Archived bool           `json:"archived"`
-	DueDate  sql.NullTime   `json:"due_date"`
-	AreaID   sql.NullInt64  `json:"area_id"`
+	ID           int64          `json:"id"`
+	Title        string         `json:"title"`
+	Priority     sql.NullString `json:"priority"`
+	Status       sql.NullString `json:"status"`
+	Archived     bool           `json:"archived"`
+	DueDate      sql.NullString `json:"due_date"`
+	AreaID       sql.NullInt64  `json:"area_id"`
+	Recurrence   sql.NullString `json:"recurrence"`
+	ParentTaskID sql.NullInt64  `json:"parent_task_id"`
 }
 
 func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (int64, error) {
@@ -151,6 +247,8 @@ func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (int64,
 		arg.Archived,
 		arg.DueDate,
 		arg.AreaID,
+		arg.Recurrence,
+		arg.ParentTaskID,
 	)
 	if err != nil {
 		return 0, err
@@ -176,6 +274,40 @@ func (q *Queries) CreateTaskBridgeNote(ctx context.Context, arg CreateTaskBridge
 	return result.LastInsertId()
 }
 
+const createTaskDependency = `-- name: CreateTaskDependency :exec
+INSERT INTO task_dependencies (blocker_id, blocked_id)
+VALUES (?, ?)
+`
+
+type CreateTaskDependencyParams struct {
+	BlockerID int64 `json:"blocker_id"`
+	BlockedID int64 `json:"blocked_id"`
+}
+
+func (q *Queries) CreateTaskDependency(ctx context.Context, arg CreateTaskDependencyParams) error {
+	_, err := q.db.ExecContext(ctx, createTaskDependency, arg.BlockerID, arg.BlockedID)
+	return err
+}
+
+const createTimeEntry = `-- name: CreateTimeEntry :one
+INSERT INTO time_entries (task_id, started_at, seconds)
+VALUES (?, ?, ?)
+RETURNING id
+`
+
+type CreateTimeEntryParams struct {
+	TaskID    int64         `json:"task_id"`
+	StartedAt string        `json:"started_at"`
+	Seconds   sql.NullInt64 `json:"seconds"`
+}
+
+func (q *Queries) CreateTimeEntry(ctx context.Context, arg CreateTimeEntryParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, createTimeEntry, arg.TaskID, arg.StartedAt, arg.Seconds)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
 const deleteAreaBridgeNote = `-- name: DeleteAreaBridgeNote :execlastid
 DELETE FROM bridge_notes 
 WHERE note_id = ?
@@ -286,6 +418,18 @@ func (q *Queries) DeleteNotesFromSingleArea(ctx context.Context, parentAreaID sq
 	return q.db.ExecContext(ctx, deleteNotesFromSingleArea, parentAreaID)
 }
 
+const deleteSavedView = `-- name: DeleteSavedView :execrows
+DELETE FROM saved_views WHERE name = ?
+`
+
+func (q *Queries) DeleteSavedView(ctx context.Context, name string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSavedView, name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteSingleArea = `-- name: DeleteSingleArea :one
 DELETE FROM areas WHERE id = ?
 returning id
@@ -300,7 +444,7 @@ func (q *Queries) DeleteSingleArea(ctx context.Context, id int64) (int64, error)
 const deleteTask = `-- name: DeleteTask :execlastid
 DELETE FROM tasks
 WHERE id = ?
-returning id, title, priority, status, archived, created_at, last_mod, due_date, area_id
+returning id, title, priority, status, archived, created_at, last_mod, due_date, area_id, recurrence, parent_task_id
 `
 
 func (q *Queries) DeleteTask(ctx context.Context, id int64) (int64, error) {
@@ -331,6 +475,24 @@ func (q *Queries) DeleteTaskBridgeNote(ctx context.Context, arg DeleteTaskBridge
 	return result.LastInsertId()
 }
 
+const deleteTaskDependency = `-- name: DeleteTaskDependency :execrows
+DELETE FROM task_dependencies
+WHERE blocker_id = ? AND blocked_id = ?
+`
+
+type DeleteTaskDependencyParams struct {
+	BlockerID int64 `json:"blocker_id"`
+	BlockedID int64 `json:"blocked_id"`
+}
+
+func (q *Queries) DeleteTaskDependency(ctx context.Context, arg DeleteTaskDependencyParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteTaskDependency, arg.BlockerID, arg.BlockedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteTasks = `-- name: DeleteTasks :execrows
 DELETE FROM tasks
 WHERE id in (/*SLICE:ids*/?)
@@ -354,135 +516,91 @@ func (q *Queries) DeleteTasks(ctx context.Context, ids []int64) (int64, error) {
 	return result.RowsAffected()
 }
 
-const findProgProjectsForArea = `-- name: FindProgProjectsForArea :many
-SELECT pp.id, pp.path
-FROM programming_projects pp
-JOIN prog_project_links pl on pp.id = pl.project_id
-WHERE pl.parent_area_id = ?
+const deleteUndoSteps = `-- name: DeleteUndoSteps :execrows
+DELETE FROM undo_steps WHERE id >= ?
 `
 
-func (q *Queries) FindProgProjectsForArea(ctx context.Context, parentAreaID sql.NullInt64) ([]ProgrammingProject, error) {
-	rows, err := q.db.QueryContext(ctx, findProgProjectsForArea, parentAreaID)
+func (q *Queries) DeleteUndoSteps(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUndoSteps, id)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []ProgrammingProject
-	for rows.Next() {
-		var i ProgrammingProject
-		if err := rows.Scan(&i.ID, &i.Path); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return items, nil
-}
-
-const findProgProjectsForTask = `-- name: FindProgProjectsForTask :one
-SELECT pp.id, pp.path
-FROM programming_projects pp
-JOIN prog_project_links pl on pp.id = pl.project_id
-WHERE pl.parent_task_id = ?
-`
-
-func (q *Queries) FindProgProjectsForTask(ctx context.Context, parentTaskID sql.NullInt64) (ProgrammingProject, error) {
-	row := q.db.QueryRowContext(ctx, findProgProjectsForTask, parentTaskID)
-	var i ProgrammingProject
-	err := row.Scan(&i.ID, &i.Path)
-	return i, err
+	return result.RowsAffected()
 }
 
-const getAreaID = `-- name: GetAreaID :one
-SELECT CAST(COALESCE(MIN(a1.id + 1), 1) AS INT) AS missing_id
-FROM areas a1
-LEFT JOIN areas a2 ON a1.id + 1 = a2.id
-WHERE a2.id IS NULL
+const deleteUnusedTags = `-- name: DeleteUnusedTags :execrows
+DELETE FROM tags
+WHERE id NOT IN (
+    SELECT tag_id FROM task_tags
+    UNION SELECT tag_id FROM area_tags
+    UNION SELECT tag_id FROM note_tags
+)
 `
 
-func (q *Queries) GetAreaID(ctx context.Context) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getAreaID)
-	var missing_id int64
-	err := row.Scan(&missing_id)
-	return missing_id, err
+func (q *Queries) DeleteUnusedTags(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteUnusedTags)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-const getNoteID = `-- name: GetNoteID :one
-SELECT CAST(COALESCE(MIN(n1.id + 1), 1) AS INT) AS missing_id
-FROM notes n1
-LEFT JOIN notes n2 ON n1.id + 1 = n2.id
-WHERE n2.id IS NULL
+const dependencyPathExists = `-- name: DependencyPathExists :one
+WITH RECURSIVE reachable(id) AS (
+    SELECT blocked_id FROM task_dependencies WHERE blocker_id = ?
+    UNION
+    SELECT task_dependencies.blocked_id
+    FROM task_dependencies
+    JOIN reachable ON task_dependencies.blocker_id = reachable.id
+)
+SELECT CAST(EXISTS (SELECT 1 FROM reachable WHERE id = ?) AS INTEGER) AS path_exists
 `
 
-func (q *Queries) GetNoteID(ctx context.Context) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getNoteID)
-	var missing_id int64
-	err := row.Scan(&missing_id)
-	return missing_id, err
+type DependencyPathExistsParams struct {
+	FromID int64 `json:"from_id"`
+	ToID   int64 `json:"to_id"`
 }
 
-const getTaskID = `-- name: GetTaskID :one
-SELECT CAST(COALESCE(MIN(t1.id + 1), 1) AS INT) AS missing_id
-FROM tasks t1
-LEFT JOIN tasks t2 ON t1.id + 1 = t2.id
-WHERE t2.id IS NULL
-`
-
-func (q *Queries) GetTaskID(ctx context.Context) (int64, error) {
-	row := q.db.QueryRowContext(ctx, getTaskID)
-	var missing_id int64
-	err := row.Scan(&missing_id)
-	return missing_id, err
+func (q *Queries) DependencyPathExists(ctx context.Context, arg DependencyPathExistsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, dependencyPathExists, arg.FromID, arg.ToID)
+	var path_exists int64
+	err := row.Scan(&path_exists)
+	return path_exists, err
 }
 
-const insertProgProject = `-- name: InsertProgProject :one
-INSERT INTO programming_projects (path)
-VALUES (?)
-RETURNING id
+const endUndoReplay = `-- name: EndUndoReplay :exec
+DELETE FROM undo_in_progress
 `
 
-func (q *Queries) InsertProgProject(ctx context.Context, path string) (int64, error) {
-	row := q.db.QueryRowContext(ctx, insertProgProject, path)
-	var id int64
-	err := row.Scan(&id)
-	return id, err
+func (q *Queries) EndUndoReplay(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, endUndoReplay)
+	return err
 }
 
-const readAllAreaNotes = `-- name: ReadAllAreaNotes :many
-SELECT notes.id, notes.title, notes.path, areas.title as area_title, areas.id as parent_id
-FROM notes
-INNER JOIN bridge_notes ON bridge_notes.note_id = notes.id
-INNER JOIN areas ON areas.ID = bridge_notes.parent_area_id AND bridge_notes.parent_cat = 2
+const exportAreaTags = `-- name: ExportAreaTags :many
+SELECT area_tags.area_id, tags.name
+FROM area_tags
+JOIN tags ON tags.id = area_tags.tag_id
+ORDER BY area_tags.area_id, tags.name
 `
 
-type ReadAllAreaNotesRow struct {
-	ID        int64  `json:"id"`
-	Title     string `json:"title"`
-	Path      string `json:"path"`
-	AreaTitle string `json:"area_title"`
-	ParentID  int64  `json:"parent_id"`
+type ExportAreaTagsRow struct {
+	AreaID int64  `json:"area_id"`
+	Name   string `json:"name"`
 }
 
-func (q *Queries) ReadAllAreaNotes(ctx context.Context) ([]ReadAllAreaNotesRow, error) {
-	rows, err := q.db.QueryContext(ctx, readAllAreaNotes)
+func (q *Queries) ExportAreaTags(ctx context.Context) ([]ExportAreaTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, exportAreaTags)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ReadAllAreaNotesRow
+	var items []ExportAreaTagsRow
 	for rows.Next() {
-		var i ReadAllAreaNotesRow
+		var i ExportAreaTagsRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.Title,
-			&i.Path,
-			&i.AreaTitle,
-			&i.ParentID,
+			&i.AreaID,
+			&i.Name,
 		); err != nil {
 			return nil, err
 		}
@@ -497,45 +615,39 @@ func (q *Queries) ReadAllAreaNotes(ctx context.Context) ([]ReadAllAreaNotesRow,
 	return items, nil
 }
 
-const readAllAreas = `-- name: ReadAllAreas :many
-SELECT 
-    areas.id, areas.title, areas.status, areas.archived,
-    notes.id, notes.title, notes.path
-FROM 
-    areas
-LEFT JOIN 
-    bridge_notes ON areas.id = bridge_notes.parent_area_id AND bridge_notes.parent_cat = 2
-LEFT JOIN 
-    notes ON bridge_notes.note_id = notes.id
+const exportAreas = `-- name: ExportAreas :many
+SELECT id, title, status, archived, CAST(created_at AS TEXT) AS created_at, CAST(last_mod AS TEXT) AS last_mod, note_template
+FROM areas
+ORDER BY id
 `
 
-type ReadAllAreasRow struct {
-	ID       int64          `json:"id"`
-	Title    string         `json:"title"`
-	Status   sql.NullString `json:"status"`
-	Archived bool           `json:"archived"`
-	ID_2     sql.NullInt64  `json:"id_2"`
-	Title_2  sql.NullString `json:"title_2"`
-	Path     sql.NullString `json:"path"`
+type ExportAreasRow struct {
+	ID           int64          `json:"id"`
+	Title        string         `json:"title"`
+	Status       sql.NullString `json:"status"`
+	Archived     bool           `json:"archived"`
+	CreatedAt    string         `json:"created_at"`
+	LastMod      string         `json:"last_mod"`
+	NoteTemplate sql.NullString `json:"note_template"`
 }
 
-func (q *Queries) ReadAllAreas(ctx context.Context) ([]ReadAllAreasRow, error) {
-	rows, err := q.db.QueryContext(ctx, readAllAreas)
+func (q *Queries) ExportAreas(ctx context.Context) ([]ExportAreasRow, error) {
+	rows, err := q.db.QueryContext(ctx, exportAreas)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ReadAllAreasRow
+	var items []ExportAreasRow
 	for rows.Next() {
-		var i ReadAllAreasRow
+		var i ExportAreasRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Title,
 			&i.Status,
 			&i.Archived,
-			&i.ID_2,
-			&i.Title_2,
-			&i.Path,
+			&i.CreatedAt,
+			&i.LastMod,
+			&i.NoteTemplate,
 		); err != nil {
 			return nil, err
 		}
@@ -550,37 +662,26 @@ func (q *Queries) ReadAllAreas(ctx context.Context) ([]ReadAllAreasRow, error) {
 	return items, nil
 }
 
-const readAllNotes = `-- name: ReadAllNotes :many
-SELECT notes.id, notes.title, notes.path, coalesce(tasks.title, areas.title, 'Unknown') [area_or_task_title], case when bridge_notes.parent_cat = 1 then 'Task' else 'Area' end as [parent_type]
-FROM notes
-INNER JOIN bridge_notes ON bridge_notes.note_id = notes.id
-LEFT JOIN tasks ON tasks.ID = bridge_notes.parent_task_id AND bridge_notes.parent_cat = 1
-LEFT JOIN areas ON areas.ID = bridge_notes.parent_area_id AND bridge_notes.parent_cat = 2
+const exportBridgeNotes = `-- name: ExportBridgeNotes :many
+SELECT note_id, parent_cat, parent_task_id, parent_area_id
+FROM bridge_notes
+ORDER BY note_id, id
 `
 
-type ReadAllNotesRow struct {
-	ID              int64  `json:"id"`
-	Title           string `json:"title"`
-	Path            string `json:"path"`
-	AreaOrTaskTitle string `json:"[area_or_task_title]"`
-	ParentType      string `json:"[parent_type]"`
-}
-
-func (q *Queries) ReadAllNotes(ctx context.Context) ([]ReadAllNotesRow, error) {
-	rows, err := q.db.QueryContext(ctx, readAllNotes)
+func (q *Queries) ExportBridgeNotes(ctx context.Context) ([]BridgeNote, error) {
+	rows, err := q.db.QueryContext(ctx, exportBridgeNotes)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ReadAllNotesRow
+	var items []BridgeNote
 	for rows.Next() {
-		var i ReadAllNotesRow
+		var i BridgeNote
 		if err := rows.Scan(
-			&i.ID,
-			&i.Title,
-			&i.Path,
-			&i.AreaOrTaskTitle,
-			&i.ParentType,
+			&i.NoteID,
+			&i.ParentCat,
+			&i.ParentTaskID,
+			&i.ParentAreaID,
 		); err != nil {
 			return nil, err
 		}
@@ -595,24 +696,25 @@ func (q *Queries) ReadAllNotes(ctx context.Context) ([]ReadAllNotesRow, error) {
 	return items, nil
 }
 
-const readAllProgProjects = `-- name: ReadAllProgProjects :many
-SELECT path
-FROM programming_projects
+const exportNoteCheckboxes = `-- name: ExportNoteCheckboxes :many
+SELECT task_id, note_id
+FROM note_checkboxes
+ORDER BY task_id
 `
 
-func (q *Queries) ReadAllProgProjects(ctx context.Context) ([]string, error) {
-	rows, err := q.db.QueryContext(ctx, readAllProgProjects)
+func (q *Queries) ExportNoteCheckboxes(ctx context.Context) ([]NoteCheckbox, error) {
+	rows, err := q.db.QueryContext(ctx, exportNoteCheckboxes)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []string
+	var items []NoteCheckbox
 	for rows.Next() {
-		var path string
-		if err := rows.Scan(&path); err != nil {
+		var i NoteCheckbox
+		if err := rows.Scan(&i.TaskID, &i.NoteID); err != nil {
 			return nil, err
 		}
-		items = append(items, path)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -623,15 +725,776 @@ func (q *Queries) ReadAllProgProjects(ctx context.Context) ([]string, error) {
 	return items, nil
 }
 
-const readAllTaskNotes = `-- name: ReadAllTaskNotes :many
-SELECT notes.id, notes.title, notes.path, tasks.title as task_title, tasks.id  as parent_id
-FROM notes
-INNER JOIN bridge_notes ON bridge_notes.note_id = notes.id
-INNER JOIN tasks ON tasks.ID = bridge_notes.parent_task_id AND bridge_notes.parent_cat = 1
+const exportNoteTags = `-- name: ExportNoteTags :many
+SELECT note_tags.note_id, tags.name
+FROM note_tags
+JOIN tags ON tags.id = note_tags.tag_id
+ORDER BY note_tags.note_id, tags.name
 `
 
-type ReadAllTaskNotesRow struct {
-	ID        int64  `json:"id"`
+type ExportNoteTagsRow struct {
+	NoteID int64  `json:"note_id"`
+	Name   string `json:"name"`
+}
+
+func (q *Queries) ExportNoteTags(ctx context.Context) ([]ExportNoteTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, exportNoteTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExportNoteTagsRow
+	for rows.Next() {
+		var i ExportNoteTagsRow
+		if err := rows.Scan(
+			&i.NoteID,
+			&i.Name,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const exportNotes = `-- name: ExportNotes :many
+SELECT id, title, path
+FROM notes
+ORDER BY id
+`
+
+func (q *Queries) ExportNotes(ctx context.Context) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, exportNotes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Path,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const exportProgProjectLinks = `-- name: ExportProgProjectLinks :many
+SELECT project_id, parent_cat, parent_task_id, parent_area_id
+FROM prog_project_links
+ORDER BY project_id, parent_task_id, parent_area_id
+`
+
+func (q *Queries) ExportProgProjectLinks(ctx context.Context) ([]ProgProjectLink, error) {
+	rows, err := q.db.QueryContext(ctx, exportProgProjectLinks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProgProjectLink
+	for rows.Next() {
+		var i ProgProjectLink
+		if err := rows.Scan(
+			&i.ProjectID,
+			&i.ParentCat,
+			&i.ParentTaskID,
+			&i.ParentAreaID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const exportProgProjects = `-- name: ExportProgProjects :many
+SELECT id, path
+FROM programming_projects
+ORDER BY id
+`
+
+func (q *Queries) ExportProgProjects(ctx context.Context) ([]ProgrammingProject, error) {
+	rows, err := q.db.QueryContext(ctx, exportProgProjects)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProgrammingProject
+	for rows.Next() {
+		var i ProgrammingProject
+		if err := rows.Scan(
+			&i.ID,
+			&i.Path,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const exportTaskDependencies = `-- name: ExportTaskDependencies :many
+SELECT blocker_id, blocked_id
+FROM task_dependencies
+ORDER BY blocker_id, blocked_id
+`
+
+func (q *Queries) ExportTaskDependencies(ctx context.Context) ([]TaskDependency, error) {
+	rows, err := q.db.QueryContext(ctx, exportTaskDependencies)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TaskDependency
+	for rows.Next() {
+		var i TaskDependency
+		if err := rows.Scan(
+			&i.BlockerID,
+			&i.BlockedID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const exportTaskTags = `-- name: ExportTaskTags :many
+SELECT task_tags.task_id, tags.name
+FROM task_tags
+JOIN tags ON tags.id = task_tags.tag_id
+ORDER BY task_tags.task_id, tags.name
+`
+
+type ExportTaskTagsRow struct {
+	TaskID int64  `json:"task_id"`
+	Name   string `json:"name"`
+}
+
+func (q *Queries) ExportTaskTags(ctx context.Context) ([]ExportTaskTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, exportTaskTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExportTaskTagsRow
+	for rows.Next() {
+		var i ExportTaskTagsRow
+		if err := rows.Scan(
+			&i.TaskID,
+			&i.Name,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const exportTasks = `-- name: ExportTasks :many
+SELECT id, title, priority, status, archived, CAST(created_at AS TEXT) AS created_at, CAST(last_mod AS TEXT) AS last_mod,
+       due_date, area_id, recurrence, parent_task_id, completed_at
+FROM tasks
+ORDER BY id
+`
+
+type ExportTasksRow struct {
+	ID           int64          `json:"id"`
+	Title        string         `json:"title"`
+	Priority     sql.NullString `json:"priority"`
+	Status       sql.NullString `json:"status"`
+	Archived     bool           `json:"archived"`
+	CreatedAt    string         `json:"created_at"`
+	LastMod      string         `json:"last_mod"`
+	DueDate      sql.NullString `json:"due_date"`
+	AreaID       sql.NullInt64  `json:"area_id"`
+	Recurrence   sql.NullString `json:"recurrence"`
+	ParentTaskID sql.NullInt64  `json:"parent_task_id"`
+	CompletedAt  sql.NullString `json:"completed_at"`
+}
+
+func (q *Queries) ExportTasks(ctx context.Context) ([]ExportTasksRow, error) {
+	rows, err := q.db.QueryContext(ctx, exportTasks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExportTasksRow
+	for rows.Next() {
+		var i ExportTasksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Priority,
+			&i.Status,
+			&i.Archived,
+			&i.CreatedAt,
+			&i.LastMod,
+			&i.DueDate,
+			&i.AreaID,
+			&i.Recurrence,
+			&i.ParentTaskID,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const exportTimeEntries = `-- name: ExportTimeEntries :many
+SELECT id, task_id, started_at, seconds
+FROM time_entries
+WHERE seconds IS NOT NULL
+ORDER BY id
+`
+
+func (q *Queries) ExportTimeEntries(ctx context.Context) ([]TimeEntry, error) {
+	rows, err := q.db.QueryContext(ctx, exportTimeEntries)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []TimeEntry
+	for rows.Next() {
+		var i TimeEntry
+		if err := rows.Scan(
+			&i.ID,
+			&i.TaskID,
+			&i.StartedAt,
+			&i.Seconds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findProgProjectsForArea = `-- name: FindProgProjectsForArea :many
+SELECT pp.id, pp.path
+FROM programming_projects pp
+JOIN prog_project_links pl on pp.id = pl.project_id
+WHERE pl.parent_area_id = ?
+`
+
+func (q *Queries) FindProgProjectsForArea(ctx context.Context, parentAreaID sql.NullInt64) ([]ProgrammingProject, error) {
+	rows, err := q.db.QueryContext(ctx, findProgProjectsForArea, parentAreaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProgrammingProject
+	for rows.Next() {
+		var i ProgrammingProject
+		if err := rows.Scan(&i.ID, &i.Path); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const findProgProjectsForTask = `-- name: FindProgProjectsForTask :one
+SELECT pp.id, pp.path
+FROM programming_projects pp
+JOIN prog_project_links pl on pp.id = pl.project_id
+WHERE pl.parent_task_id = ?
+`
+
+func (q *Queries) FindProgProjectsForTask(ctx context.Context, parentTaskID sql.NullInt64) (ProgrammingProject, error) {
+	row := q.db.QueryRowContext(ctx, findProgProjectsForTask, parentTaskID)
+	var i ProgrammingProject
+	err := row.Scan(&i.ID, &i.Path)
+	return i, err
+}
+
+const getAreaID = `-- name: GetAreaID :one
+SELECT CAST(COALESCE(MIN(a1.id + 1), 1) AS INT) AS missing_id
+FROM areas a1
+LEFT JOIN areas a2 ON a1.id + 1 = a2.id
+WHERE a2.id IS NULL
+`
+
+func (q *Queries) GetAreaID(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getAreaID)
+	var missing_id int64
+	err := row.Scan(&missing_id)
+	return missing_id, err
+}
+
+const getNoteID = `-- name: GetNoteID :one
+SELECT CAST(COALESCE(MIN(n1.id + 1), 1) AS INT) AS missing_id
+FROM notes n1
+LEFT JOIN notes n2 ON n1.id + 1 = n2.id
+WHERE n2.id IS NULL
+`
+
+func (q *Queries) GetNoteID(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getNoteID)
+	var missing_id int64
+	err := row.Scan(&missing_id)
+	return missing_id, err
+}
+
+const getTaskID = `-- name: GetTaskID :one
+SELECT CAST(COALESCE(MIN(t1.id + 1), 1) AS INT) AS missing_id
+FROM tasks t1
+LEFT JOIN tasks t2 ON t1.id + 1 = t2.id
+WHERE t2.id IS NULL
+`
+
+func (q *Queries) GetTaskID(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getTaskID)
+	var missing_id int64
+	err := row.Scan(&missing_id)
+	return missing_id, err
+}
+
+const importArea = `-- name: ImportArea :one
+INSERT INTO areas (id, title, status, archived, created_at, last_mod, note_template)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+RETURNING id
+`
+
+type ImportAreaParams struct {
+	ID           sql.NullInt64  `json:"id"`
+	Title        string         `json:"title"`
+	Status       sql.NullString `json:"status"`
+	Archived     bool           `json:"archived"`
+	CreatedAt    string         `json:"created_at"`
+	LastMod      string         `json:"last_mod"`
+	NoteTemplate sql.NullString `json:"note_template"`
+}
+
+func (q *Queries) ImportArea(ctx context.Context, arg ImportAreaParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, importArea,
+		arg.ID,
+		arg.Title,
+		arg.Status,
+		arg.Archived,
+		arg.CreatedAt,
+		arg.LastMod,
+		arg.NoteTemplate,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const importBridgeNote = `-- name: ImportBridgeNote :exec
+INSERT INTO bridge_notes (note_id, parent_cat, parent_task_id, parent_area_id)
+VALUES (?, ?, ?, ?)
+`
+
+type ImportBridgeNoteParams struct {
+	NoteID       int64         `json:"note_id"`
+	ParentCat    sql.NullInt64 `json:"parent_cat"`
+	ParentTaskID sql.NullInt64 `json:"parent_task_id"`
+	ParentAreaID sql.NullInt64 `json:"parent_area_id"`
+}
+
+func (q *Queries) ImportBridgeNote(ctx context.Context, arg ImportBridgeNoteParams) error {
+	_, err := q.db.ExecContext(ctx, importBridgeNote,
+		arg.NoteID,
+		arg.ParentCat,
+		arg.ParentTaskID,
+		arg.ParentAreaID,
+	)
+	return err
+}
+
+const importNote = `-- name: ImportNote :one
+INSERT INTO notes (id, title, path)
+VALUES (?, ?, ?)
+RETURNING id
+`
+
+type ImportNoteParams struct {
+	ID    sql.NullInt64 `json:"id"`
+	Title string        `json:"title"`
+	Path  string        `json:"path"`
+}
+
+func (q *Queries) ImportNote(ctx context.Context, arg ImportNoteParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, importNote,
+		arg.ID,
+		arg.Title,
+		arg.Path,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const importProgProjectLink = `-- name: ImportProgProjectLink :exec
+INSERT INTO prog_project_links (project_id, parent_cat, parent_task_id, parent_area_id)
+VALUES (?, ?, ?, ?)
+`
+
+type ImportProgProjectLinkParams struct {
+	ProjectID    sql.NullInt64 `json:"project_id"`
+	ParentCat    sql.NullInt64 `json:"parent_cat"`
+	ParentTaskID sql.NullInt64 `json:"parent_task_id"`
+	ParentAreaID sql.NullInt64 `json:"parent_area_id"`
+}
+
+func (q *Queries) ImportProgProjectLink(ctx context.Context, arg ImportProgProjectLinkParams) error {
+	_, err := q.db.ExecContext(ctx, importProgProjectLink,
+		arg.ProjectID,
+		arg.ParentCat,
+		arg.ParentTaskID,
+		arg.ParentAreaID,
+	)
+	return err
+}
+
+const importTask = `-- name: ImportTask :one
+INSERT INTO tasks (id, title, priority, status, archived, created_at, last_mod, due_date, area_id, recurrence, parent_task_id, completed_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+RETURNING id
+`
+
+type ImportTaskParams struct {
+	ID           sql.NullInt64  `json:"id"`
+	Title        string         `json:"title"`
+	Priority     sql.NullString `json:"priority"`
+	Status       sql.NullString `json:"status"`
+	Archived     bool           `json:"archived"`
+	CreatedAt    string         `json:"created_at"`
+	LastMod      string         `json:"last_mod"`
+	DueDate      sql.NullString `json:"due_date"`
+	AreaID       sql.NullInt64  `json:"area_id"`
+	Recurrence   sql.NullString `json:"recurrence"`
+	ParentTaskID sql.NullInt64  `json:"parent_task_id"`
+	CompletedAt  sql.NullString `json:"completed_at"`
+}
+
+func (q *Queries) ImportTask(ctx context.Context, arg ImportTaskParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, importTask,
+		arg.ID,
+		arg.Title,
+		arg.Priority,
+		arg.Status,
+		arg.Archived,
+		arg.CreatedAt,
+		arg.LastMod,
+		arg.DueDate,
+		arg.AreaID,
+		arg.Recurrence,
+		arg.ParentTaskID,
+		arg.CompletedAt,
+	)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const importTimeEntry = `-- name: ImportTimeEntry :exec
+INSERT INTO time_entries (task_id, started_at, seconds)
+VALUES (?, ?, ?)
+`
+
+type ImportTimeEntryParams struct {
+	TaskID    int64         `json:"task_id"`
+	StartedAt string        `json:"started_at"`
+	Seconds   sql.NullInt64 `json:"seconds"`
+}
+
+func (q *Queries) ImportTimeEntry(ctx context.Context, arg ImportTimeEntryParams) error {
+	_, err := q.db.ExecContext(ctx, importTimeEntry, arg.TaskID, arg.StartedAt, arg.Seconds)
+	return err
+}
+
+const insertProgProject = `-- name: InsertProgProject :one
+INSERT INTO programming_projects (path)
+VALUES (?)
+RETURNING id
+`
+
+func (q *Queries) InsertProgProject(ctx context.Context, path string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, insertProgProject, path)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const markRestoredEvents = `-- name: MarkRestoredEvents :exec
+UPDATE events SET field = 'restored' WHERE id > ? AND field = 'created'
+`
+
+func (q *Queries) MarkRestoredEvents(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, markRestoredEvents, id)
+	return err
+}
+
+const readAllAreaNotes = `-- name: ReadAllAreaNotes :many
+SELECT notes.id, notes.title, notes.path, areas.title as area_title, areas.id as parent_id
+FROM notes
+INNER JOIN bridge_notes ON bridge_notes.note_id = notes.id
+INNER JOIN areas ON areas.ID = bridge_notes.parent_area_id AND bridge_notes.parent_cat = 2
+`
+
+type ReadAllAreaNotesRow struct {
+	ID        int64  `json:"id"`
+	Title     string `json:"title"`
+	Path      string `json:"path"`
+	AreaTitle string `json:"area_title"`
+	ParentID  int64  `json:"parent_id"`
+}
+
+func (q *Queries) ReadAllAreaNotes(ctx context.Context) ([]ReadAllAreaNotesRow, error) {
+	rows, err := q.db.QueryContext(ctx, readAllAreaNotes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReadAllAreaNotesRow
+	for rows.Next() {
+		var i ReadAllAreaNotesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Path,
+			&i.AreaTitle,
+			&i.ParentID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readAllAreas = `-- name: ReadAllAreas :many
+SELECT 
+    areas.id, areas.title, areas.status, areas.archived,
+    notes.id, notes.title, notes.path
+FROM 
+    areas
+LEFT JOIN 
+    bridge_notes ON areas.id = bridge_notes.parent_area_id AND bridge_notes.parent_cat = 2
+LEFT JOIN 
+    notes ON bridge_notes.note_id = notes.id
+`
+
+type ReadAllAreasRow struct {
+	ID       int64          `json:"id"`
+	Title    string         `json:"title"`
+	Status   sql.NullString `json:"status"`
+	Archived bool           `json:"archived"`
+	ID_2     sql.NullInt64  `json:"id_2"`
+	Title_2  sql.NullString `json:"title_2"`
+	Path     sql.NullString `json:"path"`
+}
+
+func (q *Queries) ReadAllAreas(ctx context.Context) ([]ReadAllAreasRow, error) {
+	rows, err := q.db.QueryContext(ctx, readAllAreas)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReadAllAreasRow
+	for rows.Next() {
+		var i ReadAllAreasRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Status,
+			&i.Archived,
+			&i.ID_2,
+			&i.Title_2,
+			&i.Path,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readAllNotes = `-- name: ReadAllNotes :many
+SELECT notes.id, notes.title, notes.path,
+    IFNULL(
+        (SELECT GROUP_CONCAT(parent_title, ', ')
+         FROM (SELECT coalesce(tasks.title, areas.title, 'Unknown') AS parent_title
+               FROM bridge_notes
+               LEFT JOIN tasks ON tasks.ID = bridge_notes.parent_task_id AND bridge_notes.parent_cat = 1
+               LEFT JOIN areas ON areas.ID = bridge_notes.parent_area_id AND bridge_notes.parent_cat = 2
+               WHERE bridge_notes.note_id = notes.id
+               ORDER BY bridge_notes.id)),
+        ''
+    ) [area_or_task_title],
+    IFNULL(
+        (SELECT GROUP_CONCAT(parent_type, ', ')
+         FROM (SELECT case when bridge_notes.parent_cat = 1 then 'Task' else 'Area' end AS parent_type
+               FROM bridge_notes
+               WHERE bridge_notes.note_id = notes.id
+               ORDER BY bridge_notes.id)),
+        ''
+    ) [parent_type],
+    IFNULL(
+        (SELECT GROUP_CONCAT(name, ', ')
+         FROM (SELECT tags.name
+               FROM tags
+               JOIN note_tags ON note_tags.tag_id = tags.id
+               WHERE note_tags.note_id = notes.id
+               ORDER BY tags.name)),
+        ''
+    ) AS tags
+FROM notes
+WHERE EXISTS (SELECT 1 FROM bridge_notes WHERE bridge_notes.note_id = notes.id)
+ORDER BY notes.id
+`
+
+type ReadAllNotesRow struct {
+	ID              int64       `json:"id"`
+	Title           string      `json:"title"`
+	Path            string      `json:"path"`
+	AreaOrTaskTitle string      `json:"[area_or_task_title]"`
+	ParentType      string      `json:"[parent_type]"`
+	Tags            interface{} `json:"tags"`
+}
+
+func (q *Queries) ReadAllNotes(ctx context.Context) ([]ReadAllNotesRow, error) {
+	rows, err := q.db.QueryContext(ctx, readAllNotes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReadAllNotesRow
+	for rows.Next() {
+		var i ReadAllNotesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Path,
+			&i.AreaOrTaskTitle,
+			&i.ParentType,
+			&i.Tags,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readAllProgProjects = `-- name: ReadAllProgProjects :many
+SELECT path
+FROM programming_projects
+`
+
+func (q *Queries) ReadAllProgProjects(ctx context.Context) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, readAllProgProjects)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		items = append(items, path)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readAllTaskNotes = `-- name: ReadAllTaskNotes :many
+SELECT notes.id, notes.title, notes.path, tasks.title as task_title, tasks.id  as parent_id
+FROM notes
+INNER JOIN bridge_notes ON bridge_notes.note_id = notes.id
+INNER JOIN tasks ON tasks.ID = bridge_notes.parent_task_id AND bridge_notes.parent_cat = 1
+`
+
+type ReadAllTaskNotesRow struct {
+	ID        int64  `json:"id"`
 	Title     string `json:"title"`
 	Path      string `json:"path"`
 	TaskTitle string `json:"task_title"`
@@ -757,6 +1620,21 @@ func (q *Queries) ReadArea(ctx context.Context, id int64) (ReadAreaRow, error) {
 	return i, err
 }
 
+const readAreaIDByTitle = `-- name: ReadAreaIDByTitle :one
+SELECT id
+FROM areas
+WHERE title = ? COLLATE NOCASE
+ORDER BY id
+LIMIT 1
+`
+
+func (q *Queries) ReadAreaIDByTitle(ctx context.Context, title string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, readAreaIDByTitle, title)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
 const readAreaNote = `-- name: ReadAreaNote :many
 ;
 
@@ -803,6 +1681,17 @@ func (q *Queries) ReadAreaNote(ctx context.Context, parentTaskID sql.NullInt64)
 	return items, nil
 }
 
+const readAreaNoteTemplate = `-- name: ReadAreaNoteTemplate :one
+SELECT note_template FROM areas WHERE id = ?
+`
+
+func (q *Queries) ReadAreaNoteTemplate(ctx context.Context, id int64) (sql.NullString, error) {
+	row := q.db.QueryRowContext(ctx, readAreaNoteTemplate, id)
+	var note_template sql.NullString
+	err := row.Scan(&note_template)
+	return note_template, err
+}
+
 const readAreaNotes = `-- name: ReadAreaNotes :execrows
 SELECT notes.id, notes.title, notes.path, bridge_notes.parent_cat as type
 FROM notes
@@ -826,52 +1715,282 @@ func (q *Queries) ReadAreaNotes(ctx context.Context, ids []sql.NullInt64) (int64
 	if err != nil {
 		return 0, err
 	}
-	return result.RowsAffected()
+	return result.RowsAffected()
+}
+
+const readAreaTags = `-- name: ReadAreaTags :many
+SELECT tags.name
+FROM tags
+JOIN area_tags ON area_tags.tag_id = tags.id
+WHERE area_tags.area_id = ?
+ORDER BY tags.name
+`
+
+func (q *Queries) ReadAreaTags(ctx context.Context, areaID int64) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, readAreaTags, areaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readAreas = `-- name: ReadAreas :many
+;
+
+SELECT 
+    areas.id, areas.title, areas.status, areas.archived,
+    IFNULL(GROUP_CONCAT(notes.title, ', '), '') AS note_titles, pp.path,
+    IFNULL(
+        (SELECT GROUP_CONCAT(name, ', ')
+         FROM (SELECT tags.name
+               FROM tags
+               JOIN area_tags ON area_tags.tag_id = tags.id
+               WHERE area_tags.area_id = areas.id
+               ORDER BY tags.name)),
+        ''
+    ) AS tags
+FROM 
+    areas
+LEFT JOIN 
+    bridge_notes ON areas.id = bridge_notes.parent_area_id AND bridge_notes.parent_cat = 2
+LEFT JOIN 
+    notes ON bridge_notes.note_id = notes.id
+LEFT OUTER JOIN prog_project_links pjl ON pjl.parent_area_id = areas.id
+LEFT OUTER JOIN programming_projects pp ON pjl.project_id = pp.id
+GROUP BY 
+    areas.id
+`
+
+type ReadAreasRow struct {
+	ID         int64          `json:"id"`
+	Title      string         `json:"title"`
+	Status     sql.NullString `json:"status"`
+	Archived   bool           `json:"archived"`
+	NoteTitles interface{}    `json:"note_titles"`
+	Path       sql.NullString `json:"path"`
+	Tags       interface{}    `json:"tags"`
+}
+
+func (q *Queries) ReadAreas(ctx context.Context) ([]ReadAreasRow, error) {
+	rows, err := q.db.QueryContext(ctx, readAreas)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReadAreasRow
+	for rows.Next() {
+		var i ReadAreasRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Status,
+			&i.Archived,
+			&i.NoteTitles,
+			&i.Path,
+			&i.Tags,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readChangedAreaIDs = `-- name: ReadChangedAreaIDs :many
+SELECT DISTINCT entity_id
+FROM events
+WHERE entity = 'area' AND id > ?
+ORDER BY entity_id
+`
+
+func (q *Queries) ReadChangedAreaIDs(ctx context.Context, id int64) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, readChangedAreaIDs, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var entity_id int64
+		if err := rows.Scan(&entity_id); err != nil {
+			return nil, err
+		}
+		items = append(items, entity_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readChangedTasks = `-- name: ReadChangedTasks :many
+SELECT DISTINCT tasks.id, CAST(COALESCE(tasks.status = 'done', 0) AS BOOLEAN) AS done
+FROM events
+JOIN tasks ON events.entity = 'task' AND tasks.id = events.entity_id
+WHERE events.id > ?
+ORDER BY tasks.id
+`
+
+type ReadChangedTasksRow struct {
+	ID   int64 `json:"id"`
+	Done bool  `json:"done"`
+}
+
+func (q *Queries) ReadChangedTasks(ctx context.Context, id int64) ([]ReadChangedTasksRow, error) {
+	rows, err := q.db.QueryContext(ctx, readChangedTasks, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReadChangedTasksRow
+	for rows.Next() {
+		var i ReadChangedTasksRow
+		if err := rows.Scan(&i.ID, &i.Done); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readCheckboxNotes = `-- name: ReadCheckboxNotes :many
+SELECT note_checkboxes.task_id, notes.id AS note_id, notes.path
+FROM note_checkboxes
+JOIN notes ON notes.id = note_checkboxes.note_id
+WHERE note_checkboxes.task_id IN (/*SLICE:ids*/?)
+ORDER BY notes.id
+`
+
+type ReadCheckboxNotesRow struct {
+	TaskID int64  `json:"task_id"`
+	NoteID int64  `json:"note_id"`
+	Path   string `json:"path"`
+}
+
+func (q *Queries) ReadCheckboxNotes(ctx context.Context, ids []int64) ([]ReadCheckboxNotesRow, error) {
+	query := readCheckboxNotes
+	var queryParams []interface{}
+	if len(ids) > 0 {
+		for _, v := range ids {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:ids*/?", strings.Repeat(",?", len(ids))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReadCheckboxNotesRow
+	for rows.Next() {
+		var i ReadCheckboxNotesRow
+		if err := rows.Scan(&i.TaskID, &i.NoteID, &i.Path); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const readAreas = `-- name: ReadAreas :many
-;
-
-SELECT 
-    areas.id, areas.title, areas.status, areas.archived,
-    IFNULL(GROUP_CONCAT(notes.title, ', '), '') AS note_titles, pp.path
-FROM 
-    areas
-LEFT JOIN 
-    bridge_notes ON areas.id = bridge_notes.parent_area_id AND bridge_notes.parent_cat = 2
-LEFT JOIN 
-    notes ON bridge_notes.note_id = notes.id
-LEFT OUTER JOIN prog_project_links pjl ON pjl.parent_area_id = areas.id
-LEFT OUTER JOIN programming_projects pp ON pjl.project_id = pp.id
-GROUP BY 
-    areas.id
+const readEvents = `-- name: ReadEvents :many
+WITH filter(task_id, changed_at) AS (SELECT CAST(? AS INTEGER), CAST(? AS TEXT))
+SELECT events.id, events.entity, events.entity_id,
+       CAST(COALESCE(
+           CASE WHEN NOT EXISTS (SELECT 1 FROM events later
+                                 WHERE later.entity = events.entity AND later.entity_id = events.entity_id
+                                   AND later.field = 'created' AND later.id > events.id)
+                THEN COALESCE(tasks.title, areas.title, notes.title) END,
+           (SELECT deleted.old_value FROM events deleted
+            WHERE deleted.entity = events.entity AND deleted.entity_id = events.entity_id AND deleted.field = 'deleted'
+              AND deleted.id >= events.id
+            ORDER BY deleted.id LIMIT 1), '') AS TEXT) AS title,
+       events.field, events.old_value, events.new_value, events.changed_at, events.source
+FROM events
+JOIN filter
+LEFT JOIN tasks ON events.entity = 'task' AND tasks.id = events.entity_id
+LEFT JOIN areas ON events.entity = 'area' AND areas.id = events.entity_id
+LEFT JOIN notes ON events.entity = 'note' AND notes.id = events.entity_id
+WHERE (filter.task_id = 0 OR (events.entity = 'task' AND events.entity_id = filter.task_id
+       AND events.id >= (SELECT COALESCE(MAX(created.id), 0) FROM events created
+                         WHERE created.entity = 'task' AND created.entity_id = filter.task_id AND created.field = 'created')))
+  AND events.changed_at >= filter.changed_at
+ORDER BY events.changed_at, events.id
 `
 
-type ReadAreasRow struct {
-	ID         int64          `json:"id"`
-	Title      string         `json:"title"`
-	Status     sql.NullString `json:"status"`
-	Archived   bool           `json:"archived"`
-	NoteTitles interface{}    `json:"note_titles"`
-	Path       sql.NullString `json:"path"`
+type ReadEventsParams struct {
+	TaskID    int64  `json:"task_id"`
+	ChangedAt string `json:"changed_at"`
 }
 
-func (q *Queries) ReadAreas(ctx context.Context) ([]ReadAreasRow, error) {
-	rows, err := q.db.QueryContext(ctx, readAreas)
+type ReadEventsRow struct {
+	ID        int64          `json:"id"`
+	Entity    string         `json:"entity"`
+	EntityID  int64          `json:"entity_id"`
+	Title     string         `json:"title"`
+	Field     string         `json:"field"`
+	OldValue  sql.NullString `json:"old_value"`
+	NewValue  sql.NullString `json:"new_value"`
+	ChangedAt string         `json:"changed_at"`
+	Source    string         `json:"source"`
+}
+
+func (q *Queries) ReadEvents(ctx context.Context, arg ReadEventsParams) ([]ReadEventsRow, error) {
+	rows, err := q.db.QueryContext(ctx, readEvents, arg.TaskID, arg.ChangedAt)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ReadAreasRow
+	var items []ReadEventsRow
 	for rows.Next() {
-		var i ReadAreasRow
+		var i ReadEventsRow
 		if err := rows.Scan(
 			&i.ID,
+			&i.Entity,
+			&i.EntityID,
 			&i.Title,
-			&i.Status,
-			&i.Archived,
-			&i.NoteTitles,
-			&i.Path,
+			&i.Field,
+			&i.OldValue,
+			&i.NewValue,
+			&i.ChangedAt,
+			&i.Source,
 		); err != nil {
 			return nil, err
 		}
@@ -886,6 +2005,62 @@ func (q *Queries) ReadAreas(ctx context.Context) ([]ReadAreasRow, error) {
 	return items, nil
 }
 
+const readJournalTasks = `-- name: ReadJournalTasks :many
+WITH journal(day) AS (SELECT CAST(? AS TEXT))
+SELECT CAST('done' AS TEXT) AS section, tasks.id, tasks.title
+FROM tasks, journal
+WHERE tasks.status = 'done' AND date(COALESCE(tasks.completed_at, tasks.last_mod)) = journal.day
+UNION ALL
+SELECT 'doing', tasks.id, tasks.title
+FROM tasks
+WHERE tasks.status = 'doing' AND tasks.archived = 0
+UNION ALL
+SELECT 'due', tasks.id, tasks.title
+FROM tasks, journal
+WHERE tasks.due_date = journal.day AND IFNULL(tasks.status, '') != 'done' AND tasks.archived = 0
+ORDER BY section, id
+`
+
+type ReadJournalTasksRow struct {
+	Section string `json:"section"`
+	ID      int64  `json:"id"`
+	Title   string `json:"title"`
+}
+
+func (q *Queries) ReadJournalTasks(ctx context.Context, day string) ([]ReadJournalTasksRow, error) {
+	rows, err := q.db.QueryContext(ctx, readJournalTasks, day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReadJournalTasksRow
+	for rows.Next() {
+		var i ReadJournalTasksRow
+		if err := rows.Scan(&i.Section, &i.ID, &i.Title); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readLatestEventID = `-- name: ReadLatestEventID :one
+SELECT CAST(COALESCE(MAX(id), 0) AS INTEGER) AS latest_id FROM events
+`
+
+func (q *Queries) ReadLatestEventID(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, readLatestEventID)
+	var latest_id int64
+	err := row.Scan(&latest_id)
+	return latest_id, err
+}
+
 const readNote = `-- name: ReadNote :many
 SELECT notes.id, notes.title, bridge_notes.parent_cat as type
 FROM notes
@@ -927,6 +2102,8 @@ SELECT notes.id, notes.title, notes.path, bridge_notes.parent_cat as type
 FROM notes
 JOIN bridge_notes on notes.id = bridge_notes.note_id
 WHERE notes.id = ?
+ORDER BY bridge_notes.id
+LIMIT 1
 `
 
 type ReadNoteByIDRow struct {
@@ -952,6 +2129,7 @@ const readNoteByIDs = `-- name: ReadNoteByIDs :many
 SELECT notes.id, notes.title, notes.path, bridge_notes.parent_cat as type
 FROM notes
 JOIN bridge_notes on notes.id = bridge_notes.note_id
+    AND bridge_notes.id = (SELECT MIN(links.id) FROM bridge_notes links WHERE links.note_id = notes.id)
 WHERE notes.id in (/*SLICE:ids*/?)
 `
 
@@ -1000,6 +2178,223 @@ func (q *Queries) ReadNoteByIDs(ctx context.Context, ids []int64) ([]ReadNoteByI
 	return items, nil
 }
 
+const readNoteParent = `-- name: ReadNoteParent :one
+SELECT note_id, parent_cat, parent_task_id, parent_area_id
+FROM bridge_notes
+WHERE note_id = ?
+ORDER BY id
+LIMIT 1
+`
+
+func (q *Queries) ReadNoteParent(ctx context.Context, noteID int64) (BridgeNote, error) {
+	row := q.db.QueryRowContext(ctx, readNoteParent, noteID)
+	var i BridgeNote
+	err := row.Scan(
+		&i.NoteID,
+		&i.ParentCat,
+		&i.ParentTaskID,
+		&i.ParentAreaID,
+	)
+	return i, err
+}
+
+const readNoteStates = `-- name: ReadNoteStates :many
+SELECT notes.id, notes.path, bridge_notes.parent_cat,
+       tasks.id AS task_id, tasks.status AS task_status, tasks.priority AS task_priority, tasks.archived AS task_archived,
+       areas.id AS area_id, areas.title AS area_title, areas.status AS area_status, areas.archived AS area_archived
+FROM notes
+JOIN bridge_notes ON bridge_notes.note_id = notes.id
+    AND bridge_notes.id = (SELECT MIN(links.id) FROM bridge_notes links WHERE links.note_id = notes.id)
+LEFT JOIN tasks ON tasks.id = bridge_notes.parent_task_id
+LEFT JOIN areas ON areas.id = COALESCE(bridge_notes.parent_area_id, tasks.area_id)
+ORDER BY notes.id
+`
+
+type ReadNoteStatesRow struct {
+	ID           int64          `json:"id"`
+	Path         string         `json:"path"`
+	ParentCat    sql.NullInt64  `json:"parent_cat"`
+	TaskID       sql.NullInt64  `json:"task_id"`
+	TaskStatus   sql.NullString `json:"task_status"`
+	TaskPriority sql.NullString `json:"task_priority"`
+	TaskArchived sql.NullBool   `json:"task_archived"`
+	AreaID       sql.NullInt64  `json:"area_id"`
+	AreaTitle    sql.NullString `json:"area_title"`
+	AreaStatus   sql.NullString `json:"area_status"`
+	AreaArchived sql.NullBool   `json:"area_archived"`
+}
+
+func (q *Queries) ReadNoteStates(ctx context.Context) ([]ReadNoteStatesRow, error) {
+	rows, err := q.db.QueryContext(ctx, readNoteStates)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReadNoteStatesRow
+	for rows.Next() {
+		var i ReadNoteStatesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Path,
+			&i.ParentCat,
+			&i.TaskID,
+			&i.TaskStatus,
+			&i.TaskPriority,
+			&i.TaskArchived,
+			&i.AreaID,
+			&i.AreaTitle,
+			&i.AreaStatus,
+			&i.AreaArchived,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readNoteTags = `-- name: ReadNoteTags :many
+SELECT tags.name
+FROM tags
+JOIN note_tags ON note_tags.tag_id = tags.id
+WHERE note_tags.note_id = ?
+ORDER BY tags.name
+`
+
+func (q *Queries) ReadNoteTags(ctx context.Context, noteID int64) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, readNoteTags, noteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readParentNotes = `-- name: ReadParentNotes :many
+SELECT notes.id, notes.title, notes.path
+FROM notes
+JOIN bridge_notes ON bridge_notes.note_id = notes.id
+WHERE (bridge_notes.parent_cat = 1 AND bridge_notes.parent_task_id = ?1)
+   OR (bridge_notes.parent_cat = 2 AND bridge_notes.parent_area_id = ?2)
+ORDER BY notes.id
+`
+
+type ReadParentNotesParams struct {
+	TaskID sql.NullInt64 `json:"task_id"`
+	AreaID sql.NullInt64 `json:"area_id"`
+}
+
+func (q *Queries) ReadParentNotes(ctx context.Context, arg ReadParentNotesParams) ([]Note, error) {
+	rows, err := q.db.QueryContext(ctx, readParentNotes, arg.TaskID, arg.AreaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Note
+	for rows.Next() {
+		var i Note
+		if err := rows.Scan(&i.ID, &i.Title, &i.Path); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readRunningTimeEntry = `-- name: ReadRunningTimeEntry :one
+SELECT time_entries.id, time_entries.task_id, tasks.title, time_entries.started_at
+FROM time_entries
+JOIN tasks ON tasks.id = time_entries.task_id
+WHERE time_entries.seconds IS NULL
+ORDER BY time_entries.id
+LIMIT 1
+`
+
+type ReadRunningTimeEntryRow struct {
+	ID        int64  `json:"id"`
+	TaskID    int64  `json:"task_id"`
+	Title     string `json:"title"`
+	StartedAt string `json:"started_at"`
+}
+
+func (q *Queries) ReadRunningTimeEntry(ctx context.Context) (ReadRunningTimeEntryRow, error) {
+	row := q.db.QueryRowContext(ctx, readRunningTimeEntry)
+	var i ReadRunningTimeEntryRow
+	err := row.Scan(
+		&i.ID,
+		&i.TaskID,
+		&i.Title,
+		&i.StartedAt,
+	)
+	return i, err
+}
+
+const readSavedView = `-- name: ReadSavedView :one
+SELECT name, filter, created_at FROM saved_views
+WHERE name = ?
+`
+
+func (q *Queries) ReadSavedView(ctx context.Context, name string) (SavedView, error) {
+	row := q.db.QueryRowContext(ctx, readSavedView, name)
+	var i SavedView
+	err := row.Scan(&i.Name, &i.Filter, &i.CreatedAt)
+	return i, err
+}
+
+const readSavedViews = `-- name: ReadSavedViews :many
+SELECT name, filter, created_at FROM saved_views
+ORDER BY name
+`
+
+func (q *Queries) ReadSavedViews(ctx context.Context) ([]SavedView, error) {
+	rows, err := q.db.QueryContext(ctx, readSavedViews)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SavedView
+	for rows.Next() {
+		var i SavedView
+		if err := rows.Scan(&i.Name, &i.Filter, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const readTask = `-- name: ReadTask :one
 ;
 
@@ -1013,23 +2408,39 @@ SELECT
     datetime(tasks.last_mod) AS last_mod,
     ROUND((julianday('now') - julianday(tasks.created_at)), 2) AS age_in_days,
     tasks.due_date,
+    tasks.recurrence,
     IFNULL(
         (SELECT GROUP_CONCAT(title, ', ') 
          FROM (SELECT DISTINCT notes.title 
                FROM notes 
                JOIN bridge_notes ON notes.id = bridge_notes.note_id 
-               WHERE bridge_notes.parent_task_id = tasks.id)
+               WHERE bridge_notes.parent_task_id = tasks.id
+               ORDER BY notes.title)
         ), 
         ''
     ) AS note_title,
     programming_projects.path AS prog_proj,
-    areas.title AS parent_area
+    areas.title AS parent_area,
+    IFNULL(
+        (SELECT GROUP_CONCAT(blocker.id || ' ' || blocker.title, ', ')
+         FROM task_dependencies
+         JOIN tasks blocker ON blocker.id = task_dependencies.blocker_id
+         WHERE task_dependencies.blocked_id = tasks.id
+         AND IFNULL(blocker.status, '') != 'done'),
+        ''
+    ) AS blocked_by,
+    IFNULL(
+        (SELECT GROUP_CONCAT(name, ', ')
+         FROM (SELECT tags.name
+               FROM tags
+               JOIN task_tags ON task_tags.tag_id = tags.id
+               WHERE task_tags.task_id = tasks.id
+               ORDER BY tags.name)),
+        ''
+    ) AS tags,
+    (SELECT CAST(IFNULL(SUM(seconds), 0) AS INTEGER) FROM time_entries WHERE time_entries.task_id = tasks.id) AS time_logged
 FROM
     tasks
-LEFT OUTER JOIN
-    bridge_notes ON tasks.id = bridge_notes.parent_task_id AND bridge_notes.parent_cat = 1
-LEFT OUTER JOIN
-    notes ON notes.id = bridge_notes.note_id
 LEFT OUTER JOIN
     prog_project_links ON tasks.id = prog_project_links.parent_task_id
 LEFT OUTER JOIN
@@ -1049,28 +2460,164 @@ type ReadTaskRow struct {
 	CreatedAt  interface{}    `json:"created_at"`
 	LastMod    interface{}    `json:"last_mod"`
 	AgeInDays  float64        `json:"age_in_days"`
-	DueDate    sql.NullTime   `json:"due_date"`
+	DueDate    sql.NullString `json:"due_date"`
+	Recurrence sql.NullString `json:"recurrence"`
 	NoteTitle  interface{}    `json:"note_title"`
 	ProgProj   sql.NullString `json:"prog_proj"`
 	ParentArea sql.NullString `json:"parent_area"`
+	BlockedBy  interface{}    `json:"blocked_by"`
+	Tags       interface{}    `json:"tags"`
+	TimeLogged int64          `json:"time_logged"`
+}
+
+func (q *Queries) ReadTask(ctx context.Context, id int64) (ReadTaskRow, error) {
+	row := q.db.QueryRowContext(ctx, readTask, id)
+	var i ReadTaskRow
+	err := row.Scan(
+		&i.TaskID,
+		&i.TaskTitle,
+		&i.Priority,
+		&i.Status,
+		&i.Archived,
+		&i.CreatedAt,
+		&i.LastMod,
+		&i.AgeInDays,
+		&i.DueDate,
+		&i.Recurrence,
+		&i.NoteTitle,
+		&i.ProgProj,
+		&i.ParentArea,
+		&i.BlockedBy,
+		&i.Tags,
+		&i.TimeLogged,
+	)
+	return i, err
+}
+
+const readSubtaskTree = `-- name: ReadSubtaskTree :many
+WITH RECURSIVE subtasks(id, title, priority, status, due_date, parent_task_id, depth) AS (
+    SELECT t.id, t.title, t.priority, t.status, t.due_date, t.parent_task_id, 1
+    FROM tasks t
+    WHERE t.parent_task_id = ?
+    UNION ALL
+    SELECT t.id, t.title, t.priority, t.status, t.due_date, t.parent_task_id, subtasks.depth + 1
+    FROM tasks t
+    JOIN subtasks ON t.parent_task_id = subtasks.id
+)
+SELECT id, title, priority, status, due_date, CAST(parent_task_id AS INTEGER) AS parent_task_id, CAST(depth AS INTEGER) AS depth
+FROM subtasks
+ORDER BY depth, id
+`
+
+type ReadSubtaskTreeRow struct {
+	ID           int64          `json:"id"`
+	Title        string         `json:"title"`
+	Priority     sql.NullString `json:"priority"`
+	Status       sql.NullString `json:"status"`
+	DueDate      sql.NullString `json:"due_date"`
+	ParentTaskID int64          `json:"parent_task_id"`
+	Depth        int64          `json:"depth"`
+}
+
+func (q *Queries) ReadSubtaskTree(ctx context.Context, taskID sql.NullInt64) ([]ReadSubtaskTreeRow, error) {
+	rows, err := q.db.QueryContext(ctx, readSubtaskTree, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReadSubtaskTreeRow
+	for rows.Next() {
+		var i ReadSubtaskTreeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Priority,
+			&i.Status,
+			&i.DueDate,
+			&i.ParentTaskID,
+			&i.Depth,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readTaskBlockers = `-- name: ReadTaskBlockers :many
+SELECT tasks.id, tasks.title, tasks.status
+FROM task_dependencies
+JOIN tasks ON tasks.id = task_dependencies.blocker_id
+WHERE task_dependencies.blocked_id = ?
+ORDER BY tasks.id
+`
+
+type ReadTaskBlockersRow struct {
+	ID     int64          `json:"id"`
+	Title  string         `json:"title"`
+	Status sql.NullString `json:"status"`
+}
+
+func (q *Queries) ReadTaskBlockers(ctx context.Context, blockedID int64) ([]ReadTaskBlockersRow, error) {
+	rows, err := q.db.QueryContext(ctx, readTaskBlockers, blockedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReadTaskBlockersRow
+	for rows.Next() {
+		var i ReadTaskBlockersRow
+		if err := rows.Scan(&i.ID, &i.Title, &i.Status); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *Queries) ReadTask(ctx context.Context, id int64) (ReadTaskRow, error) {
-	row := q.db.QueryRowContext(ctx, readTask, id)
-	var i ReadTaskRow
+const readTaskForRecurrence = `-- name: ReadTaskForRecurrence :one
+SELECT id, title, priority, status, due_date, area_id, recurrence, parent_task_id, CAST(created_at AS TEXT) AS created_at
+FROM tasks
+WHERE id = ?
+`
+
+type ReadTaskForRecurrenceRow struct {
+	ID           int64          `json:"id"`
+	Title        string         `json:"title"`
+	Priority     sql.NullString `json:"priority"`
+	Status       sql.NullString `json:"status"`
+	DueDate      sql.NullString `json:"due_date"`
+	AreaID       sql.NullInt64  `json:"area_id"`
+	Recurrence   sql.NullString `json:"recurrence"`
+	ParentTaskID sql.NullInt64  `json:"parent_task_id"`
+	CreatedAt    string         `json:"created_at"`
+}
+
+func (q *Queries) ReadTaskForRecurrence(ctx context.Context, id int64) (ReadTaskForRecurrenceRow, error) {
+	row := q.db.QueryRowContext(ctx, readTaskForRecurrence, id)
+	var i ReadTaskForRecurrenceRow
 	err := row.Scan(
-		&i.TaskID,
-		&i.TaskTitle,
+		&i.ID,
+		&i.Title,
 		&i.Priority,
 		&i.Status,
-		&i.Archived,
-		&i.CreatedAt,
-		&i.LastMod,
-		&i.AgeInDays,
 		&i.DueDate,
-		&i.NoteTitle,
-		&i.ProgProj,
-		&i.ParentArea,
+		&i.AreaID,
+		&i.Recurrence,
+		&i.ParentTaskID,
+		&i.CreatedAt,
 	)
 	return i, err
 }
@@ -1144,6 +2691,37 @@ func (q *Queries) ReadTaskNotes(ctx context.Context, ids []sql.NullInt64) (int64
 	return result.RowsAffected()
 }
 
+const readTaskTags = `-- name: ReadTaskTags :many
+SELECT tags.name
+FROM tags
+JOIN task_tags ON task_tags.tag_id = tags.id
+WHERE task_tags.task_id = ?
+ORDER BY tags.name
+`
+
+func (q *Queries) ReadTaskTags(ctx context.Context, taskID int64) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, readTaskTags, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const readTasks = `-- name: ReadTasks :many
 SELECT 
     tasks.id, 
@@ -1152,23 +2730,41 @@ SELECT
     tasks.status, 
     tasks.archived,
     ROUND((julianday('now') - julianday(tasks.created_at)), 2) AS age_in_days,
+    tasks.due_date,
     IFNULL(
         (SELECT GROUP_CONCAT(title, ', ') 
          FROM (SELECT DISTINCT notes.title 
                FROM notes 
                JOIN bridge_notes ON notes.id = bridge_notes.note_id 
-               WHERE bridge_notes.parent_task_id = tasks.id)
+               WHERE bridge_notes.parent_task_id = tasks.id
+               ORDER BY notes.title)
         ), 
         ''
     ) AS note_titles,
     pp.path, 
-    area.title AS parent_area
+    area.title AS parent_area,
+    (SELECT COUNT(*) FROM tasks sub WHERE sub.parent_task_id = tasks.id) AS subtask_count,
+    (SELECT COUNT(*) FROM tasks sub WHERE sub.parent_task_id = tasks.id AND sub.status = 'done') AS subtasks_done,
+    IFNULL(
+        (SELECT GROUP_CONCAT(blocker.id, ', ')
+         FROM task_dependencies
+         JOIN tasks blocker ON blocker.id = task_dependencies.blocker_id
+         WHERE task_dependencies.blocked_id = tasks.id
+         AND IFNULL(blocker.status, '') != 'done'),
+        ''
+    ) AS blocked_by,
+    IFNULL(
+        (SELECT GROUP_CONCAT(name, ', ')
+         FROM (SELECT tags.name
+               FROM tags
+               JOIN task_tags ON task_tags.tag_id = tags.id
+               WHERE task_tags.task_id = tasks.id
+               ORDER BY tags.name)),
+        ''
+    ) AS tags,
+    (SELECT CAST(IFNULL(SUM(seconds), 0) AS INTEGER) FROM time_entries WHERE time_entries.task_id = tasks.id) AS time_logged
 FROM 
     tasks
-LEFT OUTER JOIN 
-    bridge_notes ON tasks.id = bridge_notes.parent_task_id AND bridge_notes.parent_cat = 1
-LEFT OUTER JOIN 
-    notes ON bridge_notes.note_id = notes.id
 LEFT OUTER JOIN 
     prog_project_links pjl ON pjl.parent_task_id = tasks.id
 LEFT OUTER JOIN 
@@ -1180,15 +2776,21 @@ GROUP BY
 `
 
 type ReadTasksRow struct {
-	ID         int64          `json:"id"`
-	Title      string         `json:"title"`
-	Priority   sql.NullString `json:"priority"`
-	Status     sql.NullString `json:"status"`
-	Archived   bool           `json:"archived"`
-	AgeInDays  float64        `json:"age_in_days"`
-	NoteTitles interface{}    `json:"note_titles"`
-	Path       sql.NullString `json:"path"`
-	ParentArea sql.NullString `json:"parent_area"`
+	ID           int64          `json:"id"`
+	Title        string         `json:"title"`
+	Priority     sql.NullString `json:"priority"`
+	Status       sql.NullString `json:"status"`
+	Archived     bool           `json:"archived"`
+	AgeInDays    float64        `json:"age_in_days"`
+	DueDate      sql.NullString `json:"due_date"`
+	NoteTitles   interface{}    `json:"note_titles"`
+	Path         sql.NullString `json:"path"`
+	ParentArea   sql.NullString `json:"parent_area"`
+	SubtaskCount int64          `json:"subtask_count"`
+	SubtasksDone int64          `json:"subtasks_done"`
+	BlockedBy    interface{}    `json:"blocked_by"`
+	Tags         interface{}    `json:"tags"`
+	TimeLogged   int64          `json:"time_logged"`
 }
 
 func (q *Queries) ReadTasks(ctx context.Context) ([]ReadTasksRow, error) {
@@ -1207,9 +2809,142 @@ func (q *Queries) ReadTasks(ctx context.Context) ([]ReadTasksRow, error) {
 			&i.Status,
 			&i.Archived,
 			&i.AgeInDays,
+			&i.DueDate,
 			&i.NoteTitles,
 			&i.Path,
 			&i.ParentArea,
+			&i.SubtaskCount,
+			&i.SubtasksDone,
+			&i.BlockedBy,
+			&i.Tags,
+			&i.TimeLogged,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readTimeByTask = `-- name: ReadTimeByTask :many
+SELECT tasks.id, tasks.title, areas.title AS area_title, programming_projects.path AS repo,
+       CAST(SUM(time_entries.seconds) AS INTEGER) AS seconds
+FROM time_entries
+JOIN tasks ON tasks.id = time_entries.task_id
+LEFT JOIN areas ON areas.id = tasks.area_id
+LEFT JOIN prog_project_links ON prog_project_links.parent_task_id = tasks.id
+LEFT JOIN programming_projects ON programming_projects.id = prog_project_links.project_id
+WHERE time_entries.seconds IS NOT NULL AND time_entries.started_at >= ?
+GROUP BY tasks.id, programming_projects.id
+ORDER BY tasks.id, repo
+`
+
+type ReadTimeByTaskRow struct {
+	ID        int64          `json:"id"`
+	Title     string         `json:"title"`
+	AreaTitle sql.NullString `json:"area_title"`
+	Repo      sql.NullString `json:"repo"`
+	Seconds   int64          `json:"seconds"`
+}
+
+func (q *Queries) ReadTimeByTask(ctx context.Context, startedAt string) ([]ReadTimeByTaskRow, error) {
+	rows, err := q.db.QueryContext(ctx, readTimeByTask, startedAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReadTimeByTaskRow
+	for rows.Next() {
+		var i ReadTimeByTaskRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.AreaTitle,
+			&i.Repo,
+			&i.Seconds,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readUndoStatements = `-- name: ReadUndoStatements :many
+SELECT statement
+FROM undo_log
+WHERE step_id >= ?
+ORDER BY id DESC
+`
+
+func (q *Queries) ReadUndoStatements(ctx context.Context, stepID int64) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, readUndoStatements, stepID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var statement string
+		if err := rows.Scan(&statement); err != nil {
+			return nil, err
+		}
+		items = append(items, statement)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const readUndoSteps = `-- name: ReadUndoSteps :many
+SELECT undo_steps.id, undo_steps.description, undo_steps.source, undo_steps.created_at,
+       CAST(COUNT(undo_log.id) AS INTEGER) AS changes
+FROM undo_steps
+LEFT JOIN undo_log ON undo_log.step_id = undo_steps.id
+GROUP BY undo_steps.id
+ORDER BY undo_steps.id DESC
+LIMIT ?
+`
+
+type ReadUndoStepsRow struct {
+	ID          int64  `json:"id"`
+	Description string `json:"description"`
+	Source      string `json:"source"`
+	CreatedAt   string `json:"created_at"`
+	Changes     int64  `json:"changes"`
+}
+
+func (q *Queries) ReadUndoSteps(ctx context.Context, limit int64) ([]ReadUndoStepsRow, error) {
+	rows, err := q.db.QueryContext(ctx, readUndoSteps, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ReadUndoStepsRow
+	for rows.Next() {
+		var i ReadUndoStepsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Description,
+			&i.Source,
+			&i.CreatedAt,
+			&i.Changes,
 		); err != nil {
 			return nil, err
 		}
@@ -1224,6 +2959,114 @@ func (q *Queries) ReadTasks(ctx context.Context) ([]ReadTasksRow, error) {
 	return items, nil
 }
 
+const readUnsharedNoteIDs = `-- name: ReadUnsharedNoteIDs :many
+SELECT note_id
+FROM bridge_notes
+GROUP BY note_id
+HAVING SUM((parent_cat = 1 AND parent_task_id IN (/*SLICE:task_ids*/?))
+        OR (parent_cat = 2 AND parent_area_id IN (/*SLICE:area_ids*/?))) = COUNT(*)
+ORDER BY note_id
+`
+
+type ReadUnsharedNoteIDsParams struct {
+	TaskIds []sql.NullInt64 `json:"task_ids"`
+	AreaIds []sql.NullInt64 `json:"area_ids"`
+}
+
+func (q *Queries) ReadUnsharedNoteIDs(ctx context.Context, arg ReadUnsharedNoteIDsParams) ([]int64, error) {
+	query := readUnsharedNoteIDs
+	var queryParams []interface{}
+	if len(arg.TaskIds) > 0 {
+		for _, v := range arg.TaskIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:task_ids*/?", strings.Repeat(",?", len(arg.TaskIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:task_ids*/?", "NULL", 1)
+	}
+	if len(arg.AreaIds) > 0 {
+		for _, v := range arg.AreaIds {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:area_ids*/?", strings.Repeat(",?", len(arg.AreaIds))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:area_ids*/?", "NULL", 1)
+	}
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var note_id int64
+		if err := rows.Scan(&note_id); err != nil {
+			return nil, err
+		}
+		items = append(items, note_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const removeNoteTag = `-- name: RemoveNoteTag :execrows
+DELETE FROM note_tags
+WHERE note_id = ? AND tag_id = (SELECT id FROM tags WHERE name = ?)
+`
+
+type RemoveNoteTagParams struct {
+	NoteID int64  `json:"note_id"`
+	Name   string `json:"name"`
+}
+
+func (q *Queries) RemoveNoteTag(ctx context.Context, arg RemoveNoteTagParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeNoteTag, arg.NoteID, arg.Name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const removeTaskTag = `-- name: RemoveTaskTag :execrows
+DELETE FROM task_tags
+WHERE task_id = ? AND tag_id = (SELECT id FROM tags WHERE name = ?)
+`
+
+type RemoveTaskTagParams struct {
+	TaskID int64  `json:"task_id"`
+	Name   string `json:"name"`
+}
+
+func (q *Queries) RemoveTaskTag(ctx context.Context, arg RemoveTaskTagParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, removeTaskTag, arg.TaskID, arg.Name)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const stopTimeEntry = `-- name: StopTimeEntry :execrows
+UPDATE time_entries SET seconds = ? WHERE id = ? AND seconds IS NULL
+`
+
+type StopTimeEntryParams struct {
+	Seconds sql.NullInt64 `json:"seconds"`
+	ID      int64         `json:"id"`
+}
+
+func (q *Queries) StopTimeEntry(ctx context.Context, arg StopTimeEntryParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, stopTimeEntry, arg.Seconds, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateAreaArchived = `-- name: UpdateAreaArchived :execresult
 UPDATE areas SET archived = ?  where id = ?
 returning id, title, status, archived, created_at, last_mod
@@ -1238,6 +3081,23 @@ func (q *Queries) UpdateAreaArchived(ctx context.Context, arg UpdateAreaArchived
 	return q.db.ExecContext(ctx, updateAreaArchived, arg.Archived, arg.ID)
 }
 
+const updateAreaNoteTemplate = `-- name: UpdateAreaNoteTemplate :execrows
+UPDATE areas SET note_template = ? WHERE id = ?
+`
+
+type UpdateAreaNoteTemplateParams struct {
+	NoteTemplate sql.NullString `json:"note_template"`
+	ID           int64          `json:"id"`
+}
+
+func (q *Queries) UpdateAreaNoteTemplate(ctx context.Context, arg UpdateAreaNoteTemplateParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateAreaNoteTemplate, arg.NoteTemplate, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateAreaStatus = `-- name: UpdateAreaStatus :execresult
 UPDATE areas SET status = ?  where id = ?
 returning id, title, status, archived, created_at, last_mod
@@ -1270,9 +3130,43 @@ func (q *Queries) UpdateAreaTitle(ctx context.Context, arg UpdateAreaTitleParams
 	return result.LastInsertId()
 }
 
+const updateNotePath = `-- name: UpdateNotePath :execrows
+UPDATE notes SET path = ? WHERE id = ?
+`
+
+type UpdateNotePathParams struct {
+	Path string `json:"path"`
+	ID   int64  `json:"id"`
+}
+
+func (q *Queries) UpdateNotePath(ctx context.Context, arg UpdateNotePathParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateNotePath, arg.Path, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateNoteTitle = `-- name: UpdateNoteTitle :execrows
+UPDATE notes SET title = ? WHERE id = ?
+`
+
+type UpdateNoteTitleParams struct {
+	Title string `json:"title"`
+	ID    int64  `json:"id"`
+}
+
+func (q *Queries) UpdateNoteTitle(ctx context.Context, arg UpdateNoteTitleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, updateNoteTitle, arg.Title, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateTaskArchived = `-- name: UpdateTaskArchived :execresult
 UPDATE tasks SET archived = ? WHERE id = ?
-returning id, title, priority, status, archived, created_at, last_mod, due_date, area_id
+returning id, title, priority, status, archived, created_at, last_mod, due_date, area_id, recurrence, parent_task_id
 `
 
 type UpdateTaskArchivedParams struct {
@@ -1286,7 +3180,7 @@ func (q *Queries) UpdateTaskArchived(ctx context.Context, arg UpdateTaskArchived
 
 const updateTaskArea = `-- name: UpdateTaskArea :execresult
 UPDATE tasks set area_id = ? where id = ?
-returning id, title, priority, status, archived, created_at, last_mod, due_date, area_id
+returning id, title, priority, status, archived, created_at, last_mod, due_date, area_id, recurrence, parent_task_id
 `
 
 type UpdateTaskAreaParams struct {
@@ -1298,9 +3192,23 @@ func (q *Queries) UpdateTaskArea(ctx context.Context, arg UpdateTaskAreaParams)
 	return q.db.ExecContext(ctx, updateTaskArea, arg.AreaID, arg.ID)
 }
 
+const updateTaskDueDate = `-- name: UpdateTaskDueDate :execresult
+UPDATE tasks SET due_date = ? WHERE id = ?
+returning id, title, priority, status, archived, created_at, last_mod, due_date, area_id, recurrence, parent_task_id
+`
+
+type UpdateTaskDueDateParams struct {
+	DueDate sql.NullString `json:"due_date"`
+	ID      int64          `json:"id"`
+}
+
+func (q *Queries) UpdateTaskDueDate(ctx context.Context, arg UpdateTaskDueDateParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, updateTaskDueDate, arg.DueDate, arg.ID)
+}
+
 const updateTaskPriority = `-- name: UpdateTaskPriority :execresult
 UPDATE tasks SET priority = ?  where id = ?
-returning id, title, priority, status, archived, created_at, last_mod, due_date, area_id
+returning id, title, priority, status, archived, created_at, last_mod, due_date, area_id, recurrence, parent_task_id
 `
 
 type UpdateTaskPriorityParams struct {
@@ -1312,9 +3220,23 @@ func (q *Queries) UpdateTaskPriority(ctx context.Context, arg UpdateTaskPriority
 	return q.db.ExecContext(ctx, updateTaskPriority, arg.Priority, arg.ID)
 }
 
+const updateTaskRecurrence = `-- name: UpdateTaskRecurrence :execresult
+UPDATE tasks SET recurrence = ? WHERE id = ?
+returning id, title, priority, status, archived, created_at, last_mod, due_date, area_id, recurrence, parent_task_id
+`
+
+type UpdateTaskRecurrenceParams struct {
+	Recurrence sql.NullString `json:"recurrence"`
+	ID         int64          `json:"id"`
+}
+
+func (q *Queries) UpdateTaskRecurrence(ctx context.Context, arg UpdateTaskRecurrenceParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, updateTaskRecurrence, arg.Recurrence, arg.ID)
+}
+
 const updateTaskStatus = `-- name: UpdateTaskStatus :execresult
 UPDATE tasks SET status = ?  where id = ?
-returning id, title, priority, status, archived, created_at, last_mod, due_date, area_id
+returning id, title, priority, status, archived, created_at, last_mod, due_date, area_id, recurrence, parent_task_id
 `
 
 type UpdateTaskStatusParams struct {
@@ -1328,7 +3250,7 @@ func (q *Queries) UpdateTaskStatus(ctx context.Context, arg UpdateTaskStatusPara
 
 const updateTaskTitle = `-- name: UpdateTaskTitle :execresult
 UPDATE tasks set title = ? where id = ?
-returning id, title, priority, status, archived, created_at, last_mod, due_date, area_id
+returning id, title, priority, status, archived, created_at, last_mod, due_date, area_id, recurrence, parent_task_id
 `
 
 type UpdateTaskTitleParams struct {
@@ -1339,3 +3261,47 @@ type UpdateTaskTitleParams struct {
 func (q *Queries) UpdateTaskTitle(ctx context.Context, arg UpdateTaskTitleParams) (sql.Result, error) {
 	return q.db.ExecContext(ctx, updateTaskTitle, arg.Title, arg.ID)
 }
+
+const upsertNoteCheckbox = `-- name: UpsertNoteCheckbox :exec
+INSERT INTO note_checkboxes (task_id, note_id)
+VALUES (?, ?)
+ON CONFLICT(task_id) DO UPDATE SET note_id = excluded.note_id
+`
+
+type UpsertNoteCheckboxParams struct {
+	TaskID int64 `json:"task_id"`
+	NoteID int64 `json:"note_id"`
+}
+
+func (q *Queries) UpsertNoteCheckbox(ctx context.Context, arg UpsertNoteCheckboxParams) error {
+	_, err := q.db.ExecContext(ctx, upsertNoteCheckbox, arg.TaskID, arg.NoteID)
+	return err
+}
+
+const upsertSavedView = `-- name: UpsertSavedView :exec
+INSERT INTO saved_views (name, filter) VALUES (?, ?)
+ON CONFLICT (name) DO UPDATE SET filter = excluded.filter
+`
+
+type UpsertSavedViewParams struct {
+	Name   string `json:"name"`
+	Filter string `json:"filter"`
+}
+
+func (q *Queries) UpsertSavedView(ctx context.Context, arg UpsertSavedViewParams) error {
+	_, err := q.db.ExecContext(ctx, upsertSavedView, arg.Name, arg.Filter)
+	return err
+}
+
+const upsertTag = `-- name: UpsertTag :one
+INSERT INTO tags (name) VALUES (?)
+ON CONFLICT (name) DO UPDATE SET name = tags.name
+RETURNING id
+`
+
+func (q *Queries) UpsertTag(ctx context.Context, name string) (int64, error) {
+	row := q.db.QueryRowContext(ctx, upsertTag, name)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
@@ -30,6 +30,7 @@ const (
 	areaColumnKeyCreatedAt = "created_at"
 	areaColumnKeyNotes     = "notes"
 	areaColumnKeyPath      = "path"
+	areaColumnKeyTags      = "tags"
 )
 
 // This is the task table "screen" model
@@ -141,6 +142,7 @@ func (m *AreasModel) loadRowsFromDatabase() ([]table.Row, error) {
 			areaColumnKeyArchived: fmt.Sprintf("%t", area.Archived),
 			areaColumnKeyNotes:    area.NoteTitles,
 			areaColumnKeyPath:     formattedPath,
+			areaColumnKeyTags:     area.Tags,
 		})
 		rows = append(rows, row)
 	}
@@ -239,6 +241,7 @@ func (m *AreasModel) updateStatus(newStatus data.StatusType) tea.Cmd {
 		if err != nil {
 			log.Fatalf("AreasModel - UpdateStatus: Error updating Area status: %v", err)
 		}
+		selectedIDs = append(selectedIDs, taskID)
 	} else if len(selectedIDs) >= 1 {
 		for _, ID := range selectedIDs {
 			_, err := queries.UpdateAreaStatus(ctx, sqlc.UpdateAreaStatusParams{Status: sql.NullString{String: string(newStatus), Valid: true}, ID: ID})
@@ -247,6 +250,9 @@ func (m *AreasModel) updateStatus(newStatus data.StatusType) tea.Cmd {
 			}
 		}
 	}
+	if _, err := data.SyncAreaNoteStates(ctx, conn, selectedIDs...); err != nil {
+		log.Fatalf("AreasModel - UpdateStatus: Error updating the frontmatter of the area notes: %v", err)
+	}
 
 	rows, err := m.loadRowsFromDatabase()
 	if err != nil {
@@ -290,11 +296,6 @@ func (m *AreasModel) addNote() tea.Cmd {
 			}
 		}
 
-		newNote := sqlc.CreateNoteParams{
-			Title: form.Title,
-			Path:  form.Path,
-		}
-
 		ctx := context.Background()
 		conn, _, err := db.ConnectDB()
 		if err != nil {
@@ -304,12 +305,21 @@ func (m *AreasModel) addNote() tea.Cmd {
 		queries := sqlc.New(conn)
 		defer conn.Close()
 
+		notePath, err := form.NotePath(ctx, queries, data.AreaNoteType, int64(areaID))
+		if err != nil {
+			log.Fatalf("Error generating the note: %v", err)
+		}
+
 		noteID, err := queries.GetNoteID(ctx)
 		if err != nil && err != sql.ErrNoRows {
 			log.Fatalf("Error getting note ID: %v", err)
 		}
 
-		err = queries.CreateNote(ctx, newNote)
+		err = queries.CreateNote(ctx, sqlc.CreateNoteParams{
+			ID:    noteID,
+			Title: form.Title,
+			Path:  notePath,
+		})
 		if err != nil {
 			log.Fatalf("Error creating note: %v", err)
 		}
@@ -325,6 +335,9 @@ func (m *AreasModel) addNote() tea.Cmd {
 		if noteID != id {
 			log.Fatal("AddNote - ProjectsModel: ", "Note ID and Bridge Note ID do not match")
 		}
+		if _, err := data.SyncNoteStates(ctx, conn, noteID); err != nil {
+			slog.Error("AddNote - ProjectsModel: Error writing the state into the note", "error", err)
+		}
 
 		// Requery the database and update the table model
 		rows, err := m.loadRowsFromDatabase()
@@ -464,16 +477,29 @@ func (m *AreasModel) addTaskToArea() tea.Cmd {
 		}
 		defer conn.Close()
 		queries := sqlc.New(conn)
-		_, err = queries.CreateTask(ctx, sqlc.CreateTaskParams{
-			Title:    form.TaskTitle,
-			Priority: sql.NullString{String: string(form.Priority), Valid: true},
-			Status:   sql.NullString{String: string(form.Status), Valid: true},
-			Archived: form.Archived,
-			AreaID:   sql.NullInt64{Int64: areaID, Valid: true},
+		dueDate, err := data.ToNullDueDate(form.DueDate)
+		if err != nil {
+			log.Fatalf("Error parsing due date: %v", err)
+		}
+		recurrence, err := data.ToNullRecurrence(form.Recurrence)
+		if err != nil {
+			log.Fatalf("Error parsing recurrence: %v", err)
+		}
+		taskID, err := queries.CreateTask(ctx, sqlc.CreateTaskParams{
+			Title:      form.TaskTitle,
+			Priority:   sql.NullString{String: string(form.Priority), Valid: true},
+			Status:     sql.NullString{String: string(form.Status), Valid: true},
+			Archived:   form.Archived,
+			AreaID:     sql.NullInt64{Int64: areaID, Valid: true},
+			DueDate:    dueDate,
+			Recurrence: recurrence,
 		})
 		if err != nil {
 			log.Fatalf("Error creating new task: %v", err)
 		}
+		if err := data.AddTaskTags(ctx, queries, taskID, data.ParseTags(form.Tags)); err != nil {
+			log.Fatalf("Error tagging task: %v", err)
+		}
 
 		// Requery the database and update the table model
 		rows, err := m.loadRowsFromDatabase()
@@ -605,6 +631,7 @@ func (m AreasModel) View() string {
 	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Primary)).Render("-Press D to delete row(s) after selecting them.") + "\n")
 	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("-Press ctrl+n to switch to the Notes View.") + "\n")
 	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Primary)).Render("-Press ctrl+t to switch to the Tasks View.") + "\n")
+	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("-Press u to undo the last change, or e.g. 3u to undo the last three.") + "\n")
 	selectedIDs := []string{}
 
 	for _, row := range m.tableModel.SelectedRows() {
@@ -646,6 +673,7 @@ func AreaViewModel() AreasModel {
 		table.NewFlexColumn(areaColumnKeyArchived, "Archived", 1),
 		table.NewFlexColumn(areaColumnKeyPath, "Repo", 1),
 		table.NewFlexColumn(areaColumnKeyNotes, "Notes", 3),
+		table.NewFlexColumn(areaColumnKeyTags, "Tags", 2),
 	}
 
 	model := AreasModel{archiveFilterEnabled: true}
@@ -752,6 +780,7 @@ func (m *AreasModel) archiveArea() tea.Cmd {
 		if err != nil {
 			slog.Error("AreasModel - archiveArea: Error updating area archived status: %v", "error", err)
 		}
+		selectedIDs[taskID] = currentArchiveState
 
 	} else if len(selectedIDs) >= 1 {
 		for ID, archiveStatus := range selectedIDs {
@@ -764,6 +793,11 @@ func (m *AreasModel) archiveArea() tea.Cmd {
 			}
 		}
 	}
+	for ID := range selectedIDs {
+		if _, err := data.SyncAreaNoteStates(ctx, conn, ID); err != nil {
+			slog.Error("AreasModel - archiveArea: Error updating the frontmatter of the area notes", "error", err)
+		}
+	}
 
 	rows, err := m.loadRowsFromDatabase()
 	if err != nil {
@@ -10,6 +10,7 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"time"
 
 	data "github.com/akthe-at/go_task/data"
 	db "github.com/akthe-at/go_task/db"
@@ -31,10 +32,14 @@ const (
 	columnKeyArchived   = "archived"
 	columnKeyCreatedAt  = "created_at"
 	columnKeyTaskAge    = "age_in_days"
+	columnKeyTime       = "time_logged"
 	columnKeyDueDate    = "due_date"
+	columnKeyProgress   = "progress"
+	columnKeyBlockedBy  = "blocked_by"
 	columnKeyNotes      = "notes"
 	columnKeyPath       = "path"
 	columnKeyArea       = "parent_area"
+	columnKeyTags       = "tags"
 	minWidth            = 120
 	minHeight           = 10
 	fixedVerticalMargin = 80
@@ -63,6 +68,8 @@ var customBorder = table.Border{
 // This is the task table "screen" model
 type TaskModel struct {
 	deleteMessage        string
+	statusMessage        string
+	filterQuery          string
 	tableModel           table.Model
 	totalWidth           int
 	totalHeight          int
@@ -71,6 +78,9 @@ type TaskModel struct {
 	selectedRowID        int
 	archiveFilterEnabled bool
 	rowFilter            bool
+	// timer is the running timer shown in the footer, timerRunning is false when no timer runs
+	timer        data.TimeEntry
+	timerRunning bool
 }
 
 // Init initializes the model (can use this to run commands upon model initialization)
@@ -124,6 +134,13 @@ func (m *TaskModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.recalculateTable()
 	case SwitchToPreviousViewMsg:
 		m.recalculateTable()
+	case TimerTickMsg:
+		if msg.err != nil {
+			slog.Error("TaskModel - TimerTickMsg: Error reading the running timer", "error", msg.err)
+		} else {
+			m.timer, m.timerRunning = msg.timer, msg.running
+		}
+		m.updateFooter()
 	}
 
 	return m, tea.Batch(cmds...)
@@ -142,9 +159,26 @@ func (m *TaskModel) refreshTableData() {
 	}
 
 	m.tableModel = m.tableModel.WithRows(rows)
+	m.loadTimer()
 	m.updateFooter()
 }
 
+// loadTimer reads the running timer for the footer
+func (m *TaskModel) loadTimer() {
+	ctx := context.Background()
+	conn, _, err := db.ConnectDB()
+	if err != nil {
+		slog.Error("TaskModel - loadTimer: Error connecting to database", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	m.timer, m.timerRunning, err = data.RunningTimer(ctx, sqlc.New(conn))
+	if err != nil {
+		slog.Error("TaskModel - loadTimer: Error reading the running timer", "error", err)
+	}
+}
+
 func (m TaskModel) calculateWidth() int {
 	return m.totalWidth - m.horizontalMargin
 }
@@ -167,23 +201,44 @@ func (m *TaskModel) loadRowsFromDatabase() ([]table.Row, error) {
 		return nil, fmt.Errorf("error reading tasks: %w", err)
 	}
 
+	var matchingIDs map[int64]bool
+	if m.filterQuery != "" {
+		matchingIDs, err = data.FilterTaskIDs(ctx, conn, m.filterQuery, data.CurrentFilterEnv())
+		if err != nil {
+			return nil, fmt.Errorf("error filtering tasks: %w", err)
+		}
+	}
+
 	var rows []table.Row
 	for _, task := range tasks {
+		if matchingIDs != nil && !matchingIDs[task.ID] {
+			continue
+		}
 		formattedPath := path.Base(task.Path.String)
 		if formattedPath == "." {
 			formattedPath = ""
 		}
+		blockedBy, _ := task.BlockedBy.(string)
 		row := table.NewRow(table.RowData{
-			columnKeyID:       fmt.Sprintf("%d", task.ID),
-			columnKeyTask:     task.Title,
-			columnKeyPriority: task.Priority.String,
-			columnKeyStatus:   task.Status.String,
-			columnKeyArchived: fmt.Sprintf("%t", task.Archived),
-			columnKeyTaskAge:  fmt.Sprintf("%v Days", task.AgeInDays),
-			columnKeyNotes:    task.NoteTitles,
-			columnKeyPath:     formattedPath,
-			columnKeyArea:     task.ParentArea.String,
+			columnKeyID:        fmt.Sprintf("%d", task.ID),
+			columnKeyTask:      task.Title,
+			columnKeyProgress:  data.FormatSubtaskProgress(task.SubtasksDone, task.SubtaskCount),
+			columnKeyPriority:  task.Priority.String,
+			columnKeyStatus:    task.Status.String,
+			columnKeyArchived:  fmt.Sprintf("%t", task.Archived),
+			columnKeyDueDate:   task.DueDate.String,
+			columnKeyTaskAge:   fmt.Sprintf("%v Days", task.AgeInDays),
+			columnKeyTime:      data.FormatTimeSpent(time.Duration(task.TimeLogged) * time.Second),
+			columnKeyNotes:     task.NoteTitles,
+			columnKeyPath:      formattedPath,
+			columnKeyArea:      task.ParentArea.String,
+			columnKeyBlockedBy: blockedBy,
+			columnKeyTags:      task.Tags,
 		})
+		// Blocked tasks are dimmed so the ones that can be worked on stand out
+		if blockedBy != "" {
+			row = row.WithStyle(lipgloss.NewStyle().Faint(true))
+		}
 		rows = append(rows, row)
 
 	}
@@ -229,15 +284,19 @@ func (m *TaskModel) filterRows() tea.Cmd {
 
 		var rows []table.Row
 		row := table.NewRow(table.RowData{
-			columnKeyID:       fmt.Sprintf("%d", result.TaskID),
-			columnKeyTask:     result.TaskTitle,
-			columnKeyPriority: result.Priority.String,
-			columnKeyStatus:   result.Status.String,
-			columnKeyArchived: fmt.Sprintf("%t", result.Archived),
-			columnKeyTaskAge:  fmt.Sprintf("%v Days", result.AgeInDays),
-			columnKeyNotes:    fmt.Sprintf("%v", result.NoteTitle),
-			columnKeyPath:     result.ProgProj.String,
-			columnKeyArea:     result.ParentArea.String,
+			columnKeyID:        fmt.Sprintf("%d", result.TaskID),
+			columnKeyTask:      result.TaskTitle,
+			columnKeyPriority:  result.Priority.String,
+			columnKeyStatus:    result.Status.String,
+			columnKeyArchived:  fmt.Sprintf("%t", result.Archived),
+			columnKeyDueDate:   result.DueDate.String,
+			columnKeyTaskAge:   fmt.Sprintf("%v Days", result.AgeInDays),
+			columnKeyTime:      data.FormatTimeSpent(time.Duration(result.TimeLogged) * time.Second),
+			columnKeyNotes:     fmt.Sprintf("%v", result.NoteTitle),
+			columnKeyPath:      result.ProgProj.String,
+			columnKeyArea:      result.ParentArea.String,
+			columnKeyBlockedBy: result.BlockedBy,
+			columnKeyTags:      result.Tags,
 		})
 		rows = append(rows, row)
 
@@ -281,11 +340,6 @@ func (m *TaskModel) addNote() tea.Cmd {
 			return nil
 		}
 
-		newNote := sqlc.CreateNoteParams{
-			Title: form.Title,
-			Path:  form.Path,
-		}
-
 		ctx := context.Background()
 		conn, _, err := db.ConnectDB()
 		if err != nil {
@@ -295,12 +349,21 @@ func (m *TaskModel) addNote() tea.Cmd {
 		queries := sqlc.New(conn)
 		defer conn.Close()
 
+		notePath, err := form.NotePath(ctx, queries, data.TaskNoteType, int64(taskID))
+		if err != nil {
+			log.Fatalf("Error generating the note: %v", err)
+		}
+
 		noteID, err := queries.GetNoteID(ctx)
 		if err != nil && err != sql.ErrNoRows {
 			log.Fatalf("Error getting note ID: %v", err)
 		}
 
-		err = queries.CreateNote(ctx, newNote)
+		err = queries.CreateNote(ctx, sqlc.CreateNoteParams{
+			ID:    noteID,
+			Title: form.Title,
+			Path:  notePath,
+		})
 		if err != nil {
 			log.Fatalf("Error creating note: %v", err)
 		}
@@ -316,6 +379,9 @@ func (m *TaskModel) addNote() tea.Cmd {
 		if noteID != id {
 			log.Fatal("AddNote - TaskModel: ", "Note ID and Bridge Note ID do not match")
 		}
+		if _, err := data.SyncNoteStates(ctx, conn, noteID); err != nil {
+			slog.Error("AddNote - TaskModel: Error writing the state into the note", "error", err)
+		}
 
 		// Requery the database and update the table model
 		rows, err := m.loadRowsFromDatabase()
@@ -334,6 +400,32 @@ func (m *TaskModel) addNote() tea.Cmd {
 	}
 }
 
+// editFilter asks for a filter expression and reloads the table with the tasks that match it
+func (m *TaskModel) editFilter() tea.Cmd {
+	form := &formInput.FilterForm{Expression: m.filterQuery}
+	theme := tui.GetSelectedTheme()
+
+	err := form.NewFilterForm(*tui.ThemeGoTask(theme))
+	if err != nil {
+		log.Printf("Error running filter form: %v", err)
+		return nil
+	}
+
+	previousQuery := m.filterQuery
+	m.filterQuery = form.Filter()
+	rows, err := m.loadRowsFromDatabase()
+	if err != nil {
+		m.statusMessage = fmt.Sprintf("Invalid filter: %v", err)
+		m.filterQuery = previousQuery
+		return nil
+	}
+	m.statusMessage = ""
+	m.tableModel = m.tableModel.WithRows(rows)
+	m.updateFooter()
+
+	return nil
+}
+
 func (m *TaskModel) addTask() tea.Cmd {
 	form := &formInput.NewTaskForm{}
 	theme := tui.GetSelectedTheme()
@@ -357,18 +449,32 @@ func (m *TaskModel) addTask() tea.Cmd {
 			log.Fatalf("addTask - TaskModel: Error getting task ID: %v", err)
 		}
 
+		dueDate, err := data.ToNullDueDate(form.DueDate)
+		if err != nil {
+			log.Fatalf("Error parsing due date: %v", err)
+		}
+		recurrence, err := data.ToNullRecurrence(form.Recurrence)
+		if err != nil {
+			log.Fatalf("Error parsing recurrence: %v", err)
+		}
+
 		newTask := sqlc.CreateTaskParams{
-			ID:       newTaskID,
-			Title:    form.TaskTitle,
-			Priority: sql.NullString{String: string(form.Priority), Valid: true},
-			Status:   sql.NullString{String: string(form.Status), Valid: true},
-			Archived: form.Archived,
+			ID:         newTaskID,
+			Title:      form.TaskTitle,
+			Priority:   sql.NullString{String: string(form.Priority), Valid: true},
+			Status:     sql.NullString{String: string(form.Status), Valid: true},
+			Archived:   form.Archived,
+			DueDate:    dueDate,
+			Recurrence: recurrence,
 		}
 
 		result, err := queries.CreateTask(ctx, newTask)
 		if err != nil {
 			log.Fatalf("Error creating task: %v", err)
 		}
+		if err := data.AddTaskTags(ctx, queries, result, data.ParseTags(form.Tags)); err != nil {
+			log.Fatalf("Error tagging task: %v", err)
+		}
 
 		if form.AreaAssignment == "yes" {
 			areaID, err := strconv.ParseInt(form.Area, 10, 64)
@@ -477,6 +583,9 @@ func (m *TaskModel) togglePriorityStatus() tea.Cmd {
 			ID:       taskID,
 		},
 		)
+		if _, err := data.SyncTaskNoteStates(ctx, conn, taskID); err != nil {
+			slog.Error("TaskModel - togglePriorityStatus: Error updating the frontmatter of the task notes", "error", err)
+		}
 	}
 
 	rows, err := m.loadRowsFromDatabase()
@@ -534,6 +643,7 @@ func (m *TaskModel) archiveTask() tea.Cmd {
 		if err != nil {
 			slog.Error("TaskModel - archiveTask: Error updating task archived status: %v", "error", err)
 		}
+		selectedIDs[taskID] = currentArchiveState
 
 	} else if len(selectedIDs) >= 1 {
 		for ID, archiveStatus := range selectedIDs {
@@ -546,6 +656,11 @@ func (m *TaskModel) archiveTask() tea.Cmd {
 			}
 		}
 	}
+	for ID := range selectedIDs {
+		if _, err := data.SyncTaskNoteStates(ctx, conn, ID); err != nil {
+			slog.Error("TaskModel - archiveTask: Error updating the frontmatter of the task notes", "error", err)
+		}
+	}
 
 	rows, err := m.loadRowsFromDatabase()
 	if err != nil {
@@ -559,6 +674,65 @@ func (m *TaskModel) archiveTask() tea.Cmd {
 	return nil
 }
 
+// highlightedTaskID is the ID of the highlighted row, ok is false when the table has no rows
+func (m TaskModel) highlightedTaskID() (int64, bool) {
+	highlightedInfo, ok := m.tableModel.HighlightedRow().Data[columnKeyID].(string)
+	if !ok {
+		return 0, false
+	}
+	taskID, err := strconv.ParseInt(highlightedInfo, 10, 64)
+	if err != nil {
+		slog.Error("TaskModel - highlightedTaskID: Error converting ID to int64", "error", err)
+		return 0, false
+	}
+	return taskID, true
+}
+
+// toggleTimer stops the timer when it runs on the highlighted task, and starts it there otherwise
+func (m *TaskModel) toggleTimer() tea.Cmd {
+	taskID, ok := m.highlightedTaskID()
+	if !ok {
+		return nil
+	}
+
+	ctx := context.Background()
+	conn, _, err := db.ConnectDB()
+	if err != nil {
+		slog.Error("TaskModel - toggleTimer: Error connecting to database", "error", err)
+		return nil
+	}
+	defer conn.Close()
+
+	var messages []string
+	running, ok, err := data.RunningTimer(ctx, sqlc.New(conn))
+	if err != nil {
+		slog.Error("TaskModel - toggleTimer: Error reading the running timer", "error", err)
+		return nil
+	}
+	if ok && running.TaskID == taskID {
+		stopped, _, err := data.StopTimer(ctx, conn, time.Now())
+		if err != nil {
+			slog.Error("TaskModel - toggleTimer: Error stopping the timer", "error", err)
+			return nil
+		}
+		messages = append(messages, fmt.Sprintf("Stopped the timer on task %d, logged %s", stopped.TaskID, data.FormatTimeSpent(stopped.Duration)))
+	} else {
+		started, stopped, err := data.StartTimer(ctx, conn, taskID, time.Now())
+		if err != nil {
+			slog.Error("TaskModel - toggleTimer: Error starting the timer", "error", err)
+			return nil
+		}
+		for _, entry := range stopped {
+			messages = append(messages, fmt.Sprintf("Stopped the timer on task %d, logged %s", entry.TaskID, data.FormatTimeSpent(entry.Duration)))
+		}
+		messages = append(messages, fmt.Sprintf("Started the timer on task %d", started.TaskID))
+	}
+	m.statusMessage = strings.Join(messages, "\n")
+
+	m.refreshTableData()
+	return nil
+}
+
 func (m *TaskModel) updateStatus(newStatus data.StatusType) tea.Cmd {
 	var selectedIDs []int64
 	ctx := context.Background()
@@ -577,8 +751,7 @@ func (m *TaskModel) updateStatus(newStatus data.StatusType) tea.Cmd {
 	}
 	defer conn.Close()
 
-	queries := sqlc.New(conn)
-
+	var changes []data.StatusChange
 	if len(selectedIDs) < 1 {
 		highlightedInfo := m.tableModel.HighlightedRow().Data[columnKeyID].(string)
 		taskID, err := strconv.ParseInt(highlightedInfo, 10, 64)
@@ -587,21 +760,25 @@ func (m *TaskModel) updateStatus(newStatus data.StatusType) tea.Cmd {
 			return nil
 		}
 
-		_, err = queries.UpdateTaskStatus(ctx, sqlc.UpdateTaskStatusParams{Status: sql.NullString{String: string(newStatus), Valid: true}, ID: taskID})
+		change, err := data.SetTaskStatus(ctx, conn, taskID, newStatus, time.Now())
 		if err != nil {
 			slog.Error("TaskModel - UpdateStatus: Error updating task status: %v", "error", err)
 			return nil
 		}
+		changes = append(changes, change)
 	} else if len(selectedIDs) >= 1 {
 		for _, ID := range selectedIDs {
-			_, err := queries.UpdateTaskStatus(ctx, sqlc.UpdateTaskStatusParams{Status: sql.NullString{String: string(newStatus), Valid: true}, ID: ID})
+			change, err := data.SetTaskStatus(ctx, conn, ID, newStatus, time.Now())
 			if err != nil {
 				slog.Error("TaskModel - UpdateStatus: Error updating task status: %v", "error", err)
 				return nil
 			}
+			changes = append(changes, change)
 		}
 	}
 
+	m.statusMessage = describeStatusChanges(changes)
+
 	rows, err := m.loadRowsFromDatabase()
 	if err != nil {
 		log.Printf("Error loading rows from database: %s", err)
@@ -637,15 +814,11 @@ func (m *TaskModel) deleteTask() tea.Cmd {
 
 	if len(selectedIDs) <= 1 {
 		queries := sqlc.New(conn)
-		// query the notes associated with the task
-		taskNoteIDs := []int64{}
-		taskNotes, err := queries.ReadTaskNote(ctx, sql.NullInt64{Int64: taskID, Valid: true})
+		// query the notes associated with the task, notes shared with other tasks or areas are kept
+		taskNoteIDs, err := data.UnsharedNoteIDs(ctx, queries, []int64{taskID}, nil)
 		if err != nil {
 			log.Fatalf("Error reading notes: %s", err)
 		}
-		for _, note := range taskNotes {
-			taskNoteIDs = append(taskNoteIDs, note.ID)
-		}
 		// delete those notes
 		if highlightedNote != "" {
 			for _, taskNoteID := range taskNoteIDs {
@@ -665,16 +838,12 @@ func (m *TaskModel) deleteTask() tea.Cmd {
 
 	} else if len(selectedIDs) > 1 {
 		queries := sqlc.New(conn)
-		// query the notes associated with the task
-		taskNoteIDs := []int64{}
-		taskNotes, err := queries.ReadTaskNote(ctx, sql.NullInt64{Int64: taskID, Valid: true})
+		// query the notes associated with the task, notes shared with other tasks or areas are kept
+		taskNoteIDs, err := data.UnsharedNoteIDs(ctx, queries, []int64{taskID}, nil)
 		if err != nil {
 			log.Printf("Error reading notes: %s", err)
 			return nil
 		}
-		for _, note := range taskNotes {
-			taskNoteIDs = append(taskNoteIDs, note.ID)
-		}
 		// delete those notes
 		if highlightedNote != "" {
 			for _, taskNoteID := range taskNoteIDs {
@@ -727,6 +896,10 @@ func (m TaskModel) View() string {
 	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("-Press 'a' to toggle archive status of a highlighted or selected tasks.") + "\n")
 	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Primary)).Render("-Press 'ctrl+n' to switch to the Notes View.") + "\n")
 	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("-Press 'ctrl+p' to switch to the Areas View.") + "\n")
+	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Primary)).Render("-Press '/' to filter tasks, e.g. status:doing priority>=high due<7d") + "\n")
+	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("-Press 'S' to start or stop the timer on the highlighted task.") + "\n")
+	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Primary)).Render("-Press 'f' to focus on the highlighted task with a pomodoro timer.") + "\n")
+	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("-Press 'u' to undo the last change, or e.g. '3u' to undo the last three.") + "\n")
 
 	selectedIDs := []string{}
 
@@ -749,6 +922,22 @@ func (m TaskModel) View() string {
 				Render(m.deleteMessage) + "\n")
 	}
 
+	if m.filterQuery != "" {
+		body.WriteString(
+			lipgloss.NewStyle().
+				Foreground(lipgloss.Color(
+					theme.Primary)).
+				Render(fmt.Sprintf("Filter: %s", m.filterQuery)) + "\n")
+	}
+
+	if m.statusMessage != "" {
+		body.WriteString(
+			lipgloss.NewStyle().
+				Foreground(lipgloss.Color(
+					theme.Warning)).
+				Render(m.statusMessage) + "\n")
+	}
+
 	body.WriteString(m.tableModel.View())
 	body.WriteString("\n")
 
@@ -765,13 +954,18 @@ func TaskViewModel() TaskModel {
 				Foreground(lipgloss.Color(theme.Secondary)).
 				Align(lipgloss.Center)),
 		table.NewFlexColumn(columnKeyTask, "Task", 3),
+		table.NewColumn(columnKeyProgress, "Subtasks", 10),
 		table.NewColumn(columnKeyPriority, "Priority", 10),
 		table.NewColumn(columnKeyStatus, "Status", 10),
 		table.NewColumn(columnKeyArchived, "Archived", 10),
+		table.NewColumn(columnKeyDueDate, "Due", 12),
 		table.NewColumn(columnKeyTaskAge, "Task Age", 15),
+		table.NewColumn(columnKeyTime, "Time", 8),
 		table.NewFlexColumn(columnKeyNotes, "Notes", 3),
 		table.NewFlexColumn(columnKeyPath, "Repo", 1),
 		table.NewFlexColumn(columnKeyArea, "Area", 3),
+		table.NewFlexColumn(columnKeyBlockedBy, "Blocked By", 2),
+		table.NewFlexColumn(columnKeyTags, "Tags", 2),
 	}
 
 	model := TaskModel{archiveFilterEnabled: true, rowFilter: false}
@@ -808,6 +1002,7 @@ func TaskViewModel() TaskModel {
 			Data:  "<Missing Data>",
 		})
 
+	model.loadTimer()
 	model.updateFooter()
 
 	return model
@@ -826,6 +1021,13 @@ func (m *TaskModel) updateFooter() {
 		m.tableModel.MaxPages(),
 		rowID,
 	)
+	if m.timerRunning {
+		footerText += fmt.Sprintf(" - Timer: %s on task %d %s",
+			data.FormatTimeSpent(m.timer.Elapsed(time.Now())),
+			m.timer.TaskID,
+			m.timer.TaskTitle,
+		)
+	}
 
 	m.tableModel = m.tableModel.WithStaticFooter(footerText)
 }
@@ -837,6 +1039,38 @@ func RunModel(m *TaskModel) {
 	}
 }
 
+// describeStatusChanges summarizes recurring instances, cascaded subtasks, and open subtask warnings
+func describeStatusChanges(changes []data.StatusChange) string {
+	var messages []string
+	for _, change := range changes {
+		for _, nextID := range change.NextTaskIDs {
+			messages = append(messages, fmt.Sprintf("Created the next recurring instance: %d", nextID))
+		}
+		if len(change.CascadedIDs) > 0 {
+			messages = append(messages, fmt.Sprintf("Also marked these subtasks done: %s", joinIDs(change.CascadedIDs)))
+		}
+		for _, path := range change.UpdatedNotes {
+			messages = append(messages, fmt.Sprintf("Updated the checkbox in %s", path))
+		}
+		if len(change.OpenSubtasks) > 0 {
+			var openIDs []int64
+			for _, subtask := range change.OpenSubtasks {
+				openIDs = append(openIDs, subtask.ID)
+			}
+			messages = append(messages, fmt.Sprintf("Warning, these subtasks are still open: %s", joinIDs(openIDs)))
+		}
+	}
+	return strings.Join(messages, "\n")
+}
+
+func joinIDs(ids []int64) string {
+	var formatted []string
+	for _, id := range ids {
+		formatted = append(formatted, strconv.FormatInt(id, 10))
+	}
+	return strings.Join(formatted, ", ")
+}
+
 func extractNoteTitles(notes []sqlc.Note) string {
 	var titles []string
 	for _, note := range notes {
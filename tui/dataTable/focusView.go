@@ -0,0 +1,195 @@
+package datatable
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/akthe-at/go_task/config"
+	data "github.com/akthe-at/go_task/data"
+	db "github.com/akthe-at/go_task/db"
+	"github.com/akthe-at/go_task/sqlc"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// FocusTickMsg counts the pomodoro of the focus view down, id tells the ticks of one focus session apart from an earlier one
+type FocusTickMsg struct {
+	id   int
+	time time.Time
+}
+
+// This is the focus "screen" model, it shows one task with a pomodoro counting down
+type FocusModel struct {
+	taskID        int64
+	title         string
+	status        string
+	timeLogged    time.Duration
+	pomodoro      data.Pomodoro
+	statusMessage string
+	totalWidth    int
+	totalHeight   int
+	tickID        int
+}
+
+// FocusViewModel starts a pomodoro on a task with the intervals from the config file
+func FocusViewModel(taskID int64, width, height, tickID int) FocusModel {
+	work, brk := config.GetPomodoroIntervals(data.DefaultPomodoroWork, data.DefaultPomodoroBreak)
+	model := FocusModel{
+		taskID:      taskID,
+		pomodoro:    data.NewPomodoro(work, brk, time.Now()),
+		totalWidth:  width,
+		totalHeight: height,
+		tickID:      tickID,
+	}
+	model.loadTask()
+	return model
+}
+
+func (m *FocusModel) Init() tea.Cmd { return m.tick() }
+
+func (m *FocusModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case " ":
+			m.pomodoro.TogglePause(time.Now())
+		case "n":
+			m.pomodoro.Skip(time.Now())
+			m.statusMessage = fmt.Sprintf("Skipped ahead to the %s", m.pomodoro.Phase)
+		case "d":
+			m.updateStatus(data.StatusDoing)
+		case "D":
+			m.updateStatus(data.StatusDone)
+		}
+	case tea.WindowSizeMsg:
+		m.totalWidth = msg.Width
+		m.totalHeight = msg.Height
+	case FocusTickMsg:
+		if msg.id != m.tickID {
+			return m, nil
+		}
+		m.advance(msg.time)
+		return m, m.tick()
+	}
+	return m, nil
+}
+
+func (m FocusModel) tick() tea.Cmd {
+	id := m.tickID
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return FocusTickMsg{id: id, time: t}
+	})
+}
+
+// advance moves the pomodoro on and logs a work interval that ran out against the task
+func (m *FocusModel) advance(now time.Time) {
+	startedAt, phase := m.pomodoro.StartedAt, m.pomodoro.Phase
+	finished := m.pomodoro.Tick(now)
+	if phase == data.PomodoroBreak && m.pomodoro.Phase == data.PomodoroWork {
+		m.statusMessage = "The break is over, back to work"
+	}
+	if !finished {
+		return
+	}
+
+	ctx := context.Background()
+	conn, _, err := db.ConnectDB()
+	if err != nil {
+		slog.Error("FocusModel - advance: Error connecting to database", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	entry, err := data.LogPomodoro(ctx, sqlc.New(conn), m.taskID, startedAt, m.pomodoro.Work)
+	if err != nil {
+		slog.Error("FocusModel - advance: Error logging the pomodoro", "error", err)
+		m.statusMessage = fmt.Sprintf("The pomodoro could not be logged on task %d", m.taskID)
+		return
+	}
+	m.statusMessage = fmt.Sprintf("Logged %s on task %d, take a %s break",
+		data.FormatTimeSpent(entry.Duration), m.taskID, data.FormatTimeSpent(m.pomodoro.Break))
+	m.loadTask()
+}
+
+// loadTask reads the title, status, and logged time of the task
+func (m *FocusModel) loadTask() {
+	ctx := context.Background()
+	conn, _, err := db.ConnectDB()
+	if err != nil {
+		slog.Error("FocusModel - loadTask: Error connecting to database", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	task, err := sqlc.New(conn).ReadTask(ctx, m.taskID)
+	if err != nil {
+		slog.Error("FocusModel - loadTask: Error reading the task", "error", err)
+		return
+	}
+	m.title = task.TaskTitle
+	m.status = task.Status.String
+	m.timeLogged = time.Duration(task.TimeLogged) * time.Second
+}
+
+func (m *FocusModel) updateStatus(newStatus data.StatusType) {
+	ctx := context.Background()
+	conn, _, err := db.ConnectDB()
+	if err != nil {
+		slog.Error("FocusModel - updateStatus: Error connecting to database", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	change, err := data.SetTaskStatus(ctx, conn, m.taskID, newStatus, time.Now())
+	if err != nil {
+		slog.Error("FocusModel - updateStatus: Error updating task status", "error", err)
+		m.statusMessage = err.Error()
+		return
+	}
+	m.statusMessage = describeStatusChanges([]data.StatusChange{change})
+	m.loadTask()
+}
+
+// View This is where we define the UI for the focus view
+func (m FocusModel) View() string {
+	now := time.Now()
+	phaseColor := theme.Warning
+	if m.pomodoro.Phase == data.PomodoroBreak {
+		phaseColor = theme.Success
+	}
+	countdown := formatCountdown(m.pomodoro.Remaining(now))
+	if m.pomodoro.Paused {
+		countdown += " (paused)"
+	}
+
+	body := strings.Builder{}
+	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Secondary)).Render(fmt.Sprintf("Focusing on task %d", m.taskID)) + "\n\n")
+	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Accent)).Bold(true).Render(m.title) + "\n")
+	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Primary)).Render(
+		fmt.Sprintf("Status: %s - Time logged: %s", m.status, data.FormatTimeSpent(m.timeLogged))) + "\n\n")
+	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(phaseColor)).Bold(true).Render(strings.ToUpper(string(m.pomodoro.Phase))) + "\n")
+	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(phaseColor)).Bold(true).Padding(0, 2).
+		Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color(phaseColor)).Render(countdown) + "\n")
+	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Primary)).Render(
+		fmt.Sprintf("Pomodoros finished: %d", m.pomodoro.Finished)) + "\n\n")
+	if m.statusMessage != "" {
+		body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render(m.statusMessage) + "\n\n")
+	}
+	body.WriteString(lipgloss.NewStyle().Faint(true).Render(
+		"'space' pause/resume - 'n' skip to the next interval - 'd'/'D' mark Doing or Done - 'esc' back to the tasks"))
+
+	content := lipgloss.NewStyle().Align(lipgloss.Center).Render(body.String())
+	return lipgloss.Place(m.totalWidth, m.totalHeight, lipgloss.Center, lipgloss.Center, content)
+}
+
+// formatCountdown renders the time left of an interval as mm:ss, rounding up so it only shows 00:00 once it ran out
+func formatCountdown(d time.Duration) string {
+	seconds := int64((d + time.Second - 1) / time.Second)
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+	}
+	return fmt.Sprintf("%02d:%02d", seconds/60, seconds%60)
+}
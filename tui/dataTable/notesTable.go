@@ -3,12 +3,13 @@ package datatable
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"os"
 	"strconv"
 	"strings"
 
-	"github.com/akthe-at/go_task/config"
 	data "github.com/akthe-at/go_task/data"
 	db "github.com/akthe-at/go_task/db"
 	"github.com/akthe-at/go_task/sqlc"
@@ -26,6 +27,7 @@ const (
 	NoteColumnPath       = "path"
 	NoteColumnLink       = "task_title"
 	NoteColumnParentType = "parent_type"
+	NoteColumnTags       = "tags"
 )
 
 type NotesModel struct {
@@ -117,6 +119,7 @@ func (m *NotesModel) View() string {
 	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Primary)).Render("-Press 'backspace' to delete row(s) after selecting or highlighting them.") + "\n")
 	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("-Press 'ctrl+t' to switch to the Tasks View.") + "\n")
 	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Primary)).Render("-Press 'ctrl+p' to switch to the Areas View.") + "\n")
+	body.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render("-Press 'u' to undo the last change, or e.g. '3u' to undo the last three.") + "\n")
 	selectedIDs := []int64{}
 
 	for _, row := range m.tableModel.SelectedRows() {
@@ -152,8 +155,9 @@ func NotesView() NotesModel {
 				Align(lipgloss.Center)),
 		table.NewColumn(NoteColumnKey, "Title", 15),
 		table.NewFlexColumn(NoteColumnPath, "Path", 2),
-		table.NewFlexColumn(NoteColumnLink, "Task", 1),
+		table.NewFlexColumn(NoteColumnLink, "Parents", 1),
 		table.NewFlexColumn(NoteColumnParentType, "Note Type", 1),
+		table.NewFlexColumn(NoteColumnTags, "Tags", 2),
 	}
 
 	model := NotesModel{}
@@ -174,9 +178,10 @@ func NotesView() NotesModel {
 		newRow := table.NewRow(table.RowData{
 			NoteColumnKeyID:      note.ID,
 			NoteColumnKey:        note.Title,
-			NoteColumnPath:       note.Path,
+			NoteColumnPath:       notePathCell(note.Path),
 			NoteColumnLink:       note.AreaOrTaskTitle,
 			NoteColumnParentType: note.ParentType,
+			NoteColumnTags:       note.Tags,
 		})
 		filteredRows = append(filteredRows, newRow)
 	}
@@ -212,6 +217,18 @@ func NotesView() NotesModel {
 	return model
 }
 
+// notePathCell shows the path of a note, flagging notes whose file was deleted or moved out of reach
+func notePathCell(path string) interface{} {
+	expanded, err := utils.ExpandPath(path)
+	if err != nil {
+		return path
+	}
+	if _, err := os.Stat(expanded); errors.Is(err, os.ErrNotExist) {
+		return table.NewStyledCell("(deleted) "+path, lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)))
+	}
+	return path
+}
+
 func (m *NotesModel) refreshTableData() {
 	rows, err := m.loadRowsFromDatabase()
 	if err != nil {
@@ -265,6 +282,11 @@ func (m *NotesModel) addNote() tea.Cmd {
 			queries := sqlc.New(conn)
 			defer conn.Close()
 
+			notePath, err := form.NotePath(ctx, queries, form.Type, int64(form.ParentID))
+			if err != nil {
+				log.Fatalf("Error generating the note: %v", err)
+			}
+
 			noteID, err := queries.GetNoteID(ctx)
 			if err != nil && err != sql.ErrNoRows {
 				log.Fatalf("Error getting note ID: %v", err)
@@ -273,7 +295,7 @@ func (m *NotesModel) addNote() tea.Cmd {
 			err = queries.CreateNote(ctx, sqlc.CreateNoteParams{
 				ID:    noteID,
 				Title: form.Title,
-				Path:  form.Path,
+				Path:  notePath,
 			},
 			)
 			if err != nil {
@@ -341,9 +363,10 @@ func (m *NotesModel) loadRowsFromDatabase() ([]table.Row, error) {
 		newRow := table.NewRow(table.RowData{
 			NoteColumnKeyID:      note.ID,
 			NoteColumnKey:        note.Title,
-			NoteColumnPath:       note.Path,
+			NoteColumnPath:       notePathCell(note.Path),
 			NoteColumnLink:       note.AreaOrTaskTitle,
 			NoteColumnParentType: note.ParentType,
+			NoteColumnTags:       note.Tags,
 		})
 		filteredRows = append(filteredRows, newRow)
 	}
@@ -405,7 +428,6 @@ func (m *NotesModel) deleteNote() tea.Cmd {
 }
 
 func (m *NotesModel) openNote() tea.Cmd {
-	editor := config.GetEditorConfig()
 	ctx := context.Background()
 	selectedIDs := []int64{}
 
@@ -429,7 +451,7 @@ func (m *NotesModel) openNote() tea.Cmd {
 		}
 		notePath, err := utils.ExpandPath(note.Path)
 
-		utils.OpenNoteInEditor(editor, notePath)
+		utils.OpenNotes(notePath)
 		if err != nil {
 			log.Fatalf("There was an error expanding the path: %v", err)
 		}
@@ -446,7 +468,7 @@ func (m *NotesModel) openNote() tea.Cmd {
 			}
 			notePaths[i] = notePath
 		}
-		utils.OpenNoteInEditor(editor, notePaths...)
+		utils.OpenNotes(notePaths...)
 
 	} else if len(selectedIDs) == 0 {
 
@@ -456,7 +478,7 @@ func (m *NotesModel) openNote() tea.Cmd {
 		}
 		notePath, err := utils.ExpandPath(note.Path)
 
-		utils.OpenNoteInEditor(editor, notePath)
+		utils.OpenNotes(notePath)
 		if err != nil {
 			log.Fatalf("There was an error expanding the path: %v", err)
 		}
@@ -1,7 +1,18 @@
 package datatable
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/akthe-at/go_task/data"
+	db "github.com/akthe-at/go_task/db"
+	"github.com/akthe-at/go_task/sqlc"
 	"github.com/akthe-at/go_task/tui"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -11,6 +22,7 @@ const (
 	NotesTableView View = iota
 	TasksTableView
 	AreasTableView
+	FocusView
 )
 
 var theme = tui.GetSelectedTheme()
@@ -22,8 +34,42 @@ type (
 	AddAreaMsg                   struct{}
 	SwitchToTasksTableViewMsg    struct{}
 	SwitchToProjectsTableViewMsg struct{}
+	// NotesChangedMsg is sent when the note watcher changed notes after their files changed on disk
+	NotesChangedMsg data.NoteChanges
+	// TimerTickMsg is sent every timerTickInterval with the running timer so the footer keeps counting,
+	// it is read in the tick command so the update loop does not wait on the database
+	TimerTickMsg struct {
+		timer   data.TimeEntry
+		running bool
+		err     error
+	}
 )
 
+// undoKeys names the changes of the keys for the undo steps, keys that change nothing are recorded under the key
+var undoKeys = map[string]string{
+	"backspace": "delete",
+	"p":         "set status planning",
+	"t":         "set status todo",
+	"d":         "set status doing",
+	"D":         "set status done",
+	"P":         "toggle priority",
+	"a":         "toggle archive",
+	"A":         "add",
+	"T":         "add task to area",
+	"S":         "start or stop timer",
+}
+
+// viewNames are the names of the views in the undo steps
+var viewNames = map[View]string{
+	NotesTableView: "notes",
+	TasksTableView: "tasks",
+	AreasTableView: "areas",
+	FocusView:      "focus",
+}
+
+// timerTickInterval is how often the running timer in the footer is updated, it shows whole minutes
+const timerTickInterval = 15 * time.Second
+
 type RootModel struct {
 	Height int
 	Width  int
@@ -31,9 +77,19 @@ type RootModel struct {
 	Tasks TaskModel
 	Notes NotesModel
 	Areas AreasModel
+	Focus FocusModel
 
 	CurrentView  View
 	PreviousView View
+
+	// undoCount collects the digits typed before 'u', e.g. 3u undoes the last three steps
+	undoCount   string
+	undoMessage string
+
+	// NoteChanges delivers the changes of the note watcher, the tables are refreshed whenever it sends
+	NoteChanges <-chan data.NoteChanges
+	// Conn is kept open while the TUI runs for the reads that repeat in the background, such as the running timer
+	Conn *sql.DB
 }
 
 func NewRootModel() RootModel {
@@ -46,7 +102,32 @@ func NewRootModel() RootModel {
 }
 
 func (m RootModel) Init() tea.Cmd {
-	return nil
+	return tea.Batch(waitForNoteChanges(m.NoteChanges), tickTimer(m.Conn))
+}
+
+// tickTimer reads the running timer after timerTickInterval, the timer may have been started or stopped from the command line
+func tickTimer(conn *sql.DB) tea.Cmd {
+	if conn == nil {
+		return nil
+	}
+	return tea.Tick(timerTickInterval, func(time.Time) tea.Msg {
+		timer, running, err := data.RunningTimer(context.Background(), sqlc.New(conn))
+		return TimerTickMsg{timer: timer, running: running, err: err}
+	})
+}
+
+// waitForNoteChanges waits for the next batch of note changes, it waits forever when notes are not watched
+func waitForNoteChanges(changes <-chan data.NoteChanges) tea.Cmd {
+	if changes == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		change, ok := <-changes
+		if !ok {
+			return nil
+		}
+		return NotesChangedMsg(change)
+	}
 }
 
 func (m RootModel) isInitialized() bool {
@@ -55,16 +136,46 @@ func (m RootModel) isInitialized() bool {
 
 func (m RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if !m.isInitialized() {
-		if _, ok := msg.(tea.WindowSizeMsg); !ok {
+		switch msg.(type) {
+		case tea.WindowSizeMsg:
+		case NotesChangedMsg:
+			// The tables load fresh data once the window is known, keep listening
+			return m, waitForNoteChanges(m.NoteChanges)
+		case TimerTickMsg:
+			return m, tickTimer(m.Conn)
+		default:
 			return m, nil
 		}
 	}
 
+	if msg, ok := msg.(tea.KeyMsg); ok && msg.String() != "u" {
+		// Everything one key press changes is undone together
+		db.BeginUndoStep(m.undoStepDescription(msg.String()))
+		defer db.EndUndoStep()
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok && m.CurrentView == FocusView {
+		// The tables keep their rows and selection while the focus view is shown
+		return m.updateFocus(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
+		key := msg.String()
+		if len(key) == 1 && key[0] >= '0' && key[0] <= '9' && (key != "0" || m.undoCount != "") {
+			m.undoCount += key
+			return m, nil
+		}
+		count := m.undoCount
+		m.undoCount = ""
+		if key != "u" {
+			m.undoMessage = ""
+		}
+		switch key {
 		case "ctrl+c":
 			return m, tea.Quit
+		case "u":
+			m.undo(count)
 		case "backspace":
 			switch m.CurrentView {
 			case TasksTableView:
@@ -116,6 +227,21 @@ func (m RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case AreasTableView:
 				m.Areas.updateStatus(data.StatusDone)
 			}
+		case "S":
+			switch m.CurrentView {
+			case TasksTableView:
+				m.Tasks.toggleTimer()
+			}
+		case "f":
+			switch m.CurrentView {
+			case TasksTableView:
+				if taskID, ok := m.Tasks.highlightedTaskID(); ok {
+					m.Focus = FocusViewModel(taskID, m.Width, m.Height, m.Focus.tickID+1)
+					m.PreviousView = m.CurrentView
+					m.CurrentView = FocusView
+					return m, m.Focus.Init()
+				}
+			}
 		case "O":
 			switch m.CurrentView {
 			case NotesTableView:
@@ -130,6 +256,11 @@ func (m RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case AreasTableView:
 				m.Areas.addArea()
 			}
+		case "/":
+			switch m.CurrentView {
+			case TasksTableView:
+				m.Tasks.editFilter()
+			}
 		case "T":
 			switch m.CurrentView {
 			case AreasTableView:
@@ -156,6 +287,8 @@ func (m RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.Height = msg.Height
 		m.Width = msg.Width
+		updatedFocus, _ := m.Focus.Update(msg)
+		m.Focus = *updatedFocus.(*FocusModel)
 		msg.Height -= 2
 		msg.Width -= 4
 		return m.propagate(msg), nil
@@ -174,11 +307,92 @@ func (m RootModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case AddTaskMsg:
 		updatedTasks, _ := m.Tasks.Update(msg)
 		m.Tasks = *updatedTasks.(*TaskModel)
+	case NotesChangedMsg:
+		if msg.Err != nil {
+			slog.Error("RootModel: Error watching the notes", "error", msg.Err)
+		}
+		m.Notes.refreshTableData()
+		m.Tasks.refreshTableData()
+		m.Areas.refreshTableData()
+		return m.propagate(msg), waitForNoteChanges(m.NoteChanges)
+	case TimerTickMsg:
+		return m.propagate(msg), tickTimer(m.Conn)
+	case FocusTickMsg:
+		if m.CurrentView != FocusView {
+			// Leaving the focus view stops its pomodoro
+			return m, nil
+		}
+		updatedFocus, cmd := m.Focus.Update(msg)
+		m.Focus = *updatedFocus.(*FocusModel)
+		return m, cmd
 	}
 
 	return m.propagate(msg), nil
 }
 
+// updateFocus handles the keys of the focus view, leaving it goes back to the tasks with their logged time refreshed
+func (m RootModel) updateFocus(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc", "q":
+		m.Tasks.refreshTableData()
+		m.CurrentView = TasksTableView
+		return m, nil
+	}
+	updatedFocus, cmd := m.Focus.Update(msg)
+	m.Focus = *updatedFocus.(*FocusModel)
+	return m, cmd
+}
+
+// undoStepDescription describes what a key press changes, e.g. "delete in tasks"
+func (m RootModel) undoStepDescription(key string) string {
+	what, ok := undoKeys[key]
+	if !ok || m.CurrentView == FocusView {
+		what = "press " + key
+	}
+	return fmt.Sprintf("%s in %s", what, viewNames[m.CurrentView])
+}
+
+// undo reverts the latest steps and reloads every table, what it restores may show up in any of them
+func (m *RootModel) undo(count string) {
+	steps := 1
+	if count != "" {
+		var err error
+		if steps, err = strconv.Atoi(count); err != nil {
+			m.undoMessage = fmt.Sprintf("Can not undo %s steps", count)
+			return
+		}
+	}
+
+	ctx := context.Background()
+	conn, _, err := db.ConnectDB()
+	if err != nil {
+		slog.Error("RootModel - undo: Error connecting to database", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	undone, err := data.UndoLast(ctx, conn, steps)
+	if errors.Is(err, data.ErrNothingToUndo) {
+		m.undoMessage = "There is nothing to undo"
+		return
+	}
+	if err != nil {
+		slog.Error("RootModel - undo: Error undoing the changes", "error", err)
+		m.undoMessage = err.Error()
+		return
+	}
+	var descriptions []string
+	for _, step := range undone {
+		descriptions = append(descriptions, step.Description)
+	}
+	m.undoMessage = "Undid " + strings.Join(descriptions, ", ")
+	m.Tasks.refreshTableData()
+	m.Notes.refreshTableData()
+	m.Areas.refreshTableData()
+}
+
 func (m *RootModel) propagate(msg tea.Msg) tea.Model {
 	var updatedTasks tea.Model
 	var updatedNotes tea.Model
@@ -203,13 +417,19 @@ func (m *RootModel) propagate(msg tea.Msg) tea.Model {
 
 func (m RootModel) View() string {
 	var s lipgloss.Style
+	var undoMessage string
+	if m.undoMessage != "" {
+		undoMessage = lipgloss.NewStyle().Foreground(lipgloss.Color(theme.Warning)).Render(m.undoMessage) + "\n"
+	}
 	switch m.CurrentView {
 	case TasksTableView:
-		return s.Render(m.Tasks.View())
+		return s.Render(undoMessage + m.Tasks.View())
 	case NotesTableView:
-		return s.Render(m.Notes.View())
+		return s.Render(undoMessage + m.Notes.View())
 	case AreasTableView:
-		return s.Render(m.Areas.View())
+		return s.Render(undoMessage + m.Areas.View())
+	case FocusView:
+		return s.Render(m.Focus.View())
 	default:
 		return s.Render("")
 	}
@@ -0,0 +1,71 @@
+package formInput
+
+import (
+	"context"
+
+	"github.com/akthe-at/go_task/data"
+	"github.com/akthe-at/go_task/db"
+	"github.com/akthe-at/go_task/sqlc"
+	"github.com/akthe-at/go_task/tui"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/log"
+)
+
+type FilterForm struct {
+	View       string
+	Expression string
+	FilterForm *huh.Form
+}
+
+// Filter returns the combined filter of the chosen saved view and the typed expression
+func (f *FilterForm) Filter() string {
+	return data.CombineFilters(f.View, f.Expression)
+}
+
+func (f *FilterForm) NewFilterForm(theme huh.Theme) error {
+	tui.ClearTerminalScreen()
+	options := fetchSavedViews()
+
+	groups := []*huh.Group{
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Start from a saved view?").
+				Options(options...).
+				Value(&f.View),
+			huh.NewInput().
+				Title("Filter tasks (leave empty to show every task)").
+				Description(data.FilterHelp).
+				Prompt(">").
+				Value(&f.Expression).
+				Validate(func(expression string) error {
+					_, err := data.CompileFilter(data.CombineFilters(f.View, expression), data.CurrentFilterEnv())
+					return err
+				}),
+		),
+	}
+	f.FilterForm = huh.NewForm(groups...)
+
+	return f.FilterForm.WithTheme(&theme).Run()
+}
+
+// fetchSavedViews offers the saved views by name, their filter is the option value
+func fetchSavedViews() []huh.Option[string] {
+	options := []huh.Option[string]{huh.NewOption("None", "")}
+
+	ctx := context.Background()
+	conn, _, err := db.ConnectDB()
+	if err != nil {
+		log.Errorf("There was an error connecting to the database: %v", err)
+		return options
+	}
+	defer conn.Close()
+
+	views, err := sqlc.New(conn).ReadSavedViews(ctx)
+	if err != nil {
+		return options
+	}
+	for _, view := range views {
+		options = append(options, huh.NewOption(view.Name+" ("+view.Filter+")", view.Filter))
+	}
+	return options
+}
@@ -3,7 +3,9 @@ package formInput
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/akthe-at/go_task/config"
 	"github.com/akthe-at/go_task/data"
 	"github.com/akthe-at/go_task/db"
 	"github.com/akthe-at/go_task/sqlc"
@@ -15,6 +17,8 @@ import (
 type NewNoteForm struct {
 	NoteForm *huh.Form
 	Title    string
+	// Template is the choice made from templateOptions, notePath explains what each choice does
+	Template string
 	Path     string
 	Type     data.NoteType
 	ParentID int
@@ -32,10 +36,20 @@ func (n *NewNoteForm) NewNoteForm(theme huh.Theme) error {
 				Title("What note do you want to add?").
 				Prompt(">").
 				Value(&n.Title),
+			huh.NewSelect[string]().
+				Title("Which template should the note be generated from?").
+				Options(templateOptions()...).
+				Value(&n.Template),
+		),
+		huh.NewGroup(
 			huh.NewInput().
 				Title("What is the note path?").
 				Prompt(">").
 				Value(&n.Path),
+		).WithHideFunc(func() bool {
+			return n.Template != linkExistingNote
+		}),
+		huh.NewGroup(
 			huh.NewSelect[data.NoteType]().
 				Title("What type of note is this?").
 				Description("Choose a type").
@@ -77,6 +91,8 @@ func (n *NewNoteForm) NewNoteForm(theme huh.Theme) error {
 type NewQuickNoteForm struct {
 	NoteForm *huh.Form
 	Title    string
+	// Template works like NewNoteForm.Template
+	Template string
 	Path     string
 	Submit   bool
 }
@@ -90,10 +106,20 @@ func (n *NewQuickNoteForm) NewNoteForm(theme huh.Theme) error {
 				Title("What note do you want to add?").
 				Prompt(">").
 				Value(&n.Title),
+			huh.NewSelect[string]().
+				Title("Which template should the note be generated from?").
+				Options(templateOptions()...).
+				Value(&n.Template),
+		),
+		huh.NewGroup(
 			huh.NewInput().
 				Title("What is the note path?").
 				Prompt(">").
 				Value(&n.Path),
+		).WithHideFunc(func() bool {
+			return n.Template != linkExistingNote
+		}),
+		huh.NewGroup(
 			huh.NewConfirm().
 				Title("Are you ready to save your note?").
 				Affirmative("Yes").
@@ -106,6 +132,55 @@ func (n *NewQuickNoteForm) NewNoteForm(theme huh.Theme) error {
 	return n.NoteForm.WithTheme(&theme).Run()
 }
 
+// NotePath returns the path of the note to link to the task or area the form was filled in for
+func (n *NewNoteForm) NotePath(ctx context.Context, queries *sqlc.Queries, noteType data.NoteType, parentID int64) (string, error) {
+	return notePath(ctx, queries, n.Title, n.Template, n.Path, noteType, parentID)
+}
+
+// NotePath is NewNoteForm.NotePath for the quick note form
+func (n *NewQuickNoteForm) NotePath(ctx context.Context, queries *sqlc.Queries, noteType data.NoteType, parentID int64) (string, error) {
+	return notePath(ctx, queries, n.Title, n.Template, n.Path, noteType, parentID)
+}
+
+/*
+notePath turns the template choice of a note form into the path of the note.
+ 1. linkExistingNote returns the path typed into the form
+ 2. A blank choice generates the note from the default template of the area, or a blank note when the area has none
+ 3. Any other choice generates the note from the template with that name
+*/
+func notePath(ctx context.Context, queries *sqlc.Queries, title, template, path string, noteType data.NoteType, parentID int64) (string, error) {
+	if template == linkExistingNote {
+		return path, nil
+	}
+	return data.GenerateTemplatedNote(ctx, queries, data.NoteTemplateRequest{
+		Dir:      config.TemplatesDir(),
+		Name:     template,
+		NoteType: noteType,
+		ParentID: parentID,
+		Title:    title,
+	}, time.Now())
+}
+
+// linkExistingNote is the template choice for linking a file that already exists,
+// it can not clash with a template because template names never contain a slash
+const linkExistingNote = "/link"
+
+// templateOptions lists the note templates after the area default and the option to link an existing file
+func templateOptions() []huh.Option[string] {
+	options := []huh.Option[string]{
+		huh.NewOption("Default template of the area", ""),
+		huh.NewOption("No template, link an existing file", linkExistingNote),
+	}
+	names, err := data.NoteTemplateNames(config.TemplatesDir())
+	if err != nil {
+		log.Errorf("There was an error reading the note templates: %v", err)
+	}
+	for _, name := range names {
+		options = append(options, huh.NewOption(name, name))
+	}
+	return options
+}
+
 func fetchNoteParent(selection data.NoteType) []huh.Option[int] {
 	var options []huh.Option[int]
 	ctx := context.Background()
@@ -23,6 +23,9 @@ type NewTaskForm struct {
 	TaskForm          *huh.Form
 	Priority          data.PriorityType
 	Status            data.StatusType
+	DueDate           string
+	Recurrence        string
+	Tags              string
 	Notes             []sqlc.Note
 	Archived          bool
 	Submit            bool
@@ -59,6 +62,40 @@ func (n *NewTaskForm) NewTaskForm(theme huh.Theme) error {
 					huh.NewOption("Done", data.StatusDone),
 				).
 				Value(&n.Status),
+			huh.NewInput().
+				Title("When is the task due? (leave blank for no due date)").
+				DescriptionFunc(func() string {
+					due, err := data.ToNullDueDate(n.DueDate)
+					if err != nil || !due.Valid {
+						return data.DateInputHelp
+					}
+					return "Resolves to " + data.DescribeDueDate(due)
+				}, &n.DueDate).
+				Prompt(">").
+				Validate(func(s string) error {
+					_, err := data.ToNullDueDate(s)
+					return err
+				}).
+				Value(&n.DueDate),
+			huh.NewInput().
+				Title("Does the task repeat? (leave blank if it does not)").
+				DescriptionFunc(func() string {
+					recurrence, err := data.ToNullRecurrence(n.Recurrence)
+					if err != nil || !recurrence.Valid {
+						return data.RecurrenceHelp
+					}
+					return "Repeats " + data.DescribeRecurrence(recurrence)
+				}, &n.Recurrence).
+				Prompt(">").
+				Validate(func(s string) error {
+					_, err := data.ToNullRecurrence(s)
+					return err
+				}).
+				Value(&n.Recurrence),
+			huh.NewInput().
+				Title("Tags (separated by spaces or commas, leave blank for none)").
+				Prompt(">").
+				Value(&n.Tags),
 			huh.NewSelect[bool]().
 				Title("Do you want to archive this task right away?").
 				Options(
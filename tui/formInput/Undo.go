@@ -0,0 +1,41 @@
+package formInput
+
+import (
+	"fmt"
+
+	"github.com/akthe-at/go_task/sqlc"
+	"github.com/akthe-at/go_task/tui"
+	"github.com/charmbracelet/huh"
+)
+
+type UndoForm struct {
+	StepID   int64
+	Submit   bool
+	UndoForm *huh.Form
+}
+
+// NewUndoForm picks one of the steps, it is undone along with every step made after it
+func (f *UndoForm) NewUndoForm(theme huh.Theme, steps []sqlc.ReadUndoStepsRow) error {
+	tui.ClearTerminalScreen()
+	var options []huh.Option[int64]
+	for _, step := range steps {
+		options = append(options, huh.NewOption(
+			fmt.Sprintf("%s  %s (%s, %d changes)", step.CreatedAt, step.Description, step.Source, step.Changes), step.ID))
+	}
+
+	groups := []*huh.Group{
+		huh.NewGroup(
+			huh.NewSelect[int64]().
+				Title("Undo back to which step?").
+				Description("The chosen step and every step after it are undone").
+				Options(options...).
+				Value(&f.StepID),
+			huh.NewConfirm().
+				Title("Undo these changes?").
+				Value(&f.Submit),
+		),
+	}
+	f.UndoForm = huh.NewForm(groups...)
+
+	return f.UndoForm.WithTheme(&theme).Run()
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/akthe-at/go_task/config"
+)
+
+// FindObsidianVault finds the root of the Obsidian vault a path belongs to
+// by checking for a .obsidian directory in the path or any of its parent directories.
+func FindObsidianVault(path string) (bool, string, error) {
+	path, err := ExpandPath(path)
+	if err != nil {
+		return false, "", fmt.Errorf("fn - FindObsidianVault: error expanding path (%s): %v", path, err)
+	}
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return false, "", fmt.Errorf("fn - FindObsidianVault: error resolving path (%s): %v", path, err)
+	}
+
+	for {
+		info, err := os.Stat(filepath.Join(dir, ".obsidian"))
+		if err == nil && info.IsDir() {
+			return true, dir, nil
+		}
+
+		parentDir := filepath.Dir(dir)
+		if parentDir == dir {
+			break
+		}
+		dir = parentDir
+	}
+
+	return false, "", nil
+}
+
+// ObsidianURI builds the obsidian://open URI of a note inside a vault, the vault is named after its directory
+func ObsidianURI(vaultRoot, notePath string) (string, error) {
+	notePath, err := ExpandPath(notePath)
+	if err != nil {
+		return "", err
+	}
+	notePath, err = filepath.Abs(notePath)
+	if err != nil {
+		return "", err
+	}
+	relPath, err := filepath.Rel(vaultRoot, notePath)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("note %s is not inside the Obsidian vault %s", notePath, vaultRoot)
+	}
+
+	// Obsidian decodes the parameters like encodeURIComponent, so spaces have to be %20 rather than +
+	escape := func(s string) string {
+		return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+	}
+	return fmt.Sprintf("obsidian://open?vault=%s&file=%s", escape(filepath.Base(vaultRoot)), escape(filepath.ToSlash(relPath))), nil
+}
+
+// OpenURI hands a URI to the default handler of the operating system
+func OpenURI(uri string) error {
+	var opener *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		opener = exec.Command("open", uri)
+	case "windows":
+		opener = exec.Command("rundll32", "url.dll,FileProtocolHandler", uri)
+	default:
+		opener = exec.Command("xdg-open", uri)
+	}
+	return opener.Run()
+}
+
+// OpenNotes opens notes in Obsidian when use_obsidian is set and the notes live in the vault above the notes path,
+// every other note is opened in the configured editor
+func OpenNotes(notePaths ...string) {
+	var editorPaths []string
+	if config.UserSettings.Selected.UseObsidian {
+		ok, vaultRoot, err := FindObsidianVault(config.UserSettings.Selected.NotesPath)
+		if err != nil {
+			log.Printf("Could not look for the Obsidian vault, opening the notes in your editor: %v", err)
+		} else if !ok {
+			log.Printf("No .obsidian directory was found above %s, opening the notes in your editor", config.UserSettings.Selected.NotesPath)
+		}
+
+		for _, notePath := range notePaths {
+			if !ok {
+				editorPaths = append(editorPaths, notePath)
+				continue
+			}
+			uri, err := ObsidianURI(vaultRoot, notePath)
+			if err != nil {
+				editorPaths = append(editorPaths, notePath)
+				continue
+			}
+			if err := OpenURI(uri); err != nil {
+				log.Fatalf("There was an error opening %s: %v", uri, err)
+			}
+		}
+	} else {
+		editorPaths = notePaths
+	}
+
+	if len(editorPaths) > 0 {
+		OpenNoteInEditor(config.GetEditorConfig(), editorPaths...)
+	}
+}